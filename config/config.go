@@ -1,8 +1,11 @@
 package config
 
 import (
+	"crypto/tls"
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,14 +14,147 @@ type Config struct {
 	Port           int
 	BaseURL        string
 	GinMode        string
+	LogLevel       string // "debug" additionally logs JSON request and response bodies
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	IdleTimeout    time.Duration
 	ShutdownTimeout time.Duration
+	RedisDrainTimeout time.Duration // How long shutdown waits for buffered and in-progress Redis writes after requests drained
+	StorageOpTimeout time.Duration // Per-operation deadline for storage calls in create/redirect handlers (0 disables)
 	
 	// Storage configuration
 	StorageType string // "memory" or "redis"
+	MemoryDataDir          string        // Directory persisting memory storage ("" keeps it in memory only)
+	MemorySnapshotInterval time.Duration // How often persisted memory storage compacts its log into a snapshot
 	RedisURL    string // Redis connection URL
+	RedisMode       string   // "standalone", "sentinel" or "cluster"
+	RedisAddrs      []string // Sentinel or cluster node addresses
+	RedisMasterName string   // Sentinel master name
+	RedisPassword   string   // Password for sentinel/cluster nodes
+	RedisMaxRetries      int           // Retries for transient Redis errors (negative disables)
+	RedisMinRetryBackoff time.Duration // Initial backoff between Redis retries
+	RedisMaxRetryBackoff time.Duration // Backoff cap between Redis retries
+	MaxURLs         int      // Maximum number of stored URLs (0 = unlimited)
+	MaxID           uint64   // Highest ID generated codes may use before creates fail (0 = uint64 limit)
+	MaxGeneratedCodeLength int // Longest generated code before creates fail (0 = unlimited)
+	URLSizeStats    bool     // Report long-URL length statistics in storage stats
+	RedisSearchScan bool     // Allow GET /urls/search on Redis (full SCAN per page)
+	
+	// Redirect lookup configuration
+	MaxCodeLength int // Longer redirect paths are rejected without a storage lookup
+	ChecksumCodes bool // Generated codes end in a check character, so mistyped codes are refused with a suggestion
+	
+	// URL validation configuration
+	URLValidators []string // Checks long URLs must pass, in order (empty = control_chars,scheme,host)
+	MaxURLLength  int      // Limit for the max_length check (0 = utils.DefaultMaxURLLength)
+	BlockedHosts  []string // Hosts, with their subdomains, refused by the blocklist check
+	
+	// Custom code configuration
+	ReservedWords []string // Words refused as custom codes, on top of the service's route prefixes
+	CaseInsensitiveCodes bool // Custom codes resolve regardless of case; responses keep the casing as typed
+	Namespaces []string // Tenant namespaces links may be created in, served as /<namespace>/<code>
+	
+	// Tag configuration
+	MaxTags int // Most tags one link may carry (0 = utils.DefaultMaxTags)
+	
+	// Self-link configuration
+	ResolveSelfLinks bool // Replace long URLs pointing at our own short links with their target instead of rejecting them
+	
+	// Stored URL cleanup
+	URLStripFragment bool     // Drop the #fragment from long URLs before storing them
+	URLStripParams   []string // Query parameters removed from long URLs before storing them ("utm_*" matches by prefix)
+	
+	// Admin configuration
+	AdminToken string // Bearer token for /admin endpoints ("" disables them)
+	
+	// API key configuration
+	APIKeys         map[string]string // API key -> owner name, sent as X-API-Key
+	OwnerRateLimit  int               // Requests per minute for an owner without its own limit
+	OwnerRateLimits map[string]int    // Per-owner requests per minute
+	OwnerNamespaces map[string]string // Owner -> namespace all of its links are created in
+	UniqueURLsPerOwner bool           // An owner's plain create for a URL it already shortened returns that link
+	
+	// Rate limit configuration
+	RateLimitDisabled bool // Skip the rate limiter entirely (RATE_LIMIT_ENABLED=false), e.g. in trusted environments
+	RateLimitBypassTokens []string // Tokens that skip the rate limiter when sent in X-RateLimit-Bypass
+	MaxConcurrentPerIP int     // Requests one client IP may have in progress at once (0 = unlimited)
+	TrustedProxies    []string // Proxy IPs/CIDRs whose Forwarded / X-Forwarded-For headers are honored (empty trusts every peer)
+	TrustedPlatform   string   // "cloudflare", "gcp" or a header name carrying the client IP set by the hosting platform ("" = none)
+	HashClientIPs     bool     // Rate limit, log and record sha256(ip+ClientIPSalt) instead of the client IP
+	ClientIPSalt      string   // Salt appended to client IPs before hashing
+	
+	// Audit configuration
+	AuditLog     string // "" (disabled), "file" or "redis"
+	AuditLogPath string // File used when AuditLog is "file"
+	AuditStream  string // Redis stream used when AuditLog is "redis"
+	
+	// Webhook configuration
+	WebhookURL         string        // Receiver POSTed a url.created event for each new link ("" disables)
+	WebhookConcurrency int           // Deliveries in flight at once
+	WebhookQueueSize   int           // Events waiting for a worker before new ones are dropped
+	WebhookTimeout     time.Duration // Per-delivery HTTP timeout
+	
+	// Reservation configuration
+	ReservationTTL time.Duration // How long a reserved code is held before release
+	
+	// Duplicate detection configuration
+	DedupWindow      time.Duration // Identical creates from one IP within this window reuse the code (0 disables)
+	ReverseIndexHash string        // "" (disabled), "sha256" or "sha256-full": reuse links for the same long URL
+	
+	// Ephemeral state configuration
+	EphemeralStore string // "memory" (per instance) or "redis" (shared): where short-lived state such as recent submissions is kept
+	
+	// Crawler configuration
+	RobotsDisallow string // Comma-separated paths disallowed in robots.txt ("" allows all)
+	
+	// Response configuration
+	JSONCase     string // "snake" (default) or "camel" for response keys
+	PublicScheme string // Overrides the scheme of returned short URLs ("" honors X-Forwarded-Proto)
+	CreateStatus201 bool // Answer newly created links with 201 Created and a Location header instead of 200
+	ProblemJSON     bool // Send errors as RFC 7807 application/problem+json instead of {"error": ...}
+	APIFormat       string // "plain" (default) or "jsonapi" to send JSON:API documents
+	ExpiresAtHeader bool // Send X-Expires-At with the link's expiration on create and redirect responses
+	
+	// Retention configuration
+	RetentionTiers   map[string]time.Duration // Named lifetimes selectable via the "retention" request field
+	DefaultRetention string                   // Tier applied when a request sets no expiration ("" = never expire)
+	ExpirationJitter time.Duration            // Random ± spread applied to tier-based expirations
+	ClockSkewTolerance time.Duration          // Links count as expired only this long after their expiration
+	CleanupInterval  time.Duration            // How often expired URLs are deleted (0 leaves them in place)
+	
+	// Analytics configuration
+	ClickRetention time.Duration // How long hourly click buckets are kept
+	ClickFlushInterval time.Duration // Buffer Redis click counts and write them this often (0 = write every click)
+	ClickSampleRate float64 // Fraction (0.0-1.0) of redirects whose referrer and user agent are recorded; counts include every click
+	TrackUniqueVisitors bool // Count approximate distinct client IPs per link (one extra write per redirect)
+	LatencyWindow  time.Duration // Sliding window for /debug/latency percentiles
+	
+	// Redirect configuration
+	MergeQueryParams bool   // Merge the request's query params into the redirect target
+	QueryPrecedence  string // "incoming" (default) or "stored" wins when a param appears in both
+	MaxRedirectDelay int    // Longest redirect_delay_seconds a link may set (0 = 30)
+	RedirectHeaders  map[string]string // Extra headers on redirect responses (nil = Referrer-Policy: no-referrer, empty = none)
+	ErrorPageDir     string // Directory with 404.html/410.html shown to browsers on missing or used-up links ("" = built-in page)
+	UpgradeHTTPRedirects bool // Redirect to https:// for http:// destinations, unless the link opts out
+	UnfurlPreview    bool     // Serve known link-preview crawlers an OpenGraph page instead of the redirect
+	UnfurlCrawlers   []string // User-Agent substrings identifying those crawlers (nil = built-in list)
+	CheckDestinationHealth    bool          // Serve a warning page instead of redirecting to destinations failing health checks
+	DestinationHealthInterval time.Duration // How often stored destinations are sampled and checked
+	DestinationHealthSample   int           // Links sampled per check pass
+	DestinationHealthTimeout  time.Duration // Per-check HEAD request timeout
+	SigningSecret    string // HMAC key for signed short URLs ("" disables POST /urls/{code}/sign)
+	
+	// TLS and security header configuration
+	TLSCertFile   string        // Serve HTTPS when both cert and key files are set
+	TLSKeyFile    string
+	TLSMinVersion uint16        // Minimum negotiated TLS version (tls.VersionTLS12 by default)
+	HSTSMaxAge    time.Duration // Strict-Transport-Security max-age (0 disables the header)
+	
+	// Chaos testing configuration, for exercising clients against a
+	// misbehaving server. Never enable in production.
+	ChaosMode       string        // Must equal ChaosModeOptIn to inject faults; any other value is ignored
+	ChaosLatencyMax time.Duration // Upper bound of the random delay added to each request
+	ChaosErrorRate  float64       // Fraction of requests failed with a 500 or 503
 }
 
 // Load loads configuration from environment variables with sensible defaults
@@ -27,15 +163,248 @@ func Load() *Config {
 		Port:            getEnvAsInt("PORT", 8080),
 		BaseURL:         getEnv("BASE_URL", "http://localhost:8080"),
 		GinMode:         getEnv("GIN_MODE", "release"),
+		LogLevel:        getEnv("LOG_LEVEL", "info"),
 		ReadTimeout:     getEnvAsDuration("READ_TIMEOUT", "10s"),
 		WriteTimeout:    getEnvAsDuration("WRITE_TIMEOUT", "10s"),
 		IdleTimeout:     getEnvAsDuration("IDLE_TIMEOUT", "60s"),
 		ShutdownTimeout: getEnvAsDuration("SHUTDOWN_TIMEOUT", "30s"),
+		RedisDrainTimeout: getEnvAsDuration("REDIS_DRAIN_TIMEOUT", "5s"),
+		StorageOpTimeout: getEnvAsDuration("STORAGE_OP_TIMEOUT", "2s"),
 		
 		// Storage configuration
 		StorageType:     getEnv("STORAGE_TYPE", "memory"),
+		MemoryDataDir:          getEnv("MEMORY_DATA_DIR", ""),
+		MemorySnapshotInterval: getEnvAsDuration("MEMORY_SNAPSHOT_INTERVAL", "5m"),
 		RedisURL:        getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		RedisMode:       getEnv("REDIS_MODE", "standalone"),
+		RedisAddrs:      getEnvAsList("REDIS_ADDRS"),
+		RedisMasterName: getEnv("REDIS_MASTER_NAME", ""),
+		RedisPassword:   getEnv("REDIS_PASSWORD", ""),
+		RedisMaxRetries:      getEnvAsInt("REDIS_MAX_RETRIES", 3),
+		RedisMinRetryBackoff: getEnvAsDuration("REDIS_RETRY_MIN_BACKOFF", "8ms"),
+		RedisMaxRetryBackoff: getEnvAsDuration("REDIS_RETRY_MAX_BACKOFF", "512ms"),
+		MaxURLs:         getEnvAsInt("MAX_URLS", 0),
+		MaxID:           getEnvAsUint64("MAX_ID", 0),
+		MaxGeneratedCodeLength: getEnvAsInt("MAX_GENERATED_CODE_LENGTH", 0),
+		URLSizeStats:    getEnvAsBool("URL_SIZE_STATS", false),
+		RedisSearchScan: getEnvAsBool("REDIS_SEARCH_SCAN", false),
+		
+		// Redirect lookup configuration
+		MaxCodeLength:   getEnvAsInt("MAX_CODE_LENGTH", 32),
+		ChecksumCodes:   getEnvAsBool("CHECKSUM_CODES", false),
+		
+		// URL validation configuration
+		URLValidators: getEnvAsList("URL_VALIDATORS"),
+		MaxURLLength:  getEnvAsInt("MAX_URL_LENGTH", 2048),
+		BlockedHosts:  getEnvAsList("BLOCKED_HOSTS"),
+		
+		// Custom code configuration
+		ReservedWords: getEnvAsList("RESERVED_WORDS"),
+		CaseInsensitiveCodes: getEnvAsBool("CASE_INSENSITIVE_CODES", false),
+		Namespaces: getEnvAsList("NAMESPACES"),
+		
+		// Tag configuration
+		MaxTags: getEnvAsInt("MAX_TAGS", 10),
+		
+		// Self-link configuration
+		ResolveSelfLinks: getEnvAsBool("RESOLVE_SELF_LINKS", false),
+		
+		// Stored URL cleanup
+		URLStripFragment: getEnvAsBool("URL_STRIP_FRAGMENT", false),
+		URLStripParams:   getEnvAsList("URL_STRIP_PARAMS"),
+		
+		// Admin configuration
+		AdminToken:      getEnv("ADMIN_TOKEN", ""),
+		
+		// API key configuration
+		APIKeys:         parsePairs(getEnv("API_KEYS", "")),
+		OwnerRateLimit:  getEnvAsInt("OWNER_RATE_LIMIT", 60),
+		OwnerRateLimits: parseOwnerRateLimits(getEnv("OWNER_RATE_LIMITS", "")),
+		OwnerNamespaces: parsePairs(getEnv("OWNER_NAMESPACES", "")),
+		UniqueURLsPerOwner: getEnvAsBool("UNIQUE_URLS_PER_OWNER", false),
+		
+		// Rate limit configuration
+		RateLimitDisabled: !getEnvAsBool("RATE_LIMIT_ENABLED", true),
+		RateLimitBypassTokens: getEnvAsList("RATE_LIMIT_BYPASS_TOKENS"),
+		MaxConcurrentPerIP: getEnvAsInt("MAX_CONCURRENT_PER_IP", 0),
+		TrustedProxies:    getEnvAsList("TRUSTED_PROXIES"),
+		TrustedPlatform:   getEnv("TRUSTED_PLATFORM", ""),
+		HashClientIPs:     getEnvAsBool("HASH_CLIENT_IPS", false),
+		ClientIPSalt:      getEnv("CLIENT_IP_SALT", ""),
+		
+		// Audit configuration
+		AuditLog:        getEnv("AUDIT_LOG", ""),
+		AuditLogPath:    getEnv("AUDIT_LOG_PATH", "audit.log"),
+		AuditStream:     getEnv("AUDIT_STREAM", "audit"),
+		
+		// Webhook configuration
+		WebhookURL:         getEnv("WEBHOOK_URL", ""),
+		WebhookConcurrency: getEnvAsInt("WEBHOOK_CONCURRENCY", 4),
+		WebhookQueueSize:   getEnvAsInt("WEBHOOK_QUEUE_SIZE", 100),
+		WebhookTimeout:     getEnvAsDuration("WEBHOOK_TIMEOUT", "5s"),
+		
+		// Reservation configuration
+		ReservationTTL:  getEnvAsDuration("RESERVATION_TTL", "5m"),
+		
+		// Duplicate detection configuration
+		DedupWindow:      getEnvAsDuration("DEDUP_WINDOW", "0s"),
+		ReverseIndexHash: getEnv("REVERSE_INDEX_HASH", ""),
+		
+		// Ephemeral state configuration
+		EphemeralStore: getEnv("EPHEMERAL_STORE", "memory"),
+		
+		// Crawler configuration
+		RobotsDisallow:  getEnv("ROBOTS_DISALLOW", "/"),
+		
+		// Response configuration
+		JSONCase:        getEnv("JSON_CASE", "snake"),
+		PublicScheme:    getEnv("PUBLIC_SCHEME", ""),
+		CreateStatus201: getEnvAsBool("CREATE_STATUS_201", false),
+		ProblemJSON:     getEnvAsBool("PROBLEM_JSON", false),
+		APIFormat:       getEnv("API_FORMAT", "plain"),
+		ExpiresAtHeader: getEnvAsBool("EXPIRES_AT_HEADER", false),
+		
+		// Retention configuration
+		RetentionTiers:   parseRetentionTiers(getEnv("RETENTION_TIERS", "short=24h,default=30d,long=365d")),
+		DefaultRetention: getEnv("DEFAULT_RETENTION", ""),
+		ExpirationJitter: getEnvAsDuration("EXPIRATION_JITTER", "0s"),
+		ClockSkewTolerance: getEnvAsDuration("CLOCK_SKEW_TOLERANCE", "0s"),
+		CleanupInterval:  getEnvAsDuration("CLEANUP_INTERVAL", "0s"),
+		
+		// Analytics configuration
+		ClickRetention: getEnvAsDuration("CLICK_RETENTION", "168h"),
+		ClickFlushInterval: getEnvAsDuration("CLICK_FLUSH_INTERVAL", "0s"),
+		ClickSampleRate: getEnvAsFloat("CLICK_SAMPLE_RATE", 1.0),
+		TrackUniqueVisitors: getEnvAsBool("TRACK_UNIQUE_VISITORS", false),
+		LatencyWindow:  getEnvAsDuration("LATENCY_WINDOW", "1m"),
+		
+		// Redirect configuration
+		MergeQueryParams: getEnvAsBool("MERGE_QUERY_PARAMS", false),
+		QueryPrecedence:  getEnv("MERGE_QUERY_PRECEDENCE", "incoming"),
+		MaxRedirectDelay: getEnvAsInt("MAX_REDIRECT_DELAY_SECONDS", 30),
+		RedirectHeaders:  parseHeaders(getEnv("REDIRECT_HEADERS", "Referrer-Policy: no-referrer")),
+		ErrorPageDir:     getEnv("ERROR_PAGE_DIR", ""),
+		UpgradeHTTPRedirects: getEnvAsBool("UPGRADE_HTTP_REDIRECTS", false),
+		UnfurlPreview:    getEnvAsBool("UNFURL_PREVIEW", false),
+		UnfurlCrawlers:   getEnvAsList("UNFURL_CRAWLERS"),
+		CheckDestinationHealth:    getEnvAsBool("CHECK_DESTINATION_HEALTH", false),
+		DestinationHealthInterval: getEnvAsDuration("DESTINATION_HEALTH_INTERVAL", "1m"),
+		DestinationHealthSample:   getEnvAsInt("DESTINATION_HEALTH_SAMPLE", 100),
+		DestinationHealthTimeout:  getEnvAsDuration("DESTINATION_HEALTH_TIMEOUT", "5s"),
+		SigningSecret:    getEnv("SIGNING_SECRET", ""),
+		
+		// TLS and security header configuration
+		TLSCertFile:   getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:    getEnv("TLS_KEY_FILE", ""),
+		TLSMinVersion: parseTLSVersion(getEnv("TLS_MIN_VERSION", "1.2")),
+		HSTSMaxAge:    getEnvAsDuration("HSTS_MAX_AGE", "0s"),
+		
+		// Chaos testing configuration
+		ChaosMode:       getEnv("CHAOS_MODE", ""),
+		ChaosLatencyMax: getEnvAsDuration("CHAOS_LATENCY_MAX", "0s"),
+		ChaosErrorRate:  getEnvAsFloat("CHAOS_ERROR_RATE", 0),
+	}
+}
+
+// ChaosModeOptIn is the only CHAOS_MODE value that enables fault injection.
+// A deliberately awkward string, so "true" or "1" copied into a production
+// environment does nothing.
+const ChaosModeOptIn = "inject-faults-for-testing"
+
+// ChaosEnabled reports whether CHAOS_MODE explicitly opts in to fault injection
+func (c *Config) ChaosEnabled() bool {
+	return c.ChaosMode == ChaosModeOptIn
+}
+
+// parseRetentionTiers parses "name=duration" pairs separated by commas.
+// Durations accept Go syntax plus a "d" suffix for days. Invalid entries are skipped.
+func parseRetentionTiers(value string) map[string]time.Duration {
+	tiers := make(map[string]time.Duration)
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		duration, err := parseDurationWithDays(strings.TrimSpace(raw))
+		if name == "" || err != nil || duration <= 0 {
+			continue
+		}
+		tiers[name] = duration
+	}
+	return tiers
+}
+
+// parsePairs parses "key=value" pairs separated by commas. Entries without
+// "=" or with an empty side are skipped.
+func parsePairs(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(pair, "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !found || key == "" || val == "" {
+			continue
+		}
+		pairs[key] = val
+	}
+	return pairs
+}
+
+// parseHeaders parses "Name: value" entries separated by "|", since header
+// values may themselves contain commas. "none" yields an empty, non-nil map.
+// Entries without a colon, name or value are skipped.
+func parseHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	if strings.EqualFold(strings.TrimSpace(value), "none") {
+		return headers
+	}
+	for _, entry := range strings.Split(value, "|") {
+		name, val, found := strings.Cut(entry, ":")
+		name, val = strings.TrimSpace(name), strings.TrimSpace(val)
+		if !found || name == "" || val == "" {
+			continue
+		}
+		headers[http.CanonicalHeaderKey(name)] = val
+	}
+	return headers
+}
+
+// parseOwnerRateLimits parses "owner=limit" pairs; non-positive limits are skipped
+func parseOwnerRateLimits(value string) map[string]int {
+	limits := make(map[string]int)
+	for owner, raw := range parsePairs(value) {
+		if limit, err := strconv.Atoi(raw); err == nil && limit > 0 {
+			limits[owner] = limit
+		}
+	}
+	return limits
+}
+
+// parseTLSVersion maps "1.0" through "1.3" to the crypto/tls version constant.
+// Unknown values fall back to TLS 1.2 rather than weakening the server.
+func parseTLSVersion(value string) uint16 {
+	switch strings.TrimPrefix(strings.TrimSpace(value), "TLS") {
+	case "1.0", "10":
+		return tls.VersionTLS10
+	case "1.1", "11":
+		return tls.VersionTLS11
+	case "1.3", "13":
+		return tls.VersionTLS13
+	default:
+		return tls.VersionTLS12
+	}
+}
+
+// parseDurationWithDays extends time.ParseDuration with a whole-day "d" suffix (e.g. "30d")
+func parseDurationWithDays(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
 	}
+	return time.ParseDuration(value)
 }
 
 // getEnv gets an environment variable with a fallback default
@@ -46,6 +415,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsList gets a comma-separated environment variable as a trimmed list
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // getEnvAsInt gets an environment variable as integer with a fallback default
 func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -56,6 +436,36 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsUint64 gets an environment variable as uint64 with a fallback default
+func getEnvAsUint64(key string, defaultValue uint64) uint64 {
+	if value := os.Getenv(key); value != "" {
+		if uintValue, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return uintValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsFloat gets an environment variable as float with a fallback default
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as boolean with a fallback default
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsDuration gets an environment variable as duration with a fallback default
 func getEnvAsDuration(key, defaultValue string) time.Duration {
 	if value := os.Getenv(key); value != "" {
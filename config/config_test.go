@@ -0,0 +1,76 @@
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+)
+
+func TestParseRetentionTiers(t *testing.T) {
+	tiers := parseRetentionTiers("short=24h, Default=30d,long=365d,broken,bad=xyz,neg=-1h")
+
+	expected := map[string]time.Duration{
+		"short":   24 * time.Hour,
+		"default": 30 * 24 * time.Hour,
+		"long":    365 * 24 * time.Hour,
+	}
+
+	if len(tiers) != len(expected) {
+		t.Fatalf("Expected %d tiers, got %d: %v", len(expected), len(tiers), tiers)
+	}
+	for name, duration := range expected {
+		if tiers[name] != duration {
+			t.Errorf("Tier %s = %v; expected %v", name, tiers[name], duration)
+		}
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := map[string]uint16{
+		"1.0":    tls.VersionTLS10,
+		"1.1":    tls.VersionTLS11,
+		"1.2":    tls.VersionTLS12,
+		"1.3":    tls.VersionTLS13,
+		"TLS1.3": tls.VersionTLS13,
+		"":       tls.VersionTLS12,
+		"bogus":  tls.VersionTLS12,
+	}
+
+	for value, expected := range tests {
+		if got := parseTLSVersion(value); got != expected {
+			t.Errorf("parseTLSVersion(%q) = %x; expected %x", value, got, expected)
+		}
+	}
+}
+
+func TestParseOwnerRateLimits(t *testing.T) {
+	limits := parseOwnerRateLimits("acme=600, beta = 120,broken,zero=0,neg=-5,nan=abc")
+
+	expected := map[string]int{"acme": 600, "beta": 120}
+	if len(limits) != len(expected) {
+		t.Fatalf("Expected %d limits, got %d: %v", len(expected), len(limits), limits)
+	}
+	for owner, limit := range expected {
+		if limits[owner] != limit {
+			t.Errorf("Limit for %s = %d; expected %d", owner, limits[owner], limit)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers := parseHeaders("referrer-policy: no-referrer | Cache-Control: private, max-age=90|broken|X-Empty:")
+
+	expected := map[string]string{"Referrer-Policy": "no-referrer", "Cache-Control": "private, max-age=90"}
+	if len(headers) != len(expected) {
+		t.Fatalf("Expected %d headers, got %d: %v", len(expected), len(headers), headers)
+	}
+	for name, value := range expected {
+		if headers[name] != value {
+			t.Errorf("Header %s = %q; expected %q", name, headers[name], value)
+		}
+	}
+
+	if none := parseHeaders("none"); none == nil || len(none) != 0 {
+		t.Errorf("parseHeaders(\"none\") = %v; expected an empty map", none)
+	}
+}
@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tiny-url-service/middleware"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+	"tiny-url-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainRequest is the payload for POST /admin/drain
+type drainRequest struct {
+	Draining *bool `json:"draining"`
+}
+
+// SetDrainMode handles POST /admin/drain - enables or disables drain mode.
+// An empty body (or {"draining": true}) enables it.
+func (h *URLHandlers) SetDrainMode(c *gin.Context) {
+	var req drainRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid JSON format", err)
+			return
+		}
+	}
+	
+	draining := true
+	if req.Draining != nil {
+		draining = *req.Draining
+	}
+	h.state.SetDraining(draining)
+	log.Printf("🚰 Drain mode set to %v via admin API", draining)
+	if draining {
+		h.recordAudit(c, "drain", "", "")
+	} else {
+		h.recordAudit(c, "undrain", "", "")
+	}
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"draining": draining,
+	})
+}
+
+// GetURLMapping handles GET /admin/urls/{shortCode} - returns the stored
+// mapping even after it has expired, flagged with "expired". Admin only:
+// public endpoints go through storage.Get, which enforces expiration.
+func (h *URLHandlers) GetURLMapping(c *gin.Context) {
+	shortCode := c.Param("shortCode")
+	
+	mapping, err := h.storage.GetRaw(shortCode)
+	if errors.Is(err, storage.ErrNotFound) {
+		h.respondError(c, http.StatusNotFound, "Short URL not found", nil)
+		return
+	}
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to load URL mapping", err)
+		return
+	}
+	
+	expired, secondsLeft := h.expiryStatus(mapping, time.Now())
+	h.respond(c, http.StatusOK, gin.H{
+		"mapping":              mapping,
+		"expired":              expired,
+		"seconds_until_expiry": secondsLeft,
+	})
+}
+
+// DecodeShortCode handles GET /debug/decode/{shortCode} - shows the numeric
+// ID a code decodes to and whether the stored mapping (if any) carries that
+// ID, to diagnose corrupted codes or checksum problems. Undecodable codes
+// (custom codes, typos) are still looked up. The destination is never shown.
+func (h *URLHandlers) DecodeShortCode(c *gin.Context) {
+	shortCode := c.Param("shortCode")
+	namespace, code := storage.SplitNamespace(shortCode)
+	response := gin.H{"short_code": shortCode}
+	if namespace != "" {
+		response["namespace"] = namespace
+	}
+	
+	encoded := code
+	if h.cfg.ChecksumCodes && len(code) > 1 {
+		// The last character is the check character, not part of the ID
+		_, valid := utils.DecodeBase62WithChecksum(code)
+		response["checksum_valid"] = valid
+		encoded = code[:len(code)-1]
+	}
+	id, decodeErr := utils.DecodeBase62Strict(encoded)
+	if decodeErr != nil {
+		response["decode_error"] = decodeErr.Error()
+	} else {
+		response["decoded_id"] = id
+	}
+	
+	mapping, err := storageCall(h, func() (*models.URLMapping, error) {
+		return h.storage.GetRaw(shortCode)
+	})
+	switch {
+	case errors.Is(err, errStorageTimeout):
+		h.respondStorageTimeout(c)
+		return
+	case errors.Is(err, storage.ErrNotFound):
+		response["mapping"] = "no mapping"
+	case err != nil:
+		h.respondError(c, http.StatusInternalServerError, "Failed to load URL mapping", err)
+		return
+	default:
+		response["mapping"] = "found"
+		response["mapping_id"] = mapping.ID
+		response["custom"] = mapping.ID == 0
+		response["id_matches"] = decodeErr == nil && mapping.ID == id
+	}
+	h.respond(c, http.StatusOK, response)
+}
+
+// ResetClicks handles POST /urls/{shortCode}/clicks/reset - sets the link's
+// access count back to zero without deleting it, e.g. after a test campaign
+func (h *URLHandlers) ResetClicks(c *gin.Context) {
+	shortCode := c.Param("shortCode")
+	if h.cfg.CaseInsensitiveCodes {
+		// Counters live under the stored key, which may be case-folded
+		if mapping, err := h.storage.GetRaw(shortCode); err == nil {
+			shortCode = mapping.ShortCode
+		}
+	}
+	
+	err := h.storage.ResetAccessCount(shortCode)
+	if errors.Is(err, storage.ErrNotFound) {
+		h.respondError(c, http.StatusNotFound, "Short URL not found", nil)
+		return
+	}
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to reset access count", err)
+		return
+	}
+	h.recordAudit(c, "reset_clicks", shortCode, "")
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"short_code":   shortCode,
+		"access_count": 0,
+	})
+}
+
+// ndjsonFlushEvery is how many NDJSON lines ExportURLs writes between
+// flushes, so clients see progress on large exports
+const ndjsonFlushEvery = 100
+
+// ExportURLs handles GET /admin/export - dumps every stored mapping, expired
+// ones included. With Accept: application/x-ndjson the mappings are streamed
+// one per line as storage yields them instead of being buffered into a
+// single JSON array, so arbitrarily large stores can be exported.
+func (h *URLHandlers) ExportURLs(c *gin.Context) {
+	if strings.Contains(c.GetHeader("Accept"), "application/x-ndjson") {
+		h.streamExport(c)
+		return
+	}
+	
+	mappings := []*models.URLMapping{}
+	err := h.storage.Each(func(mapping *models.URLMapping) error {
+		mappings = append(mappings, mapping)
+		return nil
+	})
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to export URLs", err)
+		return
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].ShortCode < mappings[j].ShortCode
+	})
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"mappings": mappings,
+	})
+}
+
+// streamExport writes one JSON mapping per line, flushing every
+// ndjsonFlushEvery lines. The status is committed with the first line, so
+// a storage error mid-stream is reported as a final {"error": ...} line.
+func (h *URLHandlers) streamExport(c *gin.Context) {
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	
+	enc := json.NewEncoder(c.Writer)
+	lines := 0
+	err := h.storage.Each(func(mapping *models.URLMapping) error {
+		if err := enc.Encode(h.shape(mapping)); err != nil {
+			return err
+		}
+		lines++
+		if lines%ndjsonFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("NDJSON export failed after %d mappings: %v", lines, err)
+		enc.Encode(h.shape(gin.H{
+			"error":   "Failed to export URLs",
+			"details": err.Error(),
+		}))
+	}
+	c.Writer.Flush()
+}
+
+// Search page size limits
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// SearchURLs handles GET /urls/search - lists mappings whose long URL contains
+// ?q=, expired ones included. Pages are ordered by short code; pass the
+// returned next_cursor as ?cursor= to fetch the next one.
+func (h *URLHandlers) SearchURLs(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		h.respondError(c, http.StatusBadRequest, "q is required", nil)
+		return
+	}
+	fields, ok := h.requestedFields(c, searchFields)
+	if !ok {
+		return
+	}
+	
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.respondError(c, http.StatusBadRequest, "limit must be a positive integer", nil)
+			return
+		}
+		limit = min(parsed, maxSearchLimit)
+	}
+	
+	// Fetch one extra mapping to learn whether another page exists
+	mappings, err := h.storage.Search(query, c.Query("cursor"), limit+1)
+	if errors.Is(err, storage.ErrSearchDisabled) {
+		h.respondError(c, http.StatusNotImplemented, "Search is disabled for this storage backend", nil)
+		return
+	}
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to search URLs", err)
+		return
+	}
+	
+	nextCursor := ""
+	if len(mappings) > limit {
+		mappings = mappings[:limit]
+		nextCursor = mappings[limit-1].ShortCode
+	}
+	
+	results := make([]gin.H, len(mappings))
+	for i, mapping := range mappings {
+		results[i] = fields.apply(gin.H{
+			"short_code":      mapping.PublicCode(),
+			"short_url":       h.shortURL(c, mapping.PublicCode()),
+			"long_url":        mapping.LongURL,
+			"created_at":      mapping.CreatedAt,
+			"expiration_date": mapping.ExpirationDate,
+			"expired":         h.storage.IsExpired(mapping),
+		})
+	}
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"results":     results,
+		"next_cursor": nextCursor,
+	})
+}
+
+// VerifyStorage handles POST /admin/verify - runs the storage consistency
+// check and returns every issue found. Nothing is modified.
+func (h *URLHandlers) VerifyStorage(c *gin.Context) {
+	issues, err := h.storage.Verify()
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to verify storage", err)
+		return
+	}
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"consistent": len(issues) == 0,
+		"issues":     issues,
+	})
+}
+
+// counterRequest is the payload for POST /admin/counter
+type counterRequest struct {
+	Value *uint64 `json:"value" binding:"required"`
+}
+
+// SetCounter handles POST /admin/counter - raises the ID counter to at least
+// the given value, e.g. after importing mappings with higher IDs. The counter
+// is never lowered; the resulting value is returned.
+func (h *URLHandlers) SetCounter(c *gin.Context) {
+	var req counterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+	
+	if err := h.storage.SetCounterFloor(*req.Value); err != nil {
+		if errors.Is(err, storage.ErrCounterOutOfRange) {
+			h.respondError(c, http.StatusBadRequest, "Counter value is past the highest allocatable ID", nil)
+			return
+		}
+		h.respondError(c, http.StatusInternalServerError, "Failed to set counter", err)
+		return
+	}
+	h.recordAudit(c, "counter_floor", "", "")
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"counter": h.storage.GetStats()["current_counter"],
+	})
+}
+
+// defaultExpiringWindow is how far ahead GET /admin/expiring looks by default
+const defaultExpiringWindow = 24 * time.Hour
+
+// GetExpiringURLs handles GET /admin/expiring - lists links expiring within
+// ?within= (a Go duration, default 24h) from now, soonest first, for
+// expiry-reminder workflows
+func (h *URLHandlers) GetExpiringURLs(c *gin.Context) {
+	within := defaultExpiringWindow
+	if raw := c.Query("within"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			h.respondError(c, http.StatusBadRequest, "within must be a positive duration such as 24h", nil)
+			return
+		}
+		within = parsed
+	}
+	fields, ok := h.requestedFields(c, expiringFields)
+	if !ok {
+		return
+	}
+	
+	from := time.Now()
+	to := from.Add(within)
+	mappings, err := h.storage.ExpiringBetween(from, to)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to list expiring URLs", err)
+		return
+	}
+	
+	results := make([]gin.H, len(mappings))
+	for i, mapping := range mappings {
+		_, secondsLeft := h.expiryStatus(mapping, from)
+		results[i] = fields.apply(gin.H{
+			"short_code":           mapping.PublicCode(),
+			"short_url":            h.shortURL(c, mapping.PublicCode()),
+			"long_url":             mapping.LongURL,
+			"expiration_date":      mapping.ExpirationDate,
+			"seconds_until_expiry": secondsLeft,
+		})
+	}
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"from":    from.UTC(),
+		"to":      to.UTC(),
+		"results": results,
+	})
+}
+
+// GetDomainCounts handles GET /admin/domains - lists how many stored links
+// target each host, most common first, so one domain dominating the
+// keyspace (a sign of abuse) stands out. ?limit= keeps the top N.
+func (h *URLHandlers) GetDomainCounts(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.respondError(c, http.StatusBadRequest, "limit must be a positive integer", nil)
+			return
+		}
+		limit = parsed
+	}
+	
+	counts, err := h.storage.CountByDomain()
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to count URLs by domain", err)
+		return
+	}
+	
+	domains := make([]gin.H, 0, len(counts))
+	total := 0
+	for host, count := range counts {
+		domains = append(domains, gin.H{"domain": host, "count": count})
+		total += count
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		ci, cj := domains[i]["count"].(int), domains[j]["count"].(int)
+		if ci != cj {
+			return ci > cj
+		}
+		return domains[i]["domain"].(string) < domains[j]["domain"].(string)
+	})
+	if limit > 0 && len(domains) > limit {
+		domains = domains[:limit]
+	}
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"total":   total,
+		"domains": domains,
+	})
+}
+
+// Audit log query limits
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// actorKey is the context key under which authentication middleware records who is acting
+const actorKey = "actor"
+
+// GetAuditLog handles GET /admin/audit - returns audit entries recorded at or
+// after ?since= (RFC 3339), oldest first, up to ?limit=
+func (h *URLHandlers) GetAuditLog(c *gin.Context) {
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "since must be an RFC 3339 timestamp", err)
+			return
+		}
+		since = parsed
+	}
+	
+	limit := defaultAuditLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.respondError(c, http.StatusBadRequest, "limit must be a positive integer", nil)
+			return
+		}
+		limit = min(parsed, maxAuditLimit)
+	}
+	
+	entries, err := h.audit.Since(since, limit)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to read audit log", err)
+		return
+	}
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"entries": entries,
+	})
+}
+
+// recordAudit appends a successful mutation to the audit trail. The actor is
+// whoever authentication identified, falling back to the client IP (hashed
+// under HASH_CLIENT_IPS).
+func (h *URLHandlers) recordAudit(c *gin.Context, action, shortCode, longURL string) {
+	actor := c.GetString(actorKey)
+	if actor == "" {
+		actor = "ip:" + middleware.ClientID(c)
+	}
+	
+	err := h.audit.Log(models.AuditEntry{
+		Time:      time.Now().UTC(),
+		Action:    action,
+		Actor:     actor,
+		ShortCode: shortCode,
+		LongURL:   longURL,
+	})
+	if err != nil {
+		log.Printf("failed to write audit entry for %s %q: %v", action, shortCode, err)
+	}
+}
+
+// respondDraining rejects a mutating request while the server is draining
+func (h *URLHandlers) respondDraining(c *gin.Context) {
+	c.Header("Retry-After", "30")
+	h.respondError(c, http.StatusServiceUnavailable, "Service is draining and not accepting new URLs", nil)
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"tiny-url-service/models"
+	"tiny-url-service/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Machine-readable codes for rejected request bodies, so clients can tell
+// a body that isn't JSON from one that is JSON but wrong
+const (
+	codeMalformedJSON = "MALFORMED_JSON" // Not parseable as a single JSON object, or empty
+	codeMissingField  = "MISSING_FIELD"  // A required field is absent or empty
+	codeInvalidField  = "INVALID_FIELD"  // A field has the wrong type or an unacceptable value
+	codeUnknownField  = "UNKNOWN_FIELD"  // A field the request type doesn't have
+)
+
+// bindError describes why a request body was rejected and, when it can be
+// attributed, which JSON field caused it
+type bindError struct {
+	Code    string // One of the code* constants
+	Field   string
+	Message string
+	Example string // Optional example of an accepted value
+}
+
+func (e *bindError) Error() string {
+	return e.Message
+}
+
+// bindShortenRequest reads and strictly decodes a create request. Besides
+// RFC3339 it accepts a plain date as expiration_date, and reports
+// unparseable dates with a specific message instead of the decoder's.
+func bindShortenRequest(c *gin.Context, req *models.ShortenRequest) *bindError {
+	return bindWithExpirationDate(c, req)
+}
+
+// bindCampaignRequest decodes a campaign request like bindShortenRequest,
+// with the same expiration_date handling
+func bindCampaignRequest(c *gin.Context, req *models.CampaignRequest) *bindError {
+	return bindWithExpirationDate(c, req)
+}
+
+// bindWithExpirationDate reads the body, normalizes its expiration_date and
+// strictly decodes it into obj
+func bindWithExpirationDate(c *gin.Context, obj interface{}) *bindError {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return &bindError{Code: codeMalformedJSON, Message: "Failed to read request body"}
+	}
+	body, bindErr := normalizeExpirationDate(body)
+	if bindErr != nil {
+		return bindErr
+	}
+	return decodeStrictJSON(body, obj)
+}
+
+// expirationDateExample is shown to clients that send an unparseable date
+const expirationDateExample = "2025-12-31T23:59:59Z"
+
+// normalizeExpirationDate rewrites a string expiration_date accepted by
+// utils.ParseExpirationDate to RFC3339 so it decodes into time.Time. Bodies
+// that are not JSON objects, and non-string values, are left for the
+// decoder to report.
+func normalizeExpirationDate(body []byte) ([]byte, *bindError) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body, nil
+	}
+	value, ok := raw["expiration_date"]
+	if !ok {
+		return body, nil
+	}
+	var date string
+	if err := json.Unmarshal(value, &date); err != nil {
+		return body, nil
+	}
+	
+	parsed, err := utils.ParseExpirationDate(date)
+	if err != nil {
+		return nil, &bindError{
+			Code:    codeInvalidField,
+			Field:   "expiration_date",
+			Message: "expiration_date must be RFC3339",
+			Example: expirationDateExample,
+		}
+	}
+	if parsed.Format(time.RFC3339) == date {
+		return body, nil
+	}
+	
+	raw["expiration_date"], _ = json.Marshal(parsed)
+	rewritten, err := json.Marshal(raw)
+	if err != nil {
+		return body, nil
+	}
+	return rewritten, nil
+}
+
+// decodeStrictJSON decodes body into obj, rejecting unknown fields and
+// wrongly typed values, then applies the struct's binding tags. Errors name
+// the offending field so client bugs such as "long_ur" surface early.
+func decodeStrictJSON(body []byte, obj interface{}) *bindError {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return describeDecodeError(err, body, obj)
+	}
+	if decoder.More() {
+		return &bindError{Code: codeMalformedJSON, Message: "Request body must contain a single JSON object"}
+	}
+	
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) && len(verrs) > 0 {
+			field := jsonFieldName(reflect.TypeOf(obj).Elem(), verrs[0].StructField())
+			if verrs[0].Tag() == "required" {
+				return &bindError{Code: codeMissingField, Field: field, Message: fmt.Sprintf("Field '%s' is required", field)}
+			}
+			return &bindError{Code: codeInvalidField, Field: field, Message: fmt.Sprintf("Field '%s' is %s", field, verrs[0].Tag())}
+		}
+		return &bindError{Code: codeInvalidField, Message: err.Error()}
+	}
+	
+	return nil
+}
+
+// describeDecodeError turns a json decoding error into a field-specific message
+func describeDecodeError(err error, body []byte, obj interface{}) *bindError {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &bindError{
+			Code:    codeInvalidField,
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("Field '%s' must be of type %s", typeErr.Field, typeErr.Type),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &bindError{Code: codeUnknownField, Field: field, Message: fmt.Sprintf("Unknown field '%s'", field)}
+	case errors.As(err, &syntaxErr):
+		return &bindError{Code: codeMalformedJSON, Message: fmt.Sprintf("Invalid JSON format: syntax error at offset %d", syntaxErr.Offset)}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &bindError{Code: codeMalformedJSON, Message: "Invalid JSON format: unexpected end of input"}
+	case errors.Is(err, io.EOF):
+		return &bindError{Code: codeMalformedJSON, Message: "Request body is required"}
+	case errors.As(err, &typeErr):
+		// A type error without a field is a body that isn't an object, e.g. []
+		return &bindError{Code: codeMalformedJSON, Message: "Request body must be a JSON object"}
+	}
+	
+	// Errors from custom unmarshalers (e.g. time.Time) carry no field name,
+	// so find the field whose value fails to decode on its own
+	if field := findInvalidField(body, reflect.TypeOf(obj).Elem()); field != "" {
+		return &bindError{Code: codeInvalidField, Field: field, Message: fmt.Sprintf("Field '%s' is invalid: %v", field, err)}
+	}
+	return &bindError{Code: codeMalformedJSON, Message: "Invalid JSON format"}
+}
+
+// findInvalidField decodes each top-level field of body separately into its
+// struct field type and returns the JSON name of the first one that fails
+func findInvalidField(body []byte, t reflect.Type) string {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return ""
+	}
+	
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonFieldName(t, field.Name)
+		value, ok := raw[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, reflect.New(field.Type).Interface()); err != nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// jsonFieldName returns the JSON key used for the named struct field
+func jsonFieldName(t reflect.Type, fieldName string) string {
+	field, ok := t.FieldByName(fieldName)
+	if !ok {
+		return fieldName
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
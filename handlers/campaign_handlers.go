@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+	"tiny-url-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCampaign handles POST /campaigns - creates a campaign whose settings
+// become the defaults of links created with its ID
+func (h *URLHandlers) CreateCampaign(c *gin.Context) {
+	var req models.CampaignRequest
+	if err := bindCampaignRequest(c, &req); err != nil {
+		h.respondBindError(c, err)
+		return
+	}
+
+	if !utils.IsValidCustomCode(req.ID) {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("id must be 1-%d letters, digits, '-' or '_'", utils.MaxCustomCodeLength),
+			"field": "id",
+		})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > maxTitleLength || utils.ContainsControlChars(req.Name) {
+		h.respondError(c, http.StatusBadRequest, "name must be at most "+strconv.Itoa(maxTitleLength)+" characters without control characters", nil)
+		return
+	}
+
+	if maxDelay := h.maxRedirectDelay(); req.RedirectDelaySeconds < 0 || req.RedirectDelaySeconds > maxDelay {
+		h.respondError(c, http.StatusBadRequest, "redirect_delay_seconds must be between 0 and "+strconv.Itoa(maxDelay), nil)
+		return
+	}
+
+	tags, invalidTags := utils.NormalizeTags(req.Tags)
+	if len(invalidTags) > 0 {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error":        fmt.Sprintf("Tags must be 1-%d letters, digits, '-' or '_', starting with a letter or digit", utils.MaxTagLength),
+			"invalid_tags": invalidTags,
+		})
+		return
+	}
+	if maxTags := h.maxTags(); len(tags) > maxTags {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error":        "Too many tags (maximum " + strconv.Itoa(maxTags) + ")",
+			"invalid_tags": tags[maxTags:],
+		})
+		return
+	}
+
+	campaign := &models.Campaign{
+		ID:                   req.ID,
+		Name:                 req.Name,
+		ExpirationDate:       req.ExpirationDate,
+		Tags:                 tags,
+		RedirectDelaySeconds: req.RedirectDelaySeconds,
+		CreatedAt:            time.Now().UTC(),
+	}
+	err := storageDo(h, func() error {
+		return h.storage.CreateCampaign(campaign)
+	})
+	if errors.Is(err, errStorageTimeout) {
+		h.respondStorageTimeout(c)
+		return
+	}
+	if errors.Is(err, storage.ErrCampaignExists) {
+		h.respondError(c, http.StatusConflict, "Campaign '"+req.ID+"' already exists", nil)
+		return
+	}
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to create campaign", err)
+		return
+	}
+
+	h.recordAudit(c, "campaign_create", campaign.ID, "")
+	h.respond(c, http.StatusCreated, campaign)
+}
+
+// GetCampaignURLs handles GET /campaigns/{id}/urls - returns the campaign
+// and every link created in it, ordered by short code
+func (h *URLHandlers) GetCampaignURLs(c *gin.Context) {
+	id := c.Param("id")
+	fields, ok := h.requestedFields(c, campaignFields)
+	if !ok {
+		return
+	}
+
+	campaign, err := storageCall(h, func() (*models.Campaign, error) {
+		return h.storage.GetCampaign(id)
+	})
+	if errors.Is(err, errStorageTimeout) {
+		h.respondStorageTimeout(c)
+		return
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		h.respondError(c, http.StatusNotFound, "Campaign not found", nil)
+		return
+	}
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to load campaign", err)
+		return
+	}
+
+	mappings, err := storageCall(h, func() ([]*models.URLMapping, error) {
+		return h.storage.CampaignURLs(id)
+	})
+	if errors.Is(err, errStorageTimeout) {
+		h.respondStorageTimeout(c)
+		return
+	}
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to list campaign links", err)
+		return
+	}
+
+	urls := make([]gin.H, len(mappings))
+	for i, mapping := range mappings {
+		link := gin.H{
+			"short_code":      mapping.PublicCode(),
+			"short_url":       h.shortURL(c, mapping.PublicCode()),
+			"created_at":      mapping.CreatedAt,
+			"expiration_date": mapping.ExpirationDate,
+			"expired":         h.storage.IsExpired(mapping),
+			"tags":            mapping.Tags,
+		}
+		// Like stats, never list where a protected link goes
+		if isProtected(mapping) {
+			link["destination_hidden"] = true
+		} else {
+			link["long_url"] = mapping.LongURL
+		}
+		urls[i] = fields.apply(link)
+	}
+
+	h.respond(c, http.StatusOK, gin.H{
+		"campaign": campaign,
+		"urls":     urls,
+	})
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+// Destination health check defaults, used when the config leaves them at zero
+const (
+	defaultDestinationHealthInterval = time.Minute
+	defaultDestinationHealthSample   = 100
+	defaultDestinationHealthTimeout  = 5 * time.Second
+)
+
+// destinationDownAfter is how many consecutive failed checks mark a host as
+// down, so one dropped packet doesn't put a warning in front of a link
+const destinationDownAfter = 2
+
+// destinationCheckConcurrency caps the HEAD requests in flight per pass
+const destinationCheckConcurrency = 8
+
+// hostHealth is the cached reachability of one destination origin
+type hostHealth struct {
+	probeURL string // A stored destination on the origin, used for the HEAD request
+	failures int    // Consecutive failed checks
+	checked  time.Time
+}
+
+// DestinationHealthChecker caches whether link destinations are reachable.
+// Every interval it samples stored mappings, sends a HEAD request to each
+// sampled origin (scheme and host) and to every origin currently failing,
+// and records the result. Redirects only read the cache, so a slow or dead
+// destination never delays them. Results not refreshed for three intervals
+// are forgotten, and unknown origins count as up.
+type DestinationHealthChecker struct {
+	store    storage.Storage
+	client   *http.Client
+	interval time.Duration
+	sample   int
+
+	mu    sync.RWMutex
+	hosts map[string]*hostHealth
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDestinationHealthChecker returns a checker sampling up to sample of
+// store's mappings every interval, with timeout per HEAD request. Call
+// Start to begin checking.
+func NewDestinationHealthChecker(store storage.Storage, interval time.Duration, sample int, timeout time.Duration) *DestinationHealthChecker {
+	if interval <= 0 {
+		interval = defaultDestinationHealthInterval
+	}
+	if sample <= 0 {
+		sample = defaultDestinationHealthSample
+	}
+	if timeout <= 0 {
+		timeout = defaultDestinationHealthTimeout
+	}
+
+	return &DestinationHealthChecker{
+		store: store,
+		client: &http.Client{
+			Timeout: timeout,
+			// A redirect is an answer; the host is up
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		interval: interval,
+		sample:   sample,
+		hosts:    make(map[string]*hostHealth),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs a check pass right away and then every interval until Stop
+func (d *DestinationHealthChecker) Start() {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			d.checkOnce()
+			select {
+			case <-ticker.C:
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background checks
+func (d *DestinationHealthChecker) Stop() {
+	d.stopOnce.Do(func() { close(d.stop) })
+}
+
+// IsDown reports whether target's origin failed its recent checks
+func (d *DestinationHealthChecker) IsDown(target string) bool {
+	origin := destinationOrigin(target)
+	if origin == "" {
+		return false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	health, ok := d.hosts[origin]
+	return ok && health.failures >= destinationDownAfter && time.Since(health.checked) < 3*d.interval
+}
+
+// checkOnce samples the store and checks the sampled origins plus every
+// origin already failing, forgetting stale results
+func (d *DestinationHealthChecker) checkOnce() {
+	probes, err := d.sampleOrigins()
+	if err != nil {
+		log.Printf("destination health: failed to sample links: %v", err)
+	}
+
+	d.mu.Lock()
+	for origin, health := range d.hosts {
+		if time.Since(health.checked) >= 3*d.interval {
+			delete(d.hosts, origin)
+		} else if _, sampled := probes[origin]; !sampled && health.failures > 0 {
+			probes[origin] = health.probeURL
+		}
+	}
+	d.mu.Unlock()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, destinationCheckConcurrency)
+	for origin, probeURL := range probes {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(origin, probeURL string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			d.record(origin, probeURL, d.probe(probeURL))
+		}(origin, probeURL)
+	}
+	wg.Wait()
+}
+
+// sampleOrigins picks up to d.sample mappings uniformly (reservoir
+// sampling over one walk of the store) and returns their destination
+// origins, each with a URL to probe it with
+func (d *DestinationHealthChecker) sampleOrigins() (map[string]string, error) {
+	reservoir := make([]*models.URLMapping, 0, d.sample)
+	seen := 0
+	err := d.store.Each(func(mapping *models.URLMapping) error {
+		seen++
+		if len(reservoir) < d.sample {
+			reservoir = append(reservoir, mapping)
+		} else if i := rand.Intn(seen); i < d.sample {
+			reservoir[i] = mapping
+		}
+		return nil
+	})
+
+	probes := make(map[string]string)
+	add := func(target string) {
+		if origin := destinationOrigin(target); origin != "" {
+			if _, ok := probes[origin]; !ok {
+				probes[origin] = target
+			}
+		}
+	}
+	for _, mapping := range reservoir {
+		add(mapping.LongURL)
+		for _, dest := range mapping.Destinations {
+			add(dest.URL)
+		}
+		for _, rule := range mapping.RedirectRules {
+			add(rule.URL)
+		}
+	}
+	return probes, err
+}
+
+// probe sends a HEAD request to probeURL. Any answer below 500 means the
+// host is up, even 404 or 405 for servers that don't support HEAD.
+func (d *DestinationHealthChecker) probe(probeURL string) bool {
+	req, err := http.NewRequest(http.MethodHead, probeURL, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", "tiny-url-health-check/1.0")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// record stores the result of checking origin
+func (d *DestinationHealthChecker) record(origin, probeURL string, up bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	health, ok := d.hosts[origin]
+	if !ok {
+		health = &hostHealth{probeURL: probeURL}
+		d.hosts[origin] = health
+	}
+	health.checked = time.Now()
+	if up {
+		health.failures = 0
+	} else {
+		health.failures++
+	}
+}
+
+// destinationOrigin returns the lowercased scheme://host[:port] of target,
+// or "" for targets that aren't absolute http(s) URLs
+func destinationOrigin(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return ""
+	}
+	return scheme + "://" + strings.ToLower(u.Host)
+}
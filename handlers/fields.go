@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Fields each response can be projected to with ?fields=. Optional members
+// are listed too, so asking for one a link doesn't have simply omits it.
+var (
+	statsFields = []string{
+		"short_code", "long_url", "created_at", "expiration_date", "id",
+		"password_protected", "max_uses", "use_count", "access_count",
+		"is_expired", "seconds_until_expiry", "destination_hidden", "title", "tags",
+		"redirect_delay_seconds", "redirect_rules", "default_rule_clicks",
+		"destinations", "unique_visitors", "series", "recent_events",
+	}
+	searchFields   = []string{"short_code", "short_url", "long_url", "created_at", "expiration_date", "expired"}
+	campaignFields = []string{"short_code", "short_url", "long_url", "created_at", "expiration_date", "expired", "tags", "destination_hidden"}
+	expiringFields = []string{"short_code", "short_url", "long_url", "expiration_date", "seconds_until_expiry"}
+)
+
+// fieldProjection is the set of fields a client asked for with ?fields=.
+// A nil projection keeps every field.
+type fieldProjection map[string]bool
+
+// apply returns the members of obj that were asked for
+func (p fieldProjection) apply(obj gin.H) gin.H {
+	if p == nil {
+		return obj
+	}
+	projected := make(gin.H, len(p))
+	for key, value := range obj {
+		if p[key] {
+			projected[key] = value
+		}
+	}
+	return projected
+}
+
+// requestedFields parses the comma-separated ?fields= query parameter
+// against the fields the endpoint knows. Unknown names get a 400 listing
+// them and the valid ones, and ok is false; the caller must return.
+func (h *URLHandlers) requestedFields(c *gin.Context, known []string) (projection fieldProjection, ok bool) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, true
+	}
+
+	valid := make(map[string]bool, len(known))
+	for _, field := range known {
+		valid[field] = true
+	}
+	projection = make(fieldProjection)
+	var unknown []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !valid[field] {
+			unknown = append(unknown, field)
+			continue
+		}
+		projection[field] = true
+	}
+	if len(unknown) > 0 {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error":        "Unknown fields: " + strings.Join(unknown, ", "),
+			"field":        "fields",
+			"valid_fields": known,
+		})
+		return nil, false
+	}
+	if len(projection) == 0 {
+		return nil, true // Only separators; keep everything
+	}
+	return projection, true
+}
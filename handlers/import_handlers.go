@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+	"tiny-url-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize caps the body of an import request
+const maxImportSize = 32 << 20
+
+// defaultImportColumns match the field names of GET /admin/export
+var defaultImportColumns = models.ImportMapping{
+	CodeCol:       "short_code",
+	URLCol:        "long_url",
+	ExpirationCol: "expiration_date",
+	TitleCol:      "title",
+}
+
+// importRowError reports a CSV row that was not imported
+type importRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportURLs handles POST /admin/import - creates a mapping per row of the
+// CSV in the request. The optional mapping names the header columns to
+// read, so exports of other systems can be ingested as they are. Bad rows
+// are reported with their line number and skipped.
+func (h *URLHandlers) ImportURLs(c *gin.Context) {
+	if h.state.IsDraining() {
+		h.respondDraining(c)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.respondError(c, http.StatusRequestEntityTooLarge, "Import body must be at most "+strconv.Itoa(maxImportSize>>20)+" MiB", nil)
+		return
+	}
+	var req models.ImportRequest
+	if bindErr := decodeStrictJSON(body, &req); bindErr != nil {
+		h.respondBindError(c, bindErr)
+		return
+	}
+
+	columns := defaultImportColumns
+	if req.Mapping != nil {
+		mergeImportColumns(&columns, *req.Mapping)
+	}
+
+	reader := csv.NewReader(strings.NewReader(req.CSV))
+	reader.FieldsPerRecord = -1 // Short rows are reported per row below
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Failed to read the CSV header", err)
+		return
+	}
+	index, err := locateImportColumns(columns, header)
+	if err != nil {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"field": "mapping",
+		})
+		return
+	}
+
+	imported := 0
+	rowErrors := []importRowError{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrors = append(rowErrors, importRowError{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "Failed to read the CSV file", err)
+			return
+		}
+		line, _ := reader.FieldPos(0)
+
+		mapping, code, rowErr := h.importRow(record, index, columns)
+		if rowErr == nil {
+			err := storageDo(h, func() error {
+				return h.storeWithCode(mapping, code)
+			})
+			if err != nil {
+				rowErr = importStoreError(code, err)
+			}
+		}
+		if rowErr != nil {
+			rowErrors = append(rowErrors, importRowError{Line: line, Error: rowErr.Error()})
+			continue
+		}
+		imported++
+		h.recordAudit(c, "import", code, mapping.LongURL)
+	}
+
+	h.respond(c, http.StatusOK, gin.H{
+		"imported": imported,
+		"failed":   len(rowErrors),
+		"errors":   rowErrors,
+	})
+}
+
+// mergeImportColumns overrides the columns custom names
+func mergeImportColumns(cols *models.ImportMapping, custom models.ImportMapping) {
+	override := func(dst *string, name string) {
+		if name = strings.TrimSpace(name); name != "" {
+			*dst = name
+		}
+	}
+	override(&cols.CodeCol, custom.CodeCol)
+	override(&cols.URLCol, custom.URLCol)
+	override(&cols.ExpirationCol, custom.ExpirationCol)
+	override(&cols.TitleCol, custom.TitleCol)
+}
+
+// importIndex holds the position of each mapped column in a CSV row, -1 for
+// optional columns the header doesn't have
+type importIndex struct {
+	code, url, expiration, title int
+}
+
+// locateImportColumns finds the mapped columns in header, ignoring case.
+// The code and URL columns are required.
+func locateImportColumns(cols models.ImportMapping, header []string) (importIndex, error) {
+	find := func(name string) int {
+		for i, column := range header {
+			// Spreadsheet exports often start with a byte order mark
+			column = strings.TrimPrefix(column, "\ufeff")
+			if strings.EqualFold(strings.TrimSpace(column), name) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	index := importIndex{
+		code:       find(cols.CodeCol),
+		url:        find(cols.URLCol),
+		expiration: find(cols.ExpirationCol),
+		title:      find(cols.TitleCol),
+	}
+	if index.code < 0 {
+		return index, fmt.Errorf("CSV header has no %q column (code_col)", cols.CodeCol)
+	}
+	if index.url < 0 {
+		return index, fmt.Errorf("CSV header has no %q column (url_col)", cols.URLCol)
+	}
+	return index, nil
+}
+
+// importRow builds the mapping for one CSV record, validating it like a
+// create request with a custom code
+func (h *URLHandlers) importRow(record []string, index importIndex, cols models.ImportMapping) (*models.URLMapping, string, error) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	code := cell(index.code)
+	if code == "" {
+		return nil, "", fmt.Errorf("missing %s", cols.CodeCol)
+	}
+	if !utils.IsValidCustomCode(code) {
+		return nil, "", fmt.Errorf("%s %q must be 1-%d letters, digits, '-' or '_'", cols.CodeCol, code, utils.MaxCustomCodeLength)
+	}
+	if _, reserved := h.reservedWords[strings.ToLower(code)]; reserved {
+		return nil, "", fmt.Errorf("%s %q is reserved", cols.CodeCol, code)
+	}
+
+	longURL := cell(index.url)
+	if longURL == "" {
+		return nil, "", fmt.Errorf("missing %s", cols.URLCol)
+	}
+	if err := h.validator.Validate(longURL); err != nil {
+		return nil, "", fmt.Errorf("invalid URL: %v", err)
+	}
+
+	mapping := &models.URLMapping{LongURL: longURL}
+	if raw := cell(index.expiration); raw != "" {
+		expiration, err := utils.ParseExpirationDate(raw)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid %s %q", cols.ExpirationCol, raw)
+		}
+		mapping.ExpirationDate = &expiration
+	}
+	if title := cell(index.title); title != "" {
+		if len(title) > maxTitleLength || utils.ContainsControlChars(title) {
+			return nil, "", fmt.Errorf("%s must be at most %d characters without control characters", cols.TitleCol, maxTitleLength)
+		}
+		mapping.Title = title
+	}
+	return mapping, code, nil
+}
+
+// importStoreError describes why storing a row failed with the messages a
+// create request would get. Unexpected errors are logged, not returned.
+func importStoreError(code string, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrCodeTaken):
+		return errors.New("Short code already taken")
+	case errors.Is(err, storage.ErrCodeReserved):
+		return errors.New("Short code is reserved")
+	case errors.Is(err, storage.ErrCapacityExceeded):
+		return errors.New("URL capacity reached")
+	case errors.Is(err, errStorageTimeout):
+		return errors.New("Storage operation timed out")
+	}
+	log.Printf("failed to import %q: %v", code, err)
+	return errors.New("Failed to create short URL")
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"tiny-url-service/middleware"
+	"tiny-url-service/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respond writes obj as the JSON response body, applying the configured
+// response shaping (e.g. camelCase keys when JSON_CASE=camel). Error bodies
+// become Problem Details when PROBLEM_JSON is set, and every body a JSON:API
+// document when API_FORMAT=jsonapi.
+func (h *URLHandlers) respond(c *gin.Context, status int, obj interface{}) {
+	if body, ok := obj.(gin.H); ok && status >= 400 {
+		obj = middleware.ErrorBody(c, status, body)
+	} else if status < 400 {
+		obj = middleware.JSONAPIDocument(c, obj)
+	}
+	c.JSON(status, h.shape(obj))
+}
+
+// shape applies the configured response shaping to obj. Streaming handlers
+// use it directly for each value they write.
+func (h *URLHandlers) shape(obj interface{}) interface{} {
+	if strings.EqualFold(h.cfg.JSONCase, "camel") {
+		shaped, err := utils.CamelCaseKeys(obj)
+		if err != nil {
+			log.Printf("Failed to convert response to camelCase: %v", err)
+		} else {
+			return shaped
+		}
+	}
+	return obj
+}
+
+// respondError writes the standard error body. The underlying error, when
+// given, is included as details.
+func (h *URLHandlers) respondError(c *gin.Context, status int, message string, err error) {
+	body := gin.H{
+		"error": message,
+	}
+	if err != nil {
+		body["details"] = err.Error()
+	}
+	h.respond(c, status, body)
+}
+
+// respondBindError answers a rejected request body with 400, its
+// machine-readable code and, when known, the offending field
+func (h *URLHandlers) respondBindError(c *gin.Context, err *bindError) {
+	body := gin.H{"error": err.Message, "code": err.Code}
+	if err.Field != "" {
+		body["field"] = err.Field
+	}
+	if err.Example != "" {
+		body["example"] = err.Example
+	}
+	h.respond(c, http.StatusBadRequest, body)
+}
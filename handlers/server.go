@@ -2,22 +2,87 @@ package handlers
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 	"tiny-url-service/config"
 	"tiny-url-service/middleware"
+	"tiny-url-service/models"
 	"tiny-url-service/storage"
+	"tiny-url-service/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RouterOption configures optional router dependencies
+type RouterOption func(*URLHandlers)
+
+// WithAuditLogger records successful mutations in logger
+func WithAuditLogger(logger storage.AuditLogger) RouterOption {
+	return func(h *URLHandlers) {
+		if logger != nil {
+			h.audit = logger
+		}
+	}
+}
+
+// WithEphemeralStore keeps short-lived state such as DEDUP_WINDOW
+// submissions in store, e.g. Redis to share it between instances
+func WithEphemeralStore(store storage.EphemeralStore) RouterOption {
+	return func(h *URLHandlers) {
+		if store != nil {
+			h.ephemeral = store
+		}
+	}
+}
+
+// routePrefixes are the first path segments of the service's own routes.
+// They are always reserved so a vanity code can never shadow a route;
+// TestReservedWordsCoverRoutes keeps the list in sync with newRouter.
+var routePrefixes = []string{"admin", "api", "campaigns", "debug", "favicon.ico", "health", "ready", "robots.txt", "urls"}
+
+// ReservedWords returns the words refused as custom codes: the route
+// prefixes plus the configured RESERVED_WORDS
+func ReservedWords(cfg *config.Config) []string {
+	words := make([]string, 0, len(routePrefixes)+len(cfg.ReservedWords))
+	words = append(words, routePrefixes...)
+	return append(words, cfg.ReservedWords...)
+}
+
+// trustedPlatformHeader maps TRUSTED_PLATFORM to the header gin reads the
+// client IP from: a known platform name, or any other value as a header name
+func trustedPlatformHeader(platform string) string {
+	switch strings.ToLower(strings.TrimSpace(platform)) {
+	case "":
+		return ""
+	case "cloudflare":
+		return gin.PlatformCloudflare
+	case "gcp":
+		return gin.PlatformGoogleAppEngine
+	default:
+		return strings.TrimSpace(platform)
+	}
+}
+
 // SetupRouter creates and configures the Gin router with all routes and middleware
-func SetupRouter(store storage.Storage, cfg *config.Config) *gin.Engine {
+func SetupRouter(store storage.Storage, cfg *config.Config, opts ...RouterOption) *gin.Engine {
+	return newRouter(store, cfg, NewServerState(), opts...)
+}
+
+// newRouter builds the router around shared runtime state so the server
+// lifecycle (e.g. SIGUSR1 drain toggling) can influence request handling
+func newRouter(store storage.Storage, cfg *config.Config, state *ServerState, opts ...RouterOption) *gin.Engine {
 	// Set Gin mode from configuration
 	gin.SetMode(cfg.GinMode)
 	
@@ -25,30 +90,384 @@ func SetupRouter(store storage.Storage, cfg *config.Config) *gin.Engine {
 	r := gin.New()
 	
 	// Add middleware
-	r.Use(gin.Logger())           // Request logging
+	if cfg.HashClientIPs {
+		r.Use(middleware.HashedIPLogger()) // Request logging without raw client IPs
+	} else {
+		r.Use(gin.Logger())           // Request logging
+	}
 	r.Use(gin.Recovery())         // Panic recovery
+	r.Use(middleware.ForwardedHeader()) // RFC 7239 Forwarded counts toward the client IP like X-Forwarded-For
+	if len(cfg.TrustedProxies) > 0 {
+		if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+			log.Printf("Ignoring invalid TRUSTED_PROXIES: %v", err)
+		}
+	}
+	r.TrustedPlatform = trustedPlatformHeader(cfg.TrustedPlatform) // Takes precedence over the proxy headers
+	if cfg.HashClientIPs {
+		if cfg.ClientIPSalt == "" {
+			log.Printf("HASH_CLIENT_IPS is on without CLIENT_IP_SALT; unsalted IP hashes are easy to reverse")
+		}
+		r.Use(middleware.HashClientIPs(cfg.ClientIPSalt)) // Everything below sees only the hash
+	}
+	r.Use(SecurityHeaders(cfg.HSTSMaxAge)) // Security headers on every response
+	if cfg.ProblemJSON {
+		r.Use(middleware.ProblemJSON()) // RFC 7807 error bodies
+	}
+	if strings.EqualFold(cfg.APIFormat, "jsonapi") {
+		r.Use(middleware.JSONAPI()) // JSON:API documents, errors included
+	}
+	if strings.EqualFold(cfg.LogLevel, "debug") {
+		r.Use(middleware.BodyLogger()) // Request/response bodies, passwords masked
+	}
+	
+	// Latency is tracked for every route, including the bot endpoints below
+	latency := middleware.NewLatencyTracker(cfg.LatencyWindow)
+	r.Use(latency.Middleware())
+	state.latency = latency
+	
+	// Bot endpoints are registered before the wildcard route and the rate
+	// limiter so they never hit short-code lookups or consume tokens
+	r.GET("/favicon.ico", FaviconHandler())
+	r.GET("/robots.txt", RobotsHandler(cfg.RobotsDisallow))
+	r.GET("/ready", ReadinessHandler(state))
+	
+	// Fault injection for client testing is only installed on an explicit
+	// opt-in; health checks stay exempt so orchestrators don't restart us
+	if cfg.ChaosEnabled() {
+		log.Printf("⚠️  CHAOS MODE: injecting up to %v latency and a %.0f%% error rate. Testing only!", cfg.ChaosLatencyMax, cfg.ChaosErrorRate*100)
+		r.Use(middleware.NewChaosMiddleware(cfg.ChaosLatencyMax, cfg.ChaosErrorRate, "/health"))
+	} else if cfg.ChaosMode != "" {
+		log.Printf("CHAOS_MODE=%q ignored; set it to %q to inject faults", cfg.ChaosMode, config.ChaosModeOptIn)
+	}
+	
 	r.Use(CORSMiddleware())       // CORS headers
 	r.Use(ContentTypeMiddleware()) // Content-Type validation
-	r.Use(middleware.NewInMemoryRateLimiter()) // Rate limiting
+	r.Use(APIKeyMiddleware(cfg.APIKeys))  // Identify the owner behind an API key
+	if cfg.MaxConcurrentPerIP > 0 {
+		r.Use(middleware.NewPerIPConcurrencyLimiter(cfg.MaxConcurrentPerIP)) // Cap slow, simultaneous requests per IP
+	}
+	if !cfg.RateLimitDisabled {
+		// Rate limiting per owner, else per IP
+		r.Use(middleware.NewKeyedRateLimiter(ownerRateLimitKey(cfg), middleware.WithBypassTokens(cfg.RateLimitBypassTokens)))
+	}
 	
 	// Create handlers instance
-	handlers := NewURLHandlers(store, cfg.BaseURL)
+	handlers := NewURLHandlers(store, cfg)
+	handlers.state = state
+	for _, opt := range opts {
+		opt(handlers)
+	}
 	
 	// Setup routes
 	r.POST("/urls", handlers.CreateShortURL)
+	r.POST("/urls/reserve", handlers.ReserveShortCode)
 	r.GET("/:shortCode", handlers.RedirectToLongURL)
+	// Namespaced codes; gin needs one wildcard name per segment, so the
+	// namespace arrives as :shortCode and the code as :code
+	r.GET("/:shortCode/:code", handlers.RedirectToLongURL)
 	r.GET("/urls/:shortCode/stats", handlers.GetURLStats)
+	r.GET("/urls/:shortCode/metrics", handlers.GetURLMetrics)
+	r.POST("/urls/stats/batch", handlers.GetBatchURLStats)
+	r.GET("/api/expand", handlers.ExpandShortURL)
+	r.GET("/urls/search", AdminAuthMiddleware(cfg.AdminToken), handlers.SearchURLs)
+	r.POST("/urls/:shortCode/clicks/reset", AdminAuthMiddleware(cfg.AdminToken), handlers.ResetClicks)
+	r.POST("/urls/:shortCode/sign", AdminAuthMiddleware(cfg.AdminToken), handlers.SignShortURL)
+	// Campaign IDs are easy to guess, so creating and listing them is admin-only
+	r.POST("/campaigns", AdminAuthMiddleware(cfg.AdminToken), handlers.CreateCampaign)
+	r.GET("/campaigns/:id/urls", AdminAuthMiddleware(cfg.AdminToken), handlers.GetCampaignURLs)
+	
+	// Admin endpoints
+	admin := r.Group("/admin", AdminAuthMiddleware(cfg.AdminToken))
+	admin.POST("/drain", handlers.SetDrainMode)
+	admin.GET("/audit", handlers.GetAuditLog)
+	admin.GET("/urls/:shortCode", handlers.GetURLMapping)
+	admin.GET("/export", handlers.ExportURLs)
+	admin.POST("/import", handlers.ImportURLs)
+	admin.GET("/expiring", handlers.GetExpiringURLs)
+	admin.GET("/domains", handlers.GetDomainCounts)
+	admin.POST("/verify", handlers.VerifyStorage)
+	admin.POST("/counter", handlers.SetCounter)
+	
+	// Debug endpoints share the admin token
+	debug := r.Group("/debug", AdminAuthMiddleware(cfg.AdminToken))
+	debug.GET("/latency", LatencyHandler(latency))
+	debug.GET("/decode/:shortCode", handlers.DecodeShortCode)
 	
 	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		stats := store.GetStats()
+	r.GET("/health", HealthHandler(store, state))
+	
+	// Unmatched paths (including "/", which never reaches /:shortCode) get
+	// the same JSON error shape as every other 404
+	r.NoRoute(handlers.NotFound)
+	
+	return r
+}
+
+// FaviconHandler answers /favicon.ico with an empty 204 response
+func FaviconHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=86400")
+		c.Status(204)
+	}
+}
+
+// RobotsHandler serves robots.txt disallowing the given comma-separated paths.
+// An empty policy allows all crawling.
+func RobotsHandler(disallow string) gin.HandlerFunc {
+	var sb strings.Builder
+	sb.WriteString("User-agent: *\n")
+	paths := strings.Split(disallow, ",")
+	for _, path := range paths {
+		sb.WriteString("Disallow: " + strings.TrimSpace(path) + "\n")
+	}
+	body := []byte(sb.String())
+	
+	return func(c *gin.Context) {
+		c.Data(200, "text/plain; charset=utf-8", body)
+	}
+}
+
+// ReadinessHandler reports whether the instance should receive new traffic.
+// It returns 503 while draining so load balancers stop routing creates here.
+func ReadinessHandler(state *ServerState) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if state.IsDraining() {
+			c.JSON(503, gin.H{
+				"status": "draining",
+			})
+			return
+		}
 		c.JSON(200, gin.H{
-			"status": "healthy",
-			"stats":  stats,
+			"status": "ready",
 		})
-	})
+	}
+}
+
+// healthCheckPrefix starts the throwaway codes written by deep health checks.
+// '~' is outside both the base62 alphabet and the custom code charset, so
+// these codes can never collide with real ones.
+const healthCheckPrefix = "~hc-"
+
+// HealthHandler reports liveness and storage stats. With ?deep=1 it also
+// verifies base62 encoding and a full store/get/delete round trip, returning
+// 503 if either fails. ?runtime=1 adds goroutine, memory and uptime figures;
+// it is opt-in because ReadMemStats briefly stops the world, so the plain
+// probe stays cheap.
+func HealthHandler(store storage.Storage, state *ServerState) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := gin.H{
+			"status":       "healthy",
+			"stats":        store.GetStats(),
+			"capabilities": store.Capabilities(),
+		}
+		
+		if withRuntime, _ := strconv.ParseBool(c.Query("runtime")); withRuntime {
+			body["runtime"] = runtimeStats(state)
+		}
+		
+		if deep, _ := strconv.ParseBool(c.Query("deep")); deep {
+			checks := gin.H{
+				"base62":             checkResult(checkBase62()),
+				"storage_round_trip": checkResult(checkStorageRoundTrip(store)),
+			}
+			body["checks"] = checks
+			for _, result := range checks {
+				if result != "ok" {
+					body["status"] = "unhealthy"
+					c.JSON(503, body)
+					return
+				}
+			}
+		}
+		
+		c.JSON(200, body)
+	}
+}
+
+// runtimeStats reports goroutines, heap allocation and uptime, for spotting
+// leaks without a profiler
+func runtimeStats(state *ServerState) gin.H {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
 	
-	return r
+	startedAt := state.StartedAt()
+	return gin.H{
+		"goroutines":     runtime.NumGoroutine(),
+		"alloc_bytes":    mem.Alloc,
+		"sys_bytes":      mem.Sys,
+		"num_gc":         mem.NumGC,
+		"started_at":     startedAt.UTC().Format(time.RFC3339),
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	}
+}
+
+// checkResult renders a check error as "ok" or its message
+func checkResult(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "ok"
+}
+
+// checkBase62 verifies that short code encoding round-trips
+func checkBase62() error {
+	for _, n := range []uint64{0, 1, 61, 62, 3843, 1<<32 + 7, math.MaxUint64} {
+		if decoded := utils.DecodeBase62(utils.EncodeBase62(n)); decoded != n {
+			return fmt.Errorf("base62 round trip failed for %d", n)
+		}
+	}
+	return nil
+}
+
+// checkStorageRoundTrip stores a throwaway mapping under a reserved code,
+// reads it back and deletes it. The mapping is removed even if a step fails.
+// A store at MAX_URLS refuses the write but is still healthy, so the check
+// falls back to a read.
+func checkStorageRoundTrip(store storage.Storage) error {
+	token, err := utils.GenerateToken(8)
+	if err != nil {
+		return fmt.Errorf("token generation failed: %w", err)
+	}
+	code := healthCheckPrefix + token
+	longURL := "https://health.check.invalid/" + token
+	
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: longURL}, code); errors.Is(err, storage.ErrCapacityExceeded) {
+		if _, err := store.Exists(code); err != nil {
+			return fmt.Errorf("exists failed: %w", err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("store failed: %w", err)
+	}
+	deleted := false
+	defer func() {
+		if !deleted {
+			if delErr := store.Delete(code); delErr != nil {
+				log.Printf("failed to clean up health check mapping %q: %v", code, delErr)
+			}
+		}
+	}()
+	
+	mapping, err := store.Get(code)
+	if err != nil {
+		return fmt.Errorf("get failed: %w", err)
+	}
+	if mapping.LongURL != longURL || mapping.ShortCode != code {
+		return fmt.Errorf("round trip mismatch: got %q for %q", mapping.LongURL, mapping.ShortCode)
+	}
+	
+	deleted = true
+	if err := store.Delete(code); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
+
+// LatencyHandler reports per-route p50/p95/p99 latency over the tracker's window
+func LatencyHandler(tracker *middleware.LatencyTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"window_seconds": tracker.Window().Seconds(),
+			"routes":         tracker.Snapshot(),
+		})
+	}
+}
+
+// ownerKey is the context key under which APIKeyMiddleware records the owner
+const ownerKey = "owner"
+
+// APIKeyMiddleware identifies the owner of the API key sent in X-API-Key.
+// Requests without a key stay anonymous; unknown keys are rejected.
+func APIKeyMiddleware(keys map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-API-Key")
+		if provided == "" {
+			c.Next()
+			return
+		}
+		
+		owner := ""
+		for key, name := range keys {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1 {
+				owner = name
+			}
+		}
+		if owner == "" {
+			middleware.ErrorJSON(c, 401, gin.H{
+				"error": "Invalid API key",
+			})
+			c.Abort()
+			return
+		}
+		
+		c.Set(ownerKey, owner)
+		c.Set(actorKey, "owner:"+owner)
+		c.Next()
+	}
+}
+
+// ownerRateLimitKey charges authenticated requests to their owner, with the
+// owner's configured limit, and anonymous requests to their client IP
+func ownerRateLimitKey(cfg *config.Config) middleware.RateLimitKeyFunc {
+	return func(c *gin.Context) (string, int, string) {
+		owner := c.GetString(ownerKey)
+		if owner == "" {
+			return middleware.ClientIPKey(c)
+		}
+		
+		limit, ok := cfg.OwnerRateLimits[owner]
+		if !ok {
+			limit = cfg.OwnerRateLimit
+		}
+		if limit <= 0 {
+			limit = middleware.DefaultRateLimit
+		}
+		return "owner:" + owner, limit, "owner"
+	}
+}
+
+// AdminAuthMiddleware requires "Authorization: Bearer <token>" on admin routes.
+// Admin routes are disabled entirely when no token is configured.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			middleware.ErrorJSON(c, 403, gin.H{
+				"error": "Admin API is disabled",
+			})
+			c.Abort()
+			return
+		}
+		
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			middleware.ErrorJSON(c, 401, gin.H{
+				"error": "Invalid or missing admin token",
+			})
+			c.Abort()
+			return
+		}
+		
+		c.Set(actorKey, "admin")
+		c.Next()
+	}
+}
+
+// SecurityHeaders sets headers that stop MIME sniffing and framing of our
+// pages. Strict-Transport-Security is only sent when hstsMaxAge is positive.
+func SecurityHeaders(hstsMaxAge time.Duration) gin.HandlerFunc {
+	hsts := ""
+	if hstsMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains", int64(hstsMaxAge.Seconds()))
+	}
+	
+	return func(c *gin.Context) {
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("X-Frame-Options", "DENY")
+		if hsts != "" {
+			c.Header("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
 }
 
 // CORSMiddleware adds CORS headers to responses
@@ -56,7 +475,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -75,7 +494,7 @@ func ContentTypeMiddleware() gin.HandlerFunc {
 		if c.Request.Method == "POST" {
 			contentType := c.GetHeader("Content-Type")
 			if contentType != "application/json" && contentType != "application/json; charset=utf-8" {
-				c.JSON(400, gin.H{
+				middleware.ErrorJSON(c, 400, gin.H{
 					"error": "Content-Type must be application/json",
 				})
 				c.Abort()
@@ -87,8 +506,9 @@ func ContentTypeMiddleware() gin.HandlerFunc {
 }
 
 // StartServer starts the HTTP server with proper configuration, timeouts, and graceful shutdown
-func StartServer(store storage.Storage, cfg *config.Config) error {
-	router := SetupRouter(store, cfg)
+func StartServer(store storage.Storage, cfg *config.Config, opts ...RouterOption) error {
+	state := NewServerState()
+	router := newRouter(store, cfg, state, opts...)
 	
 	// Create HTTP server with timeouts
 	server := &http.Server{
@@ -98,11 +518,35 @@ func StartServer(store storage.Storage, cfg *config.Config) error {
 		WriteTimeout:      cfg.WriteTimeout,
 		IdleTimeout:       cfg.IdleTimeout,
 		ReadHeaderTimeout: 5 * time.Second,
+		TLSConfig: &tls.Config{
+			MinVersion: cfg.TLSMinVersion,
+		},
+		ConnState: state.TrackConn,
 	}
+	useTLS := cfg.TLSCertFile != "" && cfg.TLSKeyFile != ""
 	
 	// Channel to listen for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	
+	// SIGUSR1 toggles drain mode for deploys. Closing the channel on return
+	// ends the toggling goroutine.
+	drain := make(chan os.Signal, 1)
+	signal.Notify(drain, syscall.SIGUSR1)
+	defer func() {
+		signal.Stop(drain)
+		close(drain)
+	}()
+	go func() {
+		for range drain {
+			if state.ToggleDraining() {
+				log.Println("🚰 Drain mode enabled: refusing new URLs, still redirecting")
+			} else {
+				log.Println("🚰 Drain mode disabled: accepting new URLs")
+			}
+		}
+	}()
 	
 	// Start server in a goroutine
 	go func() {
@@ -110,33 +554,76 @@ func StartServer(store storage.Storage, cfg *config.Config) error {
 		log.Printf("📊 Health check available at: %s/health", cfg.BaseURL)
 		log.Printf("📝 API documentation:")
 		log.Printf("   POST %s/urls - Create short URL", cfg.BaseURL)
+		log.Printf("   POST %s/urls/reserve - Reserve a short code", cfg.BaseURL)
 		log.Printf("   GET  %s/{shortCode} - Redirect to long URL", cfg.BaseURL)
 		log.Printf("   GET  %s/urls/{shortCode}/stats - Get URL stats", cfg.BaseURL)
+		log.Printf("   GET  %s/api/expand?url={shortURL} - Expand a short URL", cfg.BaseURL)
 		log.Printf("⚙️  Configuration:")
 		log.Printf("   Mode: %s", cfg.GinMode)
 		log.Printf("   Read timeout: %v", cfg.ReadTimeout)
 		log.Printf("   Write timeout: %v", cfg.WriteTimeout)
 		log.Printf("   Idle timeout: %v", cfg.IdleTimeout)
 		
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if useTLS {
+			log.Printf("🔒 Serving HTTPS (minimum TLS version %s)", tls.VersionName(cfg.TLSMinVersion))
+			err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
 	
 	// Wait for interrupt signal
 	<-quit
-	log.Println("🛑 Shutting down server...")
+	inFlight, _ := state.InFlight()
+	log.Printf("🛑 Shutting down server: draining %d in-flight requests on %d open connections (timeout %v)",
+		inFlight, state.OpenConns(), cfg.ShutdownTimeout)
 	
 	// Create context with timeout for graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 	
 	// Attempt graceful shutdown
+	start := time.Now()
 	if err := server.Shutdown(ctx); err != nil {
+		if inFlight, routes := state.InFlight(); inFlight > 0 {
+			log.Printf("❌ Shutdown timed out with %d requests still running: %s", inFlight, routes)
+		}
 		log.Printf("❌ Server forced to shutdown: %v", err)
+		drainStore(store, cfg.RedisDrainTimeout)
 		return err
 	}
 	
-	log.Println("✅ Server exited gracefully")
+	log.Printf("✅ Server exited gracefully after draining for %v", time.Since(start).Round(time.Millisecond))
+	drainStore(store, cfg.RedisDrainTimeout)
 	return nil
+}
+
+// defaultStoreDrainTimeout bounds drainStore when the config leaves it at zero
+const defaultStoreDrainTimeout = 5 * time.Second
+
+// drainStore lets storage that buffers writes (Redis with a click flush
+// interval) write them and finish its commands in progress before the
+// process exits, waiting at most timeout. It runs once requests have
+// stopped, so nothing new is buffered meanwhile.
+func drainStore(store storage.Storage, timeout time.Duration) {
+	drainer, ok := store.(storage.Drainer)
+	if !ok {
+		return
+	}
+	if timeout <= 0 {
+		timeout = defaultStoreDrainTimeout
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	if err := drainer.Drain(ctx); err != nil {
+		log.Printf("❌ Failed to drain storage: %v", err)
+		return
+	}
+	log.Printf("✅ Storage drained in %v", time.Since(start).Round(time.Millisecond))
 } 
\ No newline at end of file
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+	
+	"tiny-url-service/middleware"
+)
+
+// ServerState holds runtime state shared between the router, handlers and
+// the server lifecycle (signals, shutdown)
+type ServerState struct {
+	draining  atomic.Bool                // When true, new URLs are refused but redirects keep working
+	openConns atomic.Int64               // Client connections currently open
+	latency   *middleware.LatencyTracker // Set by newRouter; reports in-flight requests
+	startedAt time.Time                  // When the server state was created
+}
+
+// NewServerState creates the runtime state for one server instance
+func NewServerState() *ServerState {
+	return &ServerState{startedAt: time.Now()}
+}
+
+// SetDraining enables or disables drain mode
+func (s *ServerState) SetDraining(draining bool) {
+	s.draining.Store(draining)
+}
+
+// ToggleDraining flips drain mode and returns the new value
+func (s *ServerState) ToggleDraining() bool {
+	for {
+		current := s.draining.Load()
+		if s.draining.CompareAndSwap(current, !current) {
+			return !current
+		}
+	}
+}
+
+// IsDraining reports whether the server is in drain mode
+func (s *ServerState) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// TrackConn counts open client connections; install it as http.Server.ConnState
+func (s *ServerState) TrackConn(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		s.openConns.Add(1)
+	case http.StateHijacked, http.StateClosed:
+		s.openConns.Add(-1)
+	}
+}
+
+// StartedAt returns when the server state was created
+func (s *ServerState) StartedAt() time.Time {
+	return s.startedAt
+}
+
+// OpenConns returns the number of client connections currently open
+func (s *ServerState) OpenConns() int64 {
+	return s.openConns.Load()
+}
+
+// InFlight returns the number of requests currently running and a
+// "METHOD /route (n)" summary of the busy routes, sorted by route
+func (s *ServerState) InFlight() (int64, string) {
+	if s.latency == nil {
+		return 0, ""
+	}
+	
+	var total int64
+	var routes []string
+	for route, n := range s.latency.InFlight() {
+		total += n
+		routes = append(routes, fmt.Sprintf("%s (%d)", route, n))
+	}
+	sort.Strings(routes)
+	return total, strings.Join(routes, ", ")
+}
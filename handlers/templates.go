@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"tiny-url-service/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passwordFormTemplate asks for the password of a protected link and
+// resubmits it to the same short URL as ?pw=. A GET form drops the action's
+// query string, so the original query (e.g. exp and sig) rides along as
+// hidden fields.
+var passwordFormTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Password required</title>
+</head>
+<body>
+  <h1>This link is password protected</h1>
+  <form method="GET" action="{{.Action}}">
+    {{- range .Query}}
+    <input type="hidden" name="{{.Name}}" value="{{.Value}}">
+    {{- end}}
+    <label for="pw">Password</label>
+    <input type="password" id="pw" name="pw" autofocus>
+    <button type="submit">Continue</button>
+  </form>
+</body>
+</html>
+`))
+
+// countdownTemplate shows the destination and forwards to it after the
+// link's delay, via meta refresh with a script fallback that keeps the
+// visible countdown ticking
+var countdownTemplate = template.Must(template.New("countdown").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta http-equiv="refresh" content="{{.Delay}};url={{.Target}}">
+  <title>Redirecting…</title>
+</head>
+<body>
+  <h1>You are being redirected</h1>
+  <p>Continuing to <a href="{{.Target}}">{{.Target}}</a> in <span id="countdown">{{.Delay}}</span> seconds.</p>
+  <script>
+    (function() {
+      var remaining = {{.Delay}};
+      var el = document.getElementById("countdown");
+      var timer = setInterval(function() {
+        remaining--;
+        el.textContent = remaining;
+        if (remaining <= 0) {
+          clearInterval(timer);
+          window.location.href = {{.Target}};
+        }
+      }, 1000);
+    })();
+  </script>
+</body>
+</html>
+`))
+
+// unfurlTemplate is served to link-preview crawlers so pasted short links
+// unfurl with the link's title and destination. Anything else that ends up
+// with it is forwarded to the destination.
+var unfurlTemplate = template.Must(template.New("unfurl").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta http-equiv="refresh" content="0;url={{.Target}}">
+  <title>{{.Title}}</title>
+  <meta property="og:type" content="website">
+  <meta property="og:title" content="{{.Title}}">
+  <meta property="og:url" content="{{.Target}}">
+  <meta property="og:description" content="{{.Description}}">
+  <meta name="twitter:card" content="summary">
+</head>
+<body>
+  <p><a href="{{.Target}}">{{.Title}}</a></p>
+</body>
+</html>
+`))
+
+// destinationDownTemplate warns that a link's destination failed its recent
+// health checks and lets the visitor continue anyway
+var destinationDownTemplate = template.Must(template.New("destination-down").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Destination may be down</title>
+</head>
+<body>
+  <h1>This link's destination may be down</h1>
+  <p>Recent checks of <strong>{{.Target}}</strong> failed, so the page may not load.</p>
+  <p><a href="{{.Target}}">Continue anyway</a></p>
+</body>
+</html>
+`))
+
+// linkErrorTemplate is the built-in page for browsers following a link that
+// is missing (404) or used up (410). ERROR_PAGE_DIR can replace it per status.
+var linkErrorTemplate = template.Must(template.New("link-error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title}}</title>
+  <style>
+    body { font-family: system-ui, sans-serif; max-width: 32rem; margin: 15vh auto; padding: 0 1rem; color: #333; text-align: center; }
+    h1 { font-size: 1.5rem; }
+    .status { font-size: 3rem; color: #999; margin: 0; }
+  </style>
+</head>
+<body>
+  <p class="status">{{.Status}}</p>
+  <h1>{{.Title}}</h1>
+  <p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// linkErrorStatuses are the statuses an HTML error page can be served for
+var linkErrorStatuses = []int{http.StatusNotFound, http.StatusGone}
+
+// loadErrorPages returns the HTML page for each link error status: dir's
+// <status>.html when present, else the built-in page. A page that fails to
+// parse is logged and replaced by the built-in one.
+func loadErrorPages(dir string) map[int]*template.Template {
+	pages := make(map[int]*template.Template, len(linkErrorStatuses))
+	for _, status := range linkErrorStatuses {
+		pages[status] = linkErrorTemplate
+		if dir == "" {
+			continue
+		}
+		
+		path := filepath.Join(dir, fmt.Sprintf("%d.html", status))
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			var page *template.Template
+			if page, err = template.New(filepath.Base(path)).Parse(string(data)); err == nil {
+				pages[status] = page
+				continue
+			}
+		}
+		log.Printf("Using the built-in %d page: failed to load %s: %v", status, path, err)
+	}
+	return pages
+}
+
+// wantsHTML reports whether the client prefers an HTML response (i.e. a browser)
+func wantsHTML(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/html")
+}
+
+// renderHTML executes tmpl with data and writes it with the given status
+func renderHTML(c *gin.Context, status int, tmpl *template.Template, data interface{}) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		middleware.ErrorJSON(c, 500, gin.H{
+			"error": "Failed to render page",
+		})
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", []byte(sb.String()))
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errStorageTimeout is returned by storageCall when an operation outlives
+// STORAGE_OP_TIMEOUT
+var errStorageTimeout = errors.New("storage operation timed out")
+
+// storageCall runs op, giving up after the configured STORAGE_OP_TIMEOUT.
+// Storage methods take no context, so a timed-out op is not cancelled: it
+// finishes in the background and its result is discarded. The handler is
+// released either way, which bounds tail latency when a backend is slow.
+func storageCall[T any](h *URLHandlers, op func() (T, error)) (T, error) {
+	timeout := h.cfg.StorageOpTimeout
+	if timeout <= 0 {
+		return op()
+	}
+	
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1) // Buffered so an abandoned op can still finish
+	go func() {
+		value, err := op()
+		done <- result{value, err}
+	}()
+	
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-timer.C:
+		var zero T
+		return zero, fmt.Errorf("%w after %s", errStorageTimeout, timeout)
+	}
+}
+
+// storageDo is storageCall for operations that only return an error
+func storageDo(h *URLHandlers, op func() error) error {
+	_, err := storageCall(h, func() (struct{}, error) {
+		return struct{}{}, op()
+	})
+	return err
+}
+
+// respondStorageTimeout answers 503 with a Retry-After hint when a storage
+// operation timed out
+func (h *URLHandlers) respondStorageTimeout(c *gin.Context) {
+	retryAfter := int(h.cfg.StorageOpTimeout.Round(time.Second) / time.Second)
+	c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	h.respondError(c, http.StatusServiceUnavailable, "Storage is not responding, try again later", nil)
+}
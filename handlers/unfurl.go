@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUnfurlCrawlers are the User-Agent substrings of common link-preview
+// crawlers, used when UNFURL_CRAWLERS is not set
+var defaultUnfurlCrawlers = []string{
+	"Slackbot",
+	"Twitterbot",
+	"facebookexternalhit",
+	"LinkedInBot",
+	"Discordbot",
+	"TelegramBot",
+	"WhatsApp",
+	"SkypeUriPreview",
+	"redditbot",
+}
+
+// unfurlCrawlers returns the lowercased crawler substrings to match, or nil
+// when UNFURL_PREVIEW is off
+func unfurlCrawlers(cfg *config.Config) []string {
+	if !cfg.UnfurlPreview {
+		return nil
+	}
+	crawlers := cfg.UnfurlCrawlers
+	if crawlers == nil {
+		crawlers = defaultUnfurlCrawlers
+	}
+	lowered := make([]string, len(crawlers))
+	for i, crawler := range crawlers {
+		lowered[i] = strings.ToLower(crawler)
+	}
+	return lowered
+}
+
+// isUnfurlCrawler reports whether the request comes from a configured
+// link-preview crawler
+func (h *URLHandlers) isUnfurlCrawler(c *gin.Context) bool {
+	if len(h.crawlers) == 0 {
+		return false
+	}
+	userAgent := strings.ToLower(c.GetHeader("User-Agent"))
+	if userAgent == "" {
+		return false
+	}
+	for _, crawler := range h.crawlers {
+		if strings.Contains(userAgent, crawler) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveUnfurl answers a crawler with the preview page for mapping. The
+// title falls back to the destination's host when the link has none.
+func (h *URLHandlers) serveUnfurl(c *gin.Context, mapping *models.URLMapping) {
+	target := mapping.LongURL
+	if h.cfg.UpgradeHTTPRedirects && !mapping.NoHTTPSUpgrade {
+		target = upgradeToHTTPS(target)
+	}
+
+	title := mapping.Title
+	if title == "" {
+		if u, err := url.Parse(target); err == nil && u.Host != "" {
+			title = u.Host
+		} else {
+			title = target
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	renderHTML(c, http.StatusOK, unfurlTemplate, gin.H{
+		"Title":       title,
+		"Target":      target,
+		"Description": "Redirects to " + target,
+	})
+}
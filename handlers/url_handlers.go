@@ -1,7 +1,23 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tiny-url-service/config"
+	"tiny-url-service/middleware"
 	"tiny-url-service/models"
 	"tiny-url-service/storage"
 	"tiny-url-service/utils"
@@ -9,109 +25,1644 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// maxDestinations caps how many weighted destinations one short code may split across
+const maxDestinations = 10
+
+// maxDestinationWeight caps each destination's weight, so the sum pickDestination
+// draws from can't overflow
+const maxDestinationWeight = 10000
+
+// Collision suggestions: how many to offer and how many candidates to try
+const (
+	suggestionCount       = 3
+	maxSuggestionAttempts = 20
+)
+
+// defaultRule labels clicks on rule-based links that matched no rule
+const defaultRule = "default"
+
 // URLHandlers contains the storage instance and handlers
 type URLHandlers struct {
-	storage storage.Storage
-	baseURL string
+	storage       storage.Storage
+	caps          storage.StorageCapabilities // What the backend supports, read once at startup
+	createMu      sync.Mutex          // Serializes custom-code creates on backends without atomic creates
+	baseURL       string
+	cfg           *config.Config
+	state         *ServerState
+	audit         storage.AuditLogger
+	ephemeral     storage.EphemeralStore // Short-lived state such as recent submissions for DEDUP_WINDOW
+	webhooks      *webhookDispatcher  // nil unless WEBHOOK_URL is set
+	health        *DestinationHealthChecker // nil unless CHECK_DESTINATION_HEALTH is on
+	signer        *utils.URLSigner    // nil unless SIGNING_SECRET is set
+	errorPages    map[int]*template.Template // HTML pages for browsers hitting missing or used-up links
+	reservedWords map[string]struct{} // Lowercased words refused as custom codes
+	namespaces    map[string]struct{} // Namespaces links may be created in and redirected from
+	validator     *utils.Validator    // Checks every long URL and destination must pass
+	crawlers      []string            // Lowercased User-Agent substrings served a preview under UNFURL_PREVIEW
 }
 
 // NewURLHandlers creates a new URL handlers instance
-func NewURLHandlers(store storage.Storage, baseURL string) *URLHandlers {
-	return &URLHandlers{
-		storage: store,
-		baseURL: baseURL,
+func NewURLHandlers(store storage.Storage, cfg *config.Config) *URLHandlers {
+	h := &URLHandlers{
+		storage:       store,
+		caps:          store.Capabilities(),
+		baseURL:       cfg.BaseURL,
+		cfg:           cfg,
+		state:         NewServerState(),
+		audit:         storage.NopAuditLogger{},
+		reservedWords: make(map[string]struct{}),
+		namespaces:    make(map[string]struct{}),
+		validator:     newURLValidator(cfg),
+		crawlers:      unfurlCrawlers(cfg),
+	}
+	for _, word := range ReservedWords(cfg) {
+		h.reservedWords[strings.ToLower(word)] = struct{}{}
+	}
+	for _, ns := range cfg.Namespaces {
+		// A namespace is the first path segment, so it obeys the custom code rules
+		if _, reserved := h.reservedWords[strings.ToLower(ns)]; reserved || !utils.IsValidCustomCode(ns) {
+			log.Printf("Ignoring namespace %q: not usable as a path segment", ns)
+			continue
+		}
+		h.namespaces[ns] = struct{}{}
 	}
+	if cfg.DedupWindow > 0 {
+		h.ephemeral = storage.NewMemoryEphemeralStore(storage.DefaultEphemeralSweepInterval)
+	}
+	h.errorPages = loadErrorPages(cfg.ErrorPageDir)
+	if cfg.WebhookURL != "" {
+		h.webhooks = newWebhookDispatcher(cfg.WebhookURL, cfg.WebhookConcurrency, cfg.WebhookQueueSize, cfg.WebhookTimeout)
+	}
+	if cfg.SigningSecret != "" {
+		h.signer = utils.NewURLSigner(cfg.SigningSecret)
+	}
+	if cfg.CheckDestinationHealth {
+		h.health = NewDestinationHealthChecker(store, cfg.DestinationHealthInterval, cfg.DestinationHealthSample, cfg.DestinationHealthTimeout)
+		h.health.Start()
+	}
+	return h
+}
+
+// stripURL removes the parts of a long URL configured by URL_STRIP_FRAGMENT
+// and URL_STRIP_PARAMS
+func (h *URLHandlers) stripURL(longURL string) string {
+	return utils.StripURL(longURL, h.cfg.URLStripFragment, h.cfg.URLStripParams)
 }
 
 // CreateShortURL handles POST /urls - creates a new short URL
 func (h *URLHandlers) CreateShortURL(c *gin.Context) {
 	var req models.ShortenRequest
 	
-	// Bind JSON request to struct
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON format",
-			"details": err.Error(),
+	// Refuse new URLs while draining; redirects keep working
+	if h.state.IsDraining() {
+		h.respondDraining(c)
+		return
+	}
+	
+	// Strictly decode the request so unknown or mistyped fields are reported
+	if err := bindShortenRequest(c, &req); err != nil {
+		h.respondBindError(c, err)
+		return
+	}
+	if _, ok := requestedQRSize(c); !ok {
+		h.respondError(c, http.StatusBadRequest, "qr_size must be a positive integer", nil)
+		return
+	}
+	
+	// Validate requested response formats before doing any work
+	if _, unknown := requestedFormats(c); unknown != "" {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error":         "Unknown format: " + unknown,
+			"valid_formats": utils.ShortURLFormats,
+		})
+		return
+	}
+	
+	// Settings the request leaves unset come from its campaign, and are
+	// validated below like any other
+	if req.Campaign != "" {
+		campaign, err := storageCall(h, func() (*models.Campaign, error) {
+			return h.storage.GetCampaign(req.Campaign)
 		})
+		if errors.Is(err, errStorageTimeout) {
+			h.respondStorageTimeout(c)
+			return
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			h.respond(c, http.StatusBadRequest, gin.H{
+				"error": "Unknown campaign '" + req.Campaign + "'",
+				"field": "campaign",
+			})
+			return
+		}
+		if err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to load campaign", err)
+			return
+		}
+		campaign.ApplyDefaults(&req)
+	}
+	
+	// Validate URL; the failing check's reason tells the client what to fix
+	if err := h.validator.Validate(req.LongURL); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid URL: "+err.Error(), nil)
 		return
 	}
 	
-	// Validate URL
-	if !utils.IsValidURL(req.LongURL) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid URL format. Must be http:// or https://",
+	// Resolve the namespace: an API key bound to one always creates there
+	if bound := h.cfg.OwnerNamespaces[c.GetString(ownerKey)]; bound != "" {
+		if req.Namespace != "" && req.Namespace != bound {
+			h.respondError(c, http.StatusForbidden, "This API key can only create links in namespace '"+bound+"'", nil)
+			return
+		}
+		req.Namespace = bound
+	}
+	if req.Namespace != "" {
+		if _, ok := h.namespaces[req.Namespace]; !ok {
+			h.respond(c, http.StatusBadRequest, gin.H{
+				"error": "Unknown namespace '" + req.Namespace + "'",
+				"field": "namespace",
+			})
+			return
+		}
+		if req.ReservationToken != "" {
+			h.respondError(c, http.StatusBadRequest, "Reserved codes can't be claimed in a namespace", nil)
+			return
+		}
+	}
+	
+	// Validate custom code
+	if req.CustomCode != "" {
+		if !utils.IsASCII(req.CustomCode) {
+			h.respondError(c, http.StatusBadRequest, "custom_code must be ASCII; Unicode lookalike and combining characters are not allowed", nil)
+			return
+		}
+		if !utils.IsValidCustomCode(req.CustomCode) {
+			h.respondError(c, http.StatusBadRequest, fmt.Sprintf("custom_code must be 1-%d letters, digits, '-' or '_'", utils.MaxCustomCodeLength), nil)
+			return
+		}
+		if req.ReservationToken != "" {
+			h.respondError(c, http.StatusBadRequest, "Specify either custom_code or reservation_token, not both", nil)
+			return
+		}
+		// Namespaced codes live below /<namespace>/, so they can't shadow routes
+		if _, reserved := h.reservedWords[strings.ToLower(req.CustomCode)]; reserved && req.Namespace == "" {
+			h.respondError(c, http.StatusConflict, "Short code is reserved", nil)
+			return
+		}
+	}
+	
+	if req.RequireSignature && h.signer == nil {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error": "require_signature needs signed URLs to be enabled (SIGNING_SECRET)",
+			"field": "require_signature",
+		})
+		return
+	}
+	
+	// Validate use limit
+	if req.MaxUses < 0 {
+		h.respondError(c, http.StatusBadRequest, "max_uses must be zero (unlimited) or a positive number", nil)
+		return
+	}
+	
+	// Validate title
+	req.Title = strings.TrimSpace(req.Title)
+	if len(req.Title) > maxTitleLength || utils.ContainsControlChars(req.Title) {
+		h.respondError(c, http.StatusBadRequest, "title must be at most "+strconv.Itoa(maxTitleLength)+" characters without control characters", nil)
+		return
+	}
+	
+	// Validate redirect delay
+	if maxDelay := h.maxRedirectDelay(); req.RedirectDelaySeconds < 0 || req.RedirectDelaySeconds > maxDelay {
+		h.respondError(c, http.StatusBadRequest, "redirect_delay_seconds must be between 0 and "+strconv.Itoa(maxDelay), nil)
+		return
+	}
+	
+	// Validate weighted destinations
+	if len(req.Destinations) > maxDestinations {
+		h.respondError(c, http.StatusBadRequest, "Too many destinations (maximum "+strconv.Itoa(maxDestinations)+")", nil)
+		return
+	}
+	for _, dest := range req.Destinations {
+		if err := h.validator.Validate(dest.URL); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid destination URL "+dest.URL+": "+err.Error(), nil)
+			return
+		}
+		if dest.Weight <= 0 || dest.Weight > maxDestinationWeight {
+			h.respondError(c, http.StatusBadRequest, "Destination weights must be between 1 and "+strconv.Itoa(maxDestinationWeight), nil)
+			return
+		}
+	}
+	
+	// Validate device redirect rules
+	devices := make(map[string]bool)
+	for i, rule := range req.RedirectRules {
+		device := strings.ToLower(rule.Device)
+		if !utils.IsDeviceClass(device) {
+			h.respondError(c, http.StatusBadRequest, "Redirect rule device must be mobile, tablet or desktop", nil)
+			return
+		}
+		if devices[device] {
+			h.respondError(c, http.StatusBadRequest, "Duplicate redirect rule for device: "+device, nil)
+			return
+		}
+		if err := h.validator.Validate(rule.URL); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid redirect rule URL "+rule.URL+": "+err.Error(), nil)
+			return
+		}
+		devices[device] = true
+		req.RedirectRules[i].Device = device
+	}
+	
+	// Normalize tags before storage so "Marketing " and "marketing" are one tag
+	tags, invalidTags := utils.NormalizeTags(req.Tags)
+	if len(invalidTags) > 0 {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error":        fmt.Sprintf("Tags must be 1-%d letters, digits, '-' or '_', starting with a letter or digit", utils.MaxTagLength),
+			"invalid_tags": invalidTags,
 		})
 		return
 	}
+	if maxTags := h.maxTags(); len(tags) > maxTags {
+		h.respond(c, http.StatusBadRequest, gin.H{
+			"error":        "Too many tags (maximum " + strconv.Itoa(maxTags) + ")",
+			"invalid_tags": tags[maxTags:],
+		})
+		return
+	}
+	req.Tags = tags
+	
+	// Links back into this service would chain or loop; resolve or reject them
+	resolved, err := h.resolveSelfLink(req.LongURL)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	req.LongURL = resolved
+	for i := range req.Destinations {
+		if req.Destinations[i].URL, err = h.resolveSelfLink(req.Destinations[i].URL); err != nil {
+			h.respondError(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	for i := range req.RedirectRules {
+		if req.RedirectRules[i].URL, err = h.resolveSelfLink(req.RedirectRules[i].URL); err != nil {
+			h.respondError(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	
+	// Drop the fragment and tracking params the operator doesn't store, so
+	// redirects, deduplication and the reverse index all see the clean URL
+	req.LongURL = h.stripURL(req.LongURL)
+	for i := range req.Destinations {
+		req.Destinations[i].URL = h.stripURL(req.Destinations[i].URL)
+	}
+	for i := range req.RedirectRules {
+		req.RedirectRules[i].URL = h.stripURL(req.RedirectRules[i].URL)
+	}
+	
+	// Resolve a retention tier into an expiration date
+	expirationDate := req.ExpirationDate
+	if req.Retention != "" && req.ExpirationDate != nil {
+		h.respondError(c, http.StatusBadRequest, "Specify either expiration_date or retention, not both", nil)
+		return
+	}
+	tier := req.Retention
+	if tier == "" && expirationDate == nil {
+		tier = h.cfg.DefaultRetention
+	}
+	if tier != "" {
+		ttl, ok := h.cfg.RetentionTiers[strings.ToLower(tier)]
+		if !ok {
+			h.respond(c, http.StatusBadRequest, gin.H{
+				"error":       "Unknown retention tier: " + tier,
+				"valid_tiers": h.retentionTierNames(),
+			})
+			return
+		}
+		expires := time.Now().Add(ttl + h.expirationJitter(ttl))
+		expirationDate = &expires
+	}
+	
+	// A sliding link's window is its lifetime at creation
+	var slidingSeconds int64
+	if req.SlidingExpiration {
+		if expirationDate == nil {
+			h.respond(c, http.StatusBadRequest, gin.H{
+				"error": "sliding_expiration needs an expiration_date or retention",
+				"field": "sliding_expiration",
+			})
+			return
+		}
+		slidingSeconds = int64(time.Until(*expirationDate).Round(time.Second) / time.Second)
+		if slidingSeconds <= 0 {
+			h.respond(c, http.StatusBadRequest, gin.H{
+				"error": "sliding_expiration needs an expiration_date in the future",
+				"field": "sliding_expiration",
+			})
+			return
+		}
+	}
+	
+	// Return the existing code for an identical recent submission from this client
+	dedupKey := h.dedupKey(c, &req)
+	if dedupKey != "" {
+		if code, err := h.ephemeral.Get(dedupKey); err == nil {
+			existing, err := storageCall(h, func() (*models.URLMapping, error) {
+				return h.storage.Get(code)
+			})
+			if err == nil {
+				h.respond(c, http.StatusOK, h.shortenResponse(c, existing.PublicCode(), existing))
+				return
+			} else if errors.Is(err, errStorageTimeout) {
+				h.respondStorageTimeout(c)
+				return
+			}
+		}
+	}
+	
+	// Reuse an existing unconditional link for the same URL when the
+	// storage keeps a reverse index. With UNIQUE_URLS_PER_OWNER an owner
+	// only reuses its own links, so other owners' links stay separate.
+	owner := c.GetString(ownerKey)
+	if h.caps.SupportsReverseIndex && isPlainRequest(&req, expirationDate) {
+		existing, err := storageCall(h, func() (*models.URLMapping, error) {
+			if h.cfg.UniqueURLsPerOwner && owner != "" {
+				return h.storage.FindByOwnerLongURL(owner, req.LongURL)
+			}
+			return h.storage.FindByLongURL(req.LongURL)
+		})
+		if err == nil {
+			h.respond(c, http.StatusOK, h.shortenResponse(c, existing.PublicCode(), existing))
+			return
+		} else if errors.Is(err, errStorageTimeout) {
+			h.respondStorageTimeout(c)
+			return
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("failed to look up %q in the long URL index: %v", req.LongURL, err)
+		}
+	}
 	
 	// Create URL mapping
 	mapping := &models.URLMapping{
 		LongURL:        req.LongURL,
-		ExpirationDate: req.ExpirationDate,
+		ExpirationDate: expirationDate,
+		MaxUses:        req.MaxUses,
+		Destinations:   req.Destinations,
+		RedirectRules:  req.RedirectRules,
+		Tags:           req.Tags,
+		RedirectDelaySeconds: req.RedirectDelaySeconds,
+		Title:          req.Title,
+		NoHTTPSUpgrade: req.NoHTTPSUpgrade,
+		Namespace:      req.Namespace,
+		Owner:          owner,
+		Campaign:       req.Campaign,
+		RequireSignature: req.RequireSignature,
+		SlidingExpirationSeconds: slidingSeconds,
 	}
 	
-	// Store in database
-	shortCode, err := h.storage.Store(mapping)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create short URL",
-			"details": err.Error(),
+	// Hash the password so only the digest is ever stored
+	if req.Password != "" {
+		hash, err := utils.HashPassword(req.Password)
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid password", err)
+			return
+		}
+		mapping.PasswordHash = hash
+	}
+	
+	// Use the custom code, claim a reserved code, or generate a new one
+	var shortCode string
+	if req.CustomCode != "" {
+		err := storageDo(h, func() error {
+			return h.storeWithCode(mapping, storage.NamespacedCode(req.Namespace, req.CustomCode))
 		})
-		return
+		if err != nil {
+			h.respondStoreError(c, err, req.Namespace, req.CustomCode)
+			return
+		}
+		shortCode = req.CustomCode
+	} else if req.ReservationToken != "" {
+		err := storageDo(h, func() error {
+			return h.storage.ClaimReservationMapping(req.ReservationToken, mapping)
+		})
+		if err != nil {
+			if errors.Is(err, errStorageTimeout) {
+				h.respondStorageTimeout(c)
+				return
+			}
+			if errors.Is(err, storage.ErrReservationNotFound) {
+				h.respondError(c, http.StatusNotFound, "Reservation not found or expired", nil)
+				return
+			}
+			if errors.Is(err, storage.ErrCapacityExceeded) {
+				h.respondError(c, http.StatusInsufficientStorage, "URL capacity reached", nil)
+				return
+			}
+			if errors.Is(err, storage.ErrCodeTaken) {
+				h.respondError(c, http.StatusConflict, "Reserved code is no longer available", nil)
+				return
+			}
+			h.respondError(c, http.StatusInternalServerError, "Failed to claim reservation", err)
+			return
+		}
+		shortCode = mapping.ShortCode
+	} else {
+		var err error
+		shortCode, err = storageCall(h, func() (string, error) {
+			return h.storage.Store(mapping)
+		})
+		if errors.Is(err, errStorageTimeout) {
+			h.respondStorageTimeout(c)
+			return
+		}
+		if errors.Is(err, storage.ErrCapacityExceeded) {
+			h.respondError(c, http.StatusInsufficientStorage, "URL capacity reached", nil)
+			return
+		}
+		if errors.Is(err, storage.ErrIDSpaceExhausted) || errors.Is(err, storage.ErrKeyspaceExhausted) {
+			log.Printf("cannot generate short codes: %v", err)
+			h.respondError(c, http.StatusInsufficientStorage, "Short code space exhausted", nil)
+			return
+		}
+		if err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to create short URL", err)
+			return
+		}
 	}
 	
-	// Return response
+	if mapping.Namespace != "" {
+		shortCode = mapping.PublicCode()
+	}
+	
+	h.recordAudit(c, "create", shortCode, mapping.LongURL)
+	if dedupKey != "" {
+		// A concurrent duplicate may have won; its code is the one later resubmissions get
+		if _, err := h.ephemeral.SetNX(dedupKey, mapping.ShortCode, h.cfg.DedupWindow); err != nil {
+			log.Printf("failed to remember submission for duplicate detection: %v", err)
+		}
+	}
+	
+	// Return response. Only a newly created link gets 201; dedup and
+	// reverse index hits above return an existing one with 200.
+	response := h.shortenResponse(c, shortCode, mapping)
+	if h.webhooks != nil {
+		h.webhooks.enqueue(webhookEvent{
+			Event:     "url.created",
+			ShortCode: shortCode,
+			ShortURL:  response.ShortURL,
+			LongURL:   mapping.LongURL,
+			Time:      time.Now().UTC(),
+		})
+	}
+	if h.cfg.CreateStatus201 {
+		c.Header("Location", response.ShortURL)
+		h.respond(c, http.StatusCreated, response)
+		return
+	}
+	h.respond(c, http.StatusOK, response)
+}
+
+// shortenResponse builds the create response for shortCode, adding the
+// ?formats= variants and the ?qr=true QR code when requested
+func (h *URLHandlers) shortenResponse(c *gin.Context, shortCode string, mapping *models.URLMapping) models.ShortenResponse {
 	response := models.ShortenResponse{
-		ShortURL: h.baseURL + "/" + shortCode,
+		ShortURL:  h.shortURL(c, shortCode),
+		ShortCode: shortCode,
+		ExpiresAt: mapping.ExpirationDate,
+	}
+	h.setExpiresHeader(c, mapping)
+	
+	formats, _ := requestedFormats(c)
+	if len(formats) > 0 {
+		label := mapping.Title
+		if label == "" {
+			label = mapping.LongURL
+		}
+		response.Formats = make(map[string]string, len(formats))
+		for _, format := range formats {
+			response.Formats[format], _ = utils.FormatShortURL(format, response.ShortURL, shortCode, label)
+		}
 	}
 	
-	c.JSON(http.StatusOK, response)
+	// Rendering is skipped unless asked for. The link exists by now, so a
+	// short URL too long to encode is logged rather than failing the create.
+	if size, _ := requestedQRSize(c); size > 0 {
+		uri, err := utils.QRDataURI(response.ShortURL, size)
+		if err != nil {
+			log.Printf("failed to render QR code for %s: %v", shortCode, err)
+		}
+		response.QRDataURI = uri
+	}
+	return response
 }
 
-// RedirectToLongURL handles GET /{shortCode} - redirects to the original URL
+// maxTitleLength caps the optional link title
+const maxTitleLength = 200
+
+// requestedFormats parses the comma-separated ?formats= query parameter. It
+// returns the first name FormatShortURL doesn't know as unknown.
+func requestedFormats(c *gin.Context) (formats []string, unknown string) {
+	raw := c.Query("formats")
+	if raw == "" {
+		return nil, ""
+	}
+	
+	for _, format := range strings.Split(raw, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "" {
+			continue
+		}
+		if _, ok := utils.FormatShortURL(format, "", "", ""); !ok {
+			return nil, format
+		}
+		formats = append(formats, format)
+	}
+	return formats, ""
+}
+
+// requestedQRSize returns the clamped ?qr_size= (QRDefaultSize when unset)
+// if ?qr=true was given, or 0 when no QR code was requested. ok is false
+// for a qr_size that isn't a positive integer.
+func requestedQRSize(c *gin.Context) (size int, ok bool) {
+	if want, _ := strconv.ParseBool(c.Query("qr")); !want {
+		return 0, true
+	}
+	size = utils.QRDefaultSize
+	if raw := c.Query("qr_size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return 0, false
+		}
+		size = parsed
+	}
+	return utils.ClampQRSize(size), true
+}
+
+// NotFound handles requests that match no route
+func (h *URLHandlers) NotFound(c *gin.Context) {
+	h.respondError(c, http.StatusNotFound, "Not found", nil)
+}
+
+// ReserveShortCode handles POST /urls/reserve - holds the next short code for later use
+func (h *URLHandlers) ReserveShortCode(c *gin.Context) {
+	if h.state.IsDraining() {
+		h.respondDraining(c)
+		return
+	}
+	
+	code, token, err := h.storage.Reserve()
+	if errors.Is(err, storage.ErrIDSpaceExhausted) || errors.Is(err, storage.ErrKeyspaceExhausted) {
+		log.Printf("cannot reserve short codes: %v", err)
+		h.respondError(c, http.StatusInsufficientStorage, "Short code space exhausted", nil)
+		return
+	}
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to reserve short code", err)
+		return
+	}
+	
+	ttl := h.cfg.ReservationTTL
+	if ttl <= 0 {
+		ttl = storage.DefaultReservationTTL
+	}
+	
+	h.respond(c, http.StatusOK, models.ReserveResponse{
+		ShortCode:        code,
+		ShortURL:         h.shortURL(c, code),
+		ReservationToken: token,
+		ExpiresAt:        time.Now().Add(ttl),
+	})
+}
+
+// RedirectToLongURL handles GET /{shortCode} and GET /{namespace}/{shortCode}
+// - redirects to the original URL
 func (h *URLHandlers) RedirectToLongURL(c *gin.Context) {
 	shortCode := c.Param("shortCode")
+	namespace := ""
+	if code := c.Param("code"); code != "" {
+		namespace, shortCode = shortCode, code
+	}
 	
 	// Validate short code is not empty
 	if shortCode == "" {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Short code not provided",
-		})
+		h.respondLinkError(c, http.StatusNotFound, "Short code not provided")
+		return
+	}
+	
+	// Bot probes such as /wp-login.php can't be our codes; skip the lookup
+	if !h.isPlausibleCode(shortCode) {
+		h.respondLinkError(c, http.StatusNotFound, "Short URL not found")
 		return
 	}
 	
+	// Namespaced codes are stored as <namespace>:<code>
+	if namespace != "" {
+		if _, ok := h.namespaces[namespace]; !ok {
+			h.respondLinkError(c, http.StatusNotFound, "Short URL not found")
+			return
+		}
+		shortCode = storage.NamespacedCode(namespace, shortCode)
+	}
+	
 	// Get URL mapping from storage
-	mapping, err := h.storage.Get(shortCode)
+	mapping, err := storageCall(h, func() (*models.URLMapping, error) {
+		return h.storage.Get(shortCode)
+	})
+	if errors.Is(err, errStorageTimeout) {
+		h.respondStorageTimeout(c)
+		return
+	}
+	if errors.Is(err, storage.ErrChecksumMismatch) && !wantsHTML(c) {
+		h.respondChecksumMismatch(c, shortCode)
+		return
+	}
+	if err != nil {
+		h.respondLinkError(c, http.StatusNotFound, "Short URL not found")
+		return
+	}
+	
+	shortCode = mapping.ShortCode // The stored key; differs from the path for case-folded codes
+	
+	// Signed shares must carry a valid, unexpired signature
+	if !h.checkSignature(c, mapping, c.Query("exp"), c.Query("sig")) {
+		return
+	}
+	
+	// Protected links only redirect once the correct password is supplied
+	if mapping.PasswordHash != "" && !h.checkLinkPassword(c, mapping) {
+		return
+	}
+	
+	// Link-preview crawlers get OpenGraph tags instead; they aren't clicks,
+	// so no use is consumed and no access is recorded
+	if h.isUnfurlCrawler(c) {
+		h.serveUnfurl(c, mapping)
+		return
+	}
+	
+	// Use-limited links consume a use atomically and are gone once exhausted
+	if mapping.MaxUses > 0 {
+		remaining, err := storageCall(h, func() (int, error) {
+			return h.storage.ConsumeUse(shortCode)
+		})
+		if err != nil {
+			if errors.Is(err, errStorageTimeout) {
+				h.respondStorageTimeout(c)
+				return
+			}
+			if errors.Is(err, storage.ErrUsesExhausted) {
+				h.respondLinkError(c, http.StatusGone, "Short URL is no longer available")
+				return
+			}
+			h.respondError(c, http.StatusInternalServerError, "Failed to record link use", err)
+			return
+		}
+		// Negative means the link turned out to be unlimited after all
+		if remaining >= 0 {
+			c.Header(usesRemainingHeader, strconv.Itoa(remaining))
+		}
+	}
+	
+	// Analytics must never block a redirect, so failures are only logged
+	err = storageDo(h, func() error {
+		return h.storage.RecordAccess(shortCode, time.Now())
+	})
+	if err != nil {
+		log.Printf("failed to record access for %q: %v", shortCode, err)
+	}
+	// Sliding links live on while they're used; a failed extension only
+	// shortens the link's life, so it doesn't block the redirect either
+	if mapping.SlidingExpirationSeconds > 0 {
+		window := time.Duration(mapping.SlidingExpirationSeconds) * time.Second
+		extended, err := storageCall(h, func() (*models.URLMapping, error) {
+			return h.storage.GetAndExtend(shortCode, window)
+		})
+		if err != nil {
+			log.Printf("failed to extend expiration of %q: %v", shortCode, err)
+		} else {
+			mapping.ExpirationDate = extended.ExpirationDate
+		}
+	}
+	if h.cfg.TrackUniqueVisitors {
+		err = storageDo(h, func() error {
+			return h.storage.RecordVisitor(shortCode, middleware.ClientID(c))
+		})
+		if err != nil {
+			log.Printf("failed to record visitor for %q: %v", shortCode, err)
+		}
+	}
+	if h.sampleClick() {
+		event := models.AccessEvent{
+			Time:      time.Now().UTC(),
+			Referrer:  c.GetHeader("Referer"),
+			UserAgent: c.GetHeader("User-Agent"),
+			Visitor:   middleware.ClientID(c),
+		}
+		err = storageDo(h, func() error {
+			return h.storage.RecordAccessEvent(shortCode, event)
+		})
+		if err != nil {
+			log.Printf("failed to record access event for %q: %v", shortCode, err)
+		}
+	}
+	
+	// Redirect, optionally carrying the request's query params
+	target := h.resolveTarget(c, shortCode, mapping)
+	if h.cfg.UpgradeHTTPRedirects && !mapping.NoHTTPSUpgrade {
+		target = upgradeToHTTPS(target)
+	}
+	h.setExpiresHeader(c, mapping)
+	target = h.withQueryParams(c, mapping.ShortCode, target)
+	
+	// A destination whose recent health checks failed gets a warning page
+	// instead of a redirect into an error
+	if h.health != nil && h.health.IsDown(target) {
+		h.warnDestinationDown(c, mapping.ShortCode, target)
+		return
+	}
+	h.redirect(c, mapping.ShortCode, target, mapping.RedirectDelaySeconds)
+}
+
+// destinationStatusHeader marks responses that replaced a redirect because
+// the destination looks down
+const destinationStatusHeader = "X-Destination-Status"
+
+// warnDestinationDown serves the page telling the visitor target seems to
+// be down, with a link to continue anyway
+func (h *URLHandlers) warnDestinationDown(c *gin.Context, shortCode, target string) {
+	if utils.ContainsControlChars(target) {
+		log.Printf("⚠️  ALERT: refusing redirect for %q: stored URL contains control characters", shortCode)
+		h.respondError(c, http.StatusInternalServerError, "Stored URL is malformed", nil)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header(destinationStatusHeader, "down")
+	renderHTML(c, http.StatusOK, destinationDownTemplate, gin.H{
+		"Target": target,
+	})
+}
+
+// upgradeToHTTPS rewrites an http:// target to https://, dropping an
+// explicit :80. Targets on another explicit port are left alone, since a
+// service there is unlikely to speak TLS on the same port.
+func upgradeToHTTPS(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || !strings.EqualFold(u.Scheme, "http") {
+		return target
+	}
+	switch u.Port() {
+	case "":
+	case "80":
+		u.Host = u.Hostname()
+		if strings.Contains(u.Host, ":") {
+			u.Host = "[" + u.Host + "]" // IPv6 literal
+		}
+	default:
+		return target
+	}
+	u.Scheme = "https"
+	return u.String()
+}
+
+// sampleClick reports whether this redirect's detail should be recorded,
+// with probability CLICK_SAMPLE_RATE
+func (h *URLHandlers) sampleClick() bool {
+	switch rate := h.cfg.ClickSampleRate; {
+	case rate <= 0:
+		return false
+	case rate >= 1:
+		return true
+	default:
+		return rand.Float64() < rate
+	}
+}
+
+// expiresHeader carries a link's expiration on create and redirect responses
+const expiresHeader = "X-Expires-At"
+
+// usesRemainingHeader carries how many uses a use-limited link has left after
+// the redirect it is sent with
+const usesRemainingHeader = "X-Uses-Remaining"
+
+// setExpiresHeader sets X-Expires-At to mapping's expiration in RFC3339 UTC
+// when EXPIRES_AT_HEADER is enabled. Links that never expire get no header.
+func (h *URLHandlers) setExpiresHeader(c *gin.Context, mapping *models.URLMapping) {
+	if !h.cfg.ExpiresAtHeader || mapping.ExpirationDate == nil {
+		return
+	}
+	c.Header(expiresHeader, mapping.ExpirationDate.UTC().Format(time.RFC3339))
+}
+
+// respondLinkError answers a redirect that can't be followed. Browsers get
+// the HTML page for status; API clients get the usual JSON error.
+func (h *URLHandlers) respondLinkError(c *gin.Context, status int, message string) {
+	page := h.errorPages[status]
+	if page == nil || !wantsHTML(c) {
+		h.respondError(c, status, message, nil)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	renderHTML(c, status, page, gin.H{
+		"Status":    status,
+		"Title":     http.StatusText(status),
+		"Message":   message,
+		"ShortCode": strings.TrimPrefix(c.Request.URL.Path, "/"),
+	})
+}
+
+// respondChecksumMismatch answers a code whose check character is wrong with
+// a 404 that suggests the nearest existing code, if there is one
+func (h *URLHandlers) respondChecksumMismatch(c *gin.Context, shortCode string) {
+	body := gin.H{
+		"error": "Short URL not found (the code looks mistyped)",
+	}
+	namespace, code := storage.SplitNamespace(shortCode)
+	if suggestions := utils.SuggestBase62Checksum(code); len(suggestions) > 0 {
+		for i, suggestion := range suggestions {
+			suggestions[i] = storage.NamespacedCode(namespace, suggestion)
+		}
+		exists, err := h.storage.ExistsBatch(suggestions)
+		if err != nil {
+			log.Printf("failed to look up suggestions for %q: %v", shortCode, err)
+		}
+		for _, suggestion := range suggestions {
+			if exists[suggestion] {
+				body["did_you_mean"] = h.shortURL(c, (&models.URLMapping{ShortCode: suggestion, Namespace: namespace}).PublicCode())
+				break
+			}
+		}
+	}
+	h.respond(c, http.StatusNotFound, body)
+}
+
+// resolveTarget picks where this visitor goes: a matching device rule first,
+// then a weighted A/B destination, then the stored long URL. Rule and
+// destination picks are counted as labeled clicks for stats.
+func (h *URLHandlers) resolveTarget(c *gin.Context, shortCode string, mapping *models.URLMapping) string {
+	if len(mapping.RedirectRules) > 0 {
+		rule := matchRedirectRule(mapping.RedirectRules, c.GetHeader("User-Agent"))
+		label := ruleLabel(defaultRule)
+		if rule != nil {
+			label = ruleLabel(rule.Device)
+		}
+		err := storageDo(h, func() error {
+			return h.storage.RecordLabeledClick(shortCode, label)
+		})
+		if err != nil {
+			log.Printf("failed to record rule click for %q: %v", shortCode, err)
+		}
+		if rule != nil {
+			return rule.URL
+		}
+	}
+	
+	if len(mapping.Destinations) > 0 {
+		i := pickDestination(mapping.Destinations)
+		err := storageDo(h, func() error {
+			return h.storage.RecordLabeledClick(shortCode, destinationLabel(i))
+		})
+		if err != nil {
+			log.Printf("failed to record destination click for %q: %v", shortCode, err)
+		}
+		return mapping.Destinations[i].URL
+	}
+	
+	return mapping.LongURL
+}
+
+// matchRedirectRule returns the rule for the visitor's device class, if any
+func matchRedirectRule(rules []models.RedirectRule, userAgent string) *models.RedirectRule {
+	device := utils.ClassifyDevice(userAgent)
+	for i := range rules {
+		if rules[i].Device == device {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// ruleLabel is the labeled-click key for a device rule (or defaultRule)
+func ruleLabel(device string) string {
+	return "rule:" + device
+}
+
+// pickDestination returns the index of a destination chosen with probability
+// proportional to its weight
+func pickDestination(dests []models.WeightedURL) int {
+	total := 0
+	for _, dest := range dests {
+		total += dest.Weight
+	}
+	if total <= 0 {
+		return 0
+	}
+	
+	n := rand.Intn(total)
+	for i, dest := range dests {
+		if n < dest.Weight {
+			return i
+		}
+		n -= dest.Weight
+	}
+	return len(dests) - 1
+}
+
+// destinationLabel is the labeled-click key for the i-th weighted destination
+func destinationLabel(i int) string {
+	return "destination:" + strconv.Itoa(i)
+}
+
+// withQueryParams returns target merged with the request's query params when
+// MERGE_QUERY_PARAMS is on. The link password (?pw=) is never forwarded to
+// the destination, nor are the exp and sig of a signed share.
+func (h *URLHandlers) withQueryParams(c *gin.Context, shortCode, target string) string {
+	if !h.cfg.MergeQueryParams || c.Request.URL.RawQuery == "" {
+		return target
+	}
+	
+	incoming := c.Request.URL.Query()
+	incoming.Del("pw")
+	if h.signer != nil {
+		incoming.Del("exp")
+		incoming.Del("sig")
+	}
+	
+	incomingWins := !strings.EqualFold(h.cfg.QueryPrecedence, "stored")
+	merged, err := utils.MergeQueryParams(target, incoming, incomingWins)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Short URL not found",
+		log.Printf("failed to merge query params for %q: %v", shortCode, err)
+		return target
+	}
+	return merged
+}
+
+// redirect issues a 302 to target after making sure it is safe to place in
+// the Location header, or serves a countdown page forwarding to it when the
+// link has a delay. Both carry the REDIRECT_HEADERS. A stored URL with control characters indicates a
+// corrupted or malicious mapping, so it is logged and refused.
+func (h *URLHandlers) redirect(c *gin.Context, shortCode, target string, delaySeconds int) {
+	if utils.ContainsControlChars(target) {
+		log.Printf("⚠️  ALERT: refusing redirect for %q: stored URL contains control characters", shortCode)
+		h.respondError(c, http.StatusInternalServerError, "Stored URL is malformed", nil)
+		return
+	}
+	
+	headers := h.cfg.RedirectHeaders
+	if headers == nil {
+		headers = defaultRedirectHeaders
+	}
+	for name, value := range headers {
+		c.Header(name, value)
+	}
+	
+	if delaySeconds > 0 {
+		c.Header("Cache-Control", "no-store")
+		renderHTML(c, http.StatusOK, countdownTemplate, gin.H{
+			"Delay":  delaySeconds,
+			"Target": target,
 		})
 		return
 	}
 	
-	// Redirect to original URL
-	c.Redirect(http.StatusFound, mapping.LongURL)
+	c.Redirect(http.StatusFound, target)
+}
+
+// defaultRedirectHeaders keep the short URL from leaking to destinations as
+// the Referer when REDIRECT_HEADERS is not configured
+var defaultRedirectHeaders = map[string]string{"Referrer-Policy": "no-referrer"}
+
+// defaultMaxRedirectDelay is the longest redirect delay accepted when none is configured
+const defaultMaxRedirectDelay = 30
+
+// maxRedirectDelay returns the configured redirect_delay_seconds limit
+func (h *URLHandlers) maxRedirectDelay() int {
+	if h.cfg.MaxRedirectDelay <= 0 {
+		return defaultMaxRedirectDelay
+	}
+	return h.cfg.MaxRedirectDelay
 }
 
 // GetURLStats handles GET /urls/{shortCode}/stats - returns URL statistics
 func (h *URLHandlers) GetURLStats(c *gin.Context) {
 	shortCode := c.Param("shortCode")
+	fields, ok := h.requestedFields(c, statsFields)
+	if !ok {
+		return
+	}
+	
+	// Get URL mapping from storage; expired links keep their stats until purged
+	mapping, err := h.storage.GetRaw(shortCode)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Short URL not found", nil)
+		return
+	}
+	
+	shortCode = mapping.ShortCode // The stored key; differs from the path for case-folded codes
+	
+	// Return URL information; protected destinations only with the link's credentials
+	showDestination := h.destinationVisible(c, mapping)
+	stats := h.baseStats(mapping, showDestination)
+	
+	// Per-rule and per-destination click counts
+	var clicks map[string]int64
+	if len(mapping.Destinations) > 0 || len(mapping.RedirectRules) > 0 {
+		clicks, err = h.storage.LabeledClicks(shortCode)
+		if err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to load click breakdown", err)
+			return
+		}
+	}
+	if len(mapping.RedirectRules) > 0 {
+		rules := make([]gin.H, len(mapping.RedirectRules))
+		for i, rule := range mapping.RedirectRules {
+			rules[i] = gin.H{
+				"device": rule.Device,
+				"clicks": clicks[ruleLabel(rule.Device)],
+			}
+			if showDestination {
+				rules[i]["url"] = rule.URL
+			}
+		}
+		stats["redirect_rules"] = rules
+		stats["default_rule_clicks"] = clicks[ruleLabel(defaultRule)]
+	}
+	if len(mapping.Destinations) > 0 {
+		destinations := make([]gin.H, len(mapping.Destinations))
+		for i, dest := range mapping.Destinations {
+			destinations[i] = gin.H{
+				"weight": dest.Weight,
+				"clicks": clicks[destinationLabel(i)],
+			}
+			if showDestination {
+				destinations[i]["url"] = dest.URL
+			}
+		}
+		stats["destinations"] = destinations
+	}
+	
+	if h.cfg.TrackUniqueVisitors {
+		visitors, err := h.storage.UniqueVisitors(shortCode)
+		if err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to count unique visitors", err)
+			return
+		}
+		stats["unique_visitors"] = visitors
+	}
+	
+	// Optional click time series: ?series=hourly (last 24h) or ?series=daily (last 7d)
+	if series := c.Query("series"); series != "" {
+		bucket, window, ok := seriesWindow(series)
+		if !ok {
+			h.respondError(c, http.StatusBadRequest, "series must be hourly or daily", nil)
+			return
+		}
+		
+		counts, err := h.storage.AccessSeries(shortCode, bucket, time.Now().Add(-window))
+		if err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to load click series", err)
+			return
+		}
+		stats["series"] = counts
+	}
+	
+	// Optional sampled access detail: ?events=1
+	if withEvents, _ := strconv.ParseBool(c.Query("events")); withEvents {
+		events, err := h.storage.RecentAccessEvents(shortCode, storage.MaxAccessEvents)
+		if err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to load access events", err)
+			return
+		}
+		stats["recent_events"] = events
+	}
+	
+	h.respond(c, http.StatusOK, fields.apply(stats))
+}
+
+// baseStats returns the stats fields every stats response shares. Without
+// showDestination the long URL is left out and destination_hidden is set.
+func (h *URLHandlers) baseStats(mapping *models.URLMapping, showDestination bool) gin.H {
+	expired, secondsLeft := h.expiryStatus(mapping, time.Now())
+	stats := gin.H{
+		"short_code":           mapping.PublicCode(),
+		"created_at":           mapping.CreatedAt,
+		"expiration_date":      mapping.ExpirationDate,
+		"id":                   mapping.ID,
+		"password_protected":   mapping.PasswordHash != "",
+		"max_uses":             mapping.MaxUses,
+		"use_count":            mapping.UseCount,
+		"access_count":         mapping.AccessCount,
+		"is_expired":           expired,
+		"seconds_until_expiry": secondsLeft,
+	}
+	if showDestination {
+		stats["long_url"] = mapping.LongURL
+	} else {
+		stats["destination_hidden"] = true
+	}
+	if mapping.Title != "" {
+		stats["title"] = mapping.Title
+	}
+	if len(mapping.Tags) > 0 {
+		stats["tags"] = mapping.Tags
+	}
+	if mapping.RedirectDelaySeconds > 0 {
+		stats["redirect_delay_seconds"] = mapping.RedirectDelaySeconds
+	}
+	return stats
+}
+
+// maxBatchStatsCodes caps the codes accepted by one batch stats request
+const maxBatchStatsCodes = 100
+
+// batchStatsRequest is the payload for POST /urls/stats/batch
+type batchStatsRequest struct {
+	ShortCodes []string `json:"short_codes" binding:"required"`
+}
+
+// GetBatchURLStats handles POST /urls/stats/batch - returns the base stats
+// of many links in one storage round trip, keyed by code. Unknown codes map
+// to an error and expired ones to an error flagged "expired", so a
+// dashboard can tell them apart. Per-destination clicks and series are only
+// available from the single-link endpoint.
+func (h *URLHandlers) GetBatchURLStats(c *gin.Context) {
+	fields, ok := h.requestedFields(c, statsFields)
+	if !ok {
+		return
+	}
+	
+	var req batchStatsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+	if len(req.ShortCodes) == 0 || len(req.ShortCodes) > maxBatchStatsCodes {
+		h.respondError(c, http.StatusBadRequest, fmt.Sprintf("short_codes must list 1-%d codes", maxBatchStatsCodes), nil)
+		return
+	}
+	
+	mappings, err := h.storage.GetBatch(req.ShortCodes)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to load URL statistics", err)
+		return
+	}
+	
+	// Entries are shaped one by one so JSON_CASE never rewrites the codes
+	// used as keys
+	results := make(map[string]interface{}, len(req.ShortCodes))
+	for _, code := range req.ShortCodes {
+		mapping, ok := mappings[code]
+		switch {
+		case !ok:
+			results[code] = h.shape(gin.H{"error": "Short URL not found"})
+		case h.storage.IsExpired(mapping):
+			results[code] = h.shape(gin.H{"error": "Short URL has expired", "expired": true})
+		default:
+			// No per-link credentials here, so protected destinations stay hidden
+			results[code] = h.shape(fields.apply(h.baseStats(mapping, !isProtected(mapping))))
+		}
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// prometheusLabelEscaper escapes a Prometheus text-format label value
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// GetURLMetrics handles GET /urls/{shortCode}/metrics - exposes one link's
+// click count and expiry in the Prometheus text format, so a scraper can be
+// pointed at a single link
+func (h *URLHandlers) GetURLMetrics(c *gin.Context) {
+	shortCode := c.Param("shortCode")
 	
-	// Get URL mapping from storage
 	mapping, err := h.storage.Get(shortCode)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Short URL not found",
-		})
+		h.respondError(c, http.StatusNotFound, "Short URL not found", nil)
 		return
 	}
 	
-	// Return URL information
-	c.JSON(http.StatusOK, gin.H{
-		"short_code":      mapping.ShortCode,
+	var expiry int64 // Zero for links that never expire
+	if mapping.ExpirationDate != nil {
+		expiry = mapping.ExpirationDate.Unix()
+	}
+	
+	labels := fmt.Sprintf(`{code="%s"}`, prometheusLabelEscaper.Replace(shortCode))
+	var sb strings.Builder
+	sb.WriteString("# HELP tinyurl_link_clicks_total Redirects recorded for the link.\n")
+	sb.WriteString("# TYPE tinyurl_link_clicks_total counter\n")
+	fmt.Fprintf(&sb, "tinyurl_link_clicks_total%s %d\n", labels, mapping.AccessCount)
+	sb.WriteString("# HELP tinyurl_link_expiry_timestamp_seconds Unix time the link expires at, 0 if it never expires.\n")
+	sb.WriteString("# TYPE tinyurl_link_expiry_timestamp_seconds gauge\n")
+	fmt.Fprintf(&sb, "tinyurl_link_expiry_timestamp_seconds%s %d\n", labels, expiry)
+	
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(sb.String()))
+}
+
+// ExpandShortURL handles GET /api/expand - resolves a short URL (?url=) or
+// bare code (?code=) to its long URL without redirecting or counting a click
+func (h *URLHandlers) ExpandShortURL(c *gin.Context) {
+	shortCode := c.Query("code")
+	if shortURL := c.Query("url"); shortURL != "" {
+		code, err := utils.ExtractShortCode(shortURL, h.baseURL)
+		if errors.Is(err, utils.ErrForeignShortURL) {
+			h.respondError(c, http.StatusBadRequest, "Short URL does not belong to this service", nil)
+			return
+		}
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid short URL", nil)
+			return
+		}
+		shortCode = code
+	}
+	if shortCode == "" {
+		h.respondError(c, http.StatusBadRequest, "Provide a short URL via ?url= or a code via ?code=", nil)
+		return
+	}
+	
+	mapping, err := h.storage.Get(shortCode)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Short URL not found", nil)
+		return
+	}
+	
+	// Expanding must not reveal protected destinations
+	exp, sig := expandSignatureParams(c)
+	if !h.checkSignature(c, mapping, exp, sig) {
+		return
+	}
+	if mapping.PasswordHash != "" && !h.checkLinkPassword(c, mapping) {
+		return
+	}
+	
+	h.respond(c, http.StatusOK, gin.H{
+		"short_code":      mapping.PublicCode(),
+		"short_url":       h.shortURL(c, mapping.PublicCode()),
 		"long_url":        mapping.LongURL,
-		"created_at":      mapping.CreatedAt,
 		"expiration_date": mapping.ExpirationDate,
-		"id":              mapping.ID,
 	})
-} 
\ No newline at end of file
+}
+
+// storeWithCode stores mapping under a custom code. Backends that can't
+// claim a code atomically get a check-and-set under createMu instead, which
+// keeps concurrent creates on this instance from both claiming it.
+func (h *URLHandlers) storeWithCode(mapping *models.URLMapping, code string) error {
+	if h.caps.SupportsAtomicCreate {
+		return h.storage.StoreWithCode(mapping, code)
+	}
+	
+	h.createMu.Lock()
+	defer h.createMu.Unlock()
+	taken, err := h.storage.Exists(code)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("%w: %s", storage.ErrCodeTaken, code)
+	}
+	return h.storage.StoreWithCode(mapping, code)
+}
+
+// respondStoreError maps a StoreWithCode failure to a response. A taken code
+// gets a 409 listing available alternatives, or 412 when the client sent
+// "If-None-Match: *" to make the create conditional on the code being free.
+func (h *URLHandlers) respondStoreError(c *gin.Context, err error, namespace, code string) {
+	switch {
+	case errors.Is(err, storage.ErrCodeTaken):
+		suggestions, suggestErr := h.SuggestCodes(namespace, code, suggestionCount)
+		if suggestErr != nil {
+			log.Printf("failed to suggest codes for %q: %v", code, suggestErr)
+			suggestions = []string{}
+		}
+		status := http.StatusConflict
+		if strings.TrimSpace(c.GetHeader("If-None-Match")) == "*" {
+			status = http.StatusPreconditionFailed
+		}
+		h.respond(c, status, gin.H{
+			"error":       "Short code already taken",
+			"suggestions": suggestions,
+		})
+	case errors.Is(err, errStorageTimeout):
+		h.respondStorageTimeout(c)
+	case errors.Is(err, storage.ErrCodeReserved):
+		h.respondError(c, http.StatusConflict, "Short code is reserved", nil)
+	case errors.Is(err, storage.ErrCapacityExceeded):
+		h.respondError(c, http.StatusInsufficientStorage, "URL capacity reached", nil)
+	default:
+		h.respondError(c, http.StatusInternalServerError, "Failed to create short URL", err)
+	}
+}
+
+// SuggestCodes returns up to n free variants of base, alternating numbered
+// ("base-2") and random ("base-x7") suffixes. At most maxSuggestionAttempts
+// candidates are checked, in one storage round trip, within namespace.
+func (h *URLHandlers) SuggestCodes(namespace, base string, n int) ([]string, error) {
+	candidates := []string{}
+	seen := make(map[string]bool)
+	
+	for attempt := 0; attempt < maxSuggestionAttempts; attempt++ {
+		suffix := "-" + strconv.Itoa(attempt/2+2)
+		if attempt%2 == 1 {
+			suffix = "-" + randomSuffix(2)
+		}
+		
+		// Trim the base so the candidate stays within the length limit
+		prefix := base
+		if len(prefix)+len(suffix) > utils.MaxCustomCodeLength {
+			prefix = prefix[:utils.MaxCustomCodeLength-len(suffix)]
+		}
+		candidate := prefix + suffix
+		if seen[candidate] || !utils.IsValidCustomCode(candidate) {
+			continue
+		}
+		seen[candidate] = true
+		candidates = append(candidates, candidate)
+	}
+	
+	keys := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		keys[i] = storage.NamespacedCode(namespace, candidate)
+	}
+	taken, err := h.storage.ExistsBatch(keys)
+	if err != nil {
+		return []string{}, err
+	}
+	
+	suggestions := []string{}
+	for i, candidate := range candidates {
+		if len(suggestions) == n {
+			break
+		}
+		if !taken[keys[i]] {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions, nil
+}
+
+// randomSuffix returns n random lowercase letters and digits
+func randomSuffix(n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// seriesWindow maps a ?series= value to its bucket width and look-back window
+func seriesWindow(series string) (bucket, window time.Duration, ok bool) {
+	switch strings.ToLower(series) {
+	case "hourly":
+		return time.Hour, 24 * time.Hour, true
+	case "daily":
+		return 24 * time.Hour, 7 * 24 * time.Hour, true
+	}
+	return 0, 0, false
+}
+
+// shortURL builds the public short URL for code. The scheme comes from
+// PUBLIC_SCHEME when configured, otherwise from X-Forwarded-Proto so links
+// created behind a TLS-terminating proxy are returned as https.
+func (h *URLHandlers) shortURL(c *gin.Context, code string) string {
+	base := h.baseURL
+	
+	scheme := strings.ToLower(h.cfg.PublicScheme)
+	if scheme == "" {
+		scheme = strings.ToLower(strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Proto"), ",")[0]))
+	}
+	if scheme == "http" || scheme == "https" {
+		if idx := strings.Index(base, "://"); idx != -1 {
+			base = scheme + base[idx:]
+		}
+	}
+	
+	return base + "/" + code
+}
+
+// expiryStatus reports whether mapping has expired at now and the whole
+// seconds left before it does (zero or negative once it has, nil when it
+// never expires). Both come from the storage's deadline, which includes
+// CLOCK_SKEW_TOLERANCE, so they never disagree.
+func (h *URLHandlers) expiryStatus(mapping *models.URLMapping, now time.Time) (bool, *int64) {
+	deadline := h.storage.ExpiresAt(mapping)
+	if deadline == nil {
+		return false, nil
+	}
+	seconds := int64(deadline.Sub(now) / time.Second)
+	return now.After(*deadline), &seconds
+}
+
+// maxSelfLinkDepth bounds how many of our own short links resolveSelfLink follows
+const maxSelfLinkDepth = 5
+
+// resolveSelfLink checks whether target is one of our own short URLs. Other
+// URLs are returned unchanged. Self links are rejected unless
+// RESOLVE_SELF_LINKS is set, in which case the chain is followed to its
+// final external target. Links that redirect conditionally (password,
+// use limit, A/B or device rules) or that are missing, expired or loop
+// cannot be resolved and are rejected.
+func (h *URLHandlers) resolveSelfLink(target string) (string, error) {
+	seen := make(map[string]bool)
+	for depth := 0; ; depth++ {
+		code, err := utils.ExtractShortCode(target, h.baseURL)
+		if err != nil {
+			return target, nil // Not a short URL of ours
+		}
+		if !h.cfg.ResolveSelfLinks {
+			return "", errors.New("URL points at this shortener; link to the destination instead")
+		}
+		if seen[code] || depth >= maxSelfLinkDepth {
+			return "", errors.New("URL is part of a short link loop or chain that is too long")
+		}
+		seen[code] = true
+		
+		mapping, err := h.storage.Get(code)
+		if err != nil {
+			return "", errors.New("URL points at a short link of this service that does not exist")
+		}
+		if mapping.PasswordHash != "" || mapping.MaxUses > 0 || len(mapping.Destinations) > 0 || len(mapping.RedirectRules) > 0 ||
+			mapping.RedirectDelaySeconds > 0 || mapping.RequireSignature {
+			return "", errors.New("URL points at a short link of this service that cannot be resolved")
+		}
+		target = mapping.LongURL
+	}
+}
+
+// isPlausibleCode reports whether shortCode could have been issued by us:
+// no longer than MAX_CODE_LENGTH (default MaxCustomCodeLength, which also
+// covers base62 uint64 codes) and free of path separators
+func (h *URLHandlers) isPlausibleCode(shortCode string) bool {
+	maxLen := h.cfg.MaxCodeLength
+	if maxLen <= 0 {
+		maxLen = utils.MaxCustomCodeLength
+	}
+	return len(shortCode) <= maxLen && !strings.ContainsAny(shortCode, "/\\")
+}
+
+// maxTags returns the configured per-link tag limit
+func (h *URLHandlers) maxTags() int {
+	if h.cfg.MaxTags <= 0 {
+		return utils.DefaultMaxTags
+	}
+	return h.cfg.MaxTags
+}
+
+// isPlainRequest reports whether req asks for an unconditional link that
+// never expires, the only kind that can be shared between requests
+func isPlainRequest(req *models.ShortenRequest, expirationDate *time.Time) bool {
+	return expirationDate == nil && req.Password == "" && req.CustomCode == "" && req.ReservationToken == "" &&
+		req.MaxUses == 0 && len(req.Destinations) == 0 && len(req.RedirectRules) == 0 && len(req.Tags) == 0 &&
+		req.RedirectDelaySeconds == 0 && req.Title == "" && !req.NoHTTPSUpgrade && req.Namespace == "" && req.Campaign == "" &&
+		!req.RequireSignature
+}
+
+// dedupKey identifies a submission for duplicate detection: the client IP,
+// the authenticated owner, the normalized long URL and every other setting, so a resubmission only
+// matches if it would have created an equivalent link. It returns "" when
+// detection is off or doesn't apply (passwords, custom codes, reservations).
+func (h *URLHandlers) dedupKey(c *gin.Context, req *models.ShortenRequest) string {
+	if h.ephemeral == nil || h.cfg.DedupWindow <= 0 || req.Password != "" || req.CustomCode != "" || req.ReservationToken != "" {
+		return ""
+	}
+	
+	settings, err := json.Marshal(struct {
+		ExpirationDate    *time.Time
+		Retention         string
+		MaxUses           int
+		Destinations      []models.WeightedURL
+		RedirectRules     []models.RedirectRule
+		Tags              []string
+		RedirectDelay     int
+		Title             string
+		NoHTTPSUpgrade    bool
+		Namespace         string
+		Campaign          string
+		RequireSignature  bool
+		SlidingExpiration bool
+	}{req.ExpirationDate, strings.ToLower(req.Retention), req.MaxUses, req.Destinations, req.RedirectRules, req.Tags, req.RedirectDelaySeconds, req.Title, req.NoHTTPSUpgrade, req.Namespace, req.Campaign, req.RequireSignature, req.SlidingExpiration})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP() + "\x00" + c.GetString(ownerKey) + "\x00" + utils.NormalizeURL(req.LongURL) + "\x00" + string(settings)))
+	return "dedup:" + hex.EncodeToString(sum[:])
+}
+
+// expirationJitter returns a random offset within ±EXPIRATION_JITTER so links
+// created together with the same TTL don't all expire at once. The spread is
+// capped at half the TTL so a jittered link never expires immediately.
+func (h *URLHandlers) expirationJitter(ttl time.Duration) time.Duration {
+	spread := h.cfg.ExpirationJitter
+	if spread > ttl/2 {
+		spread = ttl / 2
+	}
+	if spread <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(2*int64(spread)+1)) - spread
+}
+
+// retentionTierNames returns the configured retention tier names in sorted order
+func (h *URLHandlers) retentionTierNames() []string {
+	names := make([]string, 0, len(h.cfg.RetentionTiers))
+	for name := range h.cfg.RetentionTiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkLinkPassword verifies the password supplied via ?pw= or X-Link-Password.
+// It writes a 401 response (an HTML form for browsers) and returns false when
+// the password is missing or wrong.
+func (h *URLHandlers) checkLinkPassword(c *gin.Context, mapping *models.URLMapping) bool {
+	password := linkPassword(c)
+	
+	if password != "" && utils.CheckPassword(mapping.PasswordHash, password) {
+		return true
+	}
+	
+	if password == "" && wantsHTML(c) {
+		renderHTML(c, http.StatusUnauthorized, passwordFormTemplate, gin.H{
+			"Action": h.basePath() + "/" + mapping.PublicCode(),
+			"Query":  formQuery(c.Request.URL.Query(), "pw"),
+		})
+		return false
+	}
+	
+	message := "Password required"
+	if password != "" {
+		message = "Incorrect password"
+	}
+	h.respondError(c, http.StatusUnauthorized, message, nil)
+	return false
+}
+
+// formField is one hidden input carried through an HTML form
+type formField struct {
+	Name, Value string
+}
+
+// formQuery flattens query into hidden form fields in a stable order,
+// leaving out the named parameters
+func formQuery(query url.Values, omit ...string) []formField {
+	for _, name := range omit {
+		query.Del(name)
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	
+	var fields []formField
+	for _, name := range names {
+		for _, value := range query[name] {
+			fields = append(fields, formField{name, value})
+		}
+	}
+	return fields
+}
+
+// basePath is the path of BASE_URL without its trailing slash, which short
+// URLs are served under ("" when they live at the root)
+func (h *URLHandlers) basePath() string {
+	base, err := url.Parse(h.baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(base.Path, "/")
+}
+
+// linkPassword returns the password sent for a protected link in ?pw= or
+// the X-Link-Password header
+func linkPassword(c *gin.Context) string {
+	if password := c.Query("pw"); password != "" {
+		return password
+	}
+	return c.GetHeader("X-Link-Password")
+}
+
+// isProtected reports whether following mapping needs a password or a
+// signed URL, so its destinations must not be shown to just anyone
+func isProtected(mapping *models.URLMapping) bool {
+	return mapping.PasswordHash != "" || mapping.RequireSignature
+}
+
+// destinationVisible reports whether a response other than the redirect may
+// show mapping's destinations: always for unprotected links, otherwise only
+// when the request carries what a redirect would need (the link's password
+// and, for require_signature links, a valid exp and sig). Unlike
+// checkLinkPassword and checkSignature it never writes a response.
+func (h *URLHandlers) destinationVisible(c *gin.Context, mapping *models.URLMapping) bool {
+	if mapping.RequireSignature {
+		if h.signer == nil || h.signer.Verify(mapping.PublicCode(), c.Query("exp"), c.Query("sig"), time.Now()) != nil {
+			return false
+		}
+	}
+	if mapping.PasswordHash != "" {
+		password := linkPassword(c)
+		return password != "" && utils.CheckPassword(mapping.PasswordHash, password)
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"log"
+	"strings"
+	"tiny-url-service/config"
+	"tiny-url-service/utils"
+)
+
+// defaultURLValidators is the chain used when URL_VALIDATORS is empty
+var defaultURLValidators = []string{"control_chars", "scheme", "host"}
+
+// newURLValidator builds the long-URL validation chain named by
+// URL_VALIDATORS. Unknown names are logged and skipped.
+func newURLValidator(cfg *config.Config) *utils.Validator {
+	names := cfg.URLValidators
+	if len(names) == 0 {
+		names = defaultURLValidators
+	}
+
+	funcs := make([]utils.ValidatorFunc, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "control_chars":
+			funcs = append(funcs, utils.NoControlChars)
+		case "scheme":
+			funcs = append(funcs, utils.HTTPScheme)
+		case "host":
+			funcs = append(funcs, utils.HasHost)
+		case "max_length":
+			funcs = append(funcs, utils.MaxLength(cfg.MaxURLLength))
+		case "blocklist":
+			funcs = append(funcs, utils.BlockedHosts(cfg.BlockedHosts))
+		case "private_hosts":
+			funcs = append(funcs, utils.NoPrivateHosts)
+		default:
+			log.Printf("Ignoring unknown URL validator %q", name)
+		}
+	}
+	return utils.NewValidator(funcs...)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Webhook delivery defaults, used when the config leaves them at zero
+const (
+	defaultWebhookConcurrency = 4
+	defaultWebhookQueueSize   = 100
+	defaultWebhookTimeout     = 5 * time.Second
+)
+
+// webhookEvent is the JSON body POSTed to WEBHOOK_URL
+type webhookEvent struct {
+	Event     string    `json:"event"` // "url.created"
+	ShortCode string    `json:"short_code"`
+	ShortURL  string    `json:"short_url"`
+	LongURL   string    `json:"long_url"`
+	Time      time.Time `json:"time"`
+}
+
+// webhookDispatcher delivers events from a bounded queue with a fixed number
+// of workers, so a burst of creates can neither spawn unbounded goroutines
+// nor flood the receiver. Events arriving while the queue is full are
+// dropped and logged; delivery is best effort and never retried.
+type webhookDispatcher struct {
+	url     string
+	client  *http.Client
+	queue   chan webhookEvent
+	dropped atomic.Int64
+}
+
+// newWebhookDispatcher starts workers goroutines posting to url
+func newWebhookDispatcher(url string, workers, queueSize int, timeout time.Duration) *webhookDispatcher {
+	if workers <= 0 {
+		workers = defaultWebhookConcurrency
+	}
+	if queueSize <= 0 {
+		queueSize = defaultWebhookQueueSize
+	}
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	d := &webhookDispatcher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		queue:  make(chan webhookEvent, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+// enqueue queues event for delivery without blocking, reporting whether it was accepted
+func (d *webhookDispatcher) enqueue(event webhookEvent) bool {
+	select {
+	case d.queue <- event:
+		return true
+	default:
+		dropped := d.dropped.Add(1)
+		log.Printf("webhook queue full, dropped %s event for %q (%d dropped so far)", event.Event, event.ShortCode, dropped)
+		return false
+	}
+}
+
+// work delivers queued events until the queue is closed
+func (d *webhookDispatcher) work() {
+	for event := range d.queue {
+		if err := d.deliver(event); err != nil {
+			log.Printf("failed to deliver %s webhook for %q: %v", event.Event, event.ShortCode, err)
+		}
+	}
+}
+
+// deliver POSTs event as JSON, treating any non-2xx status as a failure
+func (d *webhookDispatcher) deliver(event webhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Post(d.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return nil
+}
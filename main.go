@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io"
 	"log"
 	"strings"
 	"tiny-url-service/config"
@@ -15,26 +16,124 @@ func main() {
 	// Initialize storage based on configuration
 	var store storage.Storage
 	var err error
+	storeOpts := []storage.Option{
+		storage.WithReservationTTL(cfg.ReservationTTL),
+		storage.WithClickRetention(cfg.ClickRetention),
+		storage.WithClickFlushInterval(cfg.ClickFlushInterval),
+		storage.WithMaxURLs(int64(cfg.MaxURLs)),
+		storage.WithMaxID(cfg.MaxID),
+		storage.WithMaxGeneratedCodeLength(cfg.MaxGeneratedCodeLength),
+		storage.WithSizeStats(cfg.URLSizeStats),
+		storage.WithScanSearch(cfg.RedisSearchScan),
+		storage.WithReservedWords(handlers.ReservedWords(cfg)...),
+		storage.WithCaseInsensitiveCodes(cfg.CaseInsensitiveCodes),
+		storage.WithChecksumCodes(cfg.ChecksumCodes),
+		storage.WithClockSkewTolerance(cfg.ClockSkewTolerance),
+	}
+	if cfg.UniqueURLsPerOwner && cfg.ReverseIndexHash == "" {
+		log.Println("UNIQUE_URLS_PER_OWNER needs the long URL index; using REVERSE_INDEX_HASH=sha256")
+		cfg.ReverseIndexHash = "sha256"
+	}
+	if cfg.ReverseIndexHash != "" {
+		hash, err := storage.URLHashByName(cfg.ReverseIndexHash)
+		if err != nil {
+			log.Fatal("Invalid REVERSE_INDEX_HASH:", err)
+		}
+		storeOpts = append(storeOpts, storage.WithReverseIndex(hash))
+	}
 	
 	switch strings.ToLower(cfg.StorageType) {
 	case "redis":
-		log.Println("Initializing Redis storage...")
-		store, err = storage.NewRedisStorage(cfg.BaseURL, cfg.RedisURL)
+		log.Printf("Initializing Redis storage (%s mode)...", cfg.RedisMode)
+		store, err = storage.NewRedisStorageFromConfig(cfg.BaseURL, redisConfig(cfg), storeOpts...)
 		if err != nil {
 			log.Fatal("Failed to initialize Redis storage:", err)
 		}
 		log.Println("Redis storage initialized successfully")
 	case "memory":
 		log.Println("Initializing in-memory storage...")
-		store = storage.NewMemoryStorage(cfg.BaseURL)
+		if cfg.MemoryDataDir != "" {
+			storeOpts = append(storeOpts, storage.WithSnapshotInterval(cfg.MemorySnapshotInterval))
+			store, err = storage.OpenMemoryStorage(cfg.BaseURL, cfg.MemoryDataDir, storeOpts...)
+			if err != nil {
+				log.Fatal("Failed to load memory storage:", err)
+			}
+			log.Printf("In-memory storage persisted in %s", cfg.MemoryDataDir)
+		} else {
+			store = storage.NewMemoryStorage(cfg.BaseURL, storeOpts...)
+		}
 		log.Println("In-memory storage initialized successfully")
 	default:
 		log.Fatalf("Unknown storage type: %s. Supported types: memory, redis", cfg.StorageType)
 	}
 	
+	// Initialize the audit trail (disabled unless AUDIT_LOG is set)
+	var auditLogger storage.AuditLogger = storage.NopAuditLogger{}
+	switch strings.ToLower(cfg.AuditLog) {
+	case "":
+	case "file":
+		auditLogger, err = storage.NewFileAuditLogger(cfg.AuditLogPath)
+		if err != nil {
+			log.Fatal("Failed to initialize audit log:", err)
+		}
+		log.Printf("Audit log writing to %s", cfg.AuditLogPath)
+	case "redis":
+		auditLogger, err = storage.NewRedisAuditLogger(redisConfig(cfg), cfg.AuditStream)
+		if err != nil {
+			log.Fatal("Failed to initialize audit log:", err)
+		}
+		log.Printf("Audit log writing to Redis stream %s", cfg.AuditStream)
+	default:
+		log.Fatalf("Unknown audit log type: %s. Supported types: file, redis", cfg.AuditLog)
+	}
+	
+	// Initialize short-lived state (per instance unless EPHEMERAL_STORE=redis)
+	routerOpts := []handlers.RouterOption{handlers.WithAuditLogger(auditLogger)}
+	switch strings.ToLower(cfg.EphemeralStore) {
+	case "", "memory":
+		if store.Capabilities().Shared && cfg.DedupWindow > 0 {
+			log.Println("DEDUP_WINDOW only spans this instance; set EPHEMERAL_STORE=redis to share it")
+		}
+	case "redis":
+		ephemeral, err := storage.NewRedisEphemeralStore(redisConfig(cfg))
+		if err != nil {
+			log.Fatal("Failed to initialize ephemeral store:", err)
+		}
+		defer ephemeral.Close()
+		routerOpts = append(routerOpts, handlers.WithEphemeralStore(ephemeral))
+		log.Println("Ephemeral state kept in Redis")
+	default:
+		log.Fatalf("Unknown ephemeral store type: %s. Supported types: memory, redis", cfg.EphemeralStore)
+	}
+	
 	// Start HTTP server with graceful shutdown
 	log.Println("Starting Tiny URL Service...")
-	if err := handlers.StartServer(store, cfg); err != nil {
+	stopCleanup := storage.StartCleanup(store, cfg.CleanupInterval)
+	err = handlers.StartServer(store, cfg, routerOpts...)
+	stopCleanup()
+	
+	// Close the store in case StartServer returned before draining it (e.g. the
+	// listener failed); for Redis this returns the drain's result otherwise
+	if closer, ok := store.(io.Closer); ok {
+		if closeErr := closer.Close(); closeErr != nil {
+			log.Printf("Failed to close storage: %v", closeErr)
+		}
+	}
+	if err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-} 
\ No newline at end of file
+} 
+
+// redisConfig builds the Redis connection settings shared by storage and the audit log
+func redisConfig(cfg *config.Config) storage.RedisConfig {
+	return storage.RedisConfig{
+		Mode:            cfg.RedisMode,
+		URL:             cfg.RedisURL,
+		Addrs:           cfg.RedisAddrs,
+		MasterName:      cfg.RedisMasterName,
+		Password:        cfg.RedisPassword,
+		MaxRetries:      cfg.RedisMaxRetries,
+		MinRetryBackoff: cfg.RedisMinRetryBackoff,
+		MaxRetryBackoff: cfg.RedisMaxRetryBackoff,
+	}
+}
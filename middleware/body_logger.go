@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLoggedBody caps how many bytes of each body BodyLogger prints
+const maxLoggedBody = 4096
+
+// passwordField matches a JSON "password" member, including one cut off by
+// truncation, so its value can be masked
+var passwordField = regexp.MustCompile(`(?i)("password"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+
+// bodyLogWriter passes responses through while keeping their first bytes
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body  bytes.Buffer
+	total int
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyLogWriter) capture(b []byte) {
+	w.total += len(b)
+	if room := maxLoggedBody - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(len(b), room)])
+	}
+}
+
+// BodyLogger logs JSON request and response bodies for diagnosing client
+// issues. Only the first maxLoggedBody bytes are read up front; the request
+// body is then reassembled so handlers binding it still see all of it.
+// Password fields are masked. Redirects and non-JSON responses (images,
+// NDJSON streams) are never logged. Meant for LOG_LEVEL=debug only.
+func BodyLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody []byte
+		if c.Request.Body != nil && isJSON(c.ContentType()) {
+			head, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody+1))
+			if err == nil {
+				requestBody = head
+			}
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &bodyLogWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if len(requestBody) > 0 {
+			log.Printf("[BODY] %s %s request: %s", c.Request.Method, c.Request.URL.Path, loggableBody(requestBody, len(requestBody)))
+		}
+		status := writer.Status()
+		if status >= 300 && status < 400 || !isJSON(writer.Header().Get("Content-Type")) || writer.total == 0 {
+			return
+		}
+		log.Printf("[BODY] %s %s response %d: %s", c.Request.Method, c.Request.URL.Path, status, loggableBody(writer.body.Bytes(), writer.total))
+	}
+}
+
+// isJSON reports whether contentType is application/json or a +json type
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// loggableBody masks passwords in body and marks it when total exceeds what was kept
+func loggableBody(body []byte, total int) string {
+	if len(body) > maxLoggedBody {
+		body = body[:maxLoggedBody]
+	}
+	masked := passwordField.ReplaceAllString(string(body), `$1"[REDACTED]"`)
+	if total > len(body) {
+		masked += "... (truncated)"
+	}
+	return masked
+}
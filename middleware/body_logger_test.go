@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// captureLog redirects the standard logger into a buffer for the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	return &buf
+}
+
+func newBodyLoggerRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(BodyLogger())
+	r.POST("/urls", func(c *gin.Context) {
+		var req map[string]interface{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"long_url": req["long_url"], "password": req["password"]})
+	})
+	r.GET("/r", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "https://example.com")
+	})
+	r.GET("/qr.png", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte("\x89PNG binary"))
+	})
+	return r
+}
+
+func TestBodyLogger_LogsAndRedacts(t *testing.T) {
+	logs := captureLog(t)
+	r := newBodyLoggerRouter()
+
+	body := `{"long_url":"https://example.com/x","password":"hunter2"}`
+	req := httptest.NewRequest("POST", "/urls", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// The handler still binds the full body
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "https://example.com/x") {
+		t.Fatalf("Handler should see the whole body, got %d %s", w.Code, w.Body.String())
+	}
+
+	output := logs.String()
+	if strings.Contains(output, "hunter2") {
+		t.Errorf("Password leaked into logs: %s", output)
+	}
+	if !strings.Contains(output, "request: ") || !strings.Contains(output, "response 200: ") {
+		t.Errorf("Expected request and response lines, got %s", output)
+	}
+	if strings.Count(output, `"password":"[REDACTED]"`) != 2 {
+		t.Errorf("Expected the password masked in both bodies, got %s", output)
+	}
+}
+
+func TestBodyLogger_LargeBody(t *testing.T) {
+	logs := captureLog(t)
+	r := newBodyLoggerRouter()
+
+	longURL := "https://example.com/" + strings.Repeat("a", 3*maxLoggedBody)
+	req := httptest.NewRequest("POST", "/urls", strings.NewReader(`{"long_url":"`+longURL+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), longURL) {
+		t.Fatalf("Handler should see the whole body beyond the log cap, got %d", w.Code)
+	}
+	if !strings.Contains(logs.String(), "(truncated)") || logs.Len() > 3*maxLoggedBody {
+		t.Errorf("Expected truncated log lines, got %d bytes of logs", logs.Len())
+	}
+}
+
+func TestBodyLogger_SkipsRedirectsAndBinary(t *testing.T) {
+	logs := captureLog(t)
+	r := newBodyLoggerRouter()
+
+	for _, path := range []string{"/r", "/qr.png"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Redirect and binary bodies should not be logged, got %s", logs.String())
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChaosHeader marks responses whose failure was injected, so client tests
+// can tell chaos from real errors
+const ChaosHeader = "X-Chaos-Injected"
+
+// NewChaosMiddleware injects faults for testing clients against a slow or
+// failing server. Each request is first delayed by a random duration up to
+// maxLatency, then failed with a 500 or 503 with probability errorRate.
+// Requests to the skip paths (e.g. /health) are never touched.
+func NewChaosMiddleware(maxLatency time.Duration, errorRate float64, skip ...string) gin.HandlerFunc {
+	skipped := make(map[string]bool, len(skip))
+	for _, path := range skip {
+		skipped[path] = true
+	}
+	
+	return func(c *gin.Context) {
+		if skipped[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+		
+		if maxLatency > 0 {
+			delay := time.Duration(rand.Int63n(int64(maxLatency) + 1))
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+		
+		if errorRate > 0 && rand.Float64() < errorRate {
+			status := http.StatusInternalServerError
+			if rand.Intn(2) == 0 {
+				status = http.StatusServiceUnavailable
+				c.Header("Retry-After", "1")
+			}
+			c.Header(ChaosHeader, "true")
+			ErrorJSON(c, status, gin.H{"error": "Injected failure (chaos mode)"})
+			c.Abort()
+			return
+		}
+		
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newChaosRouter(maxLatency time.Duration, errorRate float64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewChaosMiddleware(maxLatency, errorRate, "/health"))
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestChaosMiddleware_AlwaysFails(t *testing.T) {
+	r := newChaosRouter(0, 1)
+
+	seen := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api", nil))
+		if w.Code != http.StatusInternalServerError && w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected an injected 500 or 503, got %d", w.Code)
+		}
+		if w.Header().Get(ChaosHeader) != "true" {
+			t.Errorf("Injected failure should carry %s", ChaosHeader)
+		}
+		seen[w.Code] = true
+	}
+	if !seen[http.StatusInternalServerError] || !seen[http.StatusServiceUnavailable] {
+		t.Errorf("Expected both 500s and 503s over 50 requests, got %v", seen)
+	}
+
+	// Skipped paths are never failed
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected /health to be exempt, got %d", w.Code)
+	}
+}
+
+func TestChaosMiddleware_Latency(t *testing.T) {
+	const maxLatency = 20 * time.Millisecond
+	r := newChaosRouter(maxLatency, 0)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with a zero error rate, got %d", http.StatusOK, w.Code)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 5*maxLatency+time.Second {
+		t.Errorf("Delays should stay within %v each, took %v for 5 requests", maxLatency, elapsed)
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIDKey is the context key HashClientIPs stores the hashed client IP under
+const clientIDKey = "client_id"
+
+// HashIP returns the hex-encoded sha256 of ip followed by salt
+func HashIP(ip, salt string) string {
+	sum := sha256.Sum256([]byte(ip + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashClientIPs makes ClientID return HashIP(client IP, salt) for the rest
+// of the request, so rate limiting, logs and analytics never see the raw
+// IP. It must run after anything that changes how the client IP is
+// resolved, such as ForwardedHeader.
+func HashClientIPs(salt string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientIDKey, HashIP(c.ClientIP(), salt))
+		c.Next()
+	}
+}
+
+// ClientID identifies who a request comes from: the hashed client IP under
+// HashClientIPs, else the client IP itself
+func ClientID(c *gin.Context) string {
+	if id := c.GetString(clientIDKey); id != "" {
+		return id
+	}
+	return c.ClientIP()
+}
+
+// HashedIPLogger is gin.Logger with the client IP column replaced by the
+// hash set by HashClientIPs, which must come after it in the chain
+func HashedIPLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		clientID, _ := param.Keys[clientIDKey].(string)
+		if clientID == "" {
+			clientID = "-" // Rejected before HashClientIPs ran
+		}
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			clientID,
+			param.Method,
+			param.Path,
+			param.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHashClientIPs_RateLimitKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var keys []string
+	router := gin.New()
+	router.Use(HashClientIPs("salt"))
+	router.GET("/test", func(c *gin.Context) {
+		key, _, _ := ClientIPKey(c)
+		keys = append(keys, key)
+	})
+
+	for _, addr := range []string{"192.168.1.100:1234", "192.168.1.100:5678", "192.168.1.101:1234"} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = addr
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if keys[0] != "ip:"+HashIP("192.168.1.100", "salt") {
+		t.Errorf("Expected the key to be the salted hash, got %q", keys[0])
+	}
+	if keys[0] != keys[1] {
+		t.Errorf("Expected one key per client IP, got %q and %q", keys[0], keys[1])
+	}
+	if keys[0] == keys[2] {
+		t.Error("Expected different client IPs to get different keys")
+	}
+	for _, key := range keys {
+		if strings.Contains(key, "192.168") {
+			t.Errorf("Rate limit key %q contains the raw client IP", key)
+		}
+	}
+}
+
+func TestClientID_WithoutHashing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, ClientID(c))
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "192.168.1.100:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "192.168.1.100" {
+		t.Errorf("Expected the client IP, got %q", w.Body.String())
+	}
+}
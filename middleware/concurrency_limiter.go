@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PerIPConcurrencyLimiter caps how many requests each client IP may have in
+// flight at once. Unlike the per-minute token bucket, it catches a client
+// holding many slow connections open (slowloris-style), which costs few
+// requests but ties up server resources.
+type PerIPConcurrencyLimiter struct {
+	max      int
+	mu       sync.Mutex
+	inFlight map[string]int // Client IP -> requests in progress; absent means 0
+}
+
+// NewPerIPConcurrencyLimiter allows each client IP at most max requests in
+// progress; further requests are rejected with 429 until one completes
+func NewPerIPConcurrencyLimiter(max int) gin.HandlerFunc {
+	limiter := &PerIPConcurrencyLimiter{
+		max:      max,
+		inFlight: make(map[string]int),
+	}
+	
+	return limiter.middleware()
+}
+
+// acquire counts a request from ip in, reporting false if ip is at the cap
+func (l *PerIPConcurrencyLimiter) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	if l.inFlight[ip] >= l.max {
+		return false
+	}
+	l.inFlight[ip]++
+	return true
+}
+
+// release counts a completed request from ip out, forgetting idle IPs so the
+// map only holds clients with requests in progress
+func (l *PerIPConcurrencyLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	if l.inFlight[ip] <= 1 {
+		delete(l.inFlight, ip)
+		return
+	}
+	l.inFlight[ip]--
+}
+
+// middleware returns the Gin middleware function
+func (l *PerIPConcurrencyLimiter) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := ClientID(c)
+		if !l.acquire(ip) {
+			c.Header("Retry-After", "1")
+			ErrorJSON(c, 429, gin.H{
+				"error":   "Too many concurrent requests",
+				"message": "Maximum " + strconv.Itoa(l.max) + " requests in progress per IP",
+				"limit":   l.max,
+			})
+			c.Abort()
+			return
+		}
+		defer l.release(ip)
+		
+		c.Next()
+	}
+}
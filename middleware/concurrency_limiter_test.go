@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPerIPConcurrencyLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewPerIPConcurrencyLimiter(2))
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-unblock
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(path, ip string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Hold two slow requests open from one IP
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = request("/slow", "192.168.1.1")
+		}(i)
+		<-entered
+	}
+
+	if code := request("/fast", "192.168.1.1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d for a third concurrent request, got %d", http.StatusTooManyRequests, code)
+	}
+	if code := request("/fast", "192.168.1.2"); code != http.StatusOK {
+		t.Errorf("Expected another IP to be unaffected, got %d", code)
+	}
+
+	close(unblock)
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("Slow request %d: expected status %d, got %d", i+1, http.StatusOK, code)
+		}
+	}
+
+	// Completed requests free their slots
+	if code := request("/fast", "192.168.1.1"); code != http.StatusOK {
+		t.Errorf("Expected status %d once the slow requests finished, got %d", http.StatusOK, code)
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForwardedHeader lets RFC 7239 Forwarded headers drive client IP
+// resolution. The for= identities are rewritten into X-Forwarded-For,
+// replacing any sent alongside, so gin's ClientIP applies the usual rules to
+// them: they are only honored when the direct peer is a trusted proxy, and
+// the client is found by walking back from the nearest hop past trusted
+// proxies. Identities that aren't IPs ("unknown", obfuscated "_hidden")
+// stop that walk, so the peer address is used instead.
+func ForwardedHeader() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		values := c.Request.Header.Values("Forwarded")
+		if len(values) > 0 {
+			if hops := forwardedFor(strings.Join(values, ",")); len(hops) > 0 {
+				c.Request.Header.Set("X-Forwarded-For", strings.Join(hops, ", "))
+			}
+		}
+		c.Next()
+	}
+}
+
+// forwardedFor returns the for= identity of each Forwarded element, client
+// first. IPs are returned bare (no brackets or port); other identities are
+// returned as "unknown". Elements without for= are skipped.
+func forwardedFor(header string) []string {
+	var hops []string
+	for _, element := range splitQuoted(header, ',') {
+		for _, pair := range splitQuoted(element, ';') {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "for") {
+				continue
+			}
+			hops = append(hops, forwardedNode(strings.TrimSpace(value)))
+			break
+		}
+	}
+	return hops
+}
+
+// forwardedNode extracts the IP from a for= value such as 192.0.2.1,
+// "192.0.2.1:4711" or "[2001:db8::1]:4711"
+func forwardedNode(value string) string {
+	value = strings.Trim(value, `"`)
+	if strings.HasPrefix(value, "[") {
+		// IPv6, optionally followed by a port
+		if end := strings.Index(value, "]"); end > 0 {
+			value = value[1:end]
+		}
+	} else if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	if net.ParseIP(value) == nil {
+		return "unknown"
+	}
+	return value
+}
+
+// splitQuoted splits s on sep, ignoring separators inside quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestForwardedFor(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{`for=192.0.2.60`, []string{"192.0.2.60"}},
+		{`For="192.0.2.60:4711";proto=https`, []string{"192.0.2.60"}},
+		{`for="[2001:db8::1]"`, []string{"2001:db8::1"}},
+		{`for="[2001:db8:cafe::17]:4711";by=203.0.113.43`, []string{"2001:db8:cafe::17"}},
+		{`for=192.0.2.43, for=198.51.100.17`, []string{"192.0.2.43", "198.51.100.17"}},
+		{`proto=https;host="a,b", for=198.51.100.17`, []string{"198.51.100.17"}},
+		{`for=unknown, for=_hidden, for=192.0.2.1`, []string{"unknown", "unknown", "192.0.2.1"}},
+		{`proto=https`, nil},
+	}
+	for _, tt := range tests {
+		if got := forwardedFor(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("forwardedFor(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// clientIPRouter echoes the resolved client IP, trusting proxies in 10.0.0.0/8
+func clientIPRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+		t.Fatalf("SetTrustedProxies() failed: %v", err)
+	}
+	r.Use(ForwardedHeader())
+	r.GET("/ip", func(c *gin.Context) {
+		c.String(200, c.ClientIP())
+	})
+	return r
+}
+
+func TestForwardedHeader_ClientIP(t *testing.T) {
+	r := clientIPRouter(t)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		xff        string
+		want       string
+	}{
+		{"quoted IPv6", "10.0.0.2:5000", []string{`for="[2001:db8::1]:4711"`}, "", "2001:db8::1"},
+		{"first hop past trusted proxies", "10.0.0.2:5000", []string{`for=203.0.113.9, for=198.51.100.7, for=10.0.0.1`}, "", "198.51.100.7"},
+		{"one header per proxy", "10.0.0.2:5000", []string{`for=198.51.100.7`, `for=10.0.0.1;proto=https`}, "", "198.51.100.7"},
+		{"Forwarded wins over X-Forwarded-For", "10.0.0.2:5000", []string{`for=198.51.100.7`}, "192.0.2.99", "198.51.100.7"},
+		{"obfuscated hop", "10.0.0.2:5000", []string{`for=198.51.100.7, for=_proxy`}, "", "10.0.0.2"},
+		{"untrusted peer", "192.0.2.50:5000", []string{`for=198.51.100.7`}, "", "192.0.2.50"},
+		{"X-Forwarded-For alone", "10.0.0.2:5000", nil, "198.51.100.8", "198.51.100.8"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/ip", nil)
+		req.RemoteAddr = tt.remoteAddr
+		for _, value := range tt.forwarded {
+			req.Header.Add("Forwarded", value)
+		}
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: client IP = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSONAPIContentType is the JSON:API media type
+const JSONAPIContentType = "application/vnd.api+json"
+
+// jsonAPIKey is the context key JSONAPI sets
+const jsonAPIKey = "json_api"
+
+// jsonAPILinkType is the resource type of short links
+const jsonAPILinkType = "urls"
+
+// jsonAPILinkLists are the members listing links in list responses
+var jsonAPILinkLists = []string{"urls", "results", "mappings"}
+
+// JSONAPI makes responses on this request use JSON:API documents: error
+// bodies written through ErrorBody or ErrorJSON and success bodies passed
+// through JSONAPIDocument. It takes precedence over ProblemJSON. Install it
+// before any middleware that can reject a request.
+func JSONAPI() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(jsonAPIKey, true)
+		c.Next()
+	}
+}
+
+// jsonAPIErrors converts an error body to a JSON:API errors document. The
+// "error" message becomes detail, "code" is kept, "field" becomes a source
+// pointer and every other member of body goes into meta.
+func jsonAPIErrors(c *gin.Context, status int, body gin.H) gin.H {
+	errObj := gin.H{
+		"status": strconv.Itoa(status),
+		"title":  http.StatusText(status),
+	}
+	meta := gin.H{}
+	for key, value := range body {
+		switch key {
+		case "error":
+			errObj["detail"] = value
+		case "code":
+			errObj["code"] = value
+		case "field":
+			if field, ok := value.(string); ok {
+				errObj["source"] = gin.H{"pointer": "/" + field}
+			}
+		default:
+			meta[key] = value
+		}
+	}
+	if len(meta) > 0 {
+		errObj["meta"] = meta
+	}
+	c.Header("Content-Type", JSONAPIContentType)
+	return gin.H{"errors": []gin.H{errObj}}
+}
+
+// JSONAPIDocument returns the body for a successful response. By default
+// that is obj unchanged; under JSONAPI it is wrapped in a JSON:API document
+// and the Content-Type is set to application/vnd.api+json:
+//   - a link (an object with "short_code") becomes the primary data, a
+//     resource of type "urls" identified by its short code
+//   - an object listing links under "urls", "results" or "mappings" (e.g.
+//     search results) has them as primary data and its other members as meta
+//   - anything else is sent as meta
+func JSONAPIDocument(c *gin.Context, obj interface{}) interface{} {
+	if !c.GetBool(jsonAPIKey) {
+		return obj
+	}
+	c.Header("Content-Type", JSONAPIContentType)
+
+	// Work on the JSON form so structs and maps are handled alike
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return obj
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return gin.H{"meta": obj} // Not an object
+	}
+
+	if resource, ok := jsonAPIResource(body); ok {
+		return gin.H{"data": resource}
+	}
+	for _, key := range jsonAPILinkLists {
+		if resources, ok := jsonAPIResources(body[key]); ok {
+			delete(body, key)
+			document := gin.H{"data": resources}
+			if len(body) > 0 {
+				document["meta"] = body
+			}
+			return document
+		}
+	}
+	return gin.H{"meta": body}
+}
+
+// jsonAPIResource turns a link's fields into a resource object. Members
+// JSON:API reserves ("id", "type") move to the resource's meta.
+func jsonAPIResource(fields map[string]interface{}) (gin.H, bool) {
+	code, ok := fields["short_code"].(string)
+	if !ok {
+		return nil, false
+	}
+
+	attributes := make(gin.H, len(fields))
+	meta := gin.H{}
+	for key, value := range fields {
+		switch key {
+		case "short_code":
+		case "id", "type":
+			meta[key] = value
+		default:
+			attributes[key] = value
+		}
+	}
+	resource := gin.H{
+		"type":       jsonAPILinkType,
+		"id":         code,
+		"attributes": attributes,
+	}
+	if len(meta) > 0 {
+		resource["meta"] = meta
+	}
+	return resource, true
+}
+
+// jsonAPIResources converts value to resource objects if it is a list of links
+func jsonAPIResources(value interface{}) ([]gin.H, bool) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	resources := make([]gin.H, 0, len(list))
+	for _, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		resource, ok := jsonAPIResource(fields)
+		if !ok {
+			return nil, false
+		}
+		resources = append(resources, resource)
+	}
+	return resources, true
+}
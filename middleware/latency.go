@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// latencyBounds are the upper bounds of the fixed histogram buckets. A final
+// overflow bucket catches anything slower. Percentiles are reported as the
+// upper bound of the bucket they fall in.
+var latencyBounds = [...]time.Duration{
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	1 * time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// latencySlots is how many sub-intervals the sliding window is divided into
+const latencySlots = 6
+
+// histogramSlot counts requests per bucket during one sub-interval of the window
+type histogramSlot struct {
+	epoch  atomic.Int64 // Index of the sub-interval this slot currently holds
+	counts [len(latencyBounds) + 1]atomic.Uint64
+}
+
+// routeHistogram is a ring of slots covering the sliding window for one route
+type routeHistogram struct {
+	resetMu sync.Mutex // Only taken when a slot rolls over to a new sub-interval
+	slots   [latencySlots]histogramSlot
+}
+
+// RouteLatency summarizes one route's latency over the window
+type RouteLatency struct {
+	Count int64   `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+}
+
+// LatencyTracker records per-route request latency in fixed-size histograms
+// over a sliding window. Recording is lock-free except when a slot rolls over.
+type LatencyTracker struct {
+	window   time.Duration
+	slotSize time.Duration
+	routes   sync.Map // "METHOD /route/pattern" -> *routeHistogram
+	inFlight sync.Map // "METHOD /route/pattern" -> *atomic.Int64 requests currently running
+}
+
+// NewLatencyTracker creates a tracker reporting over the given window
+func NewLatencyTracker(window time.Duration) *LatencyTracker {
+	if window < latencySlots*time.Second {
+		window = time.Minute
+	}
+	return &LatencyTracker{
+		window:   window,
+		slotSize: window / latencySlots,
+	}
+}
+
+// Window returns the sliding window the tracker reports over
+func (t *LatencyTracker) Window() time.Duration {
+	return t.window
+}
+
+// Middleware times each request and records it under its route pattern,
+// counting it as in flight while it runs. Unmatched paths share one key so
+// memory stays bounded.
+func (t *LatencyTracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		key := c.Request.Method + " " + route
+		
+		active := t.inFlightCounter(key)
+		active.Add(1)
+		defer active.Add(-1)
+		
+		c.Next()
+		t.record(key, time.Since(start), time.Now())
+	}
+}
+
+// inFlightCounter returns the in-flight request counter for route
+func (t *LatencyTracker) inFlightCounter(route string) *atomic.Int64 {
+	val, ok := t.inFlight.Load(route)
+	if !ok {
+		val, _ = t.inFlight.LoadOrStore(route, &atomic.Int64{})
+	}
+	return val.(*atomic.Int64)
+}
+
+// InFlight returns the number of requests currently running per route,
+// omitting idle routes. Shutdown uses it to report what is still draining.
+func (t *LatencyTracker) InFlight() map[string]int64 {
+	result := make(map[string]int64)
+	t.inFlight.Range(func(key, val interface{}) bool {
+		if n := val.(*atomic.Int64).Load(); n > 0 {
+			result[key.(string)] = n
+		}
+		return true
+	})
+	return result
+}
+
+// record adds one observation for route at time now
+func (t *LatencyTracker) record(route string, d time.Duration, now time.Time) {
+	val, ok := t.routes.Load(route)
+	if !ok {
+		val, _ = t.routes.LoadOrStore(route, &routeHistogram{})
+	}
+	hist := val.(*routeHistogram)
+	
+	epoch := now.UnixNano() / int64(t.slotSize)
+	slot := &hist.slots[epoch%latencySlots]
+	if slot.epoch.Load() != epoch {
+		hist.resetMu.Lock()
+		if slot.epoch.Load() != epoch {
+			for i := range slot.counts {
+				slot.counts[i].Store(0)
+			}
+			slot.epoch.Store(epoch)
+		}
+		hist.resetMu.Unlock()
+	}
+	
+	slot.counts[bucketFor(d)].Add(1)
+}
+
+// bucketFor returns the index of the histogram bucket holding d
+func bucketFor(d time.Duration) int {
+	for i, bound := range latencyBounds {
+		if d <= bound {
+			return i
+		}
+	}
+	return len(latencyBounds)
+}
+
+// Snapshot returns latency percentiles per route over the current window
+func (t *LatencyTracker) Snapshot() map[string]RouteLatency {
+	return t.snapshotAt(time.Now())
+}
+
+// snapshotAt computes percentiles from the slots still inside the window at now
+func (t *LatencyTracker) snapshotAt(now time.Time) map[string]RouteLatency {
+	current := now.UnixNano() / int64(t.slotSize)
+	result := make(map[string]RouteLatency)
+	
+	t.routes.Range(func(key, val interface{}) bool {
+		hist := val.(*routeHistogram)
+		
+		var counts [len(latencyBounds) + 1]uint64
+		var total uint64
+		for i := range hist.slots {
+			slot := &hist.slots[i]
+			if age := current - slot.epoch.Load(); age < 0 || age >= latencySlots {
+				continue
+			}
+			for b := range slot.counts {
+				n := slot.counts[b].Load()
+				counts[b] += n
+				total += n
+			}
+		}
+		if total == 0 {
+			return true
+		}
+		
+		result[key.(string)] = RouteLatency{
+			Count: int64(total),
+			P50Ms: percentile(counts[:], total, 0.50),
+			P95Ms: percentile(counts[:], total, 0.95),
+			P99Ms: percentile(counts[:], total, 0.99),
+		}
+		return true
+	})
+	
+	return result
+}
+
+// percentile returns the upper bound, in milliseconds, of the bucket holding
+// quantile q. The overflow bucket reports the largest finite bound.
+func percentile(counts []uint64, total uint64, q float64) float64 {
+	rank := uint64(q*float64(total) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	
+	var seen uint64
+	for i, n := range counts {
+		seen += n
+		if seen >= rank {
+			if i >= len(latencyBounds) {
+				i = len(latencyBounds) - 1
+			}
+			return float64(latencyBounds[i]) / float64(time.Millisecond)
+		}
+	}
+	return float64(latencyBounds[len(latencyBounds)-1]) / float64(time.Millisecond)
+}
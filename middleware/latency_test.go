@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLatencyTracker_Percentiles(t *testing.T) {
+	tracker := NewLatencyTracker(time.Minute)
+	now := time.Now()
+
+	// 90 fast requests, 9 medium, 1 slow
+	for i := 0; i < 90; i++ {
+		tracker.record("GET /fast", 80*time.Microsecond, now)
+	}
+	for i := 0; i < 9; i++ {
+		tracker.record("GET /fast", 20*time.Millisecond, now)
+	}
+	tracker.record("GET /fast", 3*time.Second, now)
+
+	snapshot := tracker.snapshotAt(now)
+	stats, ok := snapshot["GET /fast"]
+	if !ok {
+		t.Fatalf("Expected stats for GET /fast, got %v", snapshot)
+	}
+
+	if stats.Count != 100 {
+		t.Errorf("Expected count 100, got %d", stats.Count)
+	}
+	if stats.P50Ms != 0.1 {
+		t.Errorf("Expected p50 0.1ms, got %v", stats.P50Ms)
+	}
+	if stats.P95Ms != 25 {
+		t.Errorf("Expected p95 25ms, got %v", stats.P95Ms)
+	}
+	if stats.P99Ms != 25 {
+		t.Errorf("Expected p99 25ms, got %v", stats.P99Ms)
+	}
+}
+
+func TestLatencyTracker_SlidingWindow(t *testing.T) {
+	tracker := NewLatencyTracker(time.Minute)
+	start := time.Now()
+
+	tracker.record("GET /old", time.Millisecond, start)
+	tracker.record("GET /new", time.Millisecond, start.Add(30*time.Second))
+
+	snapshot := tracker.snapshotAt(start.Add(75 * time.Second))
+	if _, ok := snapshot["GET /old"]; ok {
+		t.Error("Observations older than the window should be dropped")
+	}
+	if stats := snapshot["GET /new"]; stats.Count != 1 {
+		t.Errorf("Expected 1 observation inside the window, got %d", stats.Count)
+	}
+}
+
+func TestLatencyTracker_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker := NewLatencyTracker(time.Minute)
+
+	router := gin.New()
+	router.Use(tracker.Middleware())
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/missing/1", "/missing/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	snapshot := tracker.Snapshot()
+	if stats := snapshot["GET /items/:id"]; stats.Count != 2 {
+		t.Errorf("Expected 2 observations for the route pattern, got %d", stats.Count)
+	}
+	if stats := snapshot["GET unmatched"]; stats.Count != 2 {
+		t.Errorf("Expected unmatched paths to share one key, got %d", stats.Count)
+	}
+}
+
+func TestLatencyTracker_InFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker := NewLatencyTracker(time.Minute)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.Use(tracker.Middleware())
+	router.GET("/slow/:id", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	done := make(chan struct{})
+	for _, path := range []string{"/slow/1", "/slow/2"} {
+		go func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+			done <- struct{}{}
+		}()
+		<-entered
+	}
+
+	if inFlight := tracker.InFlight(); len(inFlight) != 1 || inFlight["GET /slow/:id"] != 2 {
+		t.Errorf("Expected 2 in-flight requests on GET /slow/:id, got %v", inFlight)
+	}
+
+	close(release)
+	<-done
+	<-done
+	if inFlight := tracker.InFlight(); len(inFlight) != 0 {
+		t.Errorf("Expected no in-flight requests once handlers return, got %v", inFlight)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the RFC 7807 Problem Details media type
+const ProblemContentType = "application/problem+json"
+
+// problemJSONKey is the context key ProblemJSON sets
+const problemJSONKey = "problem_json"
+
+// ProblemJSON makes error responses written through ErrorBody or ErrorJSON
+// on this request use RFC 7807 Problem Details. Install it before any
+// middleware that can reject a request.
+func ProblemJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(problemJSONKey, true)
+		c.Next()
+	}
+}
+
+// ErrorBody returns the body for an error response. By default that is body
+// unchanged; under ProblemJSON it is converted to Problem Details and the
+// Content-Type is set to application/problem+json. The "error" message
+// becomes detail, title is the status text, and every other member of body
+// (e.g. "details", "retry_after") is kept as an extension member. Under
+// JSONAPI it becomes a JSON:API errors document instead.
+func ErrorBody(c *gin.Context, status int, body gin.H) gin.H {
+	if c.GetBool(jsonAPIKey) {
+		return jsonAPIErrors(c, status, body)
+	}
+	if !c.GetBool(problemJSONKey) {
+		return body
+	}
+
+	problem := gin.H{
+		"type":     "about:blank",
+		"title":    http.StatusText(status),
+		"status":   status,
+		"instance": c.Request.URL.Path,
+	}
+	for key, value := range body {
+		if key == "error" {
+			key = "detail"
+		}
+		if _, reserved := problem[key]; !reserved {
+			problem[key] = value
+		}
+	}
+	c.Header("Content-Type", ProblemContentType)
+	return problem
+}
+
+// ErrorJSON writes an error response shaped by ErrorBody
+func ErrorJSON(c *gin.Context, status int, body gin.H) {
+	c.JSON(status, ErrorBody(c, status, body))
+}
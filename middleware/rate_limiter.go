@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"math"
 	"strconv"
 	"sync"
@@ -18,35 +19,99 @@ type TokenBucket struct {
 	mu         sync.Mutex
 }
 
-// InMemoryRateLimiter implements per-IP token bucket rate limiting
+// DefaultRateLimit is the number of requests per minute allowed per IP
+const DefaultRateLimit = 20
+
+// RateLimitBypassHeader carries a token that exempts a request from rate
+// limiting (see WithBypassTokens)
+const RateLimitBypassHeader = "X-RateLimit-Bypass"
+
+// RateLimitKeyFunc identifies who a request is charged to. It returns the
+// bucket key and that bucket's capacity in requests per minute, plus the
+// subject ("IP", "owner") named in rate limit errors.
+type RateLimitKeyFunc func(c *gin.Context) (key string, limit int, subject string)
+
+// ClientIPKey charges every request to its client IP (see ClientID) at
+// DefaultRateLimit
+func ClientIPKey(c *gin.Context) (string, int, string) {
+	return "ip:" + ClientID(c), DefaultRateLimit, "IP"
+}
+
+// InMemoryRateLimiter implements keyed token bucket rate limiting
 type InMemoryRateLimiter struct {
-	buckets *sync.Map // map[string]*TokenBucket
+	buckets      *sync.Map // map[string]*TokenBucket
+	keyFunc      RateLimitKeyFunc
+	bypassTokens [][]byte // Tokens accepted in RateLimitBypassHeader
+}
+
+// RateLimiterOption configures a rate limiter created by NewKeyedRateLimiter
+type RateLimiterOption func(*InMemoryRateLimiter)
+
+// WithBypassTokens lets requests sending one of tokens in the
+// X-RateLimit-Bypass header skip the limiter, e.g. for trusted automation
+// that has no fixed IP. Empty tokens are ignored.
+func WithBypassTokens(tokens []string) RateLimiterOption {
+	return func(rl *InMemoryRateLimiter) {
+		for _, token := range tokens {
+			if token != "" {
+				rl.bypassTokens = append(rl.bypassTokens, []byte(token))
+			}
+		}
+	}
 }
 
 // NewInMemoryRateLimiter creates a new in-memory rate limiter
 // 20 requests per minute per IP
 func NewInMemoryRateLimiter() gin.HandlerFunc {
+	return NewKeyedRateLimiter(ClientIPKey)
+}
+
+// NewKeyedRateLimiter creates an in-memory rate limiter whose buckets are
+// chosen by keyFunc, e.g. per authenticated owner with per-owner capacities
+func NewKeyedRateLimiter(keyFunc RateLimitKeyFunc, opts ...RateLimiterOption) gin.HandlerFunc {
 	limiter := &InMemoryRateLimiter{
 		buckets: &sync.Map{},
+		keyFunc: keyFunc,
+	}
+	for _, opt := range opts {
+		opt(limiter)
 	}
 	
 	return limiter.middleware()
 }
 
-// getBucket gets or creates a token bucket for the given IP
-func (rl *InMemoryRateLimiter) getBucket(ip string) *TokenBucket {
-	val, _ := rl.buckets.LoadOrStore(ip, &TokenBucket{
-		tokens:     20.0,                    // Start with full bucket
+// bypassed reports whether the request presents a valid bypass token
+func (rl *InMemoryRateLimiter) bypassed(c *gin.Context) bool {
+	provided := c.GetHeader(RateLimitBypassHeader)
+	if provided == "" {
+		return false
+	}
+	matched := false
+	for _, token := range rl.bypassTokens {
+		// Compare against every token so timing doesn't reveal which matched
+		if subtle.ConstantTimeCompare([]byte(provided), token) == 1 {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// getBucket gets or creates a token bucket for the given key that refills
+// its full capacity once per minute
+func (rl *InMemoryRateLimiter) getBucket(key string, limit int) *TokenBucket {
+	capacity := float64(limit)
+	val, _ := rl.buckets.LoadOrStore(key, &TokenBucket{
+		tokens:     capacity,                // Start with full bucket
 		lastRefill: time.Now(),
-		capacity:   20.0,                    // 20 tokens max
-		refillRate: 20.0 / 60.0,            // 20 tokens per 60 seconds
+		capacity:   capacity,
+		refillRate: capacity / 60.0,         // Full refill every 60 seconds
 	})
 	return val.(*TokenBucket)
 }
 
-// allow checks if a request from the given IP should be allowed
-func (rl *InMemoryRateLimiter) allow(ip string) (bool, int) {
-	bucket := rl.getBucket(ip)
+// allow checks if a request charged to the given key should be allowed
+func (rl *InMemoryRateLimiter) allow(key string, limit int) (bool, int) {
+	bucket := rl.getBucket(key, limit)
 	
 	bucket.mu.Lock()
 	defer bucket.mu.Unlock()
@@ -71,26 +136,33 @@ func (rl *InMemoryRateLimiter) allow(ip string) (bool, int) {
 // middleware returns the Gin middleware function
 func (rl *InMemoryRateLimiter) middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
+		// Checked before a token is taken, so bypassed requests never drain a bucket
+		if rl.bypassed(c) {
+			c.Next()
+			return
+		}
+		
+		key, limit, subject := rl.keyFunc(c)
 		
-		allowed, remainingTokens := rl.allow(clientIP)
+		allowed, remainingTokens := rl.allow(key, limit)
 		
 		// Add rate limit headers
-		c.Header("X-RateLimit-Limit", "20")
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
 		c.Header("X-RateLimit-Window", "60")
 		c.Header("X-RateLimit-Remaining", strconv.Itoa(remainingTokens))
 		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(60*time.Second).Unix(), 10))
 		
 		if !allowed {
-			// Rate limited
-			c.Header("Retry-After", "3") // Approximately 3 seconds for next token
+			// Rate limited: roughly one token's refill time
+			retryAfter := int(math.Ceil(60.0 / float64(limit)))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
 			
-			c.JSON(429, gin.H{
+			ErrorJSON(c, 429, gin.H{
 				"error":       "Rate limit exceeded",
-				"message":     "Maximum 20 requests per minute per IP",
-				"limit":       20,
+				"message":     "Maximum " + strconv.Itoa(limit) + " requests per minute per " + subject,
+				"limit":       limit,
 				"window":      "60 seconds",
-				"retry_after": "3 seconds",
+				"retry_after": strconv.Itoa(retryAfter) + " seconds",
 			})
 			c.Abort()
 			return
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -280,4 +283,168 @@ func TestRateLimiter_IPExtraction(t *testing.T) {
 			t.Errorf("Request with RemoteAddr %s failed", tc.remoteAddr)
 		}
 	}
-} 
\ No newline at end of file
+}
+
+func TestRateLimiter_KeyFunc(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewKeyedRateLimiter(func(c *gin.Context) (string, int, string) {
+		if owner := c.GetHeader("X-Owner"); owner != "" {
+			return "owner:" + owner, 3, "owner"
+		}
+		return ClientIPKey(c)
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "success"})
+	})
+
+	request := func(owner string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.50:12345"
+		if owner != "" {
+			req.Header.Set("X-Owner", owner)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Each owner gets its own bucket with the owner capacity
+	for _, owner := range []string{"acme", "beta"} {
+		for i := 0; i < 3; i++ {
+			if w := request(owner); w.Code != http.StatusOK {
+				t.Fatalf("Request %d for %s failed with status %d", i+1, owner, w.Code)
+			}
+		}
+		w := request(owner)
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected %s to be limited after 3 requests, got %d", owner, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "3" {
+			t.Errorf("Expected X-RateLimit-Limit: 3, got %s", w.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+
+	// Anonymous traffic from the same IP is unaffected
+	if w := request(""); w.Code != http.StatusOK {
+		t.Errorf("Expected anonymous request to pass, got %d", w.Code)
+	}
+}
+
+func TestRateLimiter_ProblemJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ProblemJSON())
+	router.Use(NewKeyedRateLimiter(func(c *gin.Context) (string, int, string) {
+		return "ip:" + c.ClientIP(), 1, "IP"
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "success"})
+	})
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.60:12345"
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+	}
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, ProblemContentType) {
+		t.Errorf("Expected Content-Type %s, got %s", ProblemContentType, ct)
+	}
+
+	var problem map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	expected := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Too Many Requests",
+		"status":   float64(429),
+		"detail":   "Rate limit exceeded",
+		"instance": "/test",
+	}
+	for field, want := range expected {
+		if problem[field] != want {
+			t.Errorf("Expected %s %v, got %v", field, want, problem[field])
+		}
+	}
+	if _, ok := problem["error"]; ok {
+		t.Error("Problem Details should not keep the error member")
+	}
+	if problem["retry_after"] != "60 seconds" {
+		t.Errorf("Expected retry_after kept as an extension member, got %v", problem["retry_after"])
+	}
+}
+
+func TestRateLimiter_DefaultErrorBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewKeyedRateLimiter(func(c *gin.Context) (string, int, string) {
+		return "ip:" + c.ClientIP(), 1, "IP"
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "success"})
+	})
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+	}
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected application/json by default, got %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), `"error":"Rate limit exceeded"`) {
+		t.Errorf("Expected the default error body, got %s", w.Body.String())
+	}
+}
+
+func TestRateLimiter_BypassTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewKeyedRateLimiter(ClientIPKey, WithBypassTokens([]string{"automation-secret", ""})))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "success"})
+	})
+
+	request := func(ip, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = ip + ":12345"
+		if token != "" {
+			req.Header.Set(RateLimitBypassHeader, token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A valid token is never limited and doesn't use up the IP's allowance
+	for i := 0; i < DefaultRateLimit*2; i++ {
+		w := request("10.0.0.1", "automation-secret")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Bypassed request %d got status %d", i+1, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatal("Bypassed requests should not get rate limit headers")
+		}
+	}
+	if w := request("10.0.0.1", ""); w.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(DefaultRateLimit-1) {
+		t.Errorf("Expected the full allowance left after bypassed requests, got %s", w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	// An unknown token is limited like any other request
+	for i := 0; i < DefaultRateLimit; i++ {
+		if w := request("10.0.0.2", "guessed"); w.Code != http.StatusOK {
+			t.Fatalf("Request %d within the limit got status %d", i+1, w.Code)
+		}
+	}
+	if w := request("10.0.0.2", "guessed"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d with an invalid token, got %d", http.StatusTooManyRequests, w.Code)
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// AuditEntry is one durable record of a state change
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`               // e.g. "create", "drain"
+	Actor     string    `json:"actor"`                // Who made the change (admin or client IP)
+	ShortCode string    `json:"short_code,omitempty"`
+	LongURL   string    `json:"long_url,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Campaign groups links under a name and supplies defaults for the settings
+// a create request leaves unset
+type Campaign struct {
+	ID                   string     `json:"id"`
+	Name                 string     `json:"name,omitempty"`
+	ExpirationDate       *time.Time `json:"expiration_date,omitempty"`        // Default expiration of member links
+	Tags                 []string   `json:"tags,omitempty"`                   // Default tags, normalized like link tags
+	RedirectDelaySeconds int        `json:"redirect_delay_seconds,omitempty"` // Default countdown before redirecting
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// CampaignRequest represents the request payload for creating a campaign
+type CampaignRequest struct {
+	ID                   string     `json:"id" binding:"required"` // Letters, digits, '-' and '_', like a custom code
+	Name                 string     `json:"name,omitempty"`
+	ExpirationDate       *time.Time `json:"expiration_date,omitempty"`
+	Tags                 []string   `json:"tags,omitempty"`
+	RedirectDelaySeconds int        `json:"redirect_delay_seconds,omitempty"`
+}
+
+// ApplyDefaults fills the settings req leaves unset from the campaign.
+// Settings given in req always win; a retention tier counts as a set
+// expiration.
+func (c *Campaign) ApplyDefaults(req *ShortenRequest) {
+	if req.ExpirationDate == nil && req.Retention == "" && c.ExpirationDate != nil {
+		expires := *c.ExpirationDate
+		req.ExpirationDate = &expires
+	}
+	if len(req.Tags) == 0 && len(c.Tags) > 0 {
+		req.Tags = append([]string(nil), c.Tags...)
+	}
+	if req.RedirectDelaySeconds == 0 {
+		req.RedirectDelaySeconds = c.RedirectDelaySeconds
+	}
+}
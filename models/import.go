@@ -0,0 +1,16 @@
+package models
+
+// ImportRequest represents the request payload for importing mappings from
+// CSV exported by another system
+type ImportRequest struct {
+	CSV     string         `json:"csv" binding:"required"` // Header row first, then one mapping per row
+	Mapping *ImportMapping `json:"mapping,omitempty"`      // Header columns to read; unset ones keep their defaults
+}
+
+// ImportMapping names the CSV header columns each mapping field is read from
+type ImportMapping struct {
+	CodeCol       string `json:"code_col,omitempty"`       // Required column, default "short_code"
+	URLCol        string `json:"url_col,omitempty"`        // Required column, default "long_url"
+	ExpirationCol string `json:"expiration_col,omitempty"` // Optional column, default "expiration_date"
+	TitleCol      string `json:"title_col,omitempty"`      // Optional column, default "title"
+}
@@ -1,23 +1,117 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // URLMapping represents a mapping between a short code and a long URL
 type URLMapping struct {
 	ID             uint64     `json:"id"`
-	ShortCode      string     `json:"short_code"`
+	ShortCode      string     `json:"short_code"` // Storage key: the code, prefixed with "<namespace>:" for namespaced links
+	Namespace      string     `json:"namespace,omitempty"` // Tenant namespace; empty for the default one
+	Owner          string     `json:"owner,omitempty"` // API key owner that created the link; empty for anonymous creates
+	DisplayCode    string     `json:"display_code,omitempty"` // Custom code as typed when ShortCode is its case-folded key
 	LongURL        string     `json:"long_url"`
 	ExpirationDate *time.Time `json:"expiration_date,omitempty"` // Optional expiration
 	CreatedAt      time.Time  `json:"created_at"`
+	MaxUses        int        `json:"max_uses,omitempty"` // Zero means unlimited
+	UseCount       int        `json:"use_count"`          // Uses consumed so far (tracked for use-limited links)
+	AccessCount    int64      `json:"access_count"`       // Successful redirects recorded by RecordAccess
+	Destinations   []WeightedURL `json:"destinations,omitempty"` // Optional weighted split; LongURL is used when empty
+	RedirectRules  []RedirectRule `json:"redirect_rules,omitempty"` // Optional per-device targets evaluated before Destinations/LongURL
+	Tags           []string   `json:"tags,omitempty"` // Normalized labels (lowercase, deduplicated)
+	RedirectDelaySeconds int  `json:"redirect_delay_seconds,omitempty"` // Countdown page before redirecting; zero redirects instantly
+	Title          string     `json:"title,omitempty"` // Optional human-readable name, used as link text
+	NoHTTPSUpgrade bool       `json:"no_https_upgrade,omitempty"` // Keep an http:// destination as is under UPGRADE_HTTP_REDIRECTS
+	Campaign       string     `json:"campaign,omitempty"` // ID of the campaign the link was created in
+	RequireSignature bool     `json:"require_signature,omitempty"` // Only redirect with a valid ?exp=&sig= from POST /urls/{code}/sign
+	SlidingExpirationSeconds int64 `json:"sliding_expiration_seconds,omitempty"` // Each redirect moves the expiration to this long from then; zero keeps it fixed
+	PasswordHash   string     `json:"-"` // bcrypt hash; persisted by storage but never serialized in responses
+}
+
+// PublicCode returns the code to show users: the custom code as typed when
+// one was case-folded for storage, else the short code. Namespaced codes
+// are returned as the "<namespace>/<code>" path they are served under.
+func (m *URLMapping) PublicCode() string {
+	code := m.ShortCode
+	if m.DisplayCode != "" {
+		code = m.DisplayCode
+	}
+	if m.Namespace != "" {
+		code = strings.Replace(code, ":", "/", 1)
+	}
+	return code
 }
 
 // ShortenRequest represents the request payload for creating a short URL
 type ShortenRequest struct {
-	LongURL        string     `json:"long_url" binding:"required"`
-	ExpirationDate *time.Time `json:"expiration_date,omitempty"`
+	LongURL          string     `json:"long_url" binding:"required"`
+	ExpirationDate   *time.Time `json:"expiration_date,omitempty"`
+	ReservationToken string     `json:"reservation_token,omitempty"` // Claims a code from POST /urls/reserve
+	Password         string     `json:"password,omitempty"`          // Optional password required to follow the link
+	MaxUses          int        `json:"max_uses,omitempty"`          // Optional number of redirects before the link is gone
+	Retention        string     `json:"retention,omitempty"`         // Optional retention tier name (e.g. "short", "long")
+	Destinations     []WeightedURL `json:"destinations,omitempty"`    // Optional weighted A/B destinations
+	RedirectRules    []RedirectRule `json:"redirect_rules,omitempty"` // Optional per-device redirect targets
+	CustomCode       string     `json:"custom_code,omitempty"`       // Optional vanity code instead of a generated one
+	Tags             []string   `json:"tags,omitempty"`              // Optional labels; trimmed, lowercased and deduplicated
+	RedirectDelaySeconds int    `json:"redirect_delay_seconds,omitempty"` // Optional countdown before redirecting
+	Title            string     `json:"title,omitempty"`             // Optional link text for formatted variants
+	NoHTTPSUpgrade   bool       `json:"no_https_upgrade,omitempty"`  // Opt out of UPGRADE_HTTP_REDIRECTS for a host without HTTPS
+	Namespace        string     `json:"namespace,omitempty"`         // Optional tenant namespace from NAMESPACES; served as /<namespace>/<code>
+	Campaign         string     `json:"campaign,omitempty"`          // Optional campaign ID whose defaults fill unset settings
+	RequireSignature bool       `json:"require_signature,omitempty"` // Only follow the link through signed URLs (needs SIGNING_SECRET)
+	SlidingExpiration bool      `json:"sliding_expiration,omitempty"` // Keep the link alive while used: each redirect extends it by its original lifetime
+}
+
+// SignRequest represents the request payload for signing a short URL
+type SignRequest struct {
+	ExpiresIn string `json:"expires_in" binding:"required"` // How long the signed URL stays valid, e.g. "24h"
+}
+
+// RedirectRule sends visitors of one device class ("mobile", "tablet" or
+// "desktop", classified from User-Agent) to URL. Visitors matching no rule
+// get the link's regular destination.
+type RedirectRule struct {
+	Device string `json:"device"`
+	URL    string `json:"url"`
+}
+
+// WeightedURL is one destination of an A/B split. Redirects pick a
+// destination with probability proportional to its weight.
+type WeightedURL struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
 }
 
 // ShortenResponse represents the response for a successful URL shortening
 type ShortenResponse struct {
-	ShortURL string `json:"short_url"`
-} 
\ No newline at end of file
+	ShortURL  string     `json:"short_url"`
+	ShortCode string     `json:"short_code"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Omitted for links that never expire
+	Formats   map[string]string `json:"formats,omitempty"` // Variants requested with ?formats=, keyed by format name
+	QRDataURI string     `json:"qr_data_uri,omitempty"` // PNG QR code of short_url, requested with ?qr=true
+} 
+
+// ReserveResponse represents the response for a successful code reservation
+type ReserveResponse struct {
+	ShortCode        string    `json:"short_code"`
+	ShortURL         string    `json:"short_url"`
+	ReservationToken string    `json:"reservation_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// AccessEvent is the detail recorded for one sampled redirect
+type AccessEvent struct {
+	Time      time.Time `json:"time"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Visitor   string    `json:"visitor,omitempty"` // Client IP, or its hash under HASH_CLIENT_IPS
+}
+
+// BucketCount is the number of redirects recorded in one time bucket
+type BucketCount struct {
+	Start time.Time `json:"start"`
+	Count int64     `json:"count"`
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"tiny-url-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AuditLogger records create/update/delete operations in an append-only trail
+type AuditLogger interface {
+	// Log appends an entry to the audit trail
+	Log(entry models.AuditEntry) error
+	
+	// Since returns up to limit entries recorded at or after since, oldest first
+	Since(since time.Time, limit int) ([]models.AuditEntry, error)
+}
+
+// NopAuditLogger discards entries; it is used when auditing is not configured
+type NopAuditLogger struct{}
+
+// Log discards the entry
+func (NopAuditLogger) Log(models.AuditEntry) error { return nil }
+
+// Since always returns an empty trail
+func (NopAuditLogger) Since(time.Time, int) ([]models.AuditEntry, error) {
+	return []models.AuditEntry{}, nil
+}
+
+// FileAuditLogger appends entries as JSON lines to a file
+type FileAuditLogger struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileAuditLogger opens (or creates) path for appending audit entries
+func NewFileAuditLogger(path string) (*FileAuditLogger, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileAuditLogger{path: path, file: file}, nil
+}
+
+// Log appends entry as a single JSON line and syncs it to disk
+func (f *FileAuditLogger) Log(entry models.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return f.file.Sync()
+}
+
+// Since scans the file for entries at or after since
+func (f *FileAuditLogger) Since(since time.Time, limit int) ([]models.AuditEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	
+	entries := []models.AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() && len(entries) < limit {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip a torn trailing line rather than failing the read
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Close closes the underlying file
+func (f *FileAuditLogger) Close() error {
+	return f.file.Close()
+}
+
+// RedisAuditLogger appends entries to a Redis stream. Stream IDs are
+// millisecond timestamps, so Since is a cheap XRANGE.
+type RedisAuditLogger struct {
+	client redis.UniversalClient
+	stream string
+	ctx    context.Context
+}
+
+// NewRedisAuditLogger connects to Redis using rc and logs to stream
+func NewRedisAuditLogger(rc RedisConfig, stream string) (*RedisAuditLogger, error) {
+	client, err := newRedisClient(rc)
+	if err != nil {
+		return nil, err
+	}
+	
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &RedisAuditLogger{client: client, stream: stream, ctx: ctx}, nil
+}
+
+// Log appends entry to the stream
+func (r *RedisAuditLogger) Log(entry models.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	
+	err = r.client.XAdd(r.ctx, &redis.XAddArgs{
+		Stream: r.stream,
+		Values: map[string]interface{}{"entry": data},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
+
+// Since reads entries from the stream starting at since
+func (r *RedisAuditLogger) Since(since time.Time, limit int) ([]models.AuditEntry, error) {
+	start := "-"
+	if !since.IsZero() {
+		start = strconv.FormatInt(since.UnixMilli(), 10)
+	}
+	
+	messages, err := r.client.XRangeN(r.ctx, r.stream, start, "+", int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit stream: %w", err)
+	}
+	
+	entries := make([]models.AuditEntry, 0, len(messages))
+	for _, msg := range messages {
+		raw, ok := msg.Values["entry"].(string)
+		if !ok {
+			continue
+		}
+		var entry models.AuditEntry
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Close closes the Redis connection
+func (r *RedisAuditLogger) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+	"tiny-url-service/models"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testAuditTrail logs three entries and checks Since filtering and limits
+func testAuditTrail(t *testing.T, logger AuditLogger) {
+	t.Helper()
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	for i, code := range []string{"a", "b", "c"} {
+		err := logger.Log(models.AuditEntry{
+			Time:      base.Add(time.Duration(i) * time.Minute),
+			Action:    "create",
+			Actor:     "ip:127.0.0.1",
+			ShortCode: code,
+			LongURL:   "https://www.example.com/" + code,
+		})
+		if err != nil {
+			t.Fatalf("Log() failed: %v", err)
+		}
+	}
+
+	entries, err := logger.Since(time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("Since() failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].ShortCode != "a" || entries[2].ShortCode != "c" {
+		t.Errorf("Expected entries a, b, c in order, got %+v", entries)
+	}
+
+	entries, err = logger.Since(time.Time{}, 2)
+	if err != nil {
+		t.Fatalf("Since() failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected limit to cap entries at 2, got %d", len(entries))
+	}
+}
+
+func TestFileAuditLogger(t *testing.T) {
+	logger, err := NewFileAuditLogger(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("NewFileAuditLogger() failed: %v", err)
+	}
+	defer logger.Close()
+
+	testAuditTrail(t, logger)
+
+	entries, err := logger.Since(time.Now().UTC().Add(-time.Hour).Add(90*time.Second), 10)
+	if err != nil {
+		t.Fatalf("Since() failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ShortCode != "c" {
+		t.Errorf("Expected only entry c after the cutoff, got %+v", entries)
+	}
+}
+
+func TestRedisAuditLogger(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	logger, err := NewRedisAuditLogger(RedisConfig{URL: "redis://" + mock.Addr()}, "audit")
+	if err != nil {
+		t.Fatalf("NewRedisAuditLogger() failed: %v", err)
+	}
+	defer logger.Close()
+
+	testAuditTrail(t, logger)
+
+	// Stream IDs are assigned on append, so a cutoff in the future returns nothing
+	entries, err := logger.Since(time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Since() failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries after a future cutoff, got %d", len(entries))
+	}
+}
+
+func TestNopAuditLogger(t *testing.T) {
+	var logger AuditLogger = NopAuditLogger{}
+	if err := logger.Log(models.AuditEntry{Action: "create"}); err != nil {
+		t.Fatalf("Log() failed: %v", err)
+	}
+	entries, err := logger.Since(time.Time{}, 10)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Since() = (%v, %v), expected an empty trail", entries, err)
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"tiny-url-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// campaignKey holds a campaign's JSON in Redis
+func campaignKey(id string) string {
+	return "campaign:" + id
+}
+
+// campaignURLsKey is the set of codes created in a campaign
+func campaignURLsKey(id string) string {
+	return "campaign_urls:" + id
+}
+
+// sortByShortCode orders mappings by short code so listings are stable
+func sortByShortCode(mappings []*models.URLMapping) {
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].ShortCode < mappings[j].ShortCode
+	})
+}
+
+// CreateCampaign stores campaign unless its ID is taken
+func (m *MemoryStorage) CreateCampaign(campaign *models.Campaign) error {
+	m.campMu.Lock()
+	defer m.campMu.Unlock()
+
+	if _, exists := m.campaigns[campaign.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrCampaignExists, campaign.ID)
+	}
+	stored := *campaign
+	if err := m.logCampaign(&stored); err != nil {
+		return err
+	}
+	m.campaigns[campaign.ID] = &stored
+	return nil
+}
+
+// GetCampaign returns a copy of the campaign stored under id
+func (m *MemoryStorage) GetCampaign(id string) (*models.Campaign, error) {
+	m.campMu.RLock()
+	defer m.campMu.RUnlock()
+
+	campaign, exists := m.campaigns[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: campaign %s", ErrNotFound, id)
+	}
+	result := *campaign
+	return &result, nil
+}
+
+// CampaignURLs scans every shard for the campaign's links
+func (m *MemoryStorage) CampaignURLs(id string) ([]*models.URLMapping, error) {
+	if _, err := m.GetCampaign(id); err != nil {
+		return nil, err
+	}
+
+	members := []*models.URLMapping{}
+	err := m.Each(func(mapping *models.URLMapping) error {
+		if mapping.Campaign == id {
+			members = append(members, mapping)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortByShortCode(members)
+	return members, nil
+}
+
+// CreateCampaign stores campaign with SET NX so concurrent creates of one ID
+// can't overwrite each other
+func (r *RedisStorage) CreateCampaign(campaign *models.Campaign) error {
+	data, err := json.Marshal(campaign)
+	if err != nil {
+		return fmt.Errorf("failed to marshal campaign: %w", err)
+	}
+	stored, err := r.client.SetNX(r.ctx, campaignKey(campaign.ID), data, 0).Result()
+	if err != nil {
+		return fmt.Errorf("failed to store campaign in Redis: %w", err)
+	}
+	if !stored {
+		return fmt.Errorf("%w: %s", ErrCampaignExists, campaign.ID)
+	}
+	return nil
+}
+
+// GetCampaign reads the campaign stored under id
+func (r *RedisStorage) GetCampaign(id string) (*models.Campaign, error) {
+	data, err := r.client.Get(r.ctx, campaignKey(id)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: campaign %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign from Redis: %w", err)
+	}
+
+	var campaign models.Campaign
+	if err := json.Unmarshal([]byte(data), &campaign); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal campaign: %w", err)
+	}
+	return &campaign, nil
+}
+
+// CampaignURLs reads the campaign's member set. Delete doesn't always know
+// a mapping's campaign, so members that no longer exist (or were re-created
+// outside the campaign) are skipped here and pruned from the set.
+func (r *RedisStorage) CampaignURLs(id string) ([]*models.URLMapping, error) {
+	if _, err := r.GetCampaign(id); err != nil {
+		return nil, err
+	}
+
+	codes, err := r.client.SMembers(r.ctx, campaignURLsKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read campaign members from Redis: %w", err)
+	}
+	found, err := r.GetBatch(codes)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*models.URLMapping, 0, len(found))
+	stale := []interface{}{}
+	for _, code := range codes {
+		if mapping, ok := found[code]; ok && mapping.Campaign == id {
+			members = append(members, mapping)
+		} else {
+			stale = append(stale, code)
+		}
+	}
+	if len(stale) > 0 {
+		if err := r.client.SRem(r.ctx, campaignURLsKey(id), stale...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to prune campaign members in Redis: %w", err)
+		}
+	}
+	sortByShortCode(members)
+	return members, nil
+}
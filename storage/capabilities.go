@@ -0,0 +1,44 @@
+package storage
+
+// StorageCapabilities describes what a backend supports, so callers can
+// turn features on or fall back gracefully instead of assuming every
+// backend behaves like Redis
+type StorageCapabilities struct {
+	// SupportsNativeTTL is set when the backend expires keys by itself
+	// (e.g. reservations), rather than sweeping them on access
+	SupportsNativeTTL bool `json:"supports_native_ttl"`
+	
+	// SupportsAtomicCreate is set when StoreWithCode claims a code
+	// atomically, so two concurrent creates can't both get it
+	SupportsAtomicCreate bool `json:"supports_atomic_create"`
+	
+	// SupportsReverseIndex is set when FindByLongURL is backed by a long URL
+	// index; without it, it always returns ErrNotFound
+	SupportsReverseIndex bool `json:"supports_reverse_index"`
+	
+	// Shared is set when the data is shared between every instance using
+	// the backend, rather than private to this process
+	Shared bool `json:"shared"`
+}
+
+// Capabilities reports memory storage features. Creates are atomic under
+// the shard lock; expired reservations are swept on access.
+func (m *MemoryStorage) Capabilities() StorageCapabilities {
+	return StorageCapabilities{
+		SupportsNativeTTL:    false,
+		SupportsAtomicCreate: true,
+		SupportsReverseIndex: m.opts.reverseHash != nil,
+		Shared:               false,
+	}
+}
+
+// Capabilities reports Redis storage features. Creates use SET NX and
+// reservations expire through key TTLs.
+func (r *RedisStorage) Capabilities() StorageCapabilities {
+	return StorageCapabilities{
+		SupportsNativeTTL:    true,
+		SupportsAtomicCreate: true,
+		SupportsReverseIndex: r.opts.reverseHash != nil,
+		Shared:               true,
+	}
+}
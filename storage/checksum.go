@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"tiny-url-service/models"
+	"tiny-url-service/utils"
+)
+
+// checkedGet looks shortCode up with getRaw. With checksum codes, a code
+// that fails its check can't be a generated code, so only a custom code
+// (ID 0) may answer to it; anything else is reported as ErrChecksumMismatch
+// rather than served.
+func (o *options) checkedGet(shortCode string, getRaw func(string) (*models.URLMapping, error)) (*models.URLMapping, error) {
+	if !o.checksumCodes {
+		return getRaw(shortCode)
+	}
+	_, code := SplitNamespace(shortCode)
+	if _, ok := utils.DecodeBase62WithChecksum(code); ok {
+		return getRaw(shortCode)
+	}
+	
+	mapping, err := getRaw(shortCode)
+	if err == nil && mapping.ID == 0 {
+		return mapping, nil
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %w: %s", ErrNotFound, ErrChecksumMismatch, shortCode)
+}
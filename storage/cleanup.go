@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+	"tiny-url-service/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cleanupLockKey holds the token of the instance currently running cleanup
+const cleanupLockKey = "cleanup:lock"
+
+// renewLockScript extends a lease only if it is still held by the given token
+var renewLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLockScript deletes a lease only if it is still held by the given token
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// TryAcquireCleanupLock takes the cleanup lease with SET NX so only one
+// instance sharing this Redis runs cleanup at a time. While held, the lease
+// is renewed every ttl/3, so a slow run doesn't lose it; if the holder dies
+// it expires after ttl. release stops renewing and deletes the lease if it
+// still belongs to us, and is safe to call more than once. ok is false when
+// another instance holds the lease.
+func (r *RedisStorage) TryAcquireCleanupLock(ttl time.Duration) (release func(), ok bool, err error) {
+	token, err := utils.GenerateToken(16)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	acquired, err := r.client.SetNX(r.ctx, cleanupLockKey, token, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire cleanup lock: %w", err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				renewed, err := renewLockScript.Run(r.ctx, r.client, []string{cleanupLockKey}, token, ttl.Milliseconds()).Int()
+				if err != nil {
+					log.Printf("Failed to renew cleanup lock: %v", err)
+				} else if renewed == 0 {
+					log.Printf("Cleanup lock was lost before it was released")
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+			if err := releaseLockScript.Run(r.ctx, r.client, []string{cleanupLockKey}, token).Err(); err != nil {
+				log.Printf("Failed to release cleanup lock: %v", err)
+			}
+		})
+	}
+	return release, true, nil
+}
+
+// PurgeExpired deletes mappings whose expiration has passed, found through
+// the expiration index, and returns how many were deleted
+func (r *RedisStorage) PurgeExpired() (int, error) {
+	codes, err := r.client.ZRangeByScore(r.ctx, expirationsKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read expiration index from Redis: %w", err)
+	}
+	if len(codes) == 0 {
+		return 0, nil
+	}
+
+	found, err := r.getBatchExact(codes)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, code := range codes {
+		mapping, ok := found[code]
+		if !ok {
+			// Already gone; drop the index entry
+			if err := r.client.ZRem(r.ctx, expirationsKey, code).Err(); err != nil {
+				return purged, fmt.Errorf("failed to prune expiration index: %w", err)
+			}
+			continue
+		}
+		if !r.IsExpired(mapping) {
+			continue
+		}
+		if err := r.Delete(code); err != nil && !errors.Is(err, ErrNotFound) {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// PurgeExpired deletes expired mappings and returns how many were deleted
+func (m *MemoryStorage) PurgeExpired() (int, error) {
+	return m.purgeExpired()
+}
+
+// expiredPurger is implemented by backends that can delete expired mappings in bulk
+type expiredPurger interface {
+	PurgeExpired() (int, error)
+}
+
+// cleanupLocker is implemented by backends shared between instances, whose
+// cleanup must run on one instance at a time
+type cleanupLocker interface {
+	TryAcquireCleanupLock(ttl time.Duration) (release func(), ok bool, err error)
+}
+
+// StartCleanup deletes expired mappings from store every interval until
+// stop is called. On shared backends each run first takes the cleanup lock
+// and is skipped while another instance holds it. Stores that can't purge
+// in bulk are left alone.
+func StartCleanup(store Storage, interval time.Duration) (stop func()) {
+	purger, ok := store.(expiredPurger)
+	if !ok || interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				runCleanup(store, purger, interval)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// runCleanup performs one cleanup run, under the cleanup lock when store has one
+func runCleanup(store Storage, purger expiredPurger, interval time.Duration) {
+	if locker, ok := store.(cleanupLocker); ok {
+		release, acquired, err := locker.TryAcquireCleanupLock(interval)
+		if err != nil {
+			log.Printf("Skipping cleanup: %v", err)
+			return
+		}
+		if !acquired {
+			return // Another instance is cleaning up
+		}
+		defer release()
+	}
+
+	purged, err := purger.PurgeExpired()
+	if err != nil {
+		log.Printf("Cleanup failed after purging %d expired URLs: %v", purged, err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("🧹 Cleanup purged %d expired URLs", purged)
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// clickCounts are the increments buffered for one short code
+type clickCounts struct {
+	total int64
+	hours map[time.Time]int64 // Keyed by the UTC hour the clicks fell in
+}
+
+// clickBuffer accumulates redirect counts in memory between flushes so a
+// busy link costs one write per flush interval instead of one per redirect
+type clickBuffer struct {
+	mu     sync.Mutex
+	counts map[string]*clickCounts
+}
+
+func newClickBuffer() *clickBuffer {
+	return &clickBuffer{counts: make(map[string]*clickCounts)}
+}
+
+// add buffers n clicks of shortCode during the hour containing at
+func (b *clickBuffer) add(shortCode string, at time.Time, n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.addLocked(shortCode, at.UTC().Truncate(time.Hour), n)
+}
+
+func (b *clickBuffer) addLocked(shortCode string, hour time.Time, n int64) {
+	counts, ok := b.counts[shortCode]
+	if !ok {
+		counts = &clickCounts{hours: make(map[time.Time]int64)}
+		b.counts[shortCode] = counts
+	}
+	counts.total += n
+	counts.hours[hour] += n
+}
+
+// take returns everything buffered so far and starts a new buffer
+func (b *clickBuffer) take() map[string]*clickCounts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pending := b.counts
+	b.counts = make(map[string]*clickCounts)
+	return pending
+}
+
+// restore merges counts that failed to flush back into the buffer
+func (b *clickBuffer) restore(pending map[string]*clickCounts) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for code, counts := range pending {
+		for hour, n := range counts.hours {
+			b.addLocked(code, hour, n)
+		}
+	}
+}
+
+// drop discards buffered clicks of shortCode, e.g. when its counter is reset
+func (b *clickBuffer) drop(shortCode string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.counts, shortCode)
+}
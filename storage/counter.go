@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// counterFloorScript sets the counter to ARGV[1] unless it is already at
+// least that, returning the resulting value. Values are compared as decimal
+// strings because Lua numbers lose precision past 2^53.
+var counterFloorScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1]) or '0'
+local floor = ARGV[1]
+if #floor > #current or (#floor == #current and floor > current) then
+	redis.call('SET', KEYS[1], floor)
+	return floor
+end
+return current
+`)
+
+// SetCounterFloor raises the shared counter to v in one atomic step, so a
+// concurrent INCR is never undone
+func (r *RedisStorage) SetCounterFloor(v uint64) error {
+	// Redis counters are signed 64-bit integers
+	if v > r.opts.idLimit() || v > math.MaxInt64 {
+		return fmt.Errorf("%w: %d", ErrCounterOutOfRange, v)
+	}
+	result, err := counterFloorScript.Run(r.ctx, r.client, []string{"counter"}, strconv.FormatUint(v, 10)).Text()
+	if err != nil {
+		return fmt.Errorf("failed to set counter floor in Redis: %w", err)
+	}
+	counter, err := strconv.ParseUint(result, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid counter in Redis: %w", err)
+	}
+	atomic.StoreUint64(&r.counter, counter)
+	return nil
+}
+
+// SetCounterFloor raises the counter to v with a compare-and-swap loop, so a
+// concurrent Store is never undone. With a data directory the new floor is
+// persisted right away by compacting, since only snapshots record the counter.
+func (m *MemoryStorage) SetCounterFloor(v uint64) error {
+	if v > m.opts.idLimit() {
+		return fmt.Errorf("%w: %d", ErrCounterOutOfRange, v)
+	}
+	for {
+		current := atomic.LoadUint64(&m.counter)
+		if current >= v {
+			return nil
+		}
+		if atomic.CompareAndSwapUint64(&m.counter, current, v) {
+			return m.Compact()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"tiny-url-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// domainsKey is the set of every host a domain counter was created for
+const domainsKey = "domains"
+
+// domainKey counts the stored long URLs targeting host
+func domainKey(host string) string {
+	return "domain:" + host
+}
+
+// urlHost returns the lowercased host longURL targets, without port or
+// trailing dot, or "" when it has none
+func urlHost(longURL string) string {
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+}
+
+// CountByDomain walks every mapping, expired ones included, and counts
+// their long URLs per host
+func (m *MemoryStorage) CountByDomain() (map[string]int, error) {
+	counts := make(map[string]int)
+	err := m.Each(func(mapping *models.URLMapping) error {
+		if host := urlHost(mapping.LongURL); host != "" {
+			counts[host]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// trackDomain adds (delta 1) or removes (delta -1) a long URL from its
+// host's counter. Hosts stay in the domains set once seen, so a counter
+// dropping to 0 can't race a concurrent create of the same host.
+func (r *RedisStorage) trackDomain(longURL string, delta int64) error {
+	host := urlHost(longURL)
+	if host == "" {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	pipe.IncrBy(r.ctx, domainKey(host), delta)
+	if delta > 0 {
+		pipe.SAdd(r.ctx, domainsKey, host)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return fmt.Errorf("failed to update domain counts: %w", err)
+	}
+	return nil
+}
+
+// CountByDomain reads the domain:<host> counters kept up to date by Store
+// and Delete, skipping hosts whose links have all been deleted
+func (r *RedisStorage) CountByDomain() (map[string]int, error) {
+	hosts, err := r.client.SMembers(r.ctx, domainsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read domains from Redis: %w", err)
+	}
+
+	// Counters hash to different cluster slots, so read them in a pipeline
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(hosts))
+	for i, host := range hosts {
+		cmds[i] = pipe.Get(r.ctx, domainKey(host))
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read domain counts from Redis: %w", err)
+	}
+
+	counts := make(map[string]int, len(hosts))
+	for i, host := range hosts {
+		if n, err := cmds[i].Int(); err == nil && n > 0 {
+			counts[host] = n
+		}
+	}
+	return counts, nil
+}
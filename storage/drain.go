@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Drainer is implemented by storage that should finish its pending writes
+// before the process exits. Drain must be bounded by ctx and leaves the
+// storage closed.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// commandTracker is a go-redis hook counting the commands and pipelines in
+// progress, so Drain can wait for them before closing the client
+type commandTracker struct {
+	mu      sync.Mutex
+	active  int
+	waiters []chan struct{} // Closed once active drops to zero
+}
+
+func (t *commandTracker) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (t *commandTracker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		t.begin()
+		defer t.end()
+		return next(ctx, cmd)
+	}
+}
+
+func (t *commandTracker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		t.begin()
+		defer t.end()
+		return next(ctx, cmds)
+	}
+}
+
+// begin counts one command or pipeline in
+func (t *commandTracker) begin() {
+	t.mu.Lock()
+	t.active++
+	t.mu.Unlock()
+}
+
+// end counts one command or pipeline out, waking waiters once none are left
+func (t *commandTracker) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if t.active == 0 {
+		for _, w := range t.waiters {
+			close(w)
+		}
+		t.waiters = nil
+	}
+}
+
+// wait blocks until no command is in progress or ctx is done
+func (t *commandTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.active == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	t.waiters = append(t.waiters, idle)
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Drain stops the click flush loop, writes the buffered click counts, waits
+// for commands still in progress (e.g. a flush pipeline or a late request)
+// and then closes the Redis connection. Waiting is bounded by ctx; the
+// connection is closed either way, and ctx's error is returned if it ran
+// out. Only the first Drain or Close does the work; later calls return its
+// result.
+func (r *RedisStorage) Drain(ctx context.Context) error {
+	r.closeOnce.Do(func() {
+		r.closeErr = r.drain(ctx)
+	})
+	return r.closeErr
+}
+
+func (r *RedisStorage) drain(ctx context.Context) error {
+	var drainErr error
+	if r.clicks != nil {
+		close(r.stopFlush)
+		select {
+		case <-r.flushDone:
+			drainErr = r.flushClicks(ctx)
+		case <-ctx.Done():
+			drainErr = fmt.Errorf("click flush still running: %w", ctx.Err())
+		}
+	}
+	if err := r.commands.wait(ctx); err != nil && drainErr == nil {
+		drainErr = fmt.Errorf("Redis commands still in progress: %w", err)
+	}
+
+	if err := r.client.Close(); err != nil && drainErr == nil {
+		drainErr = err
+	}
+	return drainErr
+}
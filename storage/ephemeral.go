@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultEphemeralSweepInterval is how often the in-memory ephemeral store
+// evicts expired keys
+const DefaultEphemeralSweepInterval = time.Minute
+
+// EphemeralStore holds short-lived keyed state, such as recent submissions
+// for duplicate detection, so features needing a TTL share one mechanism
+// instead of each keeping its own expiring map
+type EphemeralStore interface {
+	// SetNX stores val under key for ttl unless key is already set, and
+	// reports whether it was stored. Of concurrent calls for one key,
+	// exactly one succeeds.
+	SetNX(key, val string, ttl time.Duration) (bool, error)
+	
+	// Get returns the value under key, or ErrNotFound if it is unset or expired
+	Get(key string) (string, error)
+}
+
+// ephemeralEntry is one value held by MemoryEphemeralStore
+type ephemeralEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// MemoryEphemeralStore is a per-instance EphemeralStore. Expired keys are
+// ignored on read and evicted by a sweeper, started by the first write, so
+// keys that are never read again don't accumulate.
+type MemoryEphemeralStore struct {
+	mu        sync.Mutex
+	entries   map[string]ephemeralEntry
+	interval  time.Duration
+	startOnce sync.Once
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryEphemeralStore creates an in-memory store swept every interval
+// (DefaultEphemeralSweepInterval when interval is not positive)
+func NewMemoryEphemeralStore(interval time.Duration) *MemoryEphemeralStore {
+	if interval <= 0 {
+		interval = DefaultEphemeralSweepInterval
+	}
+	return &MemoryEphemeralStore{
+		entries:  make(map[string]ephemeralEntry),
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// SetNX stores val under key for ttl unless an unexpired value is there
+func (m *MemoryEphemeralStore) SetNX(key, val string, ttl time.Duration) (bool, error) {
+	m.startOnce.Do(func() { go m.sweepLoop() })
+	
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	now := time.Now()
+	if entry, ok := m.entries[key]; ok && now.Before(entry.expiresAt) {
+		return false, nil
+	}
+	m.entries[key] = ephemeralEntry{value: val, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Get returns the unexpired value under key
+func (m *MemoryEphemeralStore) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	entry, ok := m.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return entry.value, nil
+}
+
+// sweep evicts expired keys
+func (m *MemoryEphemeralStore) sweep() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	now := time.Now()
+	for key, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// sweepLoop sweeps every interval until Close
+func (m *MemoryEphemeralStore) sweepLoop() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.sweep()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Close stops the sweeper
+func (m *MemoryEphemeralStore) Close() error {
+	m.closeOnce.Do(func() { close(m.stop) })
+	return nil
+}
+
+// ephemeralKeyPrefix namespaces RedisEphemeralStore keys
+const ephemeralKeyPrefix = "ephemeral:"
+
+// RedisEphemeralStore is an EphemeralStore shared by every instance using
+// the same Redis. Keys expire through Redis' native TTL.
+type RedisEphemeralStore struct {
+	client redis.UniversalClient
+	ctx    context.Context
+}
+
+// NewRedisEphemeralStore connects to Redis using rc
+func NewRedisEphemeralStore(rc RedisConfig) (*RedisEphemeralStore, error) {
+	client, err := newRedisClient(rc)
+	if err != nil {
+		return nil, err
+	}
+	
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &RedisEphemeralStore{client: client, ctx: ctx}, nil
+}
+
+// SetNX stores val under key with SET NX PX
+func (r *RedisEphemeralStore) SetNX(key, val string, ttl time.Duration) (bool, error) {
+	stored, err := r.client.SetNX(r.ctx, ephemeralKeyPrefix+key, val, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set ephemeral key in Redis: %w", err)
+	}
+	return stored, nil
+}
+
+// Get returns the value under key
+func (r *RedisEphemeralStore) Get(key string) (string, error) {
+	val, err := r.client.Get(r.ctx, ephemeralKeyPrefix+key).Result()
+	if err == redis.Nil {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get ephemeral key from Redis: %w", err)
+	}
+	return val, nil
+}
+
+// Close closes the Redis connection
+func (r *RedisEphemeralStore) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testEphemeralExpiry checks that a value is readable until its TTL passes,
+// after which the key can be set again. advance moves the store's clock.
+func testEphemeralExpiry(t *testing.T, store EphemeralStore, advance func(time.Duration)) {
+	t.Helper()
+
+	stored, err := store.SetNX("key", "first", 100*time.Millisecond)
+	if err != nil || !stored {
+		t.Fatalf("SetNX() = %v, %v; expected the first write to succeed", stored, err)
+	}
+	if stored, _ := store.SetNX("key", "second", time.Minute); stored {
+		t.Error("Expected SetNX to refuse a key that is still set")
+	}
+	if val, err := store.Get("key"); err != nil || val != "first" {
+		t.Errorf("Get() = %q, %v; expected \"first\"", val, err)
+	}
+
+	advance(150 * time.Millisecond)
+	if _, err := store.Get("key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after the TTL, got %v", err)
+	}
+	if stored, err := store.SetNX("key", "third", time.Minute); err != nil || !stored {
+		t.Errorf("SetNX() = %v, %v; expected an expired key to be settable again", stored, err)
+	}
+}
+
+// testEphemeralSetNXRace checks that exactly one of many concurrent SetNX calls wins
+func testEphemeralSetNXRace(t *testing.T, store EphemeralStore) {
+	t.Helper()
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stored, err := store.SetNX("race", "value", time.Minute)
+			if err != nil {
+				t.Errorf("SetNX() failed: %v", err)
+			}
+			if stored {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := wins.Load(); got != 1 {
+		t.Errorf("Expected exactly one SetNX to win, got %d", got)
+	}
+}
+
+func TestMemoryEphemeralStore(t *testing.T) {
+	store := NewMemoryEphemeralStore(DefaultEphemeralSweepInterval)
+	defer store.Close()
+
+	testEphemeralExpiry(t, store, time.Sleep)
+	testEphemeralSetNXRace(t, store)
+}
+
+func TestMemoryEphemeralStore_Sweep(t *testing.T) {
+	store := NewMemoryEphemeralStore(10 * time.Millisecond)
+	defer store.Close()
+
+	store.SetNX("key", "value", time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.entries) != 0 {
+		t.Errorf("Expected the sweeper to evict the expired key, %d entries left", len(store.entries))
+	}
+}
+
+func TestRedisEphemeralStore(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisEphemeralStore(RedisConfig{URL: "redis://" + mock.Addr()})
+	if err != nil {
+		t.Fatalf("NewRedisEphemeralStore() failed: %v", err)
+	}
+	defer store.Close()
+
+	testEphemeralExpiry(t, store, mock.FastForward)
+	testEphemeralSetNXRace(t, store)
+}
@@ -0,0 +1,53 @@
+package storage
+
+import "errors"
+
+// Sentinel errors returned by storage implementations so handlers can map
+// them to HTTP status codes with errors.Is
+var (
+	// ErrNotFound is returned when no mapping exists for a short code
+	ErrNotFound = errors.New("short code not found")
+	
+	// ErrExpired is returned when a mapping exists but its expiration date has passed
+	ErrExpired = errors.New("URL has expired")
+	
+	// ErrUsesExhausted is returned when a use-limited link has reached its MaxUses
+	ErrUsesExhausted = errors.New("link has reached its maximum number of uses")
+	
+	// ErrReservationNotFound is returned when a reservation token is unknown or has expired
+	ErrReservationNotFound = errors.New("reservation not found or expired")
+	
+	// ErrCodeTaken is returned when a requested short code is already in use
+	ErrCodeTaken = errors.New("short code already taken")
+	
+	// ErrCodeReserved is returned when a requested short code is a reserved word
+	ErrCodeReserved = errors.New("short code is reserved")
+	
+	// ErrCampaignExists is returned when a campaign ID is already in use
+	ErrCampaignExists = errors.New("campaign already exists")
+	
+	// ErrCapacityExceeded is returned when storing would exceed the configured maximum number of URLs
+	ErrCapacityExceeded = errors.New("storage capacity exceeded")
+	
+	// ErrIDSpaceExhausted is returned when the ID counter has reached its
+	// maximum, rather than wrapping around and reusing codes
+	ErrIDSpaceExhausted = errors.New("short code ID space exhausted")
+	
+	// ErrKeyspaceExhausted is returned when the next generated code would be
+	// longer than the configured maximum generated code length
+	ErrKeyspaceExhausted = errors.New("short code keyspace exhausted")
+	
+	// ErrCounterOutOfRange is returned by SetCounterFloor for a value past
+	// the highest ID the store may allocate
+	ErrCounterOutOfRange = errors.New("counter value out of range")
+	
+	// ErrChecksumMismatch is returned, wrapped together with ErrNotFound, by
+	// Get for a code whose check character is wrong, i.e. a likely typo
+	ErrChecksumMismatch = errors.New("short code checksum mismatch")
+	
+	// ErrInvalidBucket is returned when a click series bucket is not a whole number of hours
+	ErrInvalidBucket = errors.New("bucket must be a whole number of hours")
+	
+	// ErrSearchDisabled is returned by Search when the backend has scan-based search turned off
+	ErrSearchDisabled = errors.New("search is disabled for this storage backend")
+)
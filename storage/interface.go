@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"time"
 	"tiny-url-service/models"
 )
 
@@ -9,12 +10,160 @@ type Storage interface {
 	// Store saves a URL mapping and returns the generated short code
 	Store(mapping *models.URLMapping) (string, error)
 	
-	// Get retrieves the URL mapping for a given short code
+	// StoreWithCode saves a URL mapping under a caller-chosen short code.
+	// It returns ErrCodeTaken if the code is already stored or reserved, and
+	// ErrCodeReserved if it is a reserved word (see WithReservedWords).
+	StoreWithCode(mapping *models.URLMapping, shortCode string) error
+	
+	// Exists reports whether a short code is taken, including by expired mappings
+	Exists(shortCode string) (bool, error)
+	
+	// ExistsBatch reports Exists for many codes in one pass. The result has
+	// an entry for every requested code.
+	ExistsBatch(codes []string) (map[string]bool, error)
+	
+	// Get retrieves the URL mapping for a given short code. It returns
+	// ErrExpired for expired mappings and is what every public path must use.
 	Get(shortCode string) (*models.URLMapping, error)
 	
-	// IsExpired checks if a URL mapping has expired
+	// GetAndExtend is Get for links with a sliding expiration: if the mapping
+	// has an expiration date, it is atomically pushed out to extendBy from
+	// now (never earlier than it already was), and the returned mapping
+	// carries the new date. Mappings without one are returned unchanged.
+	GetAndExtend(shortCode string, extendBy time.Duration) (*models.URLMapping, error)
+	
+	// GetRaw retrieves the URL mapping even if it has expired, returning
+	// ErrNotFound only when the code is not stored. It bypasses expiration
+	// enforcement and is meant for admin tooling only; never serve redirects
+	// or public responses from it.
+	GetRaw(shortCode string) (*models.URLMapping, error)
+	
+	// GetBatch is GetRaw for many codes in one pass: it returns the stored
+	// mappings, expired ones included, keyed by code. Codes that don't exist
+	// are absent from the result.
+	GetBatch(codes []string) (map[string]*models.URLMapping, error)
+	
+	// FindByLongURL returns an unconditional, non-expiring mapping for longURL
+	// via the reverse index. It returns ErrNotFound on a miss or when the
+	// index is disabled.
+	FindByLongURL(longURL string) (*models.URLMapping, error)
+	
+	// FindByOwnerLongURL is FindByLongURL limited to links created by owner
+	FindByOwnerLongURL(owner, longURL string) (*models.URLMapping, error)
+	
+	// Delete removes a mapping and its counters. It returns ErrNotFound if
+	// the code is not stored.
+	Delete(shortCode string) error
+	
+	// IsExpired checks if a URL mapping has expired, allowing for the
+	// clock skew tolerance (see WithClockSkewTolerance)
 	IsExpired(mapping *models.URLMapping) bool
 	
+	// ExpiresAt returns the deadline IsExpired checks against: the
+	// expiration plus the clock skew tolerance, or nil if it never expires
+	ExpiresAt(mapping *models.URLMapping) *time.Time
+	
 	// GetStats returns storage statistics
 	GetStats() map[string]interface{}
-} 
\ No newline at end of file
+	
+	// Capabilities reports which optional features the backend supports
+	Capabilities() StorageCapabilities
+	
+	// Reserve allocates the next short code and holds it for a limited time.
+	// The returned token must be presented to ClaimReservation to use the code.
+	Reserve() (code, token string, err error)
+	
+	// ClaimReservation stores a link to longURL under the code held by token.
+	// It returns ErrReservationNotFound if the token is unknown or has expired.
+	ClaimReservation(token, longURL string) error
+	
+	// ClaimReservationMapping is ClaimReservation for a link with settings
+	// beyond its long URL. It sets the mapping's ID and ShortCode.
+	ClaimReservationMapping(token string, mapping *models.URLMapping) error
+	
+	// ConsumeUse atomically records one use of a use-limited link and returns
+	// how many uses remain. It returns ErrUsesExhausted once MaxUses is reached,
+	// and -1 remaining for links without a limit.
+	ConsumeUse(shortCode string) (remaining int, err error)
+	
+	// RecordAccess counts one redirect of shortCode at the given time, both in
+	// the link's total access count and in its hourly click series
+	RecordAccess(shortCode string, at time.Time) error
+	
+	// ResetAccessCount sets shortCode's total access count back to zero. The
+	// hourly click series and labeled clicks are left as they are.
+	ResetAccessCount(shortCode string) error
+	
+	// AccessSeries returns click counts for shortCode grouped into buckets of
+	// the given width (a whole number of hours), oldest first, starting at since.
+	// since is clamped to the click retention window.
+	AccessSeries(shortCode string, bucket time.Duration, since time.Time) ([]models.BucketCount, error)
+	
+	// RecordLabeledClick counts one redirect of shortCode under label, e.g.
+	// the A/B destination it was sent to
+	RecordLabeledClick(shortCode, label string) error
+	
+	// LabeledClicks returns the per-label redirect counts for shortCode
+	LabeledClicks(shortCode string) (map[string]int64, error)
+	
+	// RecordAccessEvent stores the detail of one redirect of shortCode. Only
+	// the latest MaxAccessEvents events per link are kept.
+	RecordAccessEvent(shortCode string, event models.AccessEvent) error
+	
+	// RecentAccessEvents returns up to limit of shortCode's stored events, newest first
+	RecentAccessEvents(shortCode string, limit int) ([]models.AccessEvent, error)
+	
+	// RecordVisitor adds visitor (e.g. the client IP) to shortCode's
+	// HyperLogLog of distinct visitors
+	RecordVisitor(shortCode, visitor string) error
+	
+	// UniqueVisitors returns the approximate number of distinct visitors
+	// recorded for shortCode, accurate to within a few percent
+	UniqueVisitors(shortCode string) (int64, error)
+	
+	// Search returns up to limit mappings, expired ones included, whose long
+	// URL contains query (case-insensitive), ordered by short code and
+	// starting after the code given in after ("" for the first page). It is
+	// an O(N) scan meant for admin tooling.
+	Search(query, after string, limit int) ([]*models.URLMapping, error)
+	
+	// Each calls fn once for every stored mapping, expired ones included, in
+	// no particular order. Mappings are read in batches so memory stays flat
+	// regardless of the store size; an error returned by fn stops the walk
+	// and is returned as is.
+	Each(fn func(*models.URLMapping) error) error
+	
+	// ExpiringBetween returns the mappings whose expiration date falls in
+	// [start, end), ordered by expiration date then short code, e.g. to
+	// remind owners before their links expire
+	ExpiringBetween(start, end time.Time) ([]*models.URLMapping, error)
+	
+	// SetCounterFloor raises the ID counter to v if it is below it, e.g. after
+	// an import left mappings with higher IDs than the counter. The counter
+	// is never lowered, since that would reuse codes. It returns
+	// ErrCounterOutOfRange if v is past the highest allocatable ID.
+	SetCounterFloor(v uint64) error
+	
+	// CreateCampaign stores a new campaign. It returns ErrCampaignExists if
+	// the ID is already in use.
+	CreateCampaign(campaign *models.Campaign) error
+	
+	// GetCampaign returns the campaign with the given ID, or ErrNotFound
+	GetCampaign(id string) (*models.Campaign, error)
+	
+	// CampaignURLs returns the mappings created in a campaign, expired ones
+	// included, ordered by short code. It returns ErrNotFound if the
+	// campaign doesn't exist.
+	CampaignURLs(id string) ([]*models.URLMapping, error)
+	
+	// CountByDomain returns how many stored long URLs, expired ones
+	// included, target each host (lowercased, without port), e.g. to spot
+	// one domain dominating the keyspace
+	CountByDomain() (map[string]int, error)
+	
+	// Verify scans every mapping and returns a description of each
+	// inconsistency found (e.g. a generated code that doesn't match its ID,
+	// an invalid long URL, a duplicate ID), or an empty list. It is
+	// read-only and meant for checks after imports or migrations.
+	Verify() ([]string, error)
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,81 +10,891 @@ import (
 	"tiny-url-service/utils"
 )
 
+// shardCount is the number of independently locked partitions of the URL map.
+// Spreading codes across shards keeps read-heavy redirect traffic from
+// contending on a single lock.
+const shardCount = 256
+
+// shard is one lock-protected partition of the URL map
+type shard struct {
+	mu     sync.RWMutex
+	urls   map[string]*models.URLMapping // shortCode -> URLMapping
+	clicks map[string]*clickRing         // shortCode -> hourly click buckets
+	labels map[string]map[string]int64   // shortCode -> label -> clicks
+	events map[string][]models.AccessEvent // shortCode -> latest access events, oldest first
+	visitors map[string]*hyperLogLog       // shortCode -> distinct visitors
+}
+
+// clickRing holds one slot per hour of the click retention window. A slot is
+// reused once its hour falls out of the window, so memory per link is bounded.
+type clickRing struct {
+	hours  []int64 // Unix hour each slot currently counts
+	counts []int64
+}
+
+// add counts one click in the slot for the given Unix hour
+func (r *clickRing) add(hour int64) {
+	slot := hour % int64(len(r.hours))
+	if r.hours[slot] != hour {
+		r.hours[slot] = hour
+		r.counts[slot] = 0
+	}
+	r.counts[slot]++
+}
+
+// count returns the clicks recorded for the given Unix hour
+func (r *clickRing) count(hour int64) int64 {
+	slot := hour % int64(len(r.hours))
+	if r.hours[slot] != hour {
+		return 0
+	}
+	return r.counts[slot]
+}
+
 // MemoryStorage implements the Storage interface using in-memory maps
 type MemoryStorage struct {
-	mu       sync.RWMutex                 // Protects the maps
-	urls     map[string]*models.URLMapping // shortCode -> URLMapping
-	counter  uint64                       // Atomic counter for unique IDs
-	baseURL  string                       // Base URL for generating short URLs
+	shards   [shardCount]*shard // Short codes partitioned by hash
+	size     int64              // Atomic count of stored URLs across all shards
+	counter  uint64             // Atomic counter for unique IDs
+	baseURL  string             // Base URL for generating short URLs
+	opts     options            // Optional behavior
+
+	resMu         sync.Mutex              // Protects reservations and reservedCodes
+	reservations  map[string]*reservation // token -> reserved code
+	reservedCodes map[string]string       // reserved code -> token
+	
+	revMu   sync.RWMutex      // Protects reverse and ownerReverse
+	reverse map[string]string // long URL hash -> short code
+	ownerReverse map[string]string // (owner, long URL) hash -> short code
+	
+	campMu    sync.RWMutex                // Protects campaigns
+	campaigns map[string]*models.Campaign // Campaign ID -> campaign
+	
+	wal *memoryWAL // Operation log; nil unless opened with OpenMemoryStorage
+}
+
+// reservation is a short code held for a client until claimed or expired
+type reservation struct {
+	id        uint64
+	code      string
+	expiresAt time.Time
 }
 
 // NewMemoryStorage creates a new in-memory storage instance
-func NewMemoryStorage(baseURL string) *MemoryStorage {
-	return &MemoryStorage{
-		urls:    make(map[string]*models.URLMapping),
-		counter: 0,
-		baseURL: baseURL,
+func NewMemoryStorage(baseURL string, opts ...Option) *MemoryStorage {
+	m := &MemoryStorage{
+		counter:      0,
+		baseURL:      baseURL,
+		opts:         newOptions(opts),
+		reservations:  make(map[string]*reservation),
+		reservedCodes: make(map[string]string),
+		reverse:       make(map[string]string),
+		ownerReverse:  make(map[string]string),
+		campaigns:     make(map[string]*models.Campaign),
+	}
+	for i := range m.shards {
+		m.shards[i] = &shard{
+			urls:   make(map[string]*models.URLMapping),
+			clicks: make(map[string]*clickRing),
+			labels: make(map[string]map[string]int64),
+			events: make(map[string][]models.AccessEvent),
+			visitors: make(map[string]*hyperLogLog),
+		}
+	}
+	return m
+}
+
+// shardFor returns the shard owning shortCode using an inline FNV-1a hash
+func (m *MemoryStorage) shardFor(shortCode string) *shard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(shortCode); i++ {
+		hash ^= uint32(shortCode[i])
+		hash *= 16777619
+	}
+	return m.shards[hash%shardCount]
+}
+
+// acquireSlot counts room for one more mapping against the size. When the
+// store is at capacity, expired mappings are reclaimed once before giving up.
+func (m *MemoryStorage) acquireSlot() error {
+	if m.opts.maxURLs <= 0 {
+		atomic.AddInt64(&m.size, 1)
+		return nil
+	}
+	
+	for attempt := 0; attempt < 2; attempt++ {
+		for {
+			current := atomic.LoadInt64(&m.size)
+			if current >= m.opts.maxURLs {
+				break
+			}
+			if atomic.CompareAndSwapInt64(&m.size, current, current+1) {
+				return nil
+			}
+		}
+		if attempt == 0 {
+			if _, err := m.purgeExpired(); err != nil {
+				return err
+			}
+		}
+	}
+	return ErrCapacityExceeded
+}
+
+// nextID advances the counter, refusing to pass the configured maximum ID
+// so it never wraps to 0 and hands out codes that are already in use, or an
+// ID whose code would be longer than the maximum generated code length
+func (m *MemoryStorage) nextID() (uint64, error) {
+	limit := m.opts.idLimit()
+	for {
+		current := atomic.LoadUint64(&m.counter)
+		if current >= limit {
+			return 0, fmt.Errorf("%w: counter at %d", ErrIDSpaceExhausted, current)
+		}
+		if err := m.opts.checkCodeLength(current + 1); err != nil {
+			return 0, err
+		}
+		if atomic.CompareAndSwapUint64(&m.counter, current, current+1) {
+			return current + 1, nil
+		}
+	}
+}
+
+// put inserts or replaces a mapping whose slot was taken with acquireSlot
+func (m *MemoryStorage) put(mapping *models.URLMapping) error {
+	sh := m.shardFor(mapping.ShortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	if err := m.logCreate(mapping); err != nil {
+		return err
+	}
+	if _, exists := sh.urls[mapping.ShortCode]; exists {
+		// Replacing an existing code does not grow the store
+		atomic.AddInt64(&m.size, -1)
+	}
+	sh.urls[mapping.ShortCode] = mapping
+	return nil
+}
+
+// putIfAbsent inserts mapping unless its code is already stored. The check,
+// log append and insert happen under the shard's write lock, so concurrent
+// creates of the same code cannot both succeed and the log keeps their order.
+func (m *MemoryStorage) putIfAbsent(mapping *models.URLMapping) (bool, error) {
+	sh := m.shardFor(mapping.ShortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	if _, exists := sh.urls[mapping.ShortCode]; exists {
+		return false, nil
+	}
+	if err := m.logCreate(mapping); err != nil {
+		return false, err
+	}
+	sh.urls[mapping.ShortCode] = mapping
+	return true, nil
+}
+
+// purgeExpired removes expired mappings, their click data and their reverse
+// index entries from every shard and returns how many were removed. Each
+// removal is logged like a Delete, so purged links don't come back when the
+// log is replayed.
+func (m *MemoryStorage) purgeExpired() (int, error) {
+	purged := 0
+	for _, sh := range m.shards {
+		removed, err := m.purgeShard(sh)
+		// As in Delete, the reverse index is updated outside the shard lock
+		for _, mapping := range removed {
+			m.unindexLongURL(mapping)
+		}
+		purged += len(removed)
+		if err != nil {
+			return purged, err
+		}
 	}
+	return purged, nil
+}
+
+// purgeShard removes the expired mappings of one shard and returns them
+func (m *MemoryStorage) purgeShard(sh *shard) ([]*models.URLMapping, error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	var removed []*models.URLMapping
+	for code, mapping := range sh.urls {
+		if !m.IsExpired(mapping) {
+			continue
+		}
+		if err := m.logDelete(code); err != nil {
+			return removed, err
+		}
+		delete(sh.urls, code)
+		delete(sh.clicks, code)
+		delete(sh.labels, code)
+		delete(sh.events, code)
+		delete(sh.visitors, code)
+		atomic.AddInt64(&m.size, -1)
+		removed = append(removed, mapping)
+	}
+	return removed, nil
 }
 
 // Store saves a URL mapping and returns the generated short code
 func (m *MemoryStorage) Store(mapping *models.URLMapping) (string, error) {
-	// Generate unique ID
-	id := atomic.AddUint64(&m.counter, 1)
+	if err := m.acquireSlot(); err != nil {
+		return "", err
+	}
 	
-	// Generate short code using base62 encoding
-	shortCode := utils.EncodeBase62(id)
+	mapping.CreatedAt = time.Now()
+	for {
+		// Generate unique ID
+		id, err := m.nextID()
+		if err != nil {
+			atomic.AddInt64(&m.size, -1)
+			return "", err
+		}
+		
+		// Generate short code using base62 encoding
+		mapping.ID = id
+		mapping.ShortCode = NamespacedCode(mapping.Namespace, m.opts.encodeID(id))
+		
+		// Skip codes a case-folded custom code already answers to
+		if folded := m.opts.foldedCode(mapping.ShortCode); folded != "" {
+			if _, taken := m.lookup(folded); taken {
+				continue
+			}
+		}
+		
+		// Skip codes already taken by custom codes
+		stored, err := m.putIfAbsent(mapping)
+		if err != nil {
+			atomic.AddInt64(&m.size, -1)
+			return "", err
+		}
+		if stored {
+			m.indexLongURL(mapping)
+			return mapping.ShortCode, nil
+		}
+	}
+}
+
+// StoreWithCode saves mapping under a caller-chosen code. It fails with
+// ErrCodeTaken if the code is stored or currently reserved.
+func (m *MemoryStorage) StoreWithCode(mapping *models.URLMapping, shortCode string) error {
+	if err := m.opts.checkReservedWord(shortCode); err != nil {
+		return err
+	}
+	key := shortCode
+	if folded := m.opts.foldedCode(shortCode); folded != "" {
+		// The typed casing may itself be a generated code
+		if _, exists := m.lookup(shortCode); exists {
+			return fmt.Errorf("%w: %s", ErrCodeTaken, shortCode)
+		}
+		key = folded
+		mapping.DisplayCode = shortCode
+	}
+	if err := m.acquireSlot(); err != nil {
+		return err
+	}
 	
-	// Complete the mapping
-	mapping.ID = id
-	mapping.ShortCode = shortCode
+	mapping.ShortCode = key
 	mapping.CreatedAt = time.Now()
 	
-	// Store with write lock
-	m.mu.Lock()
-	m.urls[shortCode] = mapping
-	m.mu.Unlock()
+	// Holding resMu keeps the code from being reserved between check and insert
+	m.resMu.Lock()
+	m.purgeExpiredReservations()
+	_, reserved := m.reservedCodes[key]
+	stored := false
+	var err error
+	if !reserved {
+		stored, err = m.putIfAbsent(mapping)
+	}
+	m.resMu.Unlock()
 	
-	return shortCode, nil
+	if err != nil {
+		atomic.AddInt64(&m.size, -1)
+		return err
+	}
+	if !stored {
+		atomic.AddInt64(&m.size, -1)
+		return fmt.Errorf("%w: %s", ErrCodeTaken, shortCode)
+	}
+	m.indexLongURL(mapping)
+	return nil
 }
 
-// Get retrieves the URL mapping for a given short code
-func (m *MemoryStorage) Get(shortCode string) (*models.URLMapping, error) {
-	m.mu.RLock()
-	mapping, exists := m.urls[shortCode]
-	m.mu.RUnlock()
+// Exists reports whether shortCode is stored, including expired mappings
+// that still occupy the code
+func (m *MemoryStorage) Exists(shortCode string) (bool, error) {
+	exists, err := m.ExistsBatch([]string{shortCode})
+	return exists[shortCode], err
+}
+
+// ExistsBatch checks many codes, taking each shard's lock once
+func (m *MemoryStorage) ExistsBatch(codes []string) (map[string]bool, error) {
+	found := m.lookupMany(codes)
+	result := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		result[code] = found[code] != nil
+	}
+	return result, nil
+}
+
+// lookup copies the mapping stored under exactly code
+func (m *MemoryStorage) lookup(code string) (*models.URLMapping, bool) {
+	sh := m.shardFor(code)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
 	
+	stored, exists := sh.urls[code]
 	if !exists {
-		return nil, fmt.Errorf("short code not found: %s", shortCode)
+		return nil, false
+	}
+	// Copy under the lock so callers never race with in-place updates such as ConsumeUse
+	mapping := *stored
+	return &mapping, true
+}
+
+// lookupMany copies the mappings for codes, taking each shard's lock once.
+// A code missing exactly falls back to its case-folded key when enabled.
+func (m *MemoryStorage) lookupMany(codes []string) map[string]*models.URLMapping {
+	byShard := make(map[*shard][]string)
+	for _, code := range codes {
+		sh := m.shardFor(code)
+		byShard[sh] = append(byShard[sh], code)
+		if folded := m.opts.foldedCode(code); folded != "" {
+			sh = m.shardFor(folded)
+			byShard[sh] = append(byShard[sh], folded)
+		}
+	}
+	
+	stored := make(map[string]*models.URLMapping, len(codes))
+	for sh, shardCodes := range byShard {
+		sh.mu.RLock()
+		for _, code := range shardCodes {
+			if mapping, exists := sh.urls[code]; exists {
+				copied := *mapping
+				stored[code] = &copied
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	
+	result := make(map[string]*models.URLMapping, len(codes))
+	for _, code := range codes {
+		if mapping := stored[code]; mapping != nil {
+			result[code] = mapping
+		} else if folded := m.opts.foldedCode(code); stored[folded] != nil {
+			result[code] = stored[folded]
+		}
+	}
+	return result
+}
+
+// Get retrieves the URL mapping for a given short code
+func (m *MemoryStorage) Get(shortCode string) (*models.URLMapping, error) {
+	mapping, err := m.opts.checkedGet(shortCode, m.GetRaw)
+	if err != nil {
+		return nil, err
 	}
 	
 	// Check if expired
 	if m.IsExpired(mapping) {
-		return nil, fmt.Errorf("URL has expired: %s", shortCode)
+		return nil, fmt.Errorf("%w: %s", ErrExpired, shortCode)
+	}
+	
+	return mapping, nil
+}
+
+// GetRaw retrieves the URL mapping without enforcing expiration (admin use only)
+func (m *MemoryStorage) GetRaw(shortCode string) (*models.URLMapping, error) {
+	if mapping, exists := m.lookup(shortCode); exists {
+		return mapping, nil
+	}
+	if folded := m.opts.foldedCode(shortCode); folded != "" {
+		if mapping, exists := m.lookup(folded); exists {
+			return mapping, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+}
+
+// GetBatch copies the requested mappings, taking each shard's lock once
+func (m *MemoryStorage) GetBatch(codes []string) (map[string]*models.URLMapping, error) {
+	return m.lookupMany(codes), nil
+}
+
+// Delete removes a mapping along with its click data
+func (m *MemoryStorage) Delete(shortCode string) error {
+	sh := m.shardFor(shortCode)
+	sh.mu.Lock()
+	mapping, exists := sh.urls[shortCode]
+	if !exists {
+		sh.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	if err := m.logDelete(shortCode); err != nil {
+		sh.mu.Unlock()
+		return err
+	}
+	delete(sh.urls, shortCode)
+	delete(sh.clicks, shortCode)
+	delete(sh.labels, shortCode)
+	delete(sh.events, shortCode)
+	delete(sh.visitors, shortCode)
+	atomic.AddInt64(&m.size, -1)
+	sh.mu.Unlock()
+	
+	m.unindexLongURL(mapping)
+	return nil
+}
+
+// indexLongURL points the reverse index entries for mapping's long URL at it
+func (m *MemoryStorage) indexLongURL(mapping *models.URLMapping) {
+	key := m.opts.reverseKey(mapping.LongURL)
+	if key == "" || !reverseIndexable(mapping) {
+		return
+	}
+	m.revMu.Lock()
+	m.reverse[key] = mapping.ShortCode
+	if ownerKey := m.opts.ownerReverseKey(mapping.Owner, mapping.LongURL); ownerKey != "" {
+		m.ownerReverse[ownerKey] = mapping.ShortCode
+	}
+	m.revMu.Unlock()
+}
+
+// unindexLongURL drops the reverse index entries that still point at mapping
+func (m *MemoryStorage) unindexLongURL(mapping *models.URLMapping) {
+	key := m.opts.reverseKey(mapping.LongURL)
+	if key == "" {
+		return
+	}
+	m.revMu.Lock()
+	if m.reverse[key] == mapping.ShortCode {
+		delete(m.reverse, key)
+	}
+	ownerKey := m.opts.ownerReverseKey(mapping.Owner, mapping.LongURL)
+	if ownerKey != "" && m.ownerReverse[ownerKey] == mapping.ShortCode {
+		delete(m.ownerReverse, ownerKey)
+	}
+	m.revMu.Unlock()
+}
+
+// FindByLongURL looks longURL up in the reverse index
+func (m *MemoryStorage) FindByLongURL(longURL string) (*models.URLMapping, error) {
+	key := m.opts.reverseKey(longURL)
+	if key == "" {
+		return nil, ErrNotFound
+	}
+	
+	m.revMu.RLock()
+	code, ok := m.reverse[key]
+	m.revMu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	
+	mapping, err := m.Get(code)
+	if err != nil || !verifyReverseHit(mapping, longURL) {
+		return nil, ErrNotFound
+	}
+	return mapping, nil
+}
+
+// FindByOwnerLongURL looks longURL up in owner's part of the reverse index
+func (m *MemoryStorage) FindByOwnerLongURL(owner, longURL string) (*models.URLMapping, error) {
+	key := m.opts.ownerReverseKey(owner, longURL)
+	if key == "" {
+		return nil, ErrNotFound
+	}
+	
+	m.revMu.RLock()
+	code, ok := m.ownerReverse[key]
+	m.revMu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
 	}
 	
+	mapping, err := m.Get(code)
+	if err != nil || mapping.Owner != owner || !verifyReverseHit(mapping, longURL) {
+		return nil, ErrNotFound
+	}
 	return mapping, nil
 }
 
 // IsExpired checks if a URL mapping has expired
 func (m *MemoryStorage) IsExpired(mapping *models.URLMapping) bool {
-	if mapping.ExpirationDate == nil {
-		return false // No expiration set
-	}
-	return time.Now().After(*mapping.ExpirationDate)
+	return m.opts.isExpired(mapping, time.Now())
+}
+
+// ExpiresAt returns when mapping counts as expired
+func (m *MemoryStorage) ExpiresAt(mapping *models.URLMapping) *time.Time {
+	return m.opts.expiresAt(mapping)
 }
 
 // GetStats returns storage statistics
 func (m *MemoryStorage) GetStats() map[string]interface{} {
-	m.mu.RLock()
-	totalUrls := len(m.urls)
-	m.mu.RUnlock()
+	totalUrls := int(atomic.LoadInt64(&m.size))
 	
 	currentCounter := atomic.LoadUint64(&m.counter)
 	
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"total_urls":      totalUrls,
 		"current_counter": currentCounter,
 		"storage_type":    "memory",
+		"max_urls":        m.opts.maxURLs,
+	}
+	if m.opts.sizeStats {
+		stats["url_size"] = m.sizeStats()
+	}
+	return stats
+}
+
+// sizeStats computes long-URL length statistics by walking every shard
+func (m *MemoryStorage) sizeStats() map[string]interface{} {
+	hist := make([]int64, len(sizeBounds)+1)
+	var lengths []int
+	var totalBytes int64
+	
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+		for _, mapping := range sh.urls {
+			n := len(mapping.LongURL)
+			lengths = append(lengths, n)
+			hist[sizeBucket(n)]++
+			totalBytes += int64(n)
+		}
+		sh.mu.RUnlock()
+	}
+	
+	return sizeSummary(hist, totalBytes, exactP95(lengths))
+}
+
+// Reserve allocates the next short code and holds it until claimed or expired
+func (m *MemoryStorage) Reserve() (string, string, error) {
+	token, err := utils.GenerateToken(16)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate reservation token: %w", err)
+	}
+	
+	m.resMu.Lock()
+	defer m.resMu.Unlock()
+	m.purgeExpiredReservations()
+	
+	// Skip codes already taken by custom codes
+	var id uint64
+	var code string
+	for {
+		if id, err = m.nextID(); err != nil {
+			return "", "", err
+		}
+		code = m.opts.encodeID(id)
+		if exists, _ := m.Exists(code); !exists {
+			break
+		}
+	}
+	
+	m.reservations[token] = &reservation{
+		id:        id,
+		code:      code,
+		expiresAt: time.Now().Add(m.opts.reservationTTL),
+	}
+	m.reservedCodes[code] = token
+	
+	return code, token, nil
+}
+
+// ClaimReservation stores a link to longURL under the code held by token
+func (m *MemoryStorage) ClaimReservation(token, longURL string) error {
+	return m.ClaimReservationMapping(token, &models.URLMapping{LongURL: longURL})
+}
+
+// ClaimReservationMapping stores the mapping under the code held by token
+func (m *MemoryStorage) ClaimReservationMapping(token string, mapping *models.URLMapping) error {
+	// Take the slot first so a full store leaves the reservation claimable
+	if err := m.acquireSlot(); err != nil {
+		return err
+	}
+	
+	m.resMu.Lock()
+	m.purgeExpiredReservations()
+	res, exists := m.reservations[token]
+	if exists {
+		delete(m.reservations, token)
+		delete(m.reservedCodes, res.code)
+	}
+	m.resMu.Unlock()
+	
+	if !exists {
+		atomic.AddInt64(&m.size, -1)
+		return ErrReservationNotFound
+	}
+	
+	mapping.ID = res.id
+	mapping.ShortCode = res.code
+	mapping.CreatedAt = time.Now()
+	if err := m.put(mapping); err != nil {
+		atomic.AddInt64(&m.size, -1)
+		return err
+	}
+	m.indexLongURL(mapping)
+	
+	return nil
+}
+
+// ConsumeUse atomically records one use of a use-limited link
+func (m *MemoryStorage) ConsumeUse(shortCode string) (int, error) {
+	sh := m.shardFor(shortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	mapping, exists := sh.urls[shortCode]
+	if !exists {
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	if mapping.MaxUses <= 0 {
+		return -1, nil
+	}
+	if mapping.UseCount >= mapping.MaxUses {
+		return 0, ErrUsesExhausted
+	}
+	
+	// Log before applying, so a crash can't hand out a one-time link twice
+	updated := *mapping
+	updated.UseCount++
+	if err := m.logUpdate(&updated); err != nil {
+		return 0, err
+	}
+	mapping.UseCount++
+	return mapping.MaxUses - mapping.UseCount, nil
+}
+
+// RecordAccess counts one redirect of shortCode in its total and hourly ring
+func (m *MemoryStorage) RecordAccess(shortCode string, at time.Time) error {
+	sh := m.shardFor(shortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	mapping, exists := sh.urls[shortCode]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	updated := *mapping
+	updated.AccessCount++
+	if err := m.logUpdate(&updated); err != nil {
+		return err
 	}
-} 
\ No newline at end of file
+	mapping.AccessCount++
+	
+	ring, exists := sh.clicks[shortCode]
+	if !exists {
+		slots := int(m.opts.clickRetention / time.Hour)
+		ring = &clickRing{
+			hours:  make([]int64, slots),
+			counts: make([]int64, slots),
+		}
+		sh.clicks[shortCode] = ring
+	}
+	ring.add(at.Unix() / 3600)
+	
+	return nil
+}
+
+// ResetAccessCount zeroes the stored access count under the shard lock
+func (m *MemoryStorage) ResetAccessCount(shortCode string) error {
+	sh := m.shardFor(shortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	mapping, exists := sh.urls[shortCode]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	updated := *mapping
+	updated.AccessCount = 0
+	if err := m.logUpdate(&updated); err != nil {
+		return err
+	}
+	mapping.AccessCount = 0
+	
+	return nil
+}
+
+// AccessSeries returns click counts for shortCode grouped into buckets
+func (m *MemoryStorage) AccessSeries(shortCode string, bucket time.Duration, since time.Time) ([]models.BucketCount, error) {
+	sh := m.shardFor(shortCode)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	
+	if _, exists := sh.urls[shortCode]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	ring := sh.clicks[shortCode]
+	
+	return buildSeries(bucket, since, time.Now(), m.opts.clickRetention, func(hours []time.Time) ([]int64, error) {
+		counts := make([]int64, len(hours))
+		if ring == nil {
+			return counts, nil
+		}
+		for i, h := range hours {
+			counts[i] = ring.count(h.Unix() / 3600)
+		}
+		return counts, nil
+	})
+}
+
+// RecordLabeledClick counts one redirect of shortCode under label
+func (m *MemoryStorage) RecordLabeledClick(shortCode, label string) error {
+	sh := m.shardFor(shortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	if _, exists := sh.urls[shortCode]; !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	
+	counts, exists := sh.labels[shortCode]
+	if !exists {
+		counts = make(map[string]int64)
+		sh.labels[shortCode] = counts
+	}
+	counts[label]++
+	
+	return nil
+}
+
+// LabeledClicks returns a copy of the per-label redirect counts for shortCode
+func (m *MemoryStorage) LabeledClicks(shortCode string) (map[string]int64, error) {
+	sh := m.shardFor(shortCode)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	
+	if _, exists := sh.urls[shortCode]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	
+	counts := make(map[string]int64, len(sh.labels[shortCode]))
+	for label, n := range sh.labels[shortCode] {
+		counts[label] = n
+	}
+	return counts, nil
+}
+
+// RecordAccessEvent appends event to shortCode's events, dropping the oldest
+// past MaxAccessEvents
+func (m *MemoryStorage) RecordAccessEvent(shortCode string, event models.AccessEvent) error {
+	sh := m.shardFor(shortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	
+	if _, exists := sh.urls[shortCode]; !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	
+	events := append(sh.events[shortCode], event)
+	if len(events) > MaxAccessEvents {
+		events = events[len(events)-MaxAccessEvents:]
+	}
+	sh.events[shortCode] = events
+	return nil
+}
+
+// RecentAccessEvents returns a copy of shortCode's latest events, newest first
+func (m *MemoryStorage) RecentAccessEvents(shortCode string, limit int) ([]models.AccessEvent, error) {
+	sh := m.shardFor(shortCode)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	
+	if _, exists := sh.urls[shortCode]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	
+	stored := sh.events[shortCode]
+	if limit < 0 {
+		limit = 0
+	}
+	if limit > len(stored) {
+		limit = len(stored)
+	}
+	events := make([]models.AccessEvent, 0, limit)
+	for i := len(stored) - 1; i >= 0 && len(events) < limit; i-- {
+		events = append(events, stored[i])
+	}
+	return events, nil
+}
+
+// purgeExpiredReservations releases reservations past their TTL.
+// Callers must hold resMu.
+func (m *MemoryStorage) purgeExpiredReservations() {
+	now := time.Now()
+	for token, res := range m.reservations {
+		if now.After(res.expiresAt) {
+			delete(m.reservations, token)
+			delete(m.reservedCodes, res.code)
+		}
+	}
+}
+
+// Search scans every shard for long URLs containing query
+func (m *MemoryStorage) Search(query, after string, limit int) ([]*models.URLMapping, error) {
+	query = strings.ToLower(query)
+	
+	var matches []*models.URLMapping
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+		for code, stored := range sh.urls {
+			if code > after && matchesQuery(stored.LongURL, query) {
+				mapping := *stored
+				matches = append(matches, &mapping)
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	
+	return searchPage(matches, after, limit), nil
+}
+
+// Each copies one shard at a time under its read lock and calls fn outside
+// it, so a slow consumer never blocks writers
+func (m *MemoryStorage) Each(fn func(*models.URLMapping) error) error {
+	var batch []models.URLMapping
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+		batch = batch[:0]
+		for _, stored := range sh.urls {
+			batch = append(batch, *stored)
+		}
+		sh.mu.RUnlock()
+		
+		for i := range batch {
+			mapping := batch[i]
+			if err := fn(&mapping); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExpiringBetween scans every shard for expiration dates within the window
+func (m *MemoryStorage) ExpiringBetween(start, end time.Time) ([]*models.URLMapping, error) {
+	var matches []*models.URLMapping
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+		for _, stored := range sh.urls {
+			if exp := stored.ExpirationDate; exp != nil && !exp.Before(start) && exp.Before(end) {
+				mapping := *stored
+				matches = append(matches, &mapping)
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	
+	sortByExpiration(matches)
+	return matches, nil
+}
+
+// Verify checks every mapping for consistency without modifying anything
+func (m *MemoryStorage) Verify() ([]string, error) {
+	return verifyMappings(m.Each, m.opts.encodeID)
+}
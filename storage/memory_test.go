@@ -1,7 +1,14 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sync"
+	"strings"
 	"testing"
 	"time"
 	"tiny-url-service/models"
@@ -177,6 +184,283 @@ func TestMemoryStorage_Expiration(t *testing.T) {
 	}
 }
 
+func TestMemoryStorage_GetRaw(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	pastTime := time.Now().Add(-time.Hour)
+	shortCode, err := store.Store(&models.URLMapping{
+		LongURL:        "https://www.example.com/past",
+		ExpirationDate: &pastTime,
+	})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	if _, err := store.Get(shortCode); !errors.Is(err, ErrExpired) {
+		t.Errorf("Get() should return ErrExpired, got %v", err)
+	}
+
+	mapping, err := store.GetRaw(shortCode)
+	if err != nil {
+		t.Fatalf("GetRaw() failed for expired URL: %v", err)
+	}
+	if mapping.LongURL != "https://www.example.com/past" {
+		t.Errorf("Expected long URL to round-trip, got %s", mapping.LongURL)
+	}
+
+	if _, err := store.GetRaw("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRaw() should return ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStorage_Search(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	pastTime := time.Now().Add(-time.Hour)
+	for _, m := range []struct {
+		code    string
+		longURL string
+		expires *time.Time
+	}{
+		{"c", "https://Example.com/c", nil},
+		{"a", "https://example.com/a", nil},
+		{"b", "https://example.com/b", &pastTime},
+		{"z", "https://other.org/z", nil},
+	} {
+		if err := store.StoreWithCode(&models.URLMapping{LongURL: m.longURL, ExpirationDate: m.expires}, m.code); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+
+	page, err := store.Search("EXAMPLE.com", "", 2)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(page) != 2 || page[0].ShortCode != "a" || page[1].ShortCode != "b" {
+		t.Fatalf("Expected first page [a b], got %v", searchCodes(page))
+	}
+
+	page, err = store.Search("example.com", "b", 2)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(page) != 1 || page[0].ShortCode != "c" {
+		t.Errorf("Expected second page [c], got %v", searchCodes(page))
+	}
+}
+
+func TestMemoryStorage_Each(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	pastTime := time.Now().Add(-time.Hour)
+	for _, code := range []string{"a", "b", "c"} {
+		mapping := &models.URLMapping{LongURL: "https://example.com/" + code}
+		if code == "b" {
+			mapping.ExpirationDate = &pastTime // Expired mappings are still visited
+		}
+		if err := store.StoreWithCode(mapping, code); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	err := store.Each(func(mapping *models.URLMapping) error {
+		seen[mapping.ShortCode] = true
+		mapping.LongURL = "mutated" // Callers get copies
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each() failed: %v", err)
+	}
+	if len(seen) != 3 || !seen["a"] || !seen["b"] || !seen["c"] {
+		t.Errorf("Expected to visit a, b and c, got %v", seen)
+	}
+	if mapping, _ := store.GetRaw("a"); mapping.LongURL != "https://example.com/a" {
+		t.Errorf("Each() callback modified the stored mapping: %s", mapping.LongURL)
+	}
+
+	stop := errors.New("stop")
+	visits := 0
+	err = store.Each(func(*models.URLMapping) error {
+		visits++
+		return stop
+	})
+	if err != stop || visits != 1 {
+		t.Errorf("Expected Each to stop with the callback error after one visit, got %v after %d", err, visits)
+	}
+}
+
+// searchCodes lists the short codes of search results for error messages
+func searchCodes(mappings []*models.URLMapping) []string {
+	codes := make([]string, len(mappings))
+	for i, m := range mappings {
+		codes[i] = m.ShortCode
+	}
+	return codes
+}
+
+func TestMemoryStorage_ExistsBatch(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	pastTime := time.Now().Add(-time.Hour)
+	for _, code := range []string{"alpha", "beta", "gamma"} {
+		mapping := &models.URLMapping{LongURL: "https://www.example.com/" + code}
+		if code == "gamma" {
+			mapping.ExpirationDate = &pastTime // Expired codes still count as taken
+		}
+		if err := store.StoreWithCode(mapping, code); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+
+	codes := []string{"alpha", "missing", "gamma", "beta", "nope", "alpha"}
+	exists, err := store.ExistsBatch(codes)
+	if err != nil {
+		t.Fatalf("ExistsBatch() failed: %v", err)
+	}
+
+	expected := map[string]bool{"alpha": true, "beta": true, "gamma": true, "missing": false, "nope": false}
+	if len(exists) != len(expected) {
+		t.Errorf("Expected %d entries, got %d: %v", len(expected), len(exists), exists)
+	}
+	for code, want := range expected {
+		if got, ok := exists[code]; !ok || got != want {
+			t.Errorf("ExistsBatch()[%q] = %v (present %v); expected %v", code, got, ok, want)
+		}
+	}
+
+	if empty, err := store.ExistsBatch(nil); err != nil || len(empty) != 0 {
+		t.Errorf("ExistsBatch(nil) = %v, %v; expected an empty map", empty, err)
+	}
+}
+
+func TestMemoryStorage_GetBatch(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	pastTime := time.Now().Add(-time.Hour)
+	for _, code := range []string{"alpha", "beta"} {
+		mapping := &models.URLMapping{LongURL: "https://www.example.com/" + code}
+		if code == "beta" {
+			mapping.ExpirationDate = &pastTime // Expired mappings are still returned
+		}
+		if err := store.StoreWithCode(mapping, code); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+	store.RecordAccess("alpha", time.Now())
+
+	mappings, err := store.GetBatch([]string{"alpha", "missing", "beta", "alpha"})
+	if err != nil {
+		t.Fatalf("GetBatch() failed: %v", err)
+	}
+	if len(mappings) != 2 {
+		t.Fatalf("Expected 2 mappings, got %d: %v", len(mappings), mappings)
+	}
+	if m := mappings["alpha"]; m == nil || m.ShortCode != "alpha" || m.LongURL != "https://www.example.com/alpha" || m.AccessCount != 1 {
+		t.Errorf("Unexpected mapping for alpha: %+v", m)
+	}
+	if m := mappings["beta"]; m == nil || !store.IsExpired(m) {
+		t.Errorf("Expected the expired beta mapping, got %+v", m)
+	}
+
+	if empty, err := store.GetBatch(nil); err != nil || len(empty) != 0 {
+		t.Errorf("GetBatch(nil) = %v, %v; expected an empty map", empty, err)
+	}
+}
+
+func TestMemoryStorage_ReverseIndex(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithReverseIndex(SHA256Truncated(16)))
+
+	shortCode, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/page"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	futureTime := time.Now().Add(time.Hour)
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/expiring", ExpirationDate: &futureTime}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// Lookups match on the normalized URL
+	found, err := store.FindByLongURL("HTTPS://WWW.example.com:443/page")
+	if err != nil {
+		t.Fatalf("FindByLongURL() failed: %v", err)
+	}
+	if found.ShortCode != shortCode {
+		t.Errorf("Expected short code %s, got %s", shortCode, found.ShortCode)
+	}
+
+	// Conditional links are not indexed
+	if _, err := store.FindByLongURL("https://www.example.com/expiring"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an expiring link, got %v", err)
+	}
+
+	// Deleting the link removes it from the index
+	if err := store.Delete(shortCode); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.FindByLongURL("https://www.example.com/page"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	// A hash collision is a miss, not a wrong hit
+	colliding := NewMemoryStorage("http://localhost:8080", WithReverseIndex(func(string) string { return "same" }))
+	if _, err := colliding.Store(&models.URLMapping{LongURL: "https://www.example.com/first"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if _, err := colliding.FindByLongURL("https://www.example.com/second"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on a hash collision, got %v", err)
+	}
+}
+
+func TestMemoryStorage_PurgeUnindexes(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithReverseIndex(SHA256Truncated(16)))
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/page", Owner: "acme"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// Expire the indexed link in place, then purge it
+	past := time.Now().Add(-time.Hour)
+	store.shardFor(code).urls[code].ExpirationDate = &past
+	if purged, err := store.PurgeExpired(); err != nil || purged != 1 {
+		t.Fatalf("PurgeExpired() = %d, %v; expected 1", purged, err)
+	}
+
+	if len(store.reverse) != 0 || len(store.ownerReverse) != 0 {
+		t.Errorf("Expected purged link to leave no index entries, got %v and %v", store.reverse, store.ownerReverse)
+	}
+}
+
+func TestMemoryStorage_OwnerReverseIndex(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithReverseIndex(SHA256Truncated(16)))
+
+	acme, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/page", Owner: "acme"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	beta, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/page", Owner: "beta"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	for owner, expected := range map[string]string{"acme": acme, "beta": beta} {
+		found, err := store.FindByOwnerLongURL(owner, "https://WWW.example.com/page")
+		if err != nil {
+			t.Fatalf("FindByOwnerLongURL(%s) failed: %v", owner, err)
+		}
+		if found.ShortCode != expected {
+			t.Errorf("FindByOwnerLongURL(%s) = %s, expected %s", owner, found.ShortCode, expected)
+		}
+	}
+	if _, err := store.FindByOwnerLongURL("gamma", "https://www.example.com/page"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an owner without the URL, got %v", err)
+	}
+
+	if err := store.Delete(acme); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.FindByOwnerLongURL("acme", "https://www.example.com/page"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
 func TestMemoryStorage_GetStats(t *testing.T) {
 	store := NewMemoryStorage("http://localhost:8080")
 
@@ -273,4 +557,1189 @@ func TestMemoryStorage_ConcurrentAccess(t *testing.T) {
 		}
 		seenCodes[mapping.ShortCode] = true
 	}
-} 
\ No newline at end of file
+}
+
+func TestMemoryStorage_ReserveAndClaim(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	code, token, err := store.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+
+	// Reserved but unclaimed codes do not resolve
+	if _, err := store.Get(code); err == nil {
+		t.Error("Get() should fail for an unclaimed reservation")
+	}
+
+	if err := store.ClaimReservation(token, "https://www.example.com/reserved"); err != nil {
+		t.Fatalf("ClaimReservation() failed: %v", err)
+	}
+
+	retrieved, err := store.Get(code)
+	if err != nil {
+		t.Fatalf("Get() failed after claim: %v", err)
+	}
+	if retrieved.LongURL != "https://www.example.com/reserved" {
+		t.Errorf("Get() returned LongURL %s, expected https://www.example.com/reserved", retrieved.LongURL)
+	}
+
+	// Tokens are single-use
+	if err := store.ClaimReservation(token, "https://other.com"); err != ErrReservationNotFound {
+		t.Errorf("Second ClaimReservation() should return ErrReservationNotFound, got %v", err)
+	}
+
+	// A claim can carry the link's other settings
+	code, token, _ = store.Reserve()
+	mapping := &models.URLMapping{LongURL: "https://www.example.com/tagged", Tags: []string{"spring"}}
+	if err := store.ClaimReservationMapping(token, mapping); err != nil {
+		t.Fatalf("ClaimReservationMapping() failed: %v", err)
+	}
+	if mapping.ShortCode != code {
+		t.Errorf("ClaimReservationMapping() set ShortCode to %s, expected %s", mapping.ShortCode, code)
+	}
+	if retrieved, _ := store.Get(code); retrieved == nil || len(retrieved.Tags) != 1 {
+		t.Errorf("Expected the claimed link to keep its tags, got %+v", retrieved)
+	}
+}
+
+func TestMemoryStorage_ReservationExpires(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithReservationTTL(10*time.Millisecond))
+
+	_, token, err := store.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	err = store.ClaimReservation(token, "https://www.example.com")
+	if err != ErrReservationNotFound {
+		t.Errorf("Expired reservation should return ErrReservationNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStorage_ConsumeUse(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	limited := &models.URLMapping{LongURL: "https://www.example.com/once", MaxUses: 2}
+	code, err := store.Store(limited)
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	for expected := 1; expected >= 0; expected-- {
+		remaining, err := store.ConsumeUse(code)
+		if err != nil {
+			t.Fatalf("ConsumeUse() failed: %v", err)
+		}
+		if remaining != expected {
+			t.Errorf("ConsumeUse() remaining = %d, expected %d", remaining, expected)
+		}
+	}
+
+	if _, err := store.ConsumeUse(code); err != ErrUsesExhausted {
+		t.Errorf("ConsumeUse() past the limit should return ErrUsesExhausted, got %v", err)
+	}
+
+	unlimited := &models.URLMapping{LongURL: "https://www.example.com/forever"}
+	code, _ = store.Store(unlimited)
+	if remaining, err := store.ConsumeUse(code); err != nil || remaining != -1 {
+		t.Errorf("ConsumeUse() on unlimited link = (%d, %v), expected (-1, nil)", remaining, err)
+	}
+}
+
+func TestMemoryStorage_AccessSeries(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	now := time.Now()
+	for _, at := range []time.Time{now.Add(-2 * time.Hour), now, now} {
+		if err := store.RecordAccess(code, at); err != nil {
+			t.Fatalf("RecordAccess() failed: %v", err)
+		}
+	}
+
+	series, err := store.AccessSeries(code, time.Hour, now.Add(-3*time.Hour))
+	if err != nil {
+		t.Fatalf("AccessSeries() failed: %v", err)
+	}
+	if len(series) != 4 {
+		t.Fatalf("Expected 4 hourly buckets, got %d", len(series))
+	}
+	if series[1].Count != 1 || series[3].Count != 2 {
+		t.Errorf("Unexpected hourly counts: %+v", series)
+	}
+	if !series[3].Start.Equal(now.UTC().Truncate(time.Hour)) {
+		t.Errorf("Last bucket should start at the current hour, got %v", series[3].Start)
+	}
+
+	daily, err := store.AccessSeries(code, 24*time.Hour, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("AccessSeries() failed: %v", err)
+	}
+	var total int64
+	for _, b := range daily {
+		total += b.Count
+	}
+	if total < 2 {
+		t.Errorf("Daily series should include today's clicks, got %+v", daily)
+	}
+
+	mapping, _ := store.Get(code)
+	if mapping.AccessCount != 3 {
+		t.Errorf("Expected access count 3, got %d", mapping.AccessCount)
+	}
+
+	if _, err := store.AccessSeries(code, 90*time.Minute, now); err != ErrInvalidBucket {
+		t.Errorf("Expected ErrInvalidBucket, got %v", err)
+	}
+	if err := store.RecordAccess("missing", now); err == nil {
+		t.Error("RecordAccess() on a missing code should fail")
+	}
+}
+
+func TestMemoryStorage_AccessSeriesRingReuse(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithClickRetention(2*time.Hour))
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+
+	// Two hours ago shares a ring slot with now and must not leak into it
+	now := time.Now()
+	store.RecordAccess(code, now.Add(-2*time.Hour))
+	store.RecordAccess(code, now)
+
+	series, err := store.AccessSeries(code, time.Hour, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("AccessSeries() failed: %v", err)
+	}
+	if last := series[len(series)-1]; last.Count != 1 {
+		t.Errorf("Expected 1 click in the current hour, got %d", last.Count)
+	}
+}
+
+func TestMemoryStorage_LabeledClicks(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+
+	for _, label := range []string{"a", "b", "a"} {
+		if err := store.RecordLabeledClick(code, label); err != nil {
+			t.Fatalf("RecordLabeledClick() failed: %v", err)
+		}
+	}
+
+	counts, err := store.LabeledClicks(code)
+	if err != nil {
+		t.Fatalf("LabeledClicks() failed: %v", err)
+	}
+	if counts["a"] != 2 || counts["b"] != 1 {
+		t.Errorf("Unexpected labeled clicks: %v", counts)
+	}
+
+	if err := store.RecordLabeledClick("missing", "a"); err == nil {
+		t.Error("RecordLabeledClick() on a missing code should fail")
+	}
+}
+
+func TestMemoryStorage_UniqueVisitors(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+
+	for i := 0; i < 5; i++ {
+		if err := store.RecordVisitor(code, "203.0.113.7"); err != nil {
+			t.Fatalf("RecordVisitor() failed: %v", err)
+		}
+	}
+	if n, err := store.UniqueVisitors(code); err != nil || n != 1 {
+		t.Errorf("Expected 1 unique visitor after repeat visits, got %d (err %v)", n, err)
+	}
+
+	// The estimate stays within a few percent for larger counts
+	for i := 0; i < 10000; i++ {
+		store.RecordVisitor(code, fmt.Sprintf("10.%d.%d.%d", i>>16, i>>8&0xff, i&0xff))
+	}
+	n, err := store.UniqueVisitors(code)
+	if err != nil {
+		t.Fatalf("UniqueVisitors() failed: %v", err)
+	}
+	if n < 9500 || n > 10500 {
+		t.Errorf("Expected about 10001 unique visitors, got %d", n)
+	}
+
+	if err := store.RecordVisitor("missing", "203.0.113.7"); err == nil {
+		t.Error("RecordVisitor() on a missing code should fail")
+	}
+	if err := store.Delete(code); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	other, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com/other"})
+	if n, _ := store.UniqueVisitors(other); n != 0 {
+		t.Errorf("Expected no visitors for a new link, got %d", n)
+	}
+}
+
+func TestMemoryStorage_ResetAccessCount(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	for i := 0; i < 3; i++ {
+		store.RecordAccess(code, time.Now())
+	}
+
+	if err := store.ResetAccessCount(code); err != nil {
+		t.Fatalf("ResetAccessCount() failed: %v", err)
+	}
+	mapping, _ := store.Get(code)
+	if mapping.AccessCount != 0 {
+		t.Errorf("Expected access count 0 after reset, got %d", mapping.AccessCount)
+	}
+
+	store.RecordAccess(code, time.Now())
+	mapping, _ = store.Get(code)
+	if mapping.AccessCount != 1 {
+		t.Errorf("Expected counting to resume after reset, got %d", mapping.AccessCount)
+	}
+
+	if err := store.ResetAccessCount("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResetAccessCount() should return ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStorage_MaxURLs(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithMaxURLs(2))
+
+	past := time.Now().Add(-time.Hour)
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/old", ExpirationDate: &past}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/1"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// The expired mapping is reclaimed to make room
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/2"}); err != nil {
+		t.Fatalf("Store() should reclaim expired mappings, got %v", err)
+	}
+
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/3"}); err != ErrCapacityExceeded {
+		t.Errorf("Store() past capacity should return ErrCapacityExceeded, got %v", err)
+	}
+
+	// A full store leaves reservations claimable later
+	_, token, err := store.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+	if err := store.ClaimReservation(token, "https://www.example.com/4"); err != ErrCapacityExceeded {
+		t.Errorf("ClaimReservation() past capacity should return ErrCapacityExceeded, got %v", err)
+	}
+
+	if total := store.GetStats()["total_urls"]; total != 2 {
+		t.Errorf("Expected total_urls 2, got %v", total)
+	}
+}
+
+func TestMemoryStorage_StoreWithCode(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/vanity"}, "2"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/other"}, "2"); !errors.Is(err, ErrCodeTaken) {
+		t.Errorf("StoreWithCode() on a taken code should return ErrCodeTaken, got %v", err)
+	}
+
+	// Generated codes skip the custom code
+	first, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com/1"})
+	second, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com/2"})
+	if first != "1" || second != "3" {
+		t.Errorf("Expected generated codes 1 and 3, got %s and %s", first, second)
+	}
+
+	mapping, err := store.Get("2")
+	if err != nil || mapping.LongURL != "https://www.example.com/vanity" {
+		t.Errorf("Custom code mapping was overwritten: %+v, %v", mapping, err)
+	}
+
+	// Reserved codes cannot be taken as custom codes
+	code, _, err := store.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, code); !errors.Is(err, ErrCodeTaken) {
+		t.Errorf("StoreWithCode() on a reserved code should return ErrCodeTaken, got %v", err)
+	}
+
+	if exists, _ := store.Exists("2"); !exists {
+		t.Error("Exists() should report the custom code")
+	}
+	if exists, _ := store.Exists("missing"); exists {
+		t.Error("Exists() should not report a missing code")
+	}
+	if total := store.GetStats()["total_urls"]; total != 3 {
+		t.Errorf("Expected total_urls 3, got %v", total)
+	}
+}
+
+func TestMemoryStorage_ReservedWords(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithReservedWords("admin", "login"))
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, "Admin"); !errors.Is(err, ErrCodeReserved) {
+		t.Errorf("StoreWithCode() of a reserved word should return ErrCodeReserved, got %v", err)
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, "mylink"); err != nil {
+		t.Errorf("StoreWithCode() of a normal code failed: %v", err)
+	}
+	if stats := store.GetStats(); stats["total_urls"] != 1 {
+		t.Errorf("Expected only the normal code to be stored, got %v", stats["total_urls"])
+	}
+}
+
+func TestMemoryStorage_CaseInsensitiveCodes(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithCaseInsensitiveCodes(true))
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, "MyLink"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	for _, code := range []string{"mylink", "MYLINK"} {
+		if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/other"}, code); !errors.Is(err, ErrCodeTaken) {
+			t.Errorf("StoreWithCode(%q) should collide with MyLink, got %v", code, err)
+		}
+	}
+
+	mapping, err := store.GetRaw("mYlInK")
+	if err != nil {
+		t.Fatalf("GetRaw() should resolve regardless of case: %v", err)
+	}
+	if mapping.ShortCode != "mylink" || mapping.PublicCode() != "MyLink" {
+		t.Errorf("Expected key mylink displayed as MyLink, got %q / %q", mapping.ShortCode, mapping.PublicCode())
+	}
+	if exists, _ := store.Exists("MYLINK"); !exists {
+		t.Error("Exists() should resolve regardless of case")
+	}
+	if batch, _ := store.GetBatch([]string{"MYLINK"}); batch["MYLINK"] == nil {
+		t.Error("GetBatch() should resolve regardless of case")
+	}
+
+	// Generated codes are mixed-case and still resolve exactly
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/generated"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if mapping, err := store.GetRaw(code); err != nil || mapping.DisplayCode != "" {
+		t.Errorf("GetRaw(%q) = %+v, %v", code, mapping, err)
+	}
+}
+
+func TestMemoryStorage_ExpiringBetween(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	now := time.Now()
+	expiries := map[string]time.Duration{"later": 2 * time.Hour, "soon": time.Hour, "far": 48 * time.Hour, "past": -time.Hour}
+	for code, offset := range expiries {
+		exp := now.Add(offset)
+		if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/" + code, ExpirationDate: &exp}, code); err != nil {
+			t.Fatalf("StoreWithCode(%q) failed: %v", code, err)
+		}
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/forever"}, "forever"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+
+	mappings, err := store.ExpiringBetween(now, now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ExpiringBetween() failed: %v", err)
+	}
+	if len(mappings) != 2 || mappings[0].ShortCode != "soon" || mappings[1].ShortCode != "later" {
+		t.Fatalf("Expected [soon later], got %+v", mappings)
+	}
+
+	// Deleted mappings leave the index
+	if err := store.Delete("soon"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	mappings, _ = store.ExpiringBetween(now, now.Add(24*time.Hour))
+	if len(mappings) != 1 || mappings[0].ShortCode != "later" {
+		t.Errorf("Expected [later] after deleting soon, got %+v", mappings)
+	}
+}
+
+func TestMemoryStorage_CounterOverflow(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	store.counter = math.MaxUint64 - 1
+
+	code, err := store.Store(&models.URLMapping{LongURL: "https://example.com/last"})
+	if err != nil {
+		t.Fatalf("Store() of the last ID failed: %v", err)
+	}
+
+	// The next ID would wrap to 0 and recycle codes
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com/wrapped"}); !errors.Is(err, ErrIDSpaceExhausted) {
+		t.Fatalf("Expected ErrIDSpaceExhausted, got %v", err)
+	}
+	if _, _, err := store.Reserve(); !errors.Is(err, ErrIDSpaceExhausted) {
+		t.Errorf("Expected Reserve() to fail with ErrIDSpaceExhausted, got %v", err)
+	}
+	if store.counter != math.MaxUint64 {
+		t.Errorf("Counter should stay at its maximum, got %d", store.counter)
+	}
+	if mapping, err := store.Get(code); err != nil || mapping.LongURL != "https://example.com/last" {
+		t.Errorf("Existing mapping should be untouched, got %+v, %v", mapping, err)
+	}
+	if total := store.GetStats()["total_urls"]; total != 1 {
+		t.Errorf("Failed Store() should not count toward total_urls, got %v", total)
+	}
+}
+
+func TestMemoryStorage_MaxID(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithMaxID(2))
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com"}); err != nil {
+			t.Fatalf("Store() %d failed: %v", i+1, err)
+		}
+	}
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com"}); !errors.Is(err, ErrIDSpaceExhausted) {
+		t.Errorf("Expected ErrIDSpaceExhausted past the max ID, got %v", err)
+	}
+
+	// Custom codes don't use the counter
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com"}, "custom"); err != nil {
+		t.Errorf("StoreWithCode() should still work, got %v", err)
+	}
+}
+
+func TestMemoryStorage_MaxGeneratedCodeLength(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithMaxGeneratedCodeLength(2))
+	// 62^2 - 1 is the last ID with a two-character code
+	if err := store.SetCounterFloor(62*62 - 2); err != nil {
+		t.Fatalf("SetCounterFloor() failed: %v", err)
+	}
+
+	code, err := store.Store(&models.URLMapping{LongURL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("Store() of the last two-character code failed: %v", err)
+	}
+	if code != "ZZ" {
+		t.Errorf("Expected code ZZ, got %q", code)
+	}
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com"}); !errors.Is(err, ErrKeyspaceExhausted) {
+		t.Errorf("Expected ErrKeyspaceExhausted past the maximum length, got %v", err)
+	}
+	if _, _, err := store.Reserve(); !errors.Is(err, ErrKeyspaceExhausted) {
+		t.Errorf("Expected Reserve() to fail with ErrKeyspaceExhausted, got %v", err)
+	}
+
+	// Custom codes may still be longer
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com"}, "custom"); err != nil {
+		t.Errorf("StoreWithCode() should still work, got %v", err)
+	}
+}
+
+func TestMaxGeneratedCodeLengthCountsChecksum(t *testing.T) {
+	o := newOptions([]Option{WithMaxGeneratedCodeLength(3), WithChecksumCodes(true)})
+	if err := o.checkCodeLength(62*62 - 1); err != nil {
+		t.Errorf("Expected a two-digit ID plus check character to fit 3 characters, got %v", err)
+	}
+	if err := o.checkCodeLength(62 * 62); !errors.Is(err, ErrKeyspaceExhausted) {
+		t.Errorf("Expected ErrKeyspaceExhausted for a three-digit ID plus check character, got %v", err)
+	}
+
+	unlimited := newOptions([]Option{WithMaxGeneratedCodeLength(11)})
+	if err := unlimited.checkCodeLength(math.MaxUint64); err != nil {
+		t.Errorf("Expected 11 characters to fit every ID, got %v", err)
+	}
+}
+
+func TestMemoryStorage_Verify(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://example.com"})
+	store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/custom"}, "custom")
+
+	issues, err := store.Verify()
+	if err != nil || len(issues) != 0 {
+		t.Fatalf("Expected a consistent store, got %v, %v", issues, err)
+	}
+
+	// Simulate a bad import: a code that doesn't match its ID, reusing the
+	// generated code's ID, with an empty long URL
+	sh := store.shardFor("zz")
+	sh.urls["zz"] = &models.URLMapping{ID: 1, ShortCode: "zz"}
+
+	issues, err = store.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	expected := []string{
+		`ID 1: shared by codes ["` + code + `" "zz"]`,
+		`code "zz": invalid long URL ""`,
+		`code "zz": stored ID 1 encodes to "1"`,
+	}
+	if strings.Join(issues, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Verify() = %q, expected %q", issues, expected)
+	}
+	if _, err := store.GetRaw("zz"); err != nil {
+		t.Errorf("Verify() must not modify the store: %v", err)
+	}
+}
+
+func TestMemoryStorage_Delete(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+
+	if err := store.Delete(code); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.Get(code); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() should return ErrNotFound, got %v", err)
+	}
+	if err := store.Delete(code); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Second Delete() should return ErrNotFound, got %v", err)
+	}
+	if total := store.GetStats()["total_urls"]; total != 0 {
+		t.Errorf("Expected total_urls 0, got %v", total)
+	}
+}
+
+func TestMemoryStorage_StoreWithCodeConcurrent(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	const workers = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	successes, taken := 0, 0
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/contender"}, "vanity")
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				successes++
+			case errors.Is(err, ErrCodeTaken):
+				taken++
+			default:
+				t.Errorf("StoreWithCode() unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if successes != 1 || taken != workers-1 {
+		t.Errorf("Expected exactly 1 success and %d ErrCodeTaken, got %d and %d", workers-1, successes, taken)
+	}
+	if total := store.GetStats()["total_urls"]; total != 1 {
+		t.Errorf("Expected total_urls 1, got %v", total)
+	}
+}
+
+func TestMemoryStorage_SizeStats(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithSizeStats(true))
+
+	// 20 short URLs and one long one: the long URL must not be the p95
+	short := "https://example.com/" + strings.Repeat("a", 10) // 30 bytes
+	for i := 0; i < 20; i++ {
+		if _, err := store.Store(&models.URLMapping{LongURL: short}); err != nil {
+			t.Fatalf("Store() failed: %v", err)
+		}
+	}
+	long := "https://example.com/" + strings.Repeat("b", 280) // 300 bytes
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: long}, "long"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+
+	sizes, ok := store.GetStats()["url_size"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected url_size in stats")
+	}
+	if sizes["count"] != int64(21) {
+		t.Errorf("Expected count 21, got %v", sizes["count"])
+	}
+	if sizes["total_bytes"] != int64(20*30+300) {
+		t.Errorf("Expected total_bytes %d, got %v", 20*30+300, sizes["total_bytes"])
+	}
+	if sizes["p95_length"] != 30 {
+		t.Errorf("Expected p95_length 30, got %v", sizes["p95_length"])
+	}
+	if avg := sizes["avg_length"].(float64); avg < 42.85 || avg > 42.86 {
+		t.Errorf("Expected avg_length ~42.86, got %v", avg)
+	}
+	distribution := sizes["distribution"].(map[string]int64)
+	if distribution["0-32"] != 20 || distribution["257-512"] != 1 {
+		t.Errorf("Unexpected distribution: %v", distribution)
+	}
+
+	// Deletes are reflected immediately
+	if err := store.Delete("long"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	sizes = store.GetStats()["url_size"].(map[string]interface{})
+	if sizes["total_bytes"] != int64(20*30) {
+		t.Errorf("Expected total_bytes %d after delete, got %v", 20*30, sizes["total_bytes"])
+	}
+
+	// Disabled by default
+	if _, ok := NewMemoryStorage("http://localhost:8080").GetStats()["url_size"]; ok {
+		t.Error("Expected no url_size without WithSizeStats")
+	}
+}
+
+func TestMemoryStorage_ConsumeUseConcurrent(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	const maxUses = 5
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com", MaxUses: maxUses})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	successes := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.ConsumeUse(code); err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes != maxUses {
+		t.Errorf("Expected exactly %d successful uses, got %d", maxUses, successes)
+	}
+}
+
+func TestMemoryStorage_ShardedStatsConsistent(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	const numURLs = 1000
+	var wg sync.WaitGroup
+	for i := 0; i < numURLs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.Store(&models.URLMapping{LongURL: "https://www.example.com/shard"})
+		}(i)
+	}
+	wg.Wait()
+
+	if total := store.GetStats()["total_urls"]; total != numURLs {
+		t.Errorf("total_urls should be %d, got %v", numURLs, total)
+	}
+}
+
+// rwMutexStore is the previous single-lock layout, kept as a benchmark baseline
+type rwMutexStore struct {
+	mu   sync.RWMutex
+	urls map[string]*models.URLMapping
+}
+
+func (s *rwMutexStore) get(code string) *models.URLMapping {
+	s.mu.RLock()
+	mapping := *s.urls[code]
+	s.mu.RUnlock()
+	return &mapping
+}
+
+// benchmarkCodes returns the first n generated codes, the same ones Store
+// hands out in the sharded benchmark
+func benchmarkCodes(n int) []string {
+	opts := newOptions(nil)
+	codes := make([]string, n)
+	for i := range codes {
+		codes[i] = opts.encodeID(uint64(i + 1))
+	}
+	return codes
+}
+
+func BenchmarkMemoryStorage_ParallelGetSharded(b *testing.B) {
+	store := NewMemoryStorage("http://localhost:8080")
+	var codes []string
+	for i := 0; i < 1000; i++ {
+		code, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+		codes = append(codes, code)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			store.Get(codes[i%len(codes)])
+			i++
+		}
+	})
+}
+
+func BenchmarkMemoryStorage_ParallelGetRWMutex(b *testing.B) {
+	store := &rwMutexStore{urls: make(map[string]*models.URLMapping)}
+	codes := benchmarkCodes(1000)
+	for _, code := range codes {
+		store.urls[code] = &models.URLMapping{ShortCode: code, LongURL: "https://www.example.com"}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			store.get(codes[i%len(codes)])
+			i++
+		}
+	})
+}
+
+func TestMemoryStorage_PersistenceSurvivesCrash(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("OpenMemoryStorage() failed: %v", err)
+	}
+
+	// One create lands in the snapshot, the rest only in the log
+	first, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/1"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Compact() failed: %v", err)
+	}
+	second, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/2"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/locked", PasswordHash: "hash"}, "locked"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	if err := store.Delete(first); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	// Counter and expiration changes after the snapshot are logged too
+	oneTime, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/once", MaxUses: 1})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if _, err := store.ConsumeUse(oneTime); err != nil {
+		t.Fatalf("ConsumeUse() failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := store.RecordAccess(second, time.Now()); err != nil {
+			t.Fatalf("RecordAccess() failed: %v", err)
+		}
+	}
+	expires := time.Now().Add(time.Minute)
+	sliding, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/sliding", ExpirationDate: &expires})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	extended, err := store.GetAndExtend(sliding, time.Hour)
+	if err != nil {
+		t.Fatalf("GetAndExtend() failed: %v", err)
+	}
+
+	// Crash: reopen the directory without closing the first instance
+	recovered, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Reopening after a crash failed: %v", err)
+	}
+	defer recovered.Close()
+
+	if mapping, err := recovered.Get(second); err != nil || mapping.LongURL != "https://www.example.com/2" {
+		t.Errorf("Create logged before the crash was lost: %v %v", mapping, err)
+	}
+	if mapping, err := recovered.Get("locked"); err != nil || mapping.PasswordHash != "hash" {
+		t.Errorf("Custom code should survive with its password hash: %v %v", mapping, err)
+	}
+	if _, err := recovered.Get(first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Logged delete should be replayed, got %v", err)
+	}
+	if stats := recovered.GetStats(); stats["total_urls"] != 4 {
+		t.Errorf("Expected 4 URLs after recovery, got %v", stats["total_urls"])
+	}
+	if _, err := recovered.ConsumeUse(oneTime); !errors.Is(err, ErrUsesExhausted) {
+		t.Errorf("One-time link consumed before the crash should stay consumed, got %v", err)
+	}
+	if mapping, err := recovered.Get(second); err != nil || mapping.AccessCount != 3 {
+		t.Errorf("Expected 3 logged accesses to survive, got %v %v", mapping, err)
+	}
+	if mapping, err := recovered.Get(sliding); err != nil || mapping.ExpirationDate == nil || !mapping.ExpirationDate.Equal(*extended.ExpirationDate) {
+		t.Errorf("Sliding extension should survive, got %v %v", mapping, err)
+	}
+
+	// The counter continues past recovered IDs instead of reusing them
+	next, err := recovered.Store(&models.URLMapping{LongURL: "https://www.example.com/3"})
+	if err != nil {
+		t.Fatalf("Store() after recovery failed: %v", err)
+	}
+	if next == first || next == second {
+		t.Errorf("Recovered storage reused code %s", next)
+	}
+}
+
+func TestMemoryStorage_PersistenceSkipsTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStorage("http://localhost:8080", dir)
+	if err != nil {
+		t.Fatalf("OpenMemoryStorage() failed: %v", err)
+	}
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// A crash mid-write leaves a record without its newline
+	if err := store.wal.append(walRecord{Op: "delete", Code: "x"}); err != nil {
+		t.Fatalf("append() failed: %v", err)
+	}
+	store.wal.file.Write([]byte(`{"op":"create","mapping":{"short_co`))
+
+	recovered, err := OpenMemoryStorage("http://localhost:8080", dir)
+	if err != nil {
+		t.Fatalf("A torn final record should not prevent recovery: %v", err)
+	}
+	defer recovered.Close()
+	if _, err := recovered.Get(code); err != nil {
+		t.Errorf("Complete records before the torn one should be replayed: %v", err)
+	}
+}
+
+func TestMemoryStorage_PersistencePurgeIsLogged(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("OpenMemoryStorage() failed: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/old", ExpirationDate: &past}, "old"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	if purged, err := store.PurgeExpired(); err != nil || purged != 1 {
+		t.Fatalf("PurgeExpired() = %d, %v; expected 1", purged, err)
+	}
+
+	// Crash: without a logged delete, replay would bring the link back
+	recovered, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Reopening after a crash failed: %v", err)
+	}
+	defer recovered.Close()
+	if _, err := recovered.GetRaw("old"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Purged link should stay purged after replay, got %v", err)
+	}
+}
+
+func TestMemoryStorage_PersistenceInterruptedCompaction(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("OpenMemoryStorage() failed: %v", err)
+	}
+	first, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/1"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// A compaction sets the log aside, then crashes before writing its snapshot
+	if _, _, err := store.captureSnapshot(); err != nil {
+		t.Fatalf("captureSnapshot() failed: %v", err)
+	}
+	second, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/2"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	recovered, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Reopening after a crash failed: %v", err)
+	}
+	defer recovered.Close()
+	for _, code := range []string{first, second} {
+		if _, err := recovered.Get(code); err != nil {
+			t.Errorf("Create of %s was lost with the interrupted compaction: %v", code, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, walCompactingFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected the set-aside log to be removed once compacted, got %v", err)
+	}
+}
+
+func TestMemoryStorage_CloseWritesSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStorage("http://localhost:8080", dir)
+	if err != nil {
+		t.Fatalf("OpenMemoryStorage() failed: %v", err)
+	}
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/late"}); err == nil {
+		t.Error("Store() after Close() should fail")
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, walFileName)); err != nil || info.Size() != 0 {
+		t.Errorf("Close() should leave an empty log, got %v %v", info, err)
+	}
+	reopened, err := OpenMemoryStorage("http://localhost:8080", dir)
+	if err != nil {
+		t.Fatalf("Reopening failed: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.Get(code); err != nil {
+		t.Errorf("Mapping should be loaded from the snapshot: %v", err)
+	}
+}
+
+func TestMemoryStorage_SetCounterFloorNeverLowers(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080", WithMaxID(1000))
+
+	if err := store.SetCounterFloor(100); err != nil {
+		t.Fatalf("SetCounterFloor(100) failed: %v", err)
+	}
+	if err := store.SetCounterFloor(10); err != nil {
+		t.Fatalf("SetCounterFloor(10) failed: %v", err)
+	}
+	mapping := &models.URLMapping{LongURL: "https://www.example.com"}
+	if _, err := store.Store(mapping); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if mapping.ID != 101 {
+		t.Errorf("Expected ID 101 after raising the counter to 100, got %d", mapping.ID)
+	}
+
+	if err := store.SetCounterFloor(1001); !errors.Is(err, ErrCounterOutOfRange) {
+		t.Errorf("Expected ErrCounterOutOfRange past MaxID, got %v", err)
+	}
+}
+
+func TestMemoryStorage_AccessEventsKeepLatest(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < MaxAccessEvents+5; i++ {
+		event := models.AccessEvent{Time: start.Add(time.Duration(i) * time.Second)}
+		if err := store.RecordAccessEvent(code, event); err != nil {
+			t.Fatalf("RecordAccessEvent() failed: %v", err)
+		}
+	}
+
+	events, err := store.RecentAccessEvents(code, MaxAccessEvents+5)
+	if err != nil {
+		t.Fatalf("RecentAccessEvents() failed: %v", err)
+	}
+	if len(events) != MaxAccessEvents {
+		t.Fatalf("Expected %d events kept, got %d", MaxAccessEvents, len(events))
+	}
+	if newest := start.Add(time.Duration(MaxAccessEvents+4) * time.Second); !events[0].Time.Equal(newest) {
+		t.Errorf("Expected the newest event first, got %v", events[0].Time)
+	}
+}
+
+func TestMemoryStorage_Campaigns(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	if err := store.CreateCampaign(&models.Campaign{ID: "spring", Name: "Spring sale", Tags: []string{"promo"}}); err != nil {
+		t.Fatalf("CreateCampaign() failed: %v", err)
+	}
+	if err := store.CreateCampaign(&models.Campaign{ID: "spring"}); !errors.Is(err, ErrCampaignExists) {
+		t.Errorf("Expected ErrCampaignExists for a taken ID, got %v", err)
+	}
+	campaign, err := store.GetCampaign("spring")
+	if err != nil || campaign.Name != "Spring sale" {
+		t.Fatalf("GetCampaign() = %v, %v", campaign, err)
+	}
+	if _, err := store.GetCampaign("autumn"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown campaign, got %v", err)
+	}
+
+	second, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com/b", Campaign: "spring"})
+	first, _ := store.Store(&models.URLMapping{LongURL: "https://www.example.com/a", Campaign: "spring"})
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/other"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	members, err := store.CampaignURLs("spring")
+	if err != nil {
+		t.Fatalf("CampaignURLs() failed: %v", err)
+	}
+	expected := []string{first, second}
+	if first > second {
+		expected = []string{second, first}
+	}
+	if len(members) != 2 || members[0].ShortCode != expected[0] || members[1].ShortCode != expected[1] {
+		t.Errorf("Expected members %v, got %d mappings", expected, len(members))
+	}
+	if _, err := store.CampaignURLs("autumn"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound listing an unknown campaign, got %v", err)
+	}
+}
+
+func TestMemoryStorage_CampaignsPersist(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("OpenMemoryStorage() failed: %v", err)
+	}
+	if err := store.CreateCampaign(&models.Campaign{ID: "logged"}); err != nil {
+		t.Fatalf("CreateCampaign() failed: %v", err)
+	}
+
+	// Replayed from the operation log after a crash
+	recovered, err := OpenMemoryStorage("http://localhost:8080", dir, WithSnapshotInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Reopening after a crash failed: %v", err)
+	}
+	if _, err := recovered.GetCampaign("logged"); err != nil {
+		t.Errorf("Campaign logged before the crash was lost: %v", err)
+	}
+
+	// Carried in the snapshot after a clean close
+	if err := recovered.CreateCampaign(&models.Campaign{ID: "snapshotted"}); err != nil {
+		t.Fatalf("CreateCampaign() failed: %v", err)
+	}
+	if err := recovered.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	reopened, err := OpenMemoryStorage("http://localhost:8080", dir)
+	if err != nil {
+		t.Fatalf("Reopening failed: %v", err)
+	}
+	defer reopened.Close()
+	for _, id := range []string{"logged", "snapshotted"} {
+		if _, err := reopened.GetCampaign(id); err != nil {
+			t.Errorf("Campaign %s should be loaded from the snapshot: %v", id, err)
+		}
+	}
+}
+
+func TestClockSkewToleranceBoundary(t *testing.T) {
+	expires := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	mapping := &models.URLMapping{ExpirationDate: &expires}
+
+	testCases := []struct {
+		name      string
+		tolerance time.Duration
+		now       time.Time
+		expired   bool
+	}{
+		{"no tolerance, at expiration", 0, expires, false},
+		{"no tolerance, just past", 0, expires.Add(time.Nanosecond), true},
+		{"tolerance, just past expiration", 2 * time.Second, expires.Add(time.Second), false},
+		{"tolerance, at the edge", 2 * time.Second, expires.Add(2 * time.Second), false},
+		{"tolerance, past the edge", 2 * time.Second, expires.Add(2*time.Second + time.Nanosecond), true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := newOptions([]Option{WithClockSkewTolerance(tc.tolerance)})
+			if expired := opts.isExpired(mapping, tc.now); expired != tc.expired {
+				t.Errorf("isExpired() = %v, expected %v", expired, tc.expired)
+			}
+		})
+	}
+
+	if (&options{}).isExpired(&models.URLMapping{}, time.Now()) {
+		t.Error("A link without an expiration should never expire")
+	}
+}
+
+func TestMemoryStorage_ClockSkewTolerance(t *testing.T) {
+	justExpired := time.Now().Add(-time.Second)
+	strict := NewMemoryStorage("http://localhost:8080")
+	tolerant := NewMemoryStorage("http://localhost:8080", WithClockSkewTolerance(time.Minute))
+
+	for _, store := range []*MemoryStorage{strict, tolerant} {
+		if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com", ExpirationDate: &justExpired}, "edge"); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+
+	if _, err := strict.Get("edge"); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected ErrExpired without tolerance, got %v", err)
+	}
+	if _, err := tolerant.Get("edge"); err != nil {
+		t.Errorf("Expected the link to resolve within the tolerance, got %v", err)
+	}
+	if purged, _ := tolerant.purgeExpired(); purged != 0 {
+		t.Errorf("Expected no purge within the tolerance, purged %d", purged)
+	}
+}
+
+func TestMemoryStorage_CountByDomain(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+	for _, longURL := range []string{
+		"https://example.com/a",
+		"https://EXAMPLE.com:8443/b",
+		"http://example.com./c",
+		"https://spam.example/1",
+		"https://spam.example/2",
+		"https://spam.example/3",
+		"https://other.org",
+	} {
+		if _, err := store.Store(&models.URLMapping{LongURL: longURL}); err != nil {
+			t.Fatalf("Store(%q) failed: %v", longURL, err)
+		}
+	}
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://other.org/deleted"})
+	if err := store.Delete(code); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	counts, err := store.CountByDomain()
+	if err != nil {
+		t.Fatalf("CountByDomain() failed: %v", err)
+	}
+	expected := map[string]int{"example.com": 3, "spam.example": 3, "other.org": 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+}
+
+func TestMemoryStorage_GetAndExtend(t *testing.T) {
+	store := NewMemoryStorage("http://localhost:8080")
+
+	soon := time.Now().Add(time.Minute)
+	code, err := store.Store(&models.URLMapping{LongURL: "https://example.com/sliding", ExpirationDate: &soon})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	extended, err := store.GetAndExtend(code, time.Hour)
+	if err != nil {
+		t.Fatalf("GetAndExtend() failed: %v", err)
+	}
+	if extended.ExpirationDate.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("Expected the expiration an hour out, got %v", extended.ExpirationDate)
+	}
+	if stored, _ := store.Get(code); !stored.ExpirationDate.Equal(*extended.ExpirationDate) {
+		t.Errorf("Expected the extension to be stored, got %v", stored.ExpirationDate)
+	}
+
+	// A shorter extension never pulls the expiration in
+	again, err := store.GetAndExtend(code, time.Minute)
+	if err != nil || !again.ExpirationDate.Equal(*extended.ExpirationDate) {
+		t.Errorf("Expected the expiration kept at %v, got %v (%v)", extended.ExpirationDate, again.ExpirationDate, err)
+	}
+
+	// Links without an expiration stay that way
+	forever, _ := store.Store(&models.URLMapping{LongURL: "https://example.com/forever"})
+	if mapping, err := store.GetAndExtend(forever, time.Hour); err != nil || mapping.ExpirationDate != nil {
+		t.Errorf("Expected no expiration, got %v (%v)", mapping, err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	expired, _ := store.Store(&models.URLMapping{LongURL: "https://example.com/expired", ExpirationDate: &past})
+	if _, err := store.GetAndExtend(expired, time.Hour); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected ErrExpired for an expired link, got %v", err)
+	}
+}
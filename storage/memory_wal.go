@@ -0,0 +1,477 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tiny-url-service/models"
+)
+
+// Files kept in a memory storage data directory. A compaction sets the log
+// aside as the compacting file until its snapshot is written.
+const (
+	snapshotFileName      = "snapshot.jsonl"
+	walFileName           = "wal.jsonl"
+	walCompactingFileName = "wal.compacting.jsonl"
+)
+
+// errStorageClosed is returned by writes after Close
+var errStorageClosed = errors.New("storage is closed")
+
+// walRecord is one line of the operation log. An update carries the
+// mapping's counters and expiration as absolute values rather than deltas,
+// so replaying one that a snapshot already includes changes nothing.
+type walRecord struct {
+	Op          string           `json:"op"`                     // "create", "update", "delete" or "campaign"
+	Code        string           `json:"code,omitempty"`         // Updated or deleted code
+	Mapping     json.RawMessage  `json:"mapping,omitempty"`      // Created mapping, as marshalMapping writes it
+	Campaign    *models.Campaign `json:"campaign,omitempty"`     // Created campaign
+	UseCount    int              `json:"use_count,omitempty"`    // Updated use count
+	AccessCount int64            `json:"access_count,omitempty"` // Updated access count
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`   // Updated expiration; nil never expires
+}
+
+// snapshotHeader is the first line of a snapshot; a mapping per line follows
+type snapshotHeader struct {
+	Counter   uint64             `json:"counter"`
+	Time      time.Time          `json:"time"`
+	Campaigns []*models.Campaign `json:"campaigns,omitempty"`
+}
+
+// memoryWAL is the append-only log of changes made since the last snapshot. Records are written with a single write each, so they
+// survive a process crash (not a power loss; the file is only synced when
+// compacting).
+type memoryWAL struct {
+	mu        sync.Mutex
+	compactMu sync.Mutex // Serializes compactions
+	dir       string
+	file      *os.File // nil once closed
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// append writes record as one JSON line
+func (w *memoryWAL) append(record walRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode log record: %w", err)
+	}
+	data = append(data, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return errStorageClosed
+	}
+	if _, err := w.file.Write(data); err != nil {
+		return fmt.Errorf("failed to append to operation log: %w", err)
+	}
+	return nil
+}
+
+// OpenMemoryStorage creates memory storage persisted in dir. It loads the
+// last snapshot, replays the operation log written after it, then logs every
+// create, delete, campaign and mapping update (consumed uses, recorded or
+// reset access counts, sliding expiration) before applying it. A snapshot is
+// rewritten and the log truncated every snapshot interval and on Close. Only
+// mappings and campaigns are persisted; reservations, click series, access
+// events and visitor counts start empty.
+func OpenMemoryStorage(baseURL, dir string, opts ...Option) (*MemoryStorage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	m := NewMemoryStorage(baseURL, opts...)
+	if err := m.loadSnapshot(filepath.Join(dir, snapshotFileName)); err != nil {
+		return nil, err
+	}
+	// A compaction interrupted by a crash leaves records that are older than
+	// the live log and may not be in the snapshot yet
+	for _, name := range []string{walCompactingFileName, walFileName} {
+		if err := m.replayLog(filepath.Join(dir, name)); err != nil {
+			return nil, err
+		}
+	}
+	m.rebuildIndexes()
+
+	file, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open operation log: %w", err)
+	}
+	m.wal = &memoryWAL{
+		dir:  dir,
+		file: file,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	// Fold the replayed log into a fresh snapshot so it doesn't grow across restarts
+	if err := m.Compact(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	go m.compactLoop(m.opts.snapshotInterval)
+
+	return m, nil
+}
+
+// loadSnapshot restores the counter and mappings from path, if it exists
+func (m *MemoryStorage) loadSnapshot(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	line, err := reader.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var header snapshotHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return fmt.Errorf("invalid snapshot header: %w", err)
+	}
+	m.counter = header.Counter
+	for _, campaign := range header.Campaigns {
+		m.campaigns[campaign.ID] = campaign
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			mapping, decodeErr := unmarshalMapping(line)
+			if decodeErr != nil {
+				return fmt.Errorf("invalid snapshot entry: %w", decodeErr)
+			}
+			m.restore(mapping)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+	}
+}
+
+// replayLog applies the records logged after the snapshot. A last line
+// without a newline was cut off by a crash mid-write and is skipped.
+func (m *MemoryStorage) replayLog(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open operation log: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Printf("Skipping incomplete final record %d of %s", n, path)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read operation log: %w", err)
+		}
+
+		var record walRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("invalid operation log record %d: %w", n, err)
+		}
+		switch record.Op {
+		case "create":
+			mapping, err := unmarshalMapping(record.Mapping)
+			if err != nil {
+				return fmt.Errorf("invalid operation log record %d: %w", n, err)
+			}
+			m.restore(mapping)
+		case "update":
+			if mapping, ok := m.shardFor(record.Code).urls[record.Code]; ok {
+				mapping.UseCount = record.UseCount
+				mapping.AccessCount = record.AccessCount
+				mapping.ExpirationDate = record.ExpiresAt
+			}
+		case "delete":
+			sh := m.shardFor(record.Code)
+			delete(sh.urls, record.Code)
+		case "campaign":
+			if record.Campaign == nil {
+				return fmt.Errorf("invalid operation log record %d: missing campaign", n)
+			}
+			m.campaigns[record.Campaign.ID] = record.Campaign
+		default:
+			return fmt.Errorf("invalid operation log record %d: unknown op %q", n, record.Op)
+		}
+	}
+}
+
+// restore puts a persisted mapping back, keeping the counter past its ID.
+// It runs before the storage is shared, so no locks are taken.
+func (m *MemoryStorage) restore(mapping *models.URLMapping) {
+	m.shardFor(mapping.ShortCode).urls[mapping.ShortCode] = mapping
+	if mapping.ID > m.counter {
+		m.counter = mapping.ID
+	}
+}
+
+// rebuildIndexes recomputes the size and reverse index after loading
+func (m *MemoryStorage) rebuildIndexes() {
+	var size int64
+	for _, sh := range m.shards {
+		for _, mapping := range sh.urls {
+			size++
+			m.indexLongURL(mapping)
+		}
+	}
+	m.size = size
+}
+
+// logCreate records mapping in the operation log; a no-op without one
+func (m *MemoryStorage) logCreate(mapping *models.URLMapping) error {
+	if m.wal == nil {
+		return nil
+	}
+	data, err := marshalMapping(mapping)
+	if err != nil {
+		return fmt.Errorf("failed to encode mapping: %w", err)
+	}
+	return m.wal.append(walRecord{Op: "create", Mapping: data})
+}
+
+// logUpdate records the counters and expiration mapping is about to have;
+// a no-op without a log
+func (m *MemoryStorage) logUpdate(mapping *models.URLMapping) error {
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.append(walRecord{
+		Op:          "update",
+		Code:        mapping.ShortCode,
+		UseCount:    mapping.UseCount,
+		AccessCount: mapping.AccessCount,
+		ExpiresAt:   mapping.ExpirationDate,
+	})
+}
+
+// logCampaign records a created campaign; a no-op without a log
+func (m *MemoryStorage) logCampaign(campaign *models.Campaign) error {
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.append(walRecord{Op: "campaign", Campaign: campaign})
+}
+
+// logDelete records the deletion of shortCode; a no-op without a log
+func (m *MemoryStorage) logDelete(shortCode string) error {
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.append(walRecord{Op: "delete", Code: shortCode})
+}
+
+// Compact writes a full snapshot and truncates the operation log. Writers
+// (which log under their shard's lock) and redirects pause only while the
+// mappings are copied and the log is set aside; the snapshot is written and
+// synced after the locks are released. It is a no-op for storage created
+// without a data directory.
+func (m *MemoryStorage) Compact() error {
+	if m.wal == nil {
+		return nil
+	}
+	m.wal.compactMu.Lock()
+	defer m.wal.compactMu.Unlock()
+
+	header, mappings, err := m.captureSnapshot()
+	if err != nil {
+		return err
+	}
+	if err := m.writeSnapshot(header, mappings); err != nil {
+		return err
+	}
+	// Every set-aside record is now in the snapshot. A crash before the
+	// removal only means they are replayed again, which is idempotent.
+	if err := os.Remove(filepath.Join(m.wal.dir, walCompactingFileName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove compacted operation log: %w", err)
+	}
+	return nil
+}
+
+// captureSnapshot copies the counter, campaigns and mappings and sets the
+// log aside, so records appended from here on go to a fresh log
+func (m *MemoryStorage) captureSnapshot() (snapshotHeader, []models.URLMapping, error) {
+	// Shard and campaign locks are always taken before the log lock, as writers do
+	for _, sh := range m.shards {
+		sh.mu.RLock()
+	}
+	defer func() {
+		for _, sh := range m.shards {
+			sh.mu.RUnlock()
+		}
+	}()
+	m.campMu.RLock()
+	defer m.campMu.RUnlock()
+	m.wal.mu.Lock()
+	defer m.wal.mu.Unlock()
+	if m.wal.file == nil {
+		return snapshotHeader{}, nil, errStorageClosed
+	}
+
+	header := snapshotHeader{Counter: atomic.LoadUint64(&m.counter), Time: time.Now().UTC()}
+	for _, campaign := range m.campaigns {
+		header.Campaigns = append(header.Campaigns, campaign)
+	}
+	// Mappings are copied by value: counters change in place under the shard lock
+	mappings := make([]models.URLMapping, 0, atomic.LoadInt64(&m.size))
+	for _, sh := range m.shards {
+		for _, mapping := range sh.urls {
+			mappings = append(mappings, *mapping)
+		}
+	}
+
+	if err := m.wal.setAside(); err != nil {
+		return snapshotHeader{}, nil, err
+	}
+	return header, mappings, nil
+}
+
+// setAside moves the log's records to the compacting file and continues
+// with an empty log. An earlier compaction that never finished left its
+// file behind; the new records are appended to it rather than replacing it.
+// Called with w.mu held.
+func (w *memoryWAL) setAside() error {
+	live := filepath.Join(w.dir, walFileName)
+	compacting := filepath.Join(w.dir, walCompactingFileName)
+
+	if _, err := os.Stat(compacting); os.IsNotExist(err) {
+		if err := os.Rename(live, compacting); err != nil {
+			return fmt.Errorf("failed to set operation log aside: %w", err)
+		}
+		file, err := os.OpenFile(live, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open operation log: %w", err)
+		}
+		w.file.Close()
+		w.file = file
+		return nil
+	}
+
+	data, err := os.ReadFile(live)
+	if err != nil {
+		return fmt.Errorf("failed to read operation log: %w", err)
+	}
+	file, err := os.OpenFile(compacting, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open compacting operation log: %w", err)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set operation log aside: %w", err)
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate operation log: %w", err)
+	}
+	return nil
+}
+
+// writeSnapshot replaces the snapshot file atomically via a rename
+func (m *MemoryStorage) writeSnapshot(header snapshotHeader, mappings []models.URLMapping) error {
+	path := filepath.Join(m.wal.dir, snapshotFileName)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	writer := bufio.NewWriter(file)
+	err = json.NewEncoder(writer).Encode(header)
+	for i := range mappings {
+		if err != nil {
+			break
+		}
+		var data []byte
+		if data, err = marshalMapping(&mappings[i]); err == nil {
+			data = append(data, '\n')
+			_, err = writer.Write(data)
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// compactLoop compacts every interval until Close
+func (m *MemoryStorage) compactLoop(interval time.Duration) {
+	defer close(m.wal.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Compact(); err != nil {
+				log.Printf("Failed to compact memory storage: %v", err)
+			}
+		case <-m.wal.stop:
+			return
+		}
+	}
+}
+
+// Close writes a final snapshot and closes the operation log. Later writes
+// fail. It is a no-op for storage created without a data directory.
+func (m *MemoryStorage) Close() error {
+	if m.wal == nil {
+		return nil
+	}
+
+	var err error
+	m.wal.closeOnce.Do(func() {
+		close(m.wal.stop)
+		<-m.wal.done
+		err = m.Compact()
+
+		m.wal.mu.Lock()
+		defer m.wal.mu.Unlock()
+		if closeErr := m.wal.file.Close(); err == nil {
+			err = closeErr
+		}
+		m.wal.file = nil
+	})
+	return err
+}
@@ -0,0 +1,25 @@
+package storage
+
+import "strings"
+
+// namespaceSeparator joins a namespace and a code into a storage key. Custom
+// codes and generated codes never contain it, so keys are unambiguous.
+const namespaceSeparator = ":"
+
+// NamespacedCode returns the storage key of code in namespace, or code
+// itself in the default (empty) namespace
+func NamespacedCode(namespace, code string) string {
+	if namespace == "" {
+		return code
+	}
+	return namespace + namespaceSeparator + code
+}
+
+// SplitNamespace splits a storage key into its namespace and code; the
+// namespace is empty for keys in the default namespace
+func SplitNamespace(key string) (namespace, code string) {
+	if i := strings.Index(key, namespaceSeparator); i >= 0 {
+		return key[:i], key[i+len(namespaceSeparator):]
+	}
+	return "", key
+}
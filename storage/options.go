@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"tiny-url-service/models"
+	"tiny-url-service/utils"
+)
+
+// DefaultReservationTTL is how long a reserved short code is held before it is released
+const DefaultReservationTTL = 5 * time.Minute
+
+// DefaultClickRetention is how long hourly click buckets are kept
+const DefaultClickRetention = 7 * 24 * time.Hour
+
+// MaxAccessEvents is how many of its latest access events a link keeps
+const MaxAccessEvents = 100
+
+// DefaultSnapshotInterval is how often persisted memory storage compacts its log into a snapshot
+const DefaultSnapshotInterval = 5 * time.Minute
+
+// options holds tunables shared by all storage implementations
+type options struct {
+	reservationTTL time.Duration
+	clickRetention time.Duration
+	maxURLs        int64
+	maxID          uint64 // Highest ID Store and Reserve may allocate (0 means math.MaxUint64)
+	maxCodeLength  int    // Longest code Store and Reserve may generate (0 means unlimited)
+	sizeStats      bool
+	scanSearch     bool
+	reverseHash    URLHashFunc // nil disables the long URL reverse index
+	reservedWords  map[string]struct{} // Lowercased words StoreWithCode refuses
+	foldCodes      bool                // Store custom codes case-folded and resolve lookups case-insensitively
+	clickFlush     time.Duration       // Buffer click counts in memory and write them this often (0 writes every click)
+	snapshotInterval time.Duration     // How often persisted memory storage rewrites its snapshot
+	checksumCodes  bool                // Generated codes end in a check character that Get validates
+	clockSkew      time.Duration       // Grace period past a link's expiration before it counts as expired
+}
+
+// Option configures optional storage behavior
+type Option func(*options)
+
+// WithReservationTTL sets how long reserved codes are held before being released
+func WithReservationTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.reservationTTL = ttl
+		}
+	}
+}
+
+// WithClickRetention sets how long hourly click buckets are kept
+func WithClickRetention(d time.Duration) Option {
+	return func(o *options) {
+		if d >= time.Hour {
+			o.clickRetention = d
+		}
+	}
+}
+
+// WithClickFlushInterval buffers redirect counts in memory and writes them
+// in one pipeline every d instead of on every redirect. Counts read back lag
+// by up to d, and clicks buffered when the process dies without Close are
+// lost. 0 keeps writing each click immediately. Only Redis storage buffers.
+func WithClickFlushInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.clickFlush = d
+		}
+	}
+}
+
+// WithSnapshotInterval sets how often memory storage opened with
+// OpenMemoryStorage writes a full snapshot and truncates its operation log
+func WithSnapshotInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.snapshotInterval = d
+		}
+	}
+}
+
+// WithMaxURLs caps how many URLs may be stored (0 means unlimited)
+func WithMaxURLs(n int64) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxURLs = n
+		}
+	}
+}
+
+// WithMaxID caps the IDs generated codes are encoded from. Once the counter
+// reaches max, Store and Reserve fail with ErrIDSpaceExhausted instead of
+// wrapping around. 0 leaves only the uint64 limit.
+func WithMaxID(max uint64) Option {
+	return func(o *options) {
+		o.maxID = max
+	}
+}
+
+// idLimit returns the highest ID that may be allocated
+func (o *options) idLimit() uint64 {
+	if o.maxID == 0 {
+		return math.MaxUint64
+	}
+	return o.maxID
+}
+
+// WithMaxGeneratedCodeLength caps the length of generated codes, including
+// the check character of WithChecksumCodes. Once the next ID would encode
+// longer than n, Store and Reserve fail with ErrKeyspaceExhausted instead of
+// handing out a longer code. Custom codes are not affected. 0 means unlimited.
+func WithMaxGeneratedCodeLength(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxCodeLength = n
+		}
+	}
+}
+
+// checkCodeLength returns ErrKeyspaceExhausted if id would encode to a code
+// longer than the maximum generated code length
+func (o *options) checkCodeLength(id uint64) error {
+	if o.maxCodeLength == 0 {
+		return nil
+	}
+	digits := o.maxCodeLength
+	if o.checksumCodes {
+		digits--
+	}
+	// 62^11 exceeds the uint64 range, so 11 digits fit every ID
+	if digits >= 11 {
+		return nil
+	}
+	limit := uint64(0)
+	if digits > 0 {
+		limit = 1
+		for i := 0; i < digits; i++ {
+			limit *= 62
+		}
+		limit-- // The largest ID with that many base62 digits
+	}
+	if id > limit {
+		return fmt.Errorf("%w: ID %d needs a code longer than %d characters", ErrKeyspaceExhausted, id, o.maxCodeLength)
+	}
+	return nil
+}
+
+// WithSizeStats enables long-URL length statistics in GetStats. Redis keeps
+// running aggregates for them, which adds a small cost to every create and delete.
+func WithSizeStats(enabled bool) Option {
+	return func(o *options) {
+		o.sizeStats = enabled
+	}
+}
+
+// WithScanSearch allows Search on backends where it requires a full keyspace
+// scan (Redis). Memory storage always supports search.
+func WithScanSearch(enabled bool) Option {
+	return func(o *options) {
+		o.scanSearch = enabled
+	}
+}
+
+// WithReverseIndex maintains a long URL -> short code index keyed by hash,
+// enabling FindByLongURL. A nil hash leaves the index disabled.
+func WithReverseIndex(hash URLHashFunc) Option {
+	return func(o *options) {
+		o.reverseHash = hash
+	}
+}
+
+// WithReservedWords makes StoreWithCode refuse the given words as codes,
+// ignoring case, e.g. route prefixes a vanity code must never shadow
+func WithReservedWords(words ...string) Option {
+	return func(o *options) {
+		if o.reservedWords == nil {
+			o.reservedWords = make(map[string]struct{}, len(words))
+		}
+		for _, word := range words {
+			o.reservedWords[strings.ToLower(word)] = struct{}{}
+		}
+	}
+}
+
+// WithCaseInsensitiveCodes stores custom codes under their lowercase key,
+// keeping the typed casing in DisplayCode, so "MyLink" and "mylink" are one
+// code. Lookups try the exact code first, since generated codes are
+// mixed-case, then its lowercase form.
+func WithCaseInsensitiveCodes(enabled bool) Option {
+	return func(o *options) {
+		o.foldCodes = enabled
+	}
+}
+
+// WithChecksumCodes appends a base62 check character to generated codes
+// (see utils.EncodeBase62WithChecksum). Get then refuses a code whose check
+// fails with ErrChecksumMismatch, unless it is a custom code, so a mistyped
+// code never resolves to someone else's link. Codes generated before it was
+// enabled stop resolving.
+func WithChecksumCodes(enabled bool) Option {
+	return func(o *options) {
+		o.checksumCodes = enabled
+	}
+}
+
+// WithClockSkewTolerance treats a link as expired only once now is past its
+// expiration plus d, so instances with slightly skewed clocks agree on links
+// right at the boundary. Negative values are ignored.
+func WithClockSkewTolerance(d time.Duration) Option {
+	return func(o *options) {
+		if d >= 0 {
+			o.clockSkew = d
+		}
+	}
+}
+
+// expiresAt returns mapping's expiration plus the clock skew tolerance, or
+// nil for links that never expire
+func (o *options) expiresAt(mapping *models.URLMapping) *time.Time {
+	if mapping.ExpirationDate == nil {
+		return nil
+	}
+	deadline := mapping.ExpirationDate.Add(o.clockSkew)
+	return &deadline
+}
+
+// isExpired reports whether now is past mapping's expiresAt deadline.
+// Links without an expiration never expire.
+func (o *options) isExpired(mapping *models.URLMapping, now time.Time) bool {
+	deadline := o.expiresAt(mapping)
+	return deadline != nil && now.After(*deadline)
+}
+
+// encodeID returns the generated short code for id
+func (o *options) encodeID(id uint64) string {
+	if o.checksumCodes {
+		return utils.EncodeBase62WithChecksum(id)
+	}
+	return utils.EncodeBase62(id)
+}
+
+// foldedCode returns the lowercase key code is also looked up under, or ""
+// when folding is off or wouldn't change the code
+func (o *options) foldedCode(code string) string {
+	if !o.foldCodes {
+		return ""
+	}
+	if folded := strings.ToLower(code); folded != code {
+		return folded
+	}
+	return ""
+}
+
+// checkReservedWord returns ErrCodeReserved if code is a reserved word
+func (o *options) checkReservedWord(code string) error {
+	if _, reserved := o.reservedWords[strings.ToLower(code)]; reserved {
+		return fmt.Errorf("%w: %s", ErrCodeReserved, code)
+	}
+	return nil
+}
+
+// newOptions applies the given options on top of the defaults
+func newOptions(opts []Option) options {
+	o := options{
+		reservationTTL: DefaultReservationTTL,
+		clickRetention: DefaultClickRetention,
+		snapshotInterval: DefaultSnapshotInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -3,7 +3,12 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"tiny-url-service/models"
@@ -13,40 +18,175 @@ import (
 )
 
 type RedisStorage struct {
-	client  *redis.Client
+	client  redis.UniversalClient
 	baseURL string
 	ctx     context.Context
 	counter uint64 // Local counter, synced with Redis
+	opts    options
+	cluster bool // Cluster mode cannot run multi-key scripts such as KEYS
+	
+	// Write-behind click counting, set up when a click flush interval is configured
+	clicks    *clickBuffer
+	stopFlush chan struct{}
+	flushDone chan struct{}
+	closeOnce sync.Once
+	closeErr  error // Result of the first Drain or Close
+	
+	commands *commandTracker // Commands in progress, awaited by Drain
 }
 
-func NewRedisStorage(baseURL, redisURL string) (*RedisStorage, error) {
-	opts, err := redis.ParseURL(redisURL)
+// RedisConfig describes how to connect to Redis
+type RedisConfig struct {
+	Mode       string   // "standalone" (default), "sentinel" or "cluster"
+	URL        string   // Connection URL used in standalone mode
+	Addrs      []string // Sentinel or cluster node addresses
+	MasterName string   // Sentinel master name
+	Password   string   // Password for sentinel/cluster nodes
+	
+	// Transient failures (network errors, LOADING, READONLY, CLUSTERDOWN,
+	// TRYAGAIN; MOVED is followed by the cluster client) are retried with
+	// exponential backoff between MinRetryBackoff and MaxRetryBackoff.
+	// Definitive replies such as redis.Nil and context cancellation are not.
+	MaxRetries      int           // Retries per command (0 keeps the go-redis default of 3, negative disables)
+	MinRetryBackoff time.Duration // 0 keeps the go-redis default of 8ms
+	MaxRetryBackoff time.Duration // 0 keeps the go-redis default of 512ms
+}
+
+// redisReservation is the JSON value stored under reservation:<token>
+type redisReservation struct {
+	ID   uint64 `json:"id"`
+	Code string `json:"code"`
+}
+
+// redisRecord is the JSON document stored under url:<code>. It carries the
+// fields URLMapping hides from API responses so they survive a round-trip.
+type redisRecord struct {
+	*models.URLMapping
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// marshalMapping serializes a mapping, including storage-only fields
+func marshalMapping(mapping *models.URLMapping) ([]byte, error) {
+	return json.Marshal(redisRecord{
+		URLMapping:   mapping,
+		PasswordHash: mapping.PasswordHash,
+	})
+}
+
+// unmarshalMapping restores a mapping serialized by marshalMapping
+func unmarshalMapping(data []byte) (*models.URLMapping, error) {
+	mapping := &models.URLMapping{}
+	record := redisRecord{URLMapping: mapping}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	mapping.PasswordHash = record.PasswordHash
+	return mapping, nil
+}
+
+// NewRedisStorage connects to a single Redis node described by redisURL
+func NewRedisStorage(baseURL, redisURL string, opts ...Option) (*RedisStorage, error) {
+	return NewRedisStorageFromConfig(baseURL, RedisConfig{URL: redisURL}, opts...)
+}
+
+// NewRedisStorageFromConfig connects to Redis in standalone, sentinel or cluster mode
+func NewRedisStorageFromConfig(baseURL string, rc RedisConfig, opts ...Option) (*RedisStorage, error) {
+	client, err := newRedisClient(rc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		return nil, err
 	}
+	return newRedisStorage(baseURL, client, strings.EqualFold(rc.Mode, "cluster"), opts...)
+}
 
-	client := redis.NewClient(opts)
+// newRedisStorage sets up storage on an already built client, which it
+// closes if the connection check fails
+func newRedisStorage(baseURL string, client redis.UniversalClient, cluster bool, opts ...Option) (*RedisStorage, error) {
 	ctx := context.Background()
 
 	// Test connection
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	storage := &RedisStorage{
-		client:  client,
-		baseURL: baseURL,
-		ctx:     ctx,
+		client:   client,
+		baseURL:  baseURL,
+		ctx:      ctx,
+		opts:     newOptions(opts),
+		cluster:  cluster,
+		commands: &commandTracker{},
 	}
+	client.AddHook(storage.commands)
 
 	// Initialize counter from Redis
 	if err := storage.initCounter(); err != nil {
 		return nil, fmt.Errorf("failed to initialize counter: %w", err)
 	}
+	if err := storage.initURLCount(); err != nil {
+		return nil, fmt.Errorf("failed to initialize URL count: %w", err)
+	}
+	
+	if storage.opts.clickFlush > 0 {
+		storage.clicks = newClickBuffer()
+		storage.stopFlush = make(chan struct{})
+		storage.flushDone = make(chan struct{})
+		go storage.flushLoop(storage.opts.clickFlush)
+	}
 
 	return storage, nil
 }
 
+// newRedisClient builds the go-redis client matching the configured mode
+func newRedisClient(rc RedisConfig) (redis.UniversalClient, error) {
+	switch strings.ToLower(rc.Mode) {
+	case "", "standalone":
+		redisOpts, err := redis.ParseURL(rc.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		rc.applyRetries(&redisOpts.MaxRetries, &redisOpts.MinRetryBackoff, &redisOpts.MaxRetryBackoff)
+		return redis.NewClient(redisOpts), nil
+	case "sentinel":
+		if rc.MasterName == "" || len(rc.Addrs) == 0 {
+			return nil, fmt.Errorf("sentinel mode requires a master name and sentinel addresses")
+		}
+		failoverOpts := &redis.FailoverOptions{
+			MasterName:    rc.MasterName,
+			SentinelAddrs: rc.Addrs,
+			Password:      rc.Password,
+		}
+		rc.applyRetries(&failoverOpts.MaxRetries, &failoverOpts.MinRetryBackoff, &failoverOpts.MaxRetryBackoff)
+		return redis.NewFailoverClient(failoverOpts), nil
+	case "cluster":
+		if len(rc.Addrs) == 0 {
+			return nil, fmt.Errorf("cluster mode requires node addresses")
+		}
+		clusterOpts := &redis.ClusterOptions{
+			Addrs:    rc.Addrs,
+			Password: rc.Password,
+		}
+		rc.applyRetries(&clusterOpts.MaxRetries, &clusterOpts.MinRetryBackoff, &clusterOpts.MaxRetryBackoff)
+		return redis.NewClusterClient(clusterOpts), nil
+	default:
+		return nil, fmt.Errorf("unknown Redis mode: %s (supported: standalone, sentinel, cluster)", rc.Mode)
+	}
+}
+
+// applyRetries copies the configured retry policy onto go-redis client
+// options, leaving unset values at their defaults (or the URL's settings)
+func (rc RedisConfig) applyRetries(maxRetries *int, minBackoff, maxBackoff *time.Duration) {
+	if rc.MaxRetries != 0 {
+		*maxRetries = rc.MaxRetries
+	}
+	if rc.MinRetryBackoff > 0 {
+		*minBackoff = rc.MinRetryBackoff
+	}
+	if rc.MaxRetryBackoff > 0 {
+		*maxBackoff = rc.MaxRetryBackoff
+	}
+}
+
 func (r *RedisStorage) initCounter() error {
 	// Get current counter value from Redis, or start at 0
 	val, err := r.client.Get(r.ctx, "counter").Uint64()
@@ -64,66 +204,616 @@ func (r *RedisStorage) initCounter() error {
 
 // Store saves a URL mapping and returns the generated short code
 func (r *RedisStorage) Store(mapping *models.URLMapping) (string, error) {
-	// Generate unique ID using Redis INCR for atomicity across instances
-	id, err := r.client.Incr(r.ctx, "counter").Result()
-	if err != nil {
-		return "", fmt.Errorf("failed to generate ID: %w", err)
+	if err := r.checkCapacity(); err != nil {
+		return "", err
+	}
+	
+	mapping.CreatedAt = time.Now()
+	for {
+		// Generate unique ID using Redis INCR for atomicity across instances
+		id, err := r.client.Incr(r.ctx, "counter").Result()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate ID: %w", err)
+		}
+		atomic.StoreUint64(&r.counter, uint64(id))
+		if err := r.checkID(id); err != nil {
+			return "", err
+		}
+
+		// Generate short code using base62 encoding
+		mapping.ID = uint64(id)
+		mapping.ShortCode = NamespacedCode(mapping.Namespace, r.opts.encodeID(uint64(id)))
+
+		// Skip codes a case-folded custom code already answers to
+		if folded := r.opts.foldedCode(mapping.ShortCode); folded != "" {
+			taken, err := r.client.Exists(r.ctx, "url:"+folded).Result()
+			if err != nil {
+				return "", fmt.Errorf("failed to check short code in Redis: %w", err)
+			}
+			if taken > 0 {
+				continue
+			}
+		}
+
+		// SET NX skips codes already taken by custom codes
+		stored, err := r.setIfAbsent(mapping, "")
+		if err != nil {
+			return "", err
+		}
+		if stored {
+			return mapping.ShortCode, nil
+		}
+	}
+}
+
+// checkID refuses IDs past the configured maximum, and IDs whose code would
+// be longer than the maximum generated code length. Redis itself fails INCR
+// rather than wrapping at the int64 limit.
+func (r *RedisStorage) checkID(id int64) error {
+	if uint64(id) > r.opts.idLimit() {
+		return fmt.Errorf("%w: counter at %d", ErrIDSpaceExhausted, id)
+	}
+	return r.opts.checkCodeLength(uint64(id))
+}
+
+// StoreWithCode saves mapping under a caller-chosen code. SET NX makes the
+// create atomic across instances; reserved codes are refused as well.
+func (r *RedisStorage) StoreWithCode(mapping *models.URLMapping, shortCode string) error {
+	if err := r.opts.checkReservedWord(shortCode); err != nil {
+		return err
+	}
+	if err := r.checkCapacity(); err != nil {
+		return err
 	}
 
-	// Generate short code using base62 encoding
-	shortCode := utils.EncodeBase62(uint64(id))
+	key := shortCode
+	if folded := r.opts.foldedCode(shortCode); folded != "" {
+		// The typed casing may itself be a generated code
+		taken, err := r.client.Exists(r.ctx, "url:"+shortCode).Result()
+		if err != nil {
+			return fmt.Errorf("failed to check short code in Redis: %w", err)
+		}
+		if taken > 0 {
+			return fmt.Errorf("%w: %s", ErrCodeTaken, shortCode)
+		}
+		key = folded
+		mapping.DisplayCode = shortCode
+	}
 
-	// Complete the mapping
-	mapping.ID = uint64(id)
-	mapping.ShortCode = shortCode
+	mapping.ShortCode = key
 	mapping.CreatedAt = time.Now()
 
-	// Serialize mapping to JSON
-	data, err := json.Marshal(mapping)
+	// Refuses codes held by a reservation in the same step
+	stored, err := r.setIfAbsent(mapping, "")
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal URL mapping: %w", err)
+		return err
+	}
+	if !stored {
+		return fmt.Errorf("%w: %s", ErrCodeTaken, shortCode)
 	}
+	return nil
+}
+
+// Exists reports whether shortCode is stored
+func (r *RedisStorage) Exists(shortCode string) (bool, error) {
+	exists, err := r.ExistsBatch([]string{shortCode})
+	return exists[shortCode], err
+}
 
-	// Store in Redis
-	if err := r.client.Set(r.ctx, "url:"+shortCode, data, 0).Err(); err != nil {
-		return "", fmt.Errorf("failed to store URL mapping in Redis: %w", err)
+// ExistsBatch checks many codes in one round trip. It pipelines EXISTS per
+// key rather than one multi-key call since keys hash to different cluster slots.
+func (r *RedisStorage) ExistsBatch(codes []string) (map[string]bool, error) {
+	result := make(map[string]bool, len(codes))
+	if len(codes) == 0 {
+		return result, nil
+	}
+	
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(codes))
+	foldedCmds := make([]*redis.IntCmd, len(codes))
+	for i, code := range codes {
+		cmds[i] = pipe.Exists(r.ctx, "url:"+code)
+		if folded := r.opts.foldedCode(code); folded != "" {
+			foldedCmds[i] = pipe.Exists(r.ctx, "url:"+folded)
+		}
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, fmt.Errorf("failed to check short codes in Redis: %w", err)
 	}
+	
+	for i, code := range codes {
+		result[code] = cmds[i].Val() > 0 || (foldedCmds[i] != nil && foldedCmds[i].Val() > 0)
+	}
+	return result, nil
+}
 
-	// Update local counter
-	atomic.StoreUint64(&r.counter, uint64(id))
+// reservedKey marks a code held by a reservation. Its hash tag is the full
+// url:<code> key name, so both live in the same cluster slot and one script
+// can check the reservation and store the mapping.
+func reservedKey(code string) string {
+	return "reserved:{url:" + code + "}"
+}
+
+// reserveScript holds a free code for the token in ARGV[1] for ARGV[2]
+// milliseconds. It returns 0 if the code is stored or already held.
+var reserveScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+if not redis.call('SET', KEYS[2], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 0
+end
+return 1
+`)
+
+// storeScript sets url:<code> to ARGV[1] unless the code is taken or held
+// by a reservation other than ARGV[2] (empty for plain stores). A claimed
+// reservation is released in the same step. It returns 1 when stored, 0
+// when the code is taken and -1 when the reservation doesn't match.
+var storeScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[2])
+local expected = false
+if ARGV[2] ~= '' then
+	expected = ARGV[2]
+end
+if holder ~= expected then
+	return -1
+end
+if not redis.call('SET', KEYS[1], ARGV[1], 'NX') then
+	return 0
+end
+if holder then
+	redis.call('DEL', KEYS[2])
+end
+return 1
+`)
+
+// setIfAbsent writes mapping with SET NX and bumps url_count on success.
+// With an empty token it reports false without error when the code is
+// already taken or reserved. With a token it claims that reservation,
+// returning ErrReservationNotFound if the token doesn't hold the code.
+func (r *RedisStorage) setIfAbsent(mapping *models.URLMapping, token string) (bool, error) {
+	data, err := marshalMapping(mapping)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal URL mapping: %w", err)
+	}
 
-	return shortCode, nil
+	keys := []string{"url:" + mapping.ShortCode, reservedKey(mapping.ShortCode)}
+	result, err := storeScript.Run(r.ctx, r.client, keys, data, token).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to store URL mapping in Redis: %w", err)
+	}
+	if result == -1 && token != "" {
+		return false, ErrReservationNotFound
+	}
+	if result != 1 {
+		return false, nil
+	}
+
+	// Track the total in a counter key since KEYS scans don't work on cluster
+	if err := r.client.Incr(r.ctx, "url_count").Err(); err != nil {
+		return true, fmt.Errorf("failed to update URL count: %w", err)
+	}
+	if err := r.trackSize(mapping.LongURL, 1); err != nil {
+		return true, err
+	}
+	if err := r.trackDomain(mapping.LongURL, 1); err != nil {
+		return true, err
+	}
+	if key := r.opts.reverseKey(mapping.LongURL); key != "" && reverseIndexable(mapping) {
+		if err := r.client.Set(r.ctx, "longurl:"+key, mapping.ShortCode, 0).Err(); err != nil {
+			return true, fmt.Errorf("failed to update long URL index: %w", err)
+		}
+		if ownerKey := r.opts.ownerReverseKey(mapping.Owner, mapping.LongURL); ownerKey != "" {
+			if err := r.client.Set(r.ctx, "ownerurl:"+ownerKey, mapping.ShortCode, 0).Err(); err != nil {
+				return true, fmt.Errorf("failed to update owner long URL index: %w", err)
+			}
+		}
+	}
+	if mapping.Campaign != "" {
+		if err := r.client.SAdd(r.ctx, campaignURLsKey(mapping.Campaign), mapping.ShortCode).Err(); err != nil {
+			return true, fmt.Errorf("failed to update campaign members: %w", err)
+		}
+	}
+	if mapping.ExpirationDate != nil {
+		member := redis.Z{Score: float64(mapping.ExpirationDate.UnixMilli()), Member: mapping.ShortCode}
+		if err := r.client.ZAdd(r.ctx, expirationsKey, member).Err(); err != nil {
+			return true, fmt.Errorf("failed to update expiration index: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// unindexScript deletes a reverse index entry only if it still points at the given code
+var unindexScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// FindByLongURL resolves longURL through the longurl:<hash> index. The
+// mapping's long URL is compared before returning it, so a hash collision
+// is a miss rather than a wrong dedup hit.
+func (r *RedisStorage) FindByLongURL(longURL string) (*models.URLMapping, error) {
+	key := r.opts.reverseKey(longURL)
+	if key == "" {
+		return nil, ErrNotFound
+	}
+	
+	code, err := r.client.Get(r.ctx, "longurl:"+key).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read long URL index: %w", err)
+	}
+	
+	mapping, err := r.Get(code)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrExpired) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !verifyReverseHit(mapping, longURL) {
+		return nil, ErrNotFound
+	}
+	return mapping, nil
+}
+
+// FindByOwnerLongURL resolves longURL through owner's ownerurl:<hash> index
+func (r *RedisStorage) FindByOwnerLongURL(owner, longURL string) (*models.URLMapping, error) {
+	key := r.opts.ownerReverseKey(owner, longURL)
+	if key == "" {
+		return nil, ErrNotFound
+	}
+	
+	code, err := r.client.Get(r.ctx, "ownerurl:"+key).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read owner long URL index: %w", err)
+	}
+	
+	mapping, err := r.Get(code)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrExpired) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Owner != owner || !verifyReverseHit(mapping, longURL) {
+		return nil, ErrNotFound
+	}
+	return mapping, nil
+}
+
+// Keys holding the running long-URL size aggregates
+const (
+	sizeBytesKey = "urlsize:bytes"
+	sizeHistKey  = "urlsize:hist"
+)
+
+// trackSize adds (delta 1) or removes (delta -1) longURL from the size
+// aggregates. It is a no-op unless size stats are enabled.
+func (r *RedisStorage) trackSize(longURL string, delta int64) error {
+	if !r.opts.sizeStats {
+		return nil
+	}
+	
+	n := len(longURL)
+	pipe := r.client.Pipeline()
+	pipe.IncrBy(r.ctx, sizeBytesKey, delta*int64(n))
+	pipe.HIncrBy(r.ctx, sizeHistKey, sizeBucketLabel(sizeBucket(n)), delta)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return fmt.Errorf("failed to update URL size stats: %w", err)
+	}
+	return nil
+}
+
+// sizeStats reads the running long-URL size aggregates. The p95 is estimated
+// from the histogram since individual lengths are not kept.
+func (r *RedisStorage) sizeStats() (map[string]interface{}, error) {
+	pipe := r.client.Pipeline()
+	bytesCmd := pipe.Get(r.ctx, sizeBytesKey)
+	histCmd := pipe.HGetAll(r.ctx, sizeHistKey)
+	if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read URL size stats: %w", err)
+	}
+	
+	totalBytes, err := bytesCmd.Int64()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read URL size stats: %w", err)
+	}
+	
+	fields := histCmd.Val()
+	hist := make([]int64, len(sizeBounds)+1)
+	for i := range hist {
+		if raw, ok := fields[sizeBucketLabel(i)]; ok {
+			hist[i], _ = strconv.ParseInt(raw, 10, 64)
+		}
+	}
+	
+	return sizeSummary(hist, totalBytes, histogramP95(hist)), nil
+}
+
+// checkCapacity returns ErrCapacityExceeded once url_count reaches the
+// configured maximum. The check is best effort: concurrent creates across
+// instances may overshoot the limit slightly.
+func (r *RedisStorage) checkCapacity() error {
+	if r.opts.maxURLs <= 0 {
+		return nil
+	}
+	
+	count, err := r.client.Get(r.ctx, "url_count").Int64()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read URL count: %w", err)
+	}
+	if count >= r.opts.maxURLs {
+		return ErrCapacityExceeded
+	}
+	return nil
 }
 
 // Get retrieves the URL mapping for a given short code
 func (r *RedisStorage) Get(shortCode string) (*models.URLMapping, error) {
-	data, err := r.client.Get(r.ctx, "url:"+shortCode).Result()
+	mapping, err := r.opts.checkedGet(shortCode, r.GetRaw)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Check if expired
+	if r.IsExpired(mapping) {
+		return nil, fmt.Errorf("%w: %s", ErrExpired, shortCode)
+	}
+	
+	return mapping, nil
+}
+
+// GetRaw retrieves the URL mapping without enforcing expiration (admin use only)
+func (r *RedisStorage) GetRaw(shortCode string) (*models.URLMapping, error) {
+	mapping, err := r.getExact(shortCode)
+	if folded := r.opts.foldedCode(shortCode); folded != "" && errors.Is(err, ErrNotFound) {
+		mapping, err = r.getExact(folded)
+		if errors.Is(err, ErrNotFound) {
+			err = fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+		}
+	}
+	return mapping, err
+}
+
+// getExact retrieves the mapping stored under exactly shortCode
+func (r *RedisStorage) getExact(shortCode string) (*models.URLMapping, error) {
+	// Counters live in their own keys so they can be INCRed atomically;
+	// fetch them alongside the mapping in a single round trip
+	pipe := r.client.Pipeline()
+	urlCmd := pipe.Get(r.ctx, "url:"+shortCode)
+	usesCmd := pipe.Get(r.ctx, "uses:"+shortCode)
+	clicksCmd := pipe.Get(r.ctx, clicksKey(shortCode))
+	if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get URL mapping from Redis: %w", err)
+	}
+	
+	data, err := urlCmd.Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("short code not found: %s", shortCode)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get URL mapping from Redis: %w", err)
 	}
 
-	var mapping models.URLMapping
-	if err := json.Unmarshal([]byte(data), &mapping); err != nil {
+	mapping, err := unmarshalMapping([]byte(data))
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal URL mapping: %w", err)
 	}
 
-	// Check if expired
-	if r.IsExpired(&mapping) {
-		return nil, fmt.Errorf("URL has expired: %s", shortCode)
+	if mapping.MaxUses > 0 {
+		used, err := usesCmd.Int()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get use count from Redis: %w", err)
+		}
+		mapping.UseCount = used
+	}
+	
+	accessCount, err := clicksCmd.Int64()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get access count from Redis: %w", err)
 	}
+	mapping.AccessCount = accessCount
 
-	return &mapping, nil
+	return mapping, nil
+}
+
+// GetBatch fetches the mappings and their counters with a single MGET, plus
+// one more for case-folded fallbacks of codes that were missing
+func (r *RedisStorage) GetBatch(codes []string) (map[string]*models.URLMapping, error) {
+	result, err := r.getBatchExact(codes)
+	if err != nil || !r.opts.foldCodes {
+		return result, err
+	}
+	
+	var retry []string
+	for _, code := range codes {
+		if folded := r.opts.foldedCode(code); folded != "" && result[code] == nil {
+			retry = append(retry, folded)
+		}
+	}
+	if len(retry) == 0 {
+		return result, nil
+	}
+	folded, err := r.getBatchExact(retry)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if mapping := folded[r.opts.foldedCode(code)]; result[code] == nil && mapping != nil {
+			result[code] = mapping
+		}
+	}
+	return result, nil
+}
+
+// getBatchExact is GetBatch without case-folded fallbacks
+func (r *RedisStorage) getBatchExact(codes []string) (map[string]*models.URLMapping, error) {
+	result := make(map[string]*models.URLMapping, len(codes))
+	if len(codes) == 0 {
+		return result, nil
+	}
+	
+	// Keys are laid out as [url:... | uses:... | clicks:...]
+	n := len(codes)
+	keys := make([]string, 0, 3*n)
+	for _, code := range codes {
+		keys = append(keys, "url:"+code)
+	}
+	for _, code := range codes {
+		keys = append(keys, "uses:"+code)
+	}
+	for _, code := range codes {
+		keys = append(keys, clicksKey(code))
+	}
+	values, err := r.mget(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URL mappings from Redis: %w", err)
+	}
+	
+	for i, code := range codes {
+		data, ok := values[i].(string)
+		if !ok {
+			continue
+		}
+		mapping, err := unmarshalMapping([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal URL mapping: %w", err)
+		}
+		mapping.ShortCode = code
+		if uses, ok := values[n+i].(string); ok && mapping.MaxUses > 0 {
+			mapping.UseCount, _ = strconv.Atoi(uses)
+		}
+		if clicks, ok := values[2*n+i].(string); ok {
+			mapping.AccessCount, _ = strconv.ParseInt(clicks, 10, 64)
+		}
+		result[code] = mapping
+	}
+	return result, nil
+}
+
+// mget is MGET, returning nil for missing keys. Cluster clients pipeline
+// single GETs instead, since the keys hash to different slots.
+func (r *RedisStorage) mget(keys []string) ([]interface{}, error) {
+	if _, ok := r.client.(*redis.ClusterClient); !ok {
+		return r.client.MGet(r.ctx, keys...).Result()
+	}
+	
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(r.ctx, key)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		if value, err := cmd.Result(); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+// Delete removes a mapping and its counter keys. Hourly click keys are left
+// to expire on their own TTL.
+func (r *RedisStorage) Delete(shortCode string) error {
+	// The deleted mapping's long URL is needed to update the domain counts,
+	// the size aggregates and the reverse index
+	data, err := r.client.GetDel(r.ctx, "url:"+shortCode).Result()
+	if err == redis.Nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete URL mapping from Redis: %w", err)
+	}
+	if mapping, err := unmarshalMapping([]byte(data)); err == nil {
+		if err := r.trackDomain(mapping.LongURL, -1); err != nil {
+			return err
+		}
+		if err := r.trackSize(mapping.LongURL, -1); err != nil {
+			return err
+		}
+		if key := r.opts.reverseKey(mapping.LongURL); key != "" {
+			if err := unindexScript.Run(r.ctx, r.client, []string{"longurl:" + key}, shortCode).Err(); err != nil {
+				return fmt.Errorf("failed to update long URL index: %w", err)
+			}
+		}
+		if key := r.opts.ownerReverseKey(mapping.Owner, mapping.LongURL); key != "" {
+			if err := unindexScript.Run(r.ctx, r.client, []string{"ownerurl:" + key}, shortCode).Err(); err != nil {
+				return fmt.Errorf("failed to update owner long URL index: %w", err)
+			}
+		}
+	}
+	
+	if r.clicks != nil {
+		r.clicks.drop(shortCode)
+	}
+	
+	// Keys hash to different cluster slots, so delete them individually
+	pipe := r.client.Pipeline()
+	pipe.Del(r.ctx, "uses:"+shortCode)
+	pipe.Del(r.ctx, clicksKey(shortCode))
+	pipe.Del(r.ctx, "clicklabels:"+shortCode)
+	pipe.Del(r.ctx, eventsKey(shortCode))
+	pipe.Del(r.ctx, visitorsKey(shortCode))
+	pipe.ZRem(r.ctx, expirationsKey, shortCode)
+	pipe.Decr(r.ctx, "url_count")
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return fmt.Errorf("failed to delete URL counters from Redis: %w", err)
+	}
+	
+	return nil
 }
 
 // IsExpired checks if a URL mapping has expired
 func (r *RedisStorage) IsExpired(mapping *models.URLMapping) bool {
-	if mapping.ExpirationDate == nil {
-		return false // No expiration set
+	return r.opts.isExpired(mapping, time.Now())
+}
+
+// ExpiresAt returns when mapping counts as expired
+func (r *RedisStorage) ExpiresAt(mapping *models.URLMapping) *time.Time {
+	return r.opts.expiresAt(mapping)
+}
+
+// initURLCount seeds url_count from the url:* keys already stored, so data
+// written before the count existed is still reported and capped. SETNX keeps
+// a count another instance seeded or incremented in the meantime.
+func (r *RedisStorage) initURLCount() error {
+	exists, err := r.client.Exists(r.ctx, "url_count").Result()
+	if err != nil || exists > 0 {
+		return err
+	}
+
+	var count atomic.Int64
+	countNode := func(ctx context.Context, node redis.UniversalClient) error {
+		iter := node.Scan(ctx, 0, "url:*", scanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			count.Add(1)
+		}
+		return iter.Err()
+	}
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(r.ctx, func(ctx context.Context, node *redis.Client) error {
+			return countNode(ctx, node)
+		})
+	} else {
+		err = countNode(r.ctx, r.client)
+	}
+	if err != nil {
+		return err
 	}
-	return time.Now().After(*mapping.ExpirationDate)
+	return r.client.SetNX(r.ctx, "url_count", count.Load(), 0).Err()
 }
 
 // GetStats returns storage statistics
@@ -131,24 +821,538 @@ func (r *RedisStorage) GetStats() map[string]interface{} {
 	// Get current counter
 	currentCounter := atomic.LoadUint64(&r.counter)
 
-	// Count total URLs (this is expensive for large datasets)
-	totalUrls, err := r.client.Eval(r.ctx, `
-		local keys = redis.call('KEYS', 'url:*')
-		return #keys
-	`, []string{}).Result()
-
-	if err != nil {
-		totalUrls = 0
+	// Read the maintained URL count, seeded at startup
+	var totalUrls interface{} = 0
+	if count, err := r.client.Get(r.ctx, "url_count").Int64(); err == nil {
+		totalUrls = count
 	}
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"total_urls":      totalUrls,
 		"current_counter": currentCounter,
 		"storage_type":    "redis",
+		"max_urls":        r.opts.maxURLs,
+	}
+	if r.opts.sizeStats {
+		if sizes, err := r.sizeStats(); err == nil {
+			stats["url_size"] = sizes
+		}
+	}
+	return stats
+}
+
+// Reserve allocates the next short code and holds it until claimed or expired.
+// The reservation key carries a Redis TTL so unclaimed codes are released automatically.
+func (r *RedisStorage) Reserve() (string, string, error) {
+	token, err := utils.GenerateToken(16)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate reservation token: %w", err)
+	}
+
+	// Skip codes already taken by custom codes
+	var res redisReservation
+	for {
+		id, err := r.client.Incr(r.ctx, "counter").Result()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate ID: %w", err)
+		}
+		atomic.StoreUint64(&r.counter, uint64(id))
+		if err := r.checkID(id); err != nil {
+			return "", "", err
+		}
+
+		res = redisReservation{ID: uint64(id), Code: r.opts.encodeID(uint64(id))}
+		taken, err := r.Exists(res.Code)
+		if err != nil {
+			return "", "", err
+		}
+		if taken {
+			continue
+		}
+
+		// reserved:{url:<code>} makes StoreWithCode refuse the code. Setting
+		// it checks url:<code> again, so a custom code stored since the
+		// EXISTS above isn't reserved over.
+		keys := []string{"url:" + res.Code, reservedKey(res.Code)}
+		held, err := reserveScript.Run(r.ctx, r.client, keys, token, r.opts.reservationTTL.Milliseconds()).Int()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to store reservation in Redis: %w", err)
+		}
+		if held == 1 {
+			break
+		}
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal reservation: %w", err)
+	}
+	if err := r.client.Set(r.ctx, "reservation:"+token, data, r.opts.reservationTTL).Err(); err != nil {
+		return "", "", fmt.Errorf("failed to store reservation in Redis: %w", err)
+	}
+
+	return res.Code, token, nil
+}
+
+// ClaimReservation stores a link to longURL under the code held by token
+func (r *RedisStorage) ClaimReservation(token, longURL string) error {
+	return r.ClaimReservationMapping(token, &models.URLMapping{LongURL: longURL})
+}
+
+// ClaimReservationMapping stores the mapping under the code held by token
+func (r *RedisStorage) ClaimReservationMapping(token string, mapping *models.URLMapping) error {
+	// Check capacity first so a full store leaves the reservation claimable
+	if err := r.checkCapacity(); err != nil {
+		return err
+	}
+
+	// reservation:<token> only locates the code. The claim itself is decided
+	// by reserved:{url:<code>}, checked and released atomically with storing
+	// the mapping, so concurrent claimers can't both succeed.
+	data, err := r.client.Get(r.ctx, "reservation:"+token).Result()
+	if err == redis.Nil {
+		return ErrReservationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get reservation from Redis: %w", err)
+	}
+
+	var res redisReservation
+	if err := json.Unmarshal([]byte(data), &res); err != nil {
+		return fmt.Errorf("failed to unmarshal reservation: %w", err)
+	}
+
+	mapping.ID = res.ID
+	mapping.ShortCode = res.Code
+	mapping.CreatedAt = time.Now()
+
+	stored, err := r.setIfAbsent(mapping, token)
+	if err != nil {
+		return err
+	}
+	r.client.Del(r.ctx, "reservation:"+token)
+	if !stored {
+		return fmt.Errorf("%w: %s", ErrCodeTaken, res.Code)
+	}
+
+	return nil
+}
+
+// ConsumeUse atomically records one use of a use-limited link. INCR on the
+// uses:<code> key guarantees concurrent redirects cannot overshoot MaxUses.
+func (r *RedisStorage) ConsumeUse(shortCode string) (int, error) {
+	data, err := r.client.Get(r.ctx, "url:"+shortCode).Result()
+	if err == redis.Nil {
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get URL mapping from Redis: %w", err)
+	}
+
+	mapping, err := unmarshalMapping([]byte(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to unmarshal URL mapping: %w", err)
+	}
+	if mapping.MaxUses <= 0 {
+		return -1, nil
+	}
+
+	used, err := r.client.Incr(r.ctx, "uses:"+shortCode).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment use count: %w", err)
+	}
+	if used > int64(mapping.MaxUses) {
+		return 0, ErrUsesExhausted
+	}
+
+	return mapping.MaxUses - int(used), nil
+}
+
+// clicksKey is the total redirect counter for a short code
+func clicksKey(shortCode string) string {
+	return "clicks:" + shortCode
+}
+
+// clicksHourKey is the counter for redirects of shortCode during hour
+func clicksHourKey(shortCode string, hour time.Time) string {
+	return "clicks:" + shortCode + ":" + hour.UTC().Format("2006010215")
+}
+
+// RecordAccess counts one redirect in clicks:<code> and the hourly
+// clicks:<code>:<yyyymmddhh> key, which expires after the click retention.
+// Existence is not checked: callers record redirects of links they just resolved.
+// With a click flush interval the redirect is only buffered until the next flush.
+func (r *RedisStorage) RecordAccess(shortCode string, at time.Time) error {
+	if r.clicks != nil {
+		r.clicks.add(shortCode, at, 1)
+		return nil
+	}
+	
+	hourKey := clicksHourKey(shortCode, at)
+	
+	pipe := r.client.Pipeline()
+	pipe.Incr(r.ctx, clicksKey(shortCode))
+	pipe.Incr(r.ctx, hourKey)
+	pipe.Expire(r.ctx, hourKey, r.opts.clickRetention)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return fmt.Errorf("failed to record access: %w", err)
+	}
+	
+	return nil
+}
+
+// ResetAccessCount deletes clicks:<code>; a missing counter reads as zero
+func (r *RedisStorage) ResetAccessCount(shortCode string) error {
+	exists, err := r.client.Exists(r.ctx, "url:"+shortCode).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check URL mapping in Redis: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	
+	if r.clicks != nil {
+		r.clicks.drop(shortCode)
+	}
+	if err := r.client.Del(r.ctx, clicksKey(shortCode)).Err(); err != nil {
+		return fmt.Errorf("failed to reset access count: %w", err)
+	}
+	return nil
+}
+
+// AccessSeries returns click counts for shortCode grouped into buckets,
+// reading the hourly counters in one pipelined round trip
+func (r *RedisStorage) AccessSeries(shortCode string, bucket time.Duration, since time.Time) ([]models.BucketCount, error) {
+	exists, err := r.client.Exists(r.ctx, "url:"+shortCode).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check URL mapping in Redis: %w", err)
+	}
+	if exists == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	
+	return buildSeries(bucket, since, time.Now(), r.opts.clickRetention, func(hours []time.Time) ([]int64, error) {
+		// Hourly keys hash to different cluster slots, so use a pipeline of GETs rather than MGET
+		pipe := r.client.Pipeline()
+		cmds := make([]*redis.StringCmd, len(hours))
+		for i, h := range hours {
+			cmds[i] = pipe.Get(r.ctx, clicksHourKey(shortCode, h))
+		}
+		if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to read click series: %w", err)
+		}
+		
+		counts := make([]int64, len(hours))
+		for i, cmd := range cmds {
+			n, err := cmd.Int64()
+			if err != nil && err != redis.Nil {
+				return nil, fmt.Errorf("failed to read click series: %w", err)
+			}
+			counts[i] = n
+		}
+		return counts, nil
+	})
+}
+
+// RecordLabeledClick counts one redirect of shortCode under label in the
+// clicklabels:<code> hash
+func (r *RedisStorage) RecordLabeledClick(shortCode, label string) error {
+	if err := r.client.HIncrBy(r.ctx, "clicklabels:"+shortCode, label, 1).Err(); err != nil {
+		return fmt.Errorf("failed to record labeled click: %w", err)
+	}
+	return nil
+}
+
+// LabeledClicks returns the per-label redirect counts for shortCode
+func (r *RedisStorage) LabeledClicks(shortCode string) (map[string]int64, error) {
+	values, err := r.client.HGetAll(r.ctx, "clicklabels:"+shortCode).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labeled clicks: %w", err)
+	}
+	
+	counts := make(map[string]int64, len(values))
+	for label, value := range values {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid click count for label %q: %w", label, err)
+		}
+		counts[label] = n
+	}
+	return counts, nil
+}
+
+// eventsKey is the list of shortCode's latest access events, newest first
+func eventsKey(shortCode string) string {
+	return "events:" + shortCode
+}
+
+// RecordAccessEvent pushes event onto events:<code> and trims the list to
+// MaxAccessEvents in one round trip
+func (r *RedisStorage) RecordAccessEvent(shortCode string, event models.AccessEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal access event: %w", err)
+	}
+	
+	pipe := r.client.Pipeline()
+	pipe.LPush(r.ctx, eventsKey(shortCode), data)
+	pipe.LTrim(r.ctx, eventsKey(shortCode), 0, MaxAccessEvents-1)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return fmt.Errorf("failed to record access event: %w", err)
+	}
+	return nil
+}
+
+// RecentAccessEvents reads the newest limit events of shortCode
+func (r *RedisStorage) RecentAccessEvents(shortCode string, limit int) ([]models.AccessEvent, error) {
+	if limit <= 0 {
+		return []models.AccessEvent{}, nil
+	}
+	values, err := r.client.LRange(r.ctx, eventsKey(shortCode), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access events: %w", err)
+	}
+	
+	events := make([]models.AccessEvent, 0, len(values))
+	for _, value := range values {
+		var event models.AccessEvent
+		if err := json.Unmarshal([]byte(value), &event); err != nil {
+			return nil, fmt.Errorf("invalid access event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// flushLoop writes buffered clicks every interval until Close
+func (r *RedisStorage) flushLoop(interval time.Duration) {
+	defer close(r.flushDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.FlushClicks(); err != nil {
+				log.Printf("Failed to flush click counts: %v", err)
+			}
+		case <-r.stopFlush:
+			return
+		}
+	}
+}
+
+// FlushClicks writes buffered click counts with one pipeline of INCRBY and
+// EXPIRE commands. Counts that fail to write are kept for the next flush.
+// It is a no-op when clicks are not buffered.
+func (r *RedisStorage) FlushClicks() error {
+	return r.flushClicks(r.ctx)
+}
+
+// flushClicks is FlushClicks bounded by ctx
+func (r *RedisStorage) flushClicks(ctx context.Context) error {
+	if r.clicks == nil {
+		return nil
+	}
+	pending := r.clicks.take()
+	if len(pending) == 0 {
+		return nil
+	}
+	
+	// Counters hash to different cluster slots, so pipeline them individually
+	pipe := r.client.Pipeline()
+	for code, counts := range pending {
+		pipe.IncrBy(ctx, clicksKey(code), counts.total)
+		for hour, n := range counts.hours {
+			hourKey := clicksHourKey(code, hour)
+			pipe.IncrBy(ctx, hourKey, n)
+			pipe.Expire(ctx, hourKey, r.opts.clickRetention)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		// A failed pipeline may have applied some increments; re-adding all
+		// of them can over-count, which is preferred to losing clicks
+		r.clicks.restore(pending)
+		return fmt.Errorf("failed to flush click counts: %w", err)
 	}
+	return nil
 }
 
-// Close closes the Redis connection
+// Close flushes buffered click counts, then closes the Redis connection.
+// It is Drain without a deadline.
 func (r *RedisStorage) Close() error {
-	return r.client.Close()
-} 
\ No newline at end of file
+	return r.Drain(context.Background())
+}
+
+// scanBatchSize is the SCAN COUNT hint and the number of mappings fetched
+// per pipeline by Search and Each
+const scanBatchSize = 500
+
+// Search walks every url:* key with SCAN and filters client-side, so each
+// page costs a full keyspace scan. It returns ErrSearchDisabled unless
+// enabled with WithScanSearch.
+func (r *RedisStorage) Search(query, after string, limit int) ([]*models.URLMapping, error) {
+	if !r.opts.scanSearch {
+		return nil, ErrSearchDisabled
+	}
+	query = strings.ToLower(query)
+	
+	var matches []*models.URLMapping
+	err := r.scanMappings(
+		func(code string) bool { return code > after },
+		func(mapping *models.URLMapping) error {
+			if matchesQuery(mapping.LongURL, query) {
+				matches = append(matches, mapping)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URL mappings in Redis: %w", err)
+	}
+	
+	return searchPage(matches, after, limit), nil
+}
+
+// Each walks every url:* key with SCAN, fetching mappings and their counters
+// scanBatchSize at a time
+func (r *RedisStorage) Each(fn func(*models.URLMapping) error) error {
+	var fnErr error
+	err := r.scanMappings(nil, func(mapping *models.URLMapping) error {
+		if err := fn(mapping); err != nil {
+			fnErr = err
+			return err
+		}
+		return nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to iterate URL mappings in Redis: %w", err)
+	}
+	return nil
+}
+
+// expirationsKey is a sorted set of short codes scored by expiration date in
+// Unix milliseconds. Mappings stored before it existed are not indexed.
+const expirationsKey = "expirations"
+
+// ExpiringBetween reads the window from the expirations sorted set and
+// fetches the mappings, dropping index entries whose mapping is gone
+func (r *RedisStorage) ExpiringBetween(start, end time.Time) ([]*models.URLMapping, error) {
+	codes, err := r.client.ZRangeByScore(r.ctx, expirationsKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(start.UnixMilli(), 10),
+		Max: "(" + strconv.FormatInt(end.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expiration index from Redis: %w", err)
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	
+	found, err := r.getBatchExact(codes)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]*models.URLMapping, 0, len(found))
+	var stale []interface{}
+	for _, code := range codes {
+		if mapping, ok := found[code]; ok && mapping.ExpirationDate != nil {
+			matches = append(matches, mapping)
+		} else {
+			stale = append(stale, code)
+		}
+	}
+	if len(stale) > 0 {
+		if err := r.client.ZRem(r.ctx, expirationsKey, stale...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to prune expiration index: %w", err)
+		}
+	}
+	
+	sortByExpiration(matches)
+	return matches, nil
+}
+
+// Verify checks every mapping, read via SCAN, for consistency without
+// modifying anything
+func (r *RedisStorage) Verify() ([]string, error) {
+	return verifyMappings(r.Each, r.opts.encodeID)
+}
+
+// scanMappings SCANs url:* on every node and calls visit for each mapping
+// whose code passes keep (nil keeps all). Calls to visit are serialized even
+// though cluster masters are scanned concurrently.
+func (r *RedisStorage) scanMappings(keep func(code string) bool, visit func(*models.URLMapping) error) error {
+	var mu sync.Mutex
+	scanNode := func(ctx context.Context, node redis.UniversalClient) error {
+		iter := node.Scan(ctx, 0, "url:*", scanBatchSize).Iterator()
+		var codes []string
+		flush := func() error {
+			if len(codes) == 0 {
+				return nil
+			}
+			// The url: keys came from this node's SCAN, but each code's uses:
+			// and clicks: keys may hash to other masters. The storage's own
+			// client routes every command to the node owning its slot.
+			pipe := r.client.Pipeline()
+			urlCmds := make([]*redis.StringCmd, len(codes))
+			usesCmds := make([]*redis.StringCmd, len(codes))
+			clicksCmds := make([]*redis.StringCmd, len(codes))
+			for i, code := range codes {
+				urlCmds[i] = pipe.Get(ctx, "url:"+code)
+				usesCmds[i] = pipe.Get(ctx, "uses:"+code)
+				clicksCmds[i] = pipe.Get(ctx, clicksKey(code))
+			}
+			if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+				return err
+			}
+			
+			mu.Lock()
+			defer mu.Unlock()
+			for i, code := range codes {
+				data, err := urlCmds[i].Result()
+				if err != nil {
+					continue // Deleted since the scan saw it
+				}
+				mapping, err := unmarshalMapping([]byte(data))
+				if err != nil {
+					continue
+				}
+				mapping.ShortCode = code
+				if mapping.MaxUses > 0 {
+					mapping.UseCount, _ = usesCmds[i].Int()
+				}
+				mapping.AccessCount, _ = clicksCmds[i].Int64()
+				if err := visit(mapping); err != nil {
+					return err
+				}
+			}
+			codes = codes[:0]
+			return nil
+		}
+		
+		for iter.Next(ctx) {
+			if code := strings.TrimPrefix(iter.Val(), "url:"); keep == nil || keep(code) {
+				codes = append(codes, code)
+			}
+			if len(codes) >= scanBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		return flush()
+	}
+	
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		// SCAN only covers one node, so walk every master
+		return cluster.ForEachMaster(r.ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanNode(ctx, node)
+		})
+	}
+	return scanNode(r.ctx, r.client)
+}
@@ -1,11 +1,21 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 	"tiny-url-service/models"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/redis/go-redis/v9"
 )
 
 func setupMockRedis(t *testing.T, baseURL string) (*RedisStorage, *miniredis.Miniredis) {
@@ -160,6 +170,405 @@ func TestRedisStorage_Expiration(t *testing.T) {
 	}
 }
 
+func TestRedisStorage_GetRaw(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	pastTime := time.Now().Add(-time.Hour)
+	shortCode, err := storage.Store(&models.URLMapping{
+		LongURL:        "https://www.example.com/past",
+		ExpirationDate: &pastTime,
+		MaxUses:        5,
+	})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	mock.Set("uses:"+shortCode, "2")
+
+	if _, err := storage.Get(shortCode); !errors.Is(err, ErrExpired) {
+		t.Errorf("Get() should return ErrExpired, got %v", err)
+	}
+
+	mapping, err := storage.GetRaw(shortCode)
+	if err != nil {
+		t.Fatalf("GetRaw() failed for expired URL: %v", err)
+	}
+	if mapping.UseCount != 2 {
+		t.Errorf("Expected use count 2, got %d", mapping.UseCount)
+	}
+
+	if _, err := storage.GetRaw("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRaw() should return ErrNotFound, got %v", err)
+	}
+}
+
+func TestRedisStorage_Search(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	disabled, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr())
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	if _, err := disabled.Search("example", "", 10); !errors.Is(err, ErrSearchDisabled) {
+		t.Errorf("Expected ErrSearchDisabled without WithScanSearch, got %v", err)
+	}
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithScanSearch(true))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	// More than one SCAN batch so pagination spans batches
+	for i := 0; i < scanBatchSize+20; i++ {
+		longURL := "https://other.org/" + strconv.Itoa(i)
+		if i%2 == 0 {
+			longURL = "https://Example.com/" + strconv.Itoa(i)
+		}
+		if _, err := store.Store(&models.URLMapping{LongURL: longURL}); err != nil {
+			t.Fatalf("Store() failed: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	after := ""
+	for {
+		page, err := store.Search("example.com", after, 100)
+		if err != nil {
+			t.Fatalf("Search() failed: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, mapping := range page {
+			if !strings.Contains(mapping.LongURL, "Example.com") {
+				t.Errorf("Unexpected match %s", mapping.LongURL)
+			}
+			if mapping.ShortCode <= after || seen[mapping.ShortCode] {
+				t.Errorf("Code %s returned out of order or twice", mapping.ShortCode)
+			}
+			seen[mapping.ShortCode] = true
+		}
+		after = page[len(page)-1].ShortCode
+	}
+	if want := (scanBatchSize + 20) / 2; len(seen) != want {
+		t.Errorf("Expected %d matches across pages, got %d", want, len(seen))
+	}
+}
+
+func TestRedisStorage_Each(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr())
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	// More than one SCAN batch so the walk spans pipelines
+	total := scanBatchSize + 20
+	for i := 0; i < total; i++ {
+		if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com/" + strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Store() failed: %v", err)
+		}
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/counted"}, "counted"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	store.RecordAccess("counted", time.Now())
+	store.RecordAccess("counted", time.Now())
+
+	seen := make(map[string]bool)
+	err = store.Each(func(mapping *models.URLMapping) error {
+		if seen[mapping.ShortCode] {
+			t.Errorf("Code %s visited twice", mapping.ShortCode)
+		}
+		seen[mapping.ShortCode] = true
+		if mapping.ShortCode == "counted" && mapping.AccessCount != 2 {
+			t.Errorf("Expected access count 2, got %d", mapping.AccessCount)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each() failed: %v", err)
+	}
+	if len(seen) != total+1 {
+		t.Errorf("Expected %d mappings, got %d", total+1, len(seen))
+	}
+
+	stop := errors.New("stop")
+	visits := 0
+	err = store.Each(func(*models.URLMapping) error {
+		visits++
+		return stop
+	})
+	if err != stop || visits != 1 {
+		t.Errorf("Expected Each to stop with the callback error after one visit, got %v after %d", err, visits)
+	}
+}
+
+func TestRedisStorage_RetriesTransientFailure(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorageFromConfig("http://localhost:8080", RedisConfig{
+		URL:             "redis://" + mock.Addr(),
+		MaxRetries:      10,
+		MinRetryBackoff: 20 * time.Millisecond,
+		MaxRetryBackoff: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	shortCode, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/failover"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// Simulate a failover blip: Redis goes away and comes back on the same address
+	mock.Close()
+	restarted := make(chan struct{})
+	go func() {
+		defer close(restarted)
+		time.Sleep(150 * time.Millisecond)
+		if err := mock.Restart(); err != nil {
+			t.Errorf("Failed to restart miniredis: %v", err)
+		}
+	}()
+
+	mapping, err := store.Get(shortCode)
+	<-restarted
+	if err != nil {
+		t.Fatalf("Get() should succeed once Redis is back, got %v", err)
+	}
+	if mapping.LongURL != "https://www.example.com/failover" {
+		t.Errorf("Expected long URL to survive the blip, got %s", mapping.LongURL)
+	}
+
+	// Definitive misses are not retried: Redis sees the lookup exactly once
+	var lookups atomic.Int32
+	mock.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if strings.EqualFold(cmd, "GET") && len(args) > 0 && args[0] == "url:missing" {
+			lookups.Add(1)
+		}
+		return false
+	})
+	if _, err := store.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("Expected a miss to be looked up once, got %d attempts", n)
+	}
+}
+
+func TestRedisStorage_RetriesDisabled(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorageFromConfig("http://localhost:8080", RedisConfig{
+		URL:        "redis://" + mock.Addr(),
+		MaxRetries: -1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+
+	mock.Close()
+	if _, err := store.Get("1"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a connection error without retries, got %v", err)
+	}
+}
+
+func TestRedisStorage_ExistsBatch(t *testing.T) {
+	store, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+	pastTime := time.Now().Add(-time.Hour)
+	for _, code := range []string{"alpha", "beta", "gamma"} {
+		mapping := &models.URLMapping{LongURL: "https://www.example.com/" + code}
+		if code == "gamma" {
+			mapping.ExpirationDate = &pastTime // Expired codes still count as taken
+		}
+		if err := store.StoreWithCode(mapping, code); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+
+	codes := []string{"alpha", "missing", "gamma", "beta", "nope", "alpha"}
+	exists, err := store.ExistsBatch(codes)
+	if err != nil {
+		t.Fatalf("ExistsBatch() failed: %v", err)
+	}
+
+	expected := map[string]bool{"alpha": true, "beta": true, "gamma": true, "missing": false, "nope": false}
+	if len(exists) != len(expected) {
+		t.Errorf("Expected %d entries, got %d: %v", len(expected), len(exists), exists)
+	}
+	for code, want := range expected {
+		if got, ok := exists[code]; !ok || got != want {
+			t.Errorf("ExistsBatch()[%q] = %v (present %v); expected %v", code, got, ok, want)
+		}
+	}
+
+	if empty, err := store.ExistsBatch(nil); err != nil || len(empty) != 0 {
+		t.Errorf("ExistsBatch(nil) = %v, %v; expected an empty map", empty, err)
+	}
+}
+
+func TestRedisStorage_GetBatch(t *testing.T) {
+	store, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+	pastTime := time.Now().Add(-time.Hour)
+	for _, code := range []string{"alpha", "beta"} {
+		mapping := &models.URLMapping{LongURL: "https://www.example.com/" + code}
+		if code == "beta" {
+			mapping.ExpirationDate = &pastTime // Expired mappings are still returned
+		}
+		if err := store.StoreWithCode(mapping, code); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+	store.RecordAccess("alpha", time.Now())
+
+	mappings, err := store.GetBatch([]string{"alpha", "missing", "beta", "alpha"})
+	if err != nil {
+		t.Fatalf("GetBatch() failed: %v", err)
+	}
+	if len(mappings) != 2 {
+		t.Fatalf("Expected 2 mappings, got %d: %v", len(mappings), mappings)
+	}
+	if m := mappings["alpha"]; m == nil || m.ShortCode != "alpha" || m.LongURL != "https://www.example.com/alpha" || m.AccessCount != 1 {
+		t.Errorf("Unexpected mapping for alpha: %+v", m)
+	}
+	if m := mappings["beta"]; m == nil || !store.IsExpired(m) {
+		t.Errorf("Expected the expired beta mapping, got %+v", m)
+	}
+
+	if empty, err := store.GetBatch(nil); err != nil || len(empty) != 0 {
+		t.Errorf("GetBatch(nil) = %v, %v; expected an empty map", empty, err)
+	}
+}
+
+func TestRedisStorage_ReverseIndex(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithReverseIndex(SHA256Truncated(16)))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+
+	shortCode, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/page"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	futureTime := time.Now().Add(time.Hour)
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/expiring", ExpirationDate: &futureTime}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// Lookups match on the normalized URL
+	found, err := store.FindByLongURL("HTTPS://WWW.example.com:443/page")
+	if err != nil {
+		t.Fatalf("FindByLongURL() failed: %v", err)
+	}
+	if found.ShortCode != shortCode {
+		t.Errorf("Expected short code %s, got %s", shortCode, found.ShortCode)
+	}
+
+	// Conditional links are not indexed
+	if _, err := store.FindByLongURL("https://www.example.com/expiring"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an expiring link, got %v", err)
+	}
+
+	// The index key is a hash, never the URL itself (domain counters name
+	// only the host, by design)
+	for _, key := range mock.Keys() {
+		if strings.Contains(key, "example.com") && !strings.HasPrefix(key, "domain:") {
+			t.Errorf("Expected no key containing the long URL, found %s", key)
+		}
+	}
+
+	// Deleting the link removes it from the index
+	if err := store.Delete(shortCode); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.FindByLongURL("https://www.example.com/page"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	// A hash collision is a miss, not a wrong hit
+	colliding, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithReverseIndex(func(string) string { return "same" }))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	if _, err := colliding.Store(&models.URLMapping{LongURL: "https://www.example.com/first"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if _, err := colliding.FindByLongURL("https://www.example.com/second"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on a hash collision, got %v", err)
+	}
+}
+
+func TestRedisStorage_OwnerReverseIndex(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithReverseIndex(SHA256Truncated(16)))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+
+	acme, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/page", Owner: "acme"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	beta, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/page", Owner: "beta"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	for owner, expected := range map[string]string{"acme": acme, "beta": beta} {
+		found, err := store.FindByOwnerLongURL(owner, "https://WWW.example.com/page")
+		if err != nil {
+			t.Fatalf("FindByOwnerLongURL(%s) failed: %v", owner, err)
+		}
+		if found.ShortCode != expected {
+			t.Errorf("FindByOwnerLongURL(%s) = %s, expected %s", owner, found.ShortCode, expected)
+		}
+	}
+	if _, err := store.FindByOwnerLongURL("gamma", "https://www.example.com/page"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an owner without the URL, got %v", err)
+	}
+
+	if err := store.Delete(acme); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.FindByOwnerLongURL("acme", "https://www.example.com/page"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	for _, key := range mock.Keys() {
+		if strings.HasPrefix(key, "ownerurl:") {
+			if code, _ := mock.Get(key); code == acme {
+				t.Errorf("Expected the deleted link's owner index entry to be gone, found %s", key)
+			}
+		}
+	}
+}
+
 func TestRedisStorage_GetStats(t *testing.T) {
 	storage, mock := setupMockRedis(t, "http://localhost:8080")
 	defer mock.Close()
@@ -451,4 +860,1333 @@ func TestRedisStorage_StoreExpiredURL(t *testing.T) {
 	if err.Error() != expectedError {
 		t.Errorf("Expected error '%s', got '%s'", expectedError, err.Error())
 	}
-} 
\ No newline at end of file
+}
+
+func TestRedisStorage_ReserveAndClaim(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, token, err := storage.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+
+	if _, err := storage.Get(code); err == nil {
+		t.Error("Get() should fail for an unclaimed reservation")
+	}
+
+	if err := storage.ClaimReservation(token, "https://www.example.com/reserved"); err != nil {
+		t.Fatalf("ClaimReservation() failed: %v", err)
+	}
+
+	retrieved, err := storage.Get(code)
+	if err != nil {
+		t.Fatalf("Get() failed after claim: %v", err)
+	}
+	if retrieved.LongURL != "https://www.example.com/reserved" {
+		t.Errorf("Get() returned LongURL %s, expected https://www.example.com/reserved", retrieved.LongURL)
+	}
+
+	if err := storage.ClaimReservation(token, "https://other.com"); err != ErrReservationNotFound {
+		t.Errorf("Second ClaimReservation() should return ErrReservationNotFound, got %v", err)
+	}
+	if mock.Exists(reservedKey(code)) || mock.Exists("reservation:"+token) {
+		t.Error("Claiming should release the reservation keys")
+	}
+
+	// The reservation marker must share url:<code>'s cluster slot for the
+	// claim and StoreWithCode to check it atomically
+	if clusterSlot(reservedKey(code)) != clusterSlot("url:"+code) {
+		t.Errorf("%s and url:%s hash to different cluster slots", reservedKey(code), code)
+	}
+}
+
+func TestRedisStorage_ConcurrentClaims(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, token, err := storage.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+
+	// Only one claimer may win, and nobody else may take the code meanwhile
+	var wg sync.WaitGroup
+	var claimed, stored atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if storage.ClaimReservation(token, fmt.Sprintf("https://www.example.com/%d", i)) == nil {
+				claimed.Add(1)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if storage.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/custom"}, code) == nil {
+				stored.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if claimed.Load() != 1 || stored.Load() != 0 {
+		t.Errorf("Expected exactly one claim and no custom store, got %d claims and %d stores", claimed.Load(), stored.Load())
+	}
+	if count, _ := mock.Get("url_count"); count != "1" {
+		t.Errorf("Expected url_count 1, got %s", count)
+	}
+}
+
+func TestRedisStorage_ReservationExpires(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	_, token, err := storage.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+
+	// Advance miniredis time past the reservation TTL
+	mock.FastForward(DefaultReservationTTL + time.Second)
+
+	err = storage.ClaimReservation(token, "https://www.example.com")
+	if err != ErrReservationNotFound {
+		t.Errorf("Expired reservation should return ErrReservationNotFound, got %v", err)
+	}
+}
+
+func TestRedisStorage_PasswordHashPersisted(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	mapping := &models.URLMapping{
+		LongURL:      "https://www.example.com/protected",
+		PasswordHash: "$2a$10$abcdefghijklmnopqrstuv",
+	}
+	shortCode, err := storage.Store(mapping)
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	retrieved, err := storage.Get(shortCode)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if retrieved.PasswordHash != mapping.PasswordHash {
+		t.Errorf("PasswordHash mismatch: got %q, expected %q", retrieved.PasswordHash, mapping.PasswordHash)
+	}
+}
+
+func TestRedisStorage_ConsumeUse(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/once", MaxUses: 1})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	remaining, err := storage.ConsumeUse(code)
+	if err != nil || remaining != 0 {
+		t.Fatalf("ConsumeUse() = (%d, %v), expected (0, nil)", remaining, err)
+	}
+
+	if _, err := storage.ConsumeUse(code); err != ErrUsesExhausted {
+		t.Errorf("ConsumeUse() past the limit should return ErrUsesExhausted, got %v", err)
+	}
+
+	retrieved, err := storage.Get(code)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if retrieved.UseCount < 1 {
+		t.Errorf("Get() should report the consumed uses, got %d", retrieved.UseCount)
+	}
+}
+
+func TestRedisStorage_AccessSeries(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	now := time.Now()
+	for _, at := range []time.Time{now.Add(-2 * time.Hour), now, now} {
+		if err := storage.RecordAccess(code, at); err != nil {
+			t.Fatalf("RecordAccess() failed: %v", err)
+		}
+	}
+
+	hourKey := "clicks:" + code + ":" + now.UTC().Format("2006010215")
+	if ttl := mock.TTL(hourKey); ttl <= 0 || ttl > DefaultClickRetention {
+		t.Errorf("Hourly key %s should expire within the retention, TTL = %v", hourKey, ttl)
+	}
+
+	series, err := storage.AccessSeries(code, time.Hour, now.Add(-3*time.Hour))
+	if err != nil {
+		t.Fatalf("AccessSeries() failed: %v", err)
+	}
+	if len(series) != 4 || series[1].Count != 1 || series[3].Count != 2 {
+		t.Errorf("Unexpected hourly series: %+v", series)
+	}
+
+	mapping, err := storage.Get(code)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if mapping.AccessCount != 3 {
+		t.Errorf("Expected access count 3, got %d", mapping.AccessCount)
+	}
+
+	if _, err := storage.AccessSeries("missing", time.Hour, now); err == nil {
+		t.Error("AccessSeries() on a missing code should fail")
+	}
+}
+
+func TestRedisStorage_LabeledClicks(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	for _, label := range []string{"a", "b", "a"} {
+		if err := storage.RecordLabeledClick(code, label); err != nil {
+			t.Fatalf("RecordLabeledClick() failed: %v", err)
+		}
+	}
+
+	counts, err := storage.LabeledClicks(code)
+	if err != nil {
+		t.Fatalf("LabeledClicks() failed: %v", err)
+	}
+	if counts["a"] != 2 || counts["b"] != 1 {
+		t.Errorf("Unexpected labeled clicks: %v", counts)
+	}
+}
+
+func TestRedisStorage_UniqueVisitors(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	for _, ip := range []string{"203.0.113.7", "203.0.113.7", "198.51.100.1", "203.0.113.7"} {
+		if err := storage.RecordVisitor(code, ip); err != nil {
+			t.Fatalf("RecordVisitor() failed: %v", err)
+		}
+	}
+
+	n, err := storage.UniqueVisitors(code)
+	if err != nil {
+		t.Fatalf("UniqueVisitors() failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 unique visitors, got %d", n)
+	}
+
+	if err := storage.Delete(code); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if mock.Exists("visitors:" + code) {
+		t.Error("Delete() should remove the visitors key")
+	}
+}
+
+func TestRedisStorage_ResetAccessCount(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	for i := 0; i < 3; i++ {
+		storage.RecordAccess(code, time.Now())
+	}
+
+	if err := storage.ResetAccessCount(code); err != nil {
+		t.Fatalf("ResetAccessCount() failed: %v", err)
+	}
+	mapping, _ := storage.Get(code)
+	if mapping.AccessCount != 0 {
+		t.Errorf("Expected access count 0 after reset, got %d", mapping.AccessCount)
+	}
+
+	storage.RecordAccess(code, time.Now())
+	mapping, _ = storage.Get(code)
+	if mapping.AccessCount != 1 {
+		t.Errorf("Expected counting to resume after reset, got %d", mapping.AccessCount)
+	}
+
+	if err := storage.ResetAccessCount("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResetAccessCount() should return ErrNotFound, got %v", err)
+	}
+}
+
+func TestRedisStorage_MaxURLs(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	storage, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithMaxURLs(1))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+
+	if _, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/1"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if _, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/2"}); err != ErrCapacityExceeded {
+		t.Errorf("Store() past capacity should return ErrCapacityExceeded, got %v", err)
+	}
+}
+
+func TestRedisStorage_SeedsURLCount(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	// Links written before url_count was maintained
+	for _, code := range []string{"a", "b", "c"} {
+		mock.Set("url:"+code, `{"long_url":"https://www.example.com/`+code+`"}`)
+	}
+
+	storage, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithMaxURLs(4))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	if stats := storage.GetStats(); stats["total_urls"] != int64(3) {
+		t.Errorf("Expected the existing links to be counted, got %v", stats["total_urls"])
+	}
+	if _, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/d"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if _, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/e"}); err != ErrCapacityExceeded {
+		t.Errorf("Store() past capacity should return ErrCapacityExceeded, got %v", err)
+	}
+
+	// A second instance keeps the count instead of seeding it again
+	if _, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr()); err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	if count, _ := mock.Get("url_count"); count != "4" {
+		t.Errorf("Expected url_count 4, got %s", count)
+	}
+}
+
+func TestRedisStorage_StoreWithCode(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	if err := storage.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/vanity"}, "2"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	if err := storage.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/other"}, "2"); !errors.Is(err, ErrCodeTaken) {
+		t.Errorf("StoreWithCode() on a taken code should return ErrCodeTaken, got %v", err)
+	}
+
+	// Generated codes skip the custom code
+	first, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/1"})
+	second, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/2"})
+	if first != "1" || second != "3" {
+		t.Errorf("Expected generated codes 1 and 3, got %s and %s", first, second)
+	}
+
+	// Reserved codes cannot be taken as custom codes
+	code, _, err := storage.Reserve()
+	if err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+	if err := storage.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, code); !errors.Is(err, ErrCodeTaken) {
+		t.Errorf("StoreWithCode() on a reserved code should return ErrCodeTaken, got %v", err)
+	}
+
+	if exists, _ := storage.Exists("2"); !exists {
+		t.Error("Exists() should report the custom code")
+	}
+	if count, _ := mock.Get("url_count"); count != "3" {
+		t.Errorf("Expected url_count 3, got %s", count)
+	}
+}
+
+func TestRedisStorage_ReservedWords(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithReservedWords("admin", "login"))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, "Admin"); !errors.Is(err, ErrCodeReserved) {
+		t.Errorf("StoreWithCode() of a reserved word should return ErrCodeReserved, got %v", err)
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, "mylink"); err != nil {
+		t.Errorf("StoreWithCode() of a normal code failed: %v", err)
+	}
+	if stats := store.GetStats(); stats["total_urls"] != int64(1) {
+		t.Errorf("Expected only the normal code to be stored, got %v", stats["total_urls"])
+	}
+}
+
+func TestRedisStorage_CaseInsensitiveCodes(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithCaseInsensitiveCodes(true))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com"}, "MyLink"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	for _, code := range []string{"mylink", "MYLINK"} {
+		if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/other"}, code); !errors.Is(err, ErrCodeTaken) {
+			t.Errorf("StoreWithCode(%q) should collide with MyLink, got %v", code, err)
+		}
+	}
+
+	mapping, err := store.GetRaw("mYlInK")
+	if err != nil {
+		t.Fatalf("GetRaw() should resolve regardless of case: %v", err)
+	}
+	if mapping.ShortCode != "mylink" || mapping.PublicCode() != "MyLink" {
+		t.Errorf("Expected key mylink displayed as MyLink, got %q / %q", mapping.ShortCode, mapping.PublicCode())
+	}
+	if exists, _ := store.Exists("MYLINK"); !exists {
+		t.Error("Exists() should resolve regardless of case")
+	}
+	if batch, _ := store.GetBatch([]string{"MYLINK"}); batch["MYLINK"] == nil {
+		t.Error("GetBatch() should resolve regardless of case")
+	}
+
+	// Generated codes are mixed-case and still resolve exactly
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com/generated"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if mapping, err := store.GetRaw(code); err != nil || mapping.DisplayCode != "" {
+		t.Errorf("GetRaw(%q) = %+v, %v", code, mapping, err)
+	}
+}
+
+func TestRedisStorage_ExpiringBetween(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr())
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	now := time.Now()
+	expiries := map[string]time.Duration{"later": 2 * time.Hour, "soon": time.Hour, "far": 48 * time.Hour, "past": -time.Hour}
+	for code, offset := range expiries {
+		exp := now.Add(offset)
+		if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/" + code, ExpirationDate: &exp}, code); err != nil {
+			t.Fatalf("StoreWithCode(%q) failed: %v", code, err)
+		}
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/forever"}, "forever"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+
+	mappings, err := store.ExpiringBetween(now, now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ExpiringBetween() failed: %v", err)
+	}
+	if len(mappings) != 2 || mappings[0].ShortCode != "soon" || mappings[1].ShortCode != "later" {
+		t.Fatalf("Expected [soon later], got %+v", mappings)
+	}
+
+	// Deleted mappings leave the index
+	if err := store.Delete("soon"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	mappings, _ = store.ExpiringBetween(now, now.Add(24*time.Hour))
+	if len(mappings) != 1 || mappings[0].ShortCode != "later" {
+		t.Errorf("Expected [later] after deleting soon, got %+v", mappings)
+	}
+}
+
+func TestRedisStorage_MaxID(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithMaxID(100))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	mock.Set("counter", "99")
+
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com"}); err != nil {
+		t.Fatalf("Store() of the last ID failed: %v", err)
+	}
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com"}); !errors.Is(err, ErrIDSpaceExhausted) {
+		t.Errorf("Expected ErrIDSpaceExhausted past the max ID, got %v", err)
+	}
+	if _, _, err := store.Reserve(); !errors.Is(err, ErrIDSpaceExhausted) {
+		t.Errorf("Expected Reserve() to fail with ErrIDSpaceExhausted, got %v", err)
+	}
+}
+
+func TestRedisStorage_MaxGeneratedCodeLength(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithMaxGeneratedCodeLength(2))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	mock.Set("counter", "3842")
+
+	if code, err := store.Store(&models.URLMapping{LongURL: "https://example.com"}); err != nil || code != "ZZ" {
+		t.Fatalf("Expected the last two-character code ZZ, got %q, %v", code, err)
+	}
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://example.com"}); !errors.Is(err, ErrKeyspaceExhausted) {
+		t.Errorf("Expected ErrKeyspaceExhausted past the maximum length, got %v", err)
+	}
+	if _, _, err := store.Reserve(); !errors.Is(err, ErrKeyspaceExhausted) {
+		t.Errorf("Expected Reserve() to fail with ErrKeyspaceExhausted, got %v", err)
+	}
+}
+
+func TestRedisStorage_Verify(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr())
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	store.Store(&models.URLMapping{LongURL: "https://example.com"})
+
+	issues, err := store.Verify()
+	if err != nil || len(issues) != 0 {
+		t.Fatalf("Expected a consistent store, got %v, %v", issues, err)
+	}
+
+	mock.Set("url:x", `{"id":9,"short_code":"x","long_url":"not-a-url"}`)
+	issues, err = store.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if len(issues) != 2 || !strings.Contains(issues[0], "invalid long URL") || !strings.Contains(issues[1], "stored ID 9") {
+		t.Errorf("Expected invalid URL and ID mismatch issues, got %q", issues)
+	}
+	if !mock.Exists("url:x") {
+		t.Error("Verify() must not modify the store")
+	}
+}
+
+func TestRedisStorage_Delete(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	storage.RecordAccess(code, time.Now())
+
+	if err := storage.Delete(code); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := storage.Get(code); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() should return ErrNotFound, got %v", err)
+	}
+	if mock.Exists("clicks:" + code) {
+		t.Error("Delete() should remove the access counter")
+	}
+	if err := storage.Delete(code); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Second Delete() should return ErrNotFound, got %v", err)
+	}
+	if count, _ := mock.Get("url_count"); count != "0" {
+		t.Errorf("Expected url_count 0, got %s", count)
+	}
+}
+
+func TestRedisStorage_SizeStats(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithSizeStats(true))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+
+	short := "https://example.com/" + strings.Repeat("a", 10) // 30 bytes
+	for i := 0; i < 20; i++ {
+		if _, err := store.Store(&models.URLMapping{LongURL: short}); err != nil {
+			t.Fatalf("Store() failed: %v", err)
+		}
+	}
+	long := "https://example.com/" + strings.Repeat("b", 280) // 300 bytes
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: long}, "long"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+
+	sizes, ok := store.GetStats()["url_size"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected url_size in stats")
+	}
+	if sizes["count"] != int64(21) {
+		t.Errorf("Expected count 21, got %v", sizes["count"])
+	}
+	if sizes["total_bytes"] != int64(20*30+300) {
+		t.Errorf("Expected total_bytes %d, got %v", 20*30+300, sizes["total_bytes"])
+	}
+	// Estimated as the upper bound of the bucket holding the p95
+	if sizes["p95_length"] != 32 {
+		t.Errorf("Expected p95_length 32, got %v", sizes["p95_length"])
+	}
+
+	if err := store.Delete("long"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := store.Delete("long"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+	sizes = store.GetStats()["url_size"].(map[string]interface{})
+	if sizes["count"] != int64(20) || sizes["total_bytes"] != int64(20*30) {
+		t.Errorf("Expected count 20 and total_bytes %d after delete, got %v and %v", 20*30, sizes["count"], sizes["total_bytes"])
+	}
+	if distribution := sizes["distribution"].(map[string]int64); distribution["257-512"] != 0 {
+		t.Errorf("Expected empty 257-512 bucket after delete, got %d", distribution["257-512"])
+	}
+}
+
+func TestRedisStorage_StoreWithCodeConcurrent(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	// Separate clients stand in for separate service instances
+	const instances = 10
+	stores := make([]*RedisStorage, instances)
+	for i := range stores {
+		stores[i], err = NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr())
+		if err != nil {
+			t.Fatalf("Failed to create Redis storage: %v", err)
+		}
+	}
+
+	results := make(chan error, instances)
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		wg.Add(1)
+		go func(store *RedisStorage) {
+			defer wg.Done()
+			results <- store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com/contender"}, "vanity")
+		}(store)
+	}
+	wg.Wait()
+	close(results)
+
+	successes := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			successes++
+		case !errors.Is(err, ErrCodeTaken):
+			t.Errorf("StoreWithCode() unexpected error: %v", err)
+		}
+	}
+	if successes != 1 {
+		t.Errorf("Expected exactly 1 successful create, got %d", successes)
+	}
+	if count, _ := mock.Get("url_count"); count != "1" {
+		t.Errorf("Expected url_count 1, got %s", count)
+	}
+}
+
+// startMockSentinel runs a miniredis that answers the SENTINEL commands
+// go-redis issues, pointing clients at master
+func startMockSentinel(t *testing.T, masterName string, master *miniredis.Miniredis) *miniredis.Miniredis {
+	sentinel, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start mock sentinel: %v", err)
+	}
+
+	sentinel.Server().Register("SENTINEL", func(c *server.Peer, cmd string, args []string) {
+		if len(args) == 0 {
+			c.WriteError("ERR wrong number of arguments for 'sentinel' command")
+			return
+		}
+		switch strings.ToLower(args[0]) {
+		case "get-master-addr-by-name":
+			if len(args) < 2 || args[1] != masterName {
+				c.WriteNull()
+				return
+			}
+			c.WriteLen(2)
+			c.WriteBulk(master.Host())
+			c.WriteBulk(master.Port())
+		case "sentinels", "replicas", "slaves":
+			c.WriteLen(0)
+		default:
+			c.WriteError("ERR unknown sentinel subcommand")
+		}
+	})
+
+	return sentinel
+}
+
+func TestRedisStorage_SentinelMode(t *testing.T) {
+	master, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer master.Close()
+
+	sentinel := startMockSentinel(t, "mymaster", master)
+	defer sentinel.Close()
+
+	storage, err := NewRedisStorageFromConfig("http://localhost:8080", RedisConfig{
+		Mode:       "sentinel",
+		Addrs:      []string{sentinel.Addr()},
+		MasterName: "mymaster",
+	})
+	if err != nil {
+		t.Fatalf("NewRedisStorageFromConfig() failed in sentinel mode: %v", err)
+	}
+	defer storage.Close()
+
+	shortCode, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/sentinel"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	// The mapping must land on the master the sentinel pointed us at
+	if !master.Exists("url:" + shortCode) {
+		t.Error("Expected mapping to be written to the sentinel-resolved master")
+	}
+	if stats := storage.GetStats(); stats["total_urls"] != int64(1) {
+		t.Errorf("total_urls should be 1, got %v", stats["total_urls"])
+	}
+}
+
+func TestRedisStorage_InvalidModeConfig(t *testing.T) {
+	configs := []RedisConfig{
+		{Mode: "sentinel"},                         // Missing master name and addresses
+		{Mode: "cluster"},                          // Missing node addresses
+		{Mode: "bogus", Addrs: []string{"x:6379"}}, // Unknown mode
+	}
+
+	for _, rc := range configs {
+		if _, err := NewRedisStorageFromConfig("http://localhost:8080", rc); err == nil {
+			t.Errorf("NewRedisStorageFromConfig(%+v) should fail", rc)
+		}
+	}
+}
+
+func TestRedisStorage_ClickFlushInterval(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithClickFlushInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := store.RecordAccess(code, now); err != nil {
+			t.Fatalf("RecordAccess() failed: %v", err)
+		}
+	}
+	if mock.Exists("clicks:" + code) {
+		t.Error("Buffered clicks should not be written before the flush interval")
+	}
+
+	// The background flush eventually writes the buffered increments
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if count, _ := mock.Get("clicks:" + code); count == "5" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Buffered clicks were never flushed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	hourKey := "clicks:" + code + ":" + now.UTC().Format("2006010215")
+	if count, _ := mock.Get(hourKey); count != "5" {
+		t.Errorf("Expected 5 clicks in the hourly bucket, got %q", count)
+	}
+	if ttl := mock.TTL(hourKey); ttl <= 0 {
+		t.Errorf("Flushed hourly key should expire, TTL = %v", ttl)
+	}
+
+	// Close writes whatever is still buffered
+	if err := store.RecordAccess(code, now); err != nil {
+		t.Fatalf("RecordAccess() failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if count, _ := mock.Get("clicks:" + code); count != "6" {
+		t.Errorf("Close() should flush buffered clicks, got %q", count)
+	}
+}
+
+func TestRedisStorage_ClickBufferDroppedOnReset(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithClickFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if err := store.RecordAccess(code, time.Now()); err != nil {
+		t.Fatalf("RecordAccess() failed: %v", err)
+	}
+	if err := store.ResetAccessCount(code); err != nil {
+		t.Fatalf("ResetAccessCount() failed: %v", err)
+	}
+	if err := store.FlushClicks(); err != nil {
+		t.Fatalf("FlushClicks() failed: %v", err)
+	}
+	if mock.Exists("clicks:" + code) {
+		t.Error("A reset should discard clicks still buffered")
+	}
+	store.Close()
+}
+
+func TestRedisStorage_DrainFlushesBufferedClicks(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithClickFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	code, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := store.RecordAccess(code, time.Now()); err != nil {
+			t.Fatalf("RecordAccess() failed: %v", err)
+		}
+	}
+	if mock.Exists("clicks:" + code) {
+		t.Fatal("Buffered clicks should not be written before the flush interval")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := store.Drain(ctx); err != nil {
+		t.Fatalf("Drain() failed: %v", err)
+	}
+	if count, _ := mock.Get("clicks:" + code); count != "3" {
+		t.Errorf("Drain() should write the buffered clicks, got %q", count)
+	}
+	if err := store.client.Ping(context.Background()).Err(); err == nil {
+		t.Error("Drain() should close the connection")
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Close() after Drain() should return Drain's result, got %v", err)
+	}
+}
+
+func TestRedisStorage_DrainWaitsForCommands(t *testing.T) {
+	store, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	// A command still in progress holds the drain until it completes
+	store.commands.begin()
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(done)
+		store.commands.end()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := store.Drain(ctx); err != nil {
+		t.Fatalf("Drain() failed: %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Error("Drain() returned before the command in progress completed")
+	}
+}
+
+func TestRedisStorage_DrainDeadline(t *testing.T) {
+	store, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	store.commands.begin()
+	defer store.commands.end()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := store.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Drain() to give up at the deadline, got %v", err)
+	}
+	if err := store.client.Ping(context.Background()).Err(); err == nil {
+		t.Error("Drain() should close the connection even past its deadline")
+	}
+}
+
+func TestRedisStorage_CleanupLock(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+
+	// Two instances sharing one Redis
+	first, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr())
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+	second, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr())
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+
+	release, ok, err := first.TryAcquireCleanupLock(time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("First instance should acquire the lock, got %v %v", ok, err)
+	}
+	if _, ok, err := second.TryAcquireCleanupLock(time.Minute); err != nil || ok {
+		t.Fatalf("Second instance should not acquire a held lock, got %v %v", ok, err)
+	}
+	if ttl := mock.TTL(cleanupLockKey); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Lock should be a lease expiring within its TTL, got %v", ttl)
+	}
+
+	release()
+	release()
+	if mock.Exists(cleanupLockKey) {
+		t.Error("release() should delete the lease")
+	}
+	release, ok, err = second.TryAcquireCleanupLock(time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Second instance should acquire a released lock, got %v %v", ok, err)
+	}
+	release()
+
+	// A lease that expired and was taken over is left to its new holder
+	release, ok, err = first.TryAcquireCleanupLock(time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("First instance should acquire a released lock, got %v %v", ok, err)
+	}
+	mock.Set(cleanupLockKey, "other-holder")
+	release()
+	if holder, _ := mock.Get(cleanupLockKey); holder != "other-holder" {
+		t.Errorf("release() should leave another holder's lease alone, got %q", holder)
+	}
+}
+
+func TestRedisStorage_CleanupLockRenewal(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	const ttl = 90 * time.Millisecond
+	release, ok, err := storage.TryAcquireCleanupLock(ttl)
+	if err != nil || !ok {
+		t.Fatalf("TryAcquireCleanupLock() failed: %v %v", ok, err)
+	}
+
+	// Without renewal the lease would be gone after 100ms of Redis time
+	mock.FastForward(50 * time.Millisecond)
+	time.Sleep(ttl / 2)
+	mock.FastForward(50 * time.Millisecond)
+	if !mock.Exists(cleanupLockKey) {
+		t.Error("Lease should be renewed while held")
+	}
+
+	// A lease taken over by someone else is never deleted by the old holder
+	mock.Set(cleanupLockKey, "other-instance")
+	release()
+	if value, _ := mock.Get(cleanupLockKey); value != "other-instance" {
+		t.Errorf("release() removed a lease it no longer held, got %q", value)
+	}
+}
+
+func TestRedisStorage_PurgeExpired(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	expired, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/old", ExpirationDate: &past})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	live, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/new", ExpirationDate: &future})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	purged, err := storage.PurgeExpired()
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeExpired() = %d, %v; want 1", purged, err)
+	}
+	if mock.Exists("url:" + expired) {
+		t.Error("Expired mapping should be deleted")
+	}
+	if !mock.Exists("url:" + live) {
+		t.Error("Live mapping should be kept")
+	}
+}
+
+func TestRedisStorage_SetCounterFloorNeverLowers(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	// Values past 2^53 must compare exactly
+	floor := uint64(1<<53 + 1)
+	if err := storage.SetCounterFloor(floor); err != nil {
+		t.Fatalf("SetCounterFloor() failed: %v", err)
+	}
+	if err := storage.SetCounterFloor(floor - 1); err != nil {
+		t.Fatalf("SetCounterFloor() failed: %v", err)
+	}
+	if got, _ := mock.Get("counter"); got != strconv.FormatUint(floor, 10) {
+		t.Errorf("Expected counter %d, got %s", floor, got)
+	}
+
+	mapping := &models.URLMapping{LongURL: "https://www.example.com"}
+	if _, err := storage.Store(mapping); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	if mapping.ID != floor+1 {
+		t.Errorf("Expected ID %d, got %d", floor+1, mapping.ID)
+	}
+
+	if err := storage.SetCounterFloor(1 << 63); !errors.Is(err, ErrCounterOutOfRange) {
+		t.Errorf("Expected ErrCounterOutOfRange past the Redis limit, got %v", err)
+	}
+}
+
+func TestRedisStorage_AccessEventsKeepLatest(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	code, err := storage.Store(&models.URLMapping{LongURL: "https://www.example.com"})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	for i := 0; i < MaxAccessEvents+5; i++ {
+		event := models.AccessEvent{Time: time.Unix(int64(i), 0).UTC(), Referrer: strconv.Itoa(i)}
+		if err := storage.RecordAccessEvent(code, event); err != nil {
+			t.Fatalf("RecordAccessEvent() failed: %v", err)
+		}
+	}
+
+	events, err := storage.RecentAccessEvents(code, 3)
+	if err != nil {
+		t.Fatalf("RecentAccessEvents() failed: %v", err)
+	}
+	if len(events) != 3 || events[0].Referrer != strconv.Itoa(MaxAccessEvents+4) {
+		t.Errorf("Expected the 3 newest events, got %+v", events)
+	}
+	if n, _ := mock.List(eventsKey(code)); len(n) != MaxAccessEvents {
+		t.Errorf("Expected the list trimmed to %d events, got %d", MaxAccessEvents, len(n))
+	}
+
+	if err := storage.Delete(code); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if mock.Exists(eventsKey(code)) {
+		t.Error("Expected Delete to remove the events list")
+	}
+}
+
+func TestRedisStorage_Campaigns(t *testing.T) {
+	storage, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	if err := storage.CreateCampaign(&models.Campaign{ID: "spring", Name: "Spring sale"}); err != nil {
+		t.Fatalf("CreateCampaign() failed: %v", err)
+	}
+	if err := storage.CreateCampaign(&models.Campaign{ID: "spring"}); !errors.Is(err, ErrCampaignExists) {
+		t.Errorf("Expected ErrCampaignExists for a taken ID, got %v", err)
+	}
+	if campaign, err := storage.GetCampaign("spring"); err != nil || campaign.Name != "Spring sale" {
+		t.Fatalf("GetCampaign() = %v, %v", campaign, err)
+	}
+
+	kept, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/kept", Campaign: "spring"})
+	deleted, _ := storage.Store(&models.URLMapping{LongURL: "https://www.example.com/deleted", Campaign: "spring"})
+	if err := storage.Delete(deleted); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	members, err := storage.CampaignURLs("spring")
+	if err != nil {
+		t.Fatalf("CampaignURLs() failed: %v", err)
+	}
+	if len(members) != 1 || members[0].ShortCode != kept {
+		t.Errorf("Expected only %s to be listed, got %d mappings", kept, len(members))
+	}
+
+	// The deleted code is pruned from the member set while listing
+	if isMember, _ := mock.SIsMember("campaign_urls:spring", deleted); isMember {
+		t.Errorf("Expected %s to be pruned from the member set", deleted)
+	}
+	if _, err := storage.CampaignURLs("autumn"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound listing an unknown campaign, got %v", err)
+	}
+}
+
+func TestRedisStorage_ClockSkewTolerance(t *testing.T) {
+	mock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mock.Close()
+	store, err := NewRedisStorage("http://localhost:8080", "redis://"+mock.Addr(), WithClockSkewTolerance(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create Redis storage: %v", err)
+	}
+
+	justExpired := time.Now().Add(-time.Second)
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com", ExpirationDate: &justExpired}, "edge"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	if _, err := store.Get("edge"); err != nil {
+		t.Errorf("Expected the link to resolve within the tolerance, got %v", err)
+	}
+	if purged, err := store.PurgeExpired(); err != nil || purged != 0 {
+		t.Errorf("PurgeExpired() = (%d, %v), expected nothing purged within the tolerance", purged, err)
+	}
+
+	longExpired := time.Now().Add(-2 * time.Minute)
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://www.example.com", ExpirationDate: &longExpired}, "gone"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	if _, err := store.Get("gone"); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected ErrExpired past the tolerance, got %v", err)
+	}
+}
+
+func TestRedisStorage_CountByDomain(t *testing.T) {
+	store, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	for _, longURL := range []string{
+		"https://example.com/a",
+		"https://EXAMPLE.com:8443/b",
+		"https://spam.example/1",
+		"https://spam.example/2",
+	} {
+		if _, err := store.Store(&models.URLMapping{LongURL: longURL}); err != nil {
+			t.Fatalf("Store(%q) failed: %v", longURL, err)
+		}
+	}
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://spam.example/3"}, "spam3"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	gone, _ := store.Store(&models.URLMapping{LongURL: "https://gone.example"})
+	if err := store.Delete(gone); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	counts, err := store.CountByDomain()
+	if err != nil {
+		t.Fatalf("CountByDomain() failed: %v", err)
+	}
+	expected := map[string]int{"example.com": 2, "spam.example": 3}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+
+	// The counters are maintained on write, not recomputed
+	if value, _ := mock.Get("domain:spam.example"); value != "3" {
+		t.Errorf("Expected domain:spam.example to be 3, got %q", value)
+	}
+}
+
+func TestRedisStorage_GetAndExtend(t *testing.T) {
+	store, mock := setupMockRedis(t, "http://localhost:8080")
+	defer mock.Close()
+
+	soon := time.Now().Add(time.Minute)
+	code, err := store.Store(&models.URLMapping{LongURL: "https://example.com/sliding", ExpirationDate: &soon})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	extended, err := store.GetAndExtend(code, time.Hour)
+	if err != nil {
+		t.Fatalf("GetAndExtend() failed: %v", err)
+	}
+	if extended.ExpirationDate.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("Expected the expiration an hour out, got %v", extended.ExpirationDate)
+	}
+	if stored, _ := store.Get(code); !stored.ExpirationDate.Equal(*extended.ExpirationDate) {
+		t.Errorf("Expected the extension to be stored, got %v", stored.ExpirationDate)
+	}
+
+	// The expiration index follows, so the link is no longer due soon
+	due, err := store.ExpiringBetween(time.Now(), time.Now().Add(30*time.Minute))
+	if err != nil || len(due) != 0 {
+		t.Errorf("Expected nothing expiring in 30 minutes, got %d mappings (%v)", len(due), err)
+	}
+	later, _ := store.ExpiringBetween(time.Now(), time.Now().Add(2*time.Hour))
+	if len(later) != 1 || later[0].ShortCode != code {
+		t.Errorf("Expected %s to expire within 2 hours, got %v", code, later)
+	}
+
+	again, err := store.GetAndExtend(code, time.Minute)
+	if err != nil || !again.ExpirationDate.Equal(*extended.ExpirationDate) {
+		t.Errorf("Expected the expiration kept at %v, got %v (%v)", extended.ExpirationDate, again.ExpirationDate, err)
+	}
+
+	if _, err := store.GetAndExtend("missing", time.Hour); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// clusterSlot mirrors Redis Cluster key hashing: CRC16 (XMODEM) of the key,
+// or of its {hash tag} when present, modulo 16384
+func clusterSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % 16384
+}
+
+// startFakeClusterNode runs a miniredis that answers MOVED for keyed
+// commands whose slot lies outside [lo, hi], the way a cluster master does
+func startFakeClusterNode(t *testing.T, lo, hi int, other func() string) *miniredis.Miniredis {
+	node, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	node.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		var key string
+		switch strings.ToUpper(cmd) {
+		case "PING", "HELLO", "CLIENT", "SCAN", "SELECT", "INFO", "MULTI", "EXEC", "DISCARD", "AUTH", "READONLY", "CLUSTER":
+			return false
+		case "EVAL", "EVALSHA":
+			if len(args) < 3 || args[1] == "0" {
+				return false
+			}
+			key = args[2]
+		default:
+			if len(args) == 0 {
+				return false
+			}
+			key = args[0]
+		}
+		if slot := clusterSlot(key); slot < lo || slot > hi {
+			c.WriteError(fmt.Sprintf("MOVED %d %s", slot, other()))
+			return true
+		}
+		return false
+	})
+	return node
+}
+
+func TestRedisStorage_ClusterScanReadsCountersAcrossMasters(t *testing.T) {
+	var a, b *miniredis.Miniredis
+	a = startFakeClusterNode(t, 0, 8191, func() string { return b.Addr() })
+	defer a.Close()
+	b = startFakeClusterNode(t, 8192, 16383, func() string { return a.Addr() })
+	defer b.Close()
+
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		ClusterSlots: func(ctx context.Context) ([]redis.ClusterSlot, error) {
+			return []redis.ClusterSlot{
+				{Start: 0, End: 8191, Nodes: []redis.ClusterNode{{Addr: a.Addr()}}},
+				{Start: 8192, End: 16383, Nodes: []redis.ClusterNode{{Addr: b.Addr()}}},
+			}, nil
+		},
+	})
+	storage, err := newRedisStorage("http://localhost:8080", client, true)
+	if err != nil {
+		t.Fatalf("newRedisStorage() failed: %v", err)
+	}
+	defer storage.Close()
+
+	want := map[string]int64{}
+	split := 0
+	for i := 0; i < 20; i++ {
+		code, err := storage.Store(&models.URLMapping{LongURL: fmt.Sprintf("https://example.com/%d", i)})
+		if err != nil {
+			t.Fatalf("Store() failed: %v", err)
+		}
+		for n := 0; n <= i%3; n++ {
+			if err := storage.RecordAccess(code, time.Now()); err != nil {
+				t.Fatalf("RecordAccess() failed: %v", err)
+			}
+		}
+		want[code] = int64(i%3 + 1)
+		if (clusterSlot("url:"+code) < 8192) != (clusterSlot(clicksKey(code)) < 8192) {
+			split++
+		}
+	}
+	if split == 0 {
+		t.Fatal("Expected some codes whose url: and clicks: keys live on different masters")
+	}
+
+	got := map[string]int64{}
+	if err := storage.Each(func(m *models.URLMapping) error {
+		got[m.ShortCode] = m.AccessCount
+		return nil
+	}); err != nil {
+		t.Fatalf("Each() failed on a multi-master cluster: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Each() access counts = %v, want %v", got, want)
+	}
+}
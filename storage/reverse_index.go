@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"tiny-url-service/models"
+	"tiny-url-service/utils"
+)
+
+// URLHashFunc maps a normalized long URL to the key of its reverse index
+// entry, so the index never stores long URLs verbatim
+type URLHashFunc func(normalizedURL string) string
+
+// SHA256Truncated hashes with SHA-256 and keeps the first n bytes (hex
+// encoded). n outside 1-32 keeps the full digest.
+func SHA256Truncated(n int) URLHashFunc {
+	if n <= 0 || n > sha256.Size {
+		n = sha256.Size
+	}
+	return func(normalizedURL string) string {
+		sum := sha256.Sum256([]byte(normalizedURL))
+		return hex.EncodeToString(sum[:n])
+	}
+}
+
+// URLHashByName returns the hash strategy for a REVERSE_INDEX_HASH value:
+// "sha256" (truncated to 128 bits) or "sha256-full"
+func URLHashByName(name string) (URLHashFunc, error) {
+	switch strings.ToLower(name) {
+	case "sha256":
+		return SHA256Truncated(16), nil
+	case "sha256-full":
+		return SHA256Truncated(sha256.Size), nil
+	default:
+		return nil, fmt.Errorf("unknown reverse index hash: %s (supported: sha256, sha256-full)", name)
+	}
+}
+
+// reverseIndexable reports whether mapping belongs in the reverse index.
+// Only unconditional, non-expiring links are interchangeable with another
+// request for the same URL, so only those are indexed.
+func reverseIndexable(mapping *models.URLMapping) bool {
+	return mapping.PasswordHash == "" && mapping.MaxUses == 0 && mapping.ExpirationDate == nil &&
+		len(mapping.Destinations) == 0 && len(mapping.RedirectRules) == 0 && !mapping.RequireSignature
+}
+
+// reverseKey returns the index key for longURL, or "" when the index is disabled
+func (o options) reverseKey(longURL string) string {
+	if o.reverseHash == nil {
+		return ""
+	}
+	return o.reverseHash(utils.NormalizeURL(longURL))
+}
+
+// ownerReverseKey returns the owner-scoped index key for longURL, or "" when
+// the index is disabled or there is no owner
+func (o options) ownerReverseKey(owner, longURL string) string {
+	if o.reverseHash == nil || owner == "" {
+		return ""
+	}
+	return o.reverseHash(owner + "\x00" + utils.NormalizeURL(longURL))
+}
+
+// verifyReverseHit guards against hash collisions: the mapping found through
+// the index must actually be for longURL and still be indexable
+func verifyReverseHit(mapping *models.URLMapping, longURL string) bool {
+	return reverseIndexable(mapping) && utils.NormalizeURL(mapping.LongURL) == utils.NormalizeURL(longURL)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"tiny-url-service/models"
+)
+
+// searchPage sorts matches by short code and returns up to limit of them
+// after the cursor code, so every backend paginates identically
+func searchPage(matches []*models.URLMapping, after string, limit int) []*models.URLMapping {
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ShortCode < matches[j].ShortCode
+	})
+	
+	start := sort.Search(len(matches), func(i int) bool {
+		return matches[i].ShortCode > after
+	})
+	matches = matches[start:]
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// sortByExpiration orders mappings by expiration date, then short code
+func sortByExpiration(mappings []*models.URLMapping) {
+	sort.Slice(mappings, func(i, j int) bool {
+		a, b := mappings[i].ExpirationDate, mappings[j].ExpirationDate
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return mappings[i].ShortCode < mappings[j].ShortCode
+	})
+}
+
+// matchesQuery reports whether longURL contains the already-lowercased query
+func matchesQuery(longURL, query string) bool {
+	return strings.Contains(strings.ToLower(longURL), query)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"time"
+	"tiny-url-service/models"
+)
+
+// buildSeries groups hourly click counts into buckets of the given width,
+// covering since up to now. since is clamped to the retention window so a
+// caller can never ask for an unbounded number of hourly counters.
+// hourly must return one count per requested hour, in order.
+func buildSeries(bucket time.Duration, since, now time.Time, retention time.Duration, hourly func(hours []time.Time) ([]int64, error)) ([]models.BucketCount, error) {
+	if bucket < time.Hour || bucket%time.Hour != 0 {
+		return nil, ErrInvalidBucket
+	}
+	
+	now = now.UTC()
+	if oldest := now.Add(-retention); since.Before(oldest) {
+		since = oldest
+	}
+	start := since.UTC().Truncate(bucket)
+	
+	var hours []time.Time
+	for h := start; !h.After(now); h = h.Add(time.Hour) {
+		hours = append(hours, h)
+	}
+	
+	counts, err := hourly(hours)
+	if err != nil {
+		return nil, err
+	}
+	
+	perBucket := int(bucket / time.Hour)
+	series := make([]models.BucketCount, 0, len(hours)/perBucket+1)
+	for i, h := range hours {
+		if i%perBucket == 0 {
+			series = append(series, models.BucketCount{Start: h})
+		}
+		series[len(series)-1].Count += counts[i]
+	}
+	
+	return series, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// sizeBounds are the inclusive upper bounds, in bytes, of the long-URL length
+// histogram. Lengths above the last bound fall into an overflow bucket.
+var sizeBounds = [...]int{32, 64, 128, 256, 512, 1024, 2048}
+
+// sizeBucket returns the histogram bucket index for a long URL of n bytes
+func sizeBucket(n int) int {
+	for i, bound := range sizeBounds {
+		if n <= bound {
+			return i
+		}
+	}
+	return len(sizeBounds)
+}
+
+// sizeBucketLabel names bucket i, e.g. "33-64" or "2049+"
+func sizeBucketLabel(i int) string {
+	if i >= len(sizeBounds) {
+		return strconv.Itoa(sizeBounds[len(sizeBounds)-1]+1) + "+"
+	}
+	lower := 0
+	if i > 0 {
+		lower = sizeBounds[i-1] + 1
+	}
+	return strconv.Itoa(lower) + "-" + strconv.Itoa(sizeBounds[i])
+}
+
+// exactP95 returns the 95th percentile of the given lengths, sorting them in place
+func exactP95(lengths []int) int {
+	if len(lengths) == 0 {
+		return 0
+	}
+	sort.Ints(lengths)
+	return lengths[int(math.Ceil(0.95*float64(len(lengths))))-1]
+}
+
+// histogramP95 estimates the 95th percentile as the upper bound of the bucket
+// that contains it. The overflow bucket reports its lower bound.
+func histogramP95(hist []int64) int {
+	var total int64
+	for _, n := range hist {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	
+	rank := int64(math.Ceil(0.95 * float64(total)))
+	var seen int64
+	for i, n := range hist {
+		seen += n
+		if seen >= rank && i < len(sizeBounds) {
+			return sizeBounds[i]
+		}
+	}
+	return sizeBounds[len(sizeBounds)-1] + 1
+}
+
+// sizeSummary builds the "url_size" block reported by GetStats
+func sizeSummary(hist []int64, totalBytes int64, p95 int) map[string]interface{} {
+	var count int64
+	distribution := make(map[string]int64, len(hist))
+	for i, n := range hist {
+		count += n
+		distribution[sizeBucketLabel(i)] = n
+	}
+	
+	avg := 0.0
+	if count > 0 {
+		avg = math.Round(float64(totalBytes)/float64(count)*100) / 100
+	}
+	
+	return map[string]interface{}{
+		"count":        count,
+		"avg_length":   avg,
+		"p95_length":   p95,
+		"total_bytes":  totalBytes,
+		"distribution": distribution,
+	}
+}
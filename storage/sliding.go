@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"tiny-url-service/models"
+)
+
+// maxExtendRetries bounds how often Redis GetAndExtend retries when the
+// mapping changes between reading and writing it
+const maxExtendRetries = 5
+
+// extendedExpiration returns the expiration mapping should have after an
+// access at now: extendBy from now, or its current one if that is later.
+// ok is false when nothing changes.
+func extendedExpiration(mapping *models.URLMapping, extendBy time.Duration, now time.Time) (time.Time, bool) {
+	if mapping.ExpirationDate == nil || extendBy <= 0 {
+		return time.Time{}, false
+	}
+	extended := now.Add(extendBy)
+	if !mapping.ExpirationDate.Before(extended) {
+		return time.Time{}, false
+	}
+	return extended, true
+}
+
+// GetAndExtend is Get plus the extension, done under the shard lock so it
+// can't race with Delete or purging
+func (m *MemoryStorage) GetAndExtend(shortCode string, extendBy time.Duration) (*models.URLMapping, error) {
+	mapping, err := m.Get(shortCode)
+	if err != nil || mapping.ExpirationDate == nil {
+		return mapping, err
+	}
+
+	sh := m.shardFor(mapping.ShortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	// Look again under the lock; the link may have changed since Get
+	stored, exists := sh.urls[mapping.ShortCode]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	if m.IsExpired(stored) {
+		return nil, fmt.Errorf("%w: %s", ErrExpired, shortCode)
+	}
+	if extended, ok := extendedExpiration(stored, extendBy, time.Now()); ok {
+		updated := *stored
+		updated.ExpirationDate = &extended
+		if err := m.logUpdate(&updated); err != nil {
+			return nil, err
+		}
+		sh.urls[mapping.ShortCode] = &updated
+		stored = &updated
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+// GetAndExtend is Get plus the extension. The mapping is rewritten in a
+// WATCH/MULTI transaction, so a concurrent change (another extension, a
+// delete) makes it retry rather than be overwritten. The expiration index
+// is updated afterwards; purging re-checks the mapping, so a stale entry
+// never deletes an extended link.
+func (r *RedisStorage) GetAndExtend(shortCode string, extendBy time.Duration) (*models.URLMapping, error) {
+	mapping, err := r.Get(shortCode)
+	if err != nil || mapping.ExpirationDate == nil {
+		return mapping, err
+	}
+
+	key := "url:" + mapping.ShortCode
+	var extended time.Time
+	for attempt := 0; attempt < maxExtendRetries; attempt++ {
+		extended = time.Time{}
+		err = r.client.Watch(r.ctx, func(tx *redis.Tx) error {
+			data, err := tx.Get(r.ctx, key).Result()
+			if err == redis.Nil {
+				return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get URL mapping from Redis: %w", err)
+			}
+			stored, err := unmarshalMapping([]byte(data))
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal URL mapping: %w", err)
+			}
+			if r.IsExpired(stored) {
+				return fmt.Errorf("%w: %s", ErrExpired, shortCode)
+			}
+			mapping.ExpirationDate = stored.ExpirationDate
+
+			next, ok := extendedExpiration(stored, extendBy, time.Now())
+			if !ok {
+				return nil
+			}
+			stored.ExpirationDate = &next
+			updated, err := marshalMapping(stored)
+			if err != nil {
+				return fmt.Errorf("failed to marshal URL mapping: %w", err)
+			}
+			_, err = tx.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(r.ctx, key, updated, redis.KeepTTL)
+				return nil
+			})
+			if err == nil {
+				extended = next
+			}
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+	}
+	if errors.Is(err, redis.TxFailedErr) {
+		return nil, fmt.Errorf("failed to extend expiration of %s: mapping kept changing", shortCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !extended.IsZero() {
+		mapping.ExpirationDate = &extended
+		member := redis.Z{Score: float64(extended.UnixMilli()), Member: mapping.ShortCode}
+		if err := r.client.ZAdd(r.ctx, expirationsKey, member).Err(); err != nil {
+			return mapping, fmt.Errorf("failed to update expiration index: %w", err)
+		}
+	}
+	return mapping, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"tiny-url-service/models"
+	"tiny-url-service/utils"
+)
+
+// verifyMappings walks every mapping with each and reports inconsistencies,
+// sorted: generated codes that don't encode their stored ID with encode, missing or
+// invalid long URLs, and IDs shared by several codes. Custom codes carry
+// ID 0 and are exempt from the ID checks.
+func verifyMappings(each func(fn func(*models.URLMapping) error) error, encode func(uint64) string) ([]string, error) {
+	issues := []string{}
+	codesByID := make(map[uint64][]string)
+	
+	err := each(func(mapping *models.URLMapping) error {
+		if !utils.IsValidURL(mapping.LongURL) {
+			issues = append(issues, fmt.Sprintf("code %q: invalid long URL %q", mapping.ShortCode, mapping.LongURL))
+		}
+		if mapping.ID == 0 {
+			return nil
+		}
+		if _, code := SplitNamespace(mapping.ShortCode); encode(mapping.ID) != code {
+			expected := NamespacedCode(mapping.Namespace, encode(mapping.ID))
+			issues = append(issues, fmt.Sprintf("code %q: stored ID %d encodes to %q", mapping.ShortCode, mapping.ID, expected))
+		}
+		codesByID[mapping.ID] = append(codesByID[mapping.ID], mapping.ShortCode)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	for id, codes := range codesByID {
+		if len(codes) > 1 {
+			sort.Strings(codes)
+			issues = append(issues, fmt.Sprintf("ID %d: shared by codes %q", id, codes))
+		}
+	}
+	sort.Strings(issues)
+	return issues, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of hash bits picking a register of the
+// in-memory HyperLogLog: 2^12 one-byte registers per link, for a standard
+// error of about 1.6%
+const hllPrecision = 12
+
+// hyperLogLog estimates the number of distinct visitors of one link in
+// constant memory, like Redis PFADD/PFCOUNT
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+// add records visitor
+func (h *hyperLogLog) add(visitor string) {
+	hash := visitorHash(visitor)
+	index := hash >> (64 - hllPrecision)
+	// Leading zeros of the remaining bits, plus one; the sentinel bit caps it
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// count returns the estimated number of distinct visitors added
+func (h *hyperLogLog) count() int64 {
+	const m = float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// Linear counting is far more accurate while many registers are unset,
+	// which keeps small counts exact in practice
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(estimate + 0.5)
+}
+
+// visitorHash is 64-bit FNV-1a followed by the splitmix64 finalizer, which
+// spreads the bits of similar inputs (e.g. neighboring IPs) evenly
+func visitorHash(visitor string) uint64 {
+	hash := uint64(14695981039346656037)
+	for i := 0; i < len(visitor); i++ {
+		hash ^= uint64(visitor[i])
+		hash *= 1099511628211
+	}
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+	return hash
+}
+
+// visitorsKey is the HyperLogLog of shortCode's distinct visitors in Redis
+func visitorsKey(shortCode string) string {
+	return "visitors:" + shortCode
+}
+
+// RecordVisitor adds visitor to shortCode's HyperLogLog
+func (m *MemoryStorage) RecordVisitor(shortCode, visitor string) error {
+	sh := m.shardFor(shortCode)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, exists := sh.urls[shortCode]; !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+
+	hll, exists := sh.visitors[shortCode]
+	if !exists {
+		hll = &hyperLogLog{}
+		sh.visitors[shortCode] = hll
+	}
+	hll.add(visitor)
+	return nil
+}
+
+// UniqueVisitors returns the estimate of shortCode's HyperLogLog
+func (m *MemoryStorage) UniqueVisitors(shortCode string) (int64, error) {
+	sh := m.shardFor(shortCode)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if _, exists := sh.urls[shortCode]; !exists {
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, shortCode)
+	}
+	if hll := sh.visitors[shortCode]; hll != nil {
+		return hll.count(), nil
+	}
+	return 0, nil
+}
+
+// RecordVisitor adds visitor to visitors:<code> with PFADD. Like
+// RecordAccess it doesn't check that the link exists, and it is never
+// buffered by the click flush interval.
+func (r *RedisStorage) RecordVisitor(shortCode, visitor string) error {
+	if err := r.client.PFAdd(r.ctx, visitorsKey(shortCode), visitor).Err(); err != nil {
+		return fmt.Errorf("failed to record visitor: %w", err)
+	}
+	return nil
+}
+
+// UniqueVisitors returns the PFCOUNT of visitors:<code>; a missing key reads as zero
+func (r *RedisStorage) UniqueVisitors(shortCode string) (int64, error) {
+	n, err := r.client.PFCount(r.ctx, visitorsKey(shortCode)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unique visitors: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestWeightedDestinations(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url": "https://example.com/fallback",
+		"destinations": []map[string]interface{}{
+			{"url": "https://example.com/a", "weight": 70},
+			{"url": "https://example.com/b", "weight": 30},
+		},
+	})
+
+	const redirects = 10
+	for i := 0; i < redirects; i++ {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+		resp.Body.Close()
+
+		location := resp.Header.Get("Location")
+		if location != "https://example.com/a" && location != "https://example.com/b" {
+			t.Fatalf("Redirect %d went to unexpected destination %q", i+1, location)
+		}
+	}
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats", nil, nil)
+	defer resp.Body.Close()
+
+	var stats struct {
+		Destinations []struct {
+			URL    string `json:"url"`
+			Weight int    `json:"weight"`
+			Clicks int    `json:"clicks"`
+		} `json:"destinations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+
+	if len(stats.Destinations) != 2 {
+		t.Fatalf("Expected 2 destinations in stats, got %d", len(stats.Destinations))
+	}
+	if stats.Destinations[0].Weight != 70 || stats.Destinations[1].Weight != 30 {
+		t.Errorf("Unexpected destination weights: %+v", stats.Destinations)
+	}
+	if total := stats.Destinations[0].Clicks + stats.Destinations[1].Clicks; total != redirects {
+		t.Errorf("Expected %d destination clicks in total, got %d", redirects, total)
+	}
+}
+
+func TestWeightedDestinationsValidation(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		destinations []map[string]interface{}
+	}{
+		{"zero weight", []map[string]interface{}{{"url": "https://example.com/a", "weight": 0}}},
+		{"negative weight", []map[string]interface{}{{"url": "https://example.com/a", "weight": -5}}},
+		{"weight above the cap", []map[string]interface{}{{"url": "https://example.com/a", "weight": 10001}}},
+		{"overflowing weights", []map[string]interface{}{
+			{"url": "https://example.com/a", "weight": int64(math.MaxInt64)},
+			{"url": "https://example.com/b", "weight": int64(math.MaxInt64)},
+		}},
+		{"invalid url", []map[string]interface{}{{"url": "ftp://example.com/a", "weight": 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+				"long_url":     "https://example.com",
+				"destinations": tt.destinations,
+			}, nil)
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+		})
+	}
+
+	// The cap itself is allowed
+	createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":     "https://example.com",
+		"destinations": []map[string]interface{}{{"url": "https://example.com/a", "weight": 10000}},
+	})
+}
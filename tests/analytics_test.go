@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+func TestStatsClickSeries(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/analytics"})
+
+	for i := 0; i < 3; i++ {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+		}
+	}
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats?series=hourly", nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var stats struct {
+		AccessCount int `json:"access_count"`
+		Series      []struct {
+			Start time.Time `json:"start"`
+			Count int       `json:"count"`
+		} `json:"series"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+
+	if stats.AccessCount != 3 {
+		t.Errorf("Expected access_count 3, got %d", stats.AccessCount)
+	}
+	if len(stats.Series) < 24 {
+		t.Fatalf("Expected at least 24 hourly buckets, got %d", len(stats.Series))
+	}
+	if last := stats.Series[len(stats.Series)-1]; last.Count != 3 {
+		t.Errorf("Expected 3 clicks in the current hour, got %d", last.Count)
+	}
+}
+
+func TestStatsClickSeriesInvalid(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats?series=weekly", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestResetClicks(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/reset"})
+	for i := 0; i < 2; i++ {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+		resp.Body.Close()
+	}
+
+	// Admin only
+	resp := doJSON(t, "POST", server.URL+"/urls/"+code+"/clicks/reset", struct{}{}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "POST", server.URL+"/urls/"+code+"/clicks/reset", struct{}{}, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/urls/"+code+"/stats", nil, nil)
+	defer resp.Body.Close()
+	var stats struct {
+		AccessCount int `json:"access_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+	if stats.AccessCount != 0 {
+		t.Errorf("Expected access_count 0 after reset, got %d", stats.AccessCount)
+	}
+
+	resp = doJSON(t, "POST", server.URL+"/urls/missing/clicks/reset", struct{}{}, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestLinkPrometheusMetrics(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	expiry := time.Now().Add(48 * time.Hour).Truncate(time.Second).UTC()
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":        "https://example.com/metrics",
+		"expiration_date": expiry.Format(time.RFC3339),
+	})
+	for i := 0; i < 2; i++ {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+		resp.Body.Close()
+	}
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/metrics", nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Expected Prometheus text content type, got %q", ct)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		"# TYPE tinyurl_link_clicks_total counter\n",
+		`tinyurl_link_clicks_total{code="` + code + `"} 2` + "\n",
+		"# TYPE tinyurl_link_expiry_timestamp_seconds gauge\n",
+		`tinyurl_link_expiry_timestamp_seconds{code="` + code + `"} ` + strconv.FormatInt(expiry.Unix(), 10) + "\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/urls/missing/metrics", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestBatchStats(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	pastTime := time.Now().Add(-time.Hour)
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/old", ExpirationDate: &pastTime}, "old_link"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	code, _ := store.Store(&models.URLMapping{LongURL: "https://example.com/batch"})
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.JSONCase = "camel"
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+	resp.Body.Close()
+
+	resp = doJSON(t, "POST", server.URL+"/urls/stats/batch", map[string]interface{}{
+		"short_codes": []string{code, "missing", "old_link"},
+	}, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var body struct {
+		Results map[string]map[string]interface{} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %v", body.Results)
+	}
+	if stats := body.Results[code]; stats["accessCount"] != float64(1) || stats["longUrl"] != "https://example.com/batch" {
+		t.Errorf("Unexpected stats for %s: %v", code, stats)
+	}
+	if missing := body.Results["missing"]; missing["error"] != "Short URL not found" {
+		t.Errorf("Expected not found for missing, got %v", missing)
+	}
+	// Codes used as keys keep their spelling under JSON_CASE=camel
+	if expired := body.Results["old_link"]; expired["expired"] != true {
+		t.Errorf("Expected old_link to be reported as expired, got %v", body.Results)
+	}
+
+	resp = doJSON(t, "POST", server.URL+"/urls/stats/batch", map[string]interface{}{"short_codes": []string{}}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an empty batch, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"tiny-url-service/config"
+	"tiny-url-service/handlers"
+	"tiny-url-service/storage"
+)
+
+func TestAdminAuditLog(t *testing.T) {
+	auditLogger, err := storage.NewFileAuditLogger(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to create audit logger: %v", err)
+	}
+	defer auditLogger.Close()
+
+	server := setupTestServerWithStore(nil, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	}, handlers.WithAuditLogger(auditLogger))
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/audited"})
+
+	resp := doJSON(t, "POST", server.URL+"/admin/drain", map[string]bool{"draining": false}, adminHeaders())
+	resp.Body.Close()
+
+	resp = doJSON(t, "GET", server.URL+"/admin/audit", nil, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var body struct {
+		Entries []struct {
+			Action    string `json:"action"`
+			Actor     string `json:"actor"`
+			ShortCode string `json:"short_code"`
+			LongURL   string `json:"long_url"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode audit response: %v", err)
+	}
+
+	if len(body.Entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %+v", body.Entries)
+	}
+	if e := body.Entries[0]; e.Action != "create" || e.ShortCode != code || e.LongURL != "https://example.com/audited" || e.Actor != "ip:127.0.0.1" {
+		t.Errorf("Unexpected create entry: %+v", e)
+	}
+	if e := body.Entries[1]; e.Action != "undrain" || e.Actor != "admin" {
+		t.Errorf("Unexpected drain entry: %+v", e)
+	}
+}
+
+func TestAdminAuditLogDisabledByDefault(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+
+	resp := doJSON(t, "GET", server.URL+"/admin/audit", nil, adminHeaders())
+	defer resp.Body.Close()
+
+	var body struct {
+		Entries []json.RawMessage `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode audit response: %v", err)
+	}
+	if len(body.Entries) != 0 {
+		t.Errorf("Expected an empty audit log when unconfigured, got %d entries", len(body.Entries))
+	}
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+)
+
+type campaignURLsResponse struct {
+	Campaign struct {
+		ID   string   `json:"id"`
+		Tags []string `json:"tags"`
+	} `json:"campaign"`
+	URLs []struct {
+		ShortCode      string     `json:"short_code"`
+		ExpirationDate *time.Time `json:"expiration_date"`
+		Tags           []string   `json:"tags"`
+	} `json:"urls"`
+}
+
+func TestCampaignDefaults(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.RateLimitDisabled = true
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/campaigns", map[string]interface{}{
+		"id":                     "spring-sale",
+		"name":                   "Spring sale",
+		"expiration_date":        "2099-06-30",
+		"tags":                   []string{"Promo", "spring"},
+		"redirect_delay_seconds": 3,
+	}, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status %d creating the campaign, got %d", http.StatusCreated, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "POST", server.URL+"/campaigns", map[string]interface{}{"id": "spring-sale"}, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status %d for a taken campaign ID, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	inherited := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url": "https://example.com/inherited",
+		"campaign": "spring-sale",
+	})
+	overridden := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":        "https://example.com/overridden",
+		"campaign":        "spring-sale",
+		"expiration_date": "2099-01-31",
+		"tags":            []string{"vip"},
+	})
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/unrelated"})
+
+	// The inherited countdown applies to the redirect
+	redirect, err := noRedirectClient.Get(server.URL + "/" + inherited)
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	redirect.Body.Close()
+	if redirect.StatusCode != http.StatusOK {
+		t.Errorf("Expected the countdown page (status %d), got %d", http.StatusOK, redirect.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/campaigns/spring-sale/urls", nil, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d listing the campaign, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var listing campaignURLsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listing.Campaign.ID != "spring-sale" || len(listing.Campaign.Tags) != 2 || listing.Campaign.Tags[0] != "promo" {
+		t.Errorf("Unexpected campaign in listing: %+v", listing.Campaign)
+	}
+	if len(listing.URLs) != 2 {
+		t.Fatalf("Expected the campaign's 2 links, got %d", len(listing.URLs))
+	}
+
+	for _, link := range listing.URLs {
+		switch link.ShortCode {
+		case inherited:
+			if link.ExpirationDate == nil || link.ExpirationDate.Format("2006-01-02") != "2099-06-30" {
+				t.Errorf("Expected the campaign's expiration, got %v", link.ExpirationDate)
+			}
+			if len(link.Tags) != 2 || link.Tags[0] != "promo" || link.Tags[1] != "spring" {
+				t.Errorf("Expected the campaign's tags, got %v", link.Tags)
+			}
+		case overridden:
+			if link.ExpirationDate == nil || link.ExpirationDate.Format("2006-01-02") != "2099-01-31" {
+				t.Errorf("Expected the link's own expiration, got %v", link.ExpirationDate)
+			}
+			if len(link.Tags) != 1 || link.Tags[0] != "vip" {
+				t.Errorf("Expected the link's own tags, got %v", link.Tags)
+			}
+		default:
+			t.Errorf("Unexpected link %s in the campaign", link.ShortCode)
+		}
+	}
+}
+
+func TestUnknownCampaign(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url": "https://example.com",
+		"campaign": "missing",
+	}, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["field"] != "campaign" {
+		t.Errorf("Expected field campaign, got %v", body["field"])
+	}
+
+	list := doJSON(t, "GET", server.URL+"/campaigns/missing/urls", nil, adminHeaders())
+	list.Body.Close()
+	if list.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d listing an unknown campaign, got %d", http.StatusNotFound, list.StatusCode)
+	}
+}
+
+func TestCampaignValidation(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	testCases := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"missing id", map[string]interface{}{"name": "No ID"}},
+		{"invalid id", map[string]interface{}{"id": "spring sale"}},
+		{"invalid tag", map[string]interface{}{"id": "tags", "tags": []string{"not valid!"}}},
+		{"negative delay", map[string]interface{}{"id": "delay", "redirect_delay_seconds": -1}},
+		{"bad date", map[string]interface{}{"id": "date", "expiration_date": "next week"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := doJSON(t, "POST", server.URL+"/campaigns", tc.body, adminHeaders())
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestCampaignRoutesRequireAdmin(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/campaigns", map[string]interface{}{"id": "spring-sale"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d creating a campaign without the admin token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "POST", server.URL+"/campaigns", map[string]interface{}{"id": "spring-sale"}, adminHeaders())
+	resp.Body.Close()
+	const secret = "https://example.com/protected-sale"
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": secret, "password": "hunter2", "campaign": "spring-sale"})
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/open-sale", "campaign": "spring-sale"})
+
+	resp = doJSON(t, "GET", server.URL+"/campaigns/spring-sale/urls", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d listing a campaign without the admin token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	list := statsBody(t, "GET", server.URL+"/campaigns/spring-sale/urls", nil, adminHeaders())
+	if strings.Contains(list, secret) || !strings.Contains(list, `"destination_hidden":true`) {
+		t.Errorf("Campaign list revealed a protected destination: %s", list)
+	}
+	if !strings.Contains(list, "https://example.com/open-sale") {
+		t.Errorf("Expected unprotected destinations to be listed, got %s", list)
+	}
+}
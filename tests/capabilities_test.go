@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/storage"
+)
+
+// nonAtomicStore reports that it can't claim codes atomically, so handlers
+// must serialize custom-code creates themselves
+type nonAtomicStore struct {
+	*storage.MemoryStorage
+}
+
+func (s nonAtomicStore) Capabilities() storage.StorageCapabilities {
+	caps := s.MemoryStorage.Capabilities()
+	caps.SupportsAtomicCreate = false
+	return caps
+}
+
+func TestHealthReportsCapabilities(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	resp := doJSON(t, "GET", server.URL+"/health", nil, nil)
+	defer resp.Body.Close()
+	var body struct {
+		Capabilities *storage.StorageCapabilities `json:"capabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode health response: %v", err)
+	}
+	if body.Capabilities == nil {
+		t.Fatal("Expected capabilities in the health response")
+	}
+	if !body.Capabilities.SupportsAtomicCreate || body.Capabilities.Shared || body.Capabilities.SupportsReverseIndex {
+		t.Errorf("Unexpected capabilities for plain memory storage: %+v", *body.Capabilities)
+	}
+}
+
+func TestCustomCodeWithoutAtomicCreate(t *testing.T) {
+	store := nonAtomicStore{storage.NewMemoryStorage("http://localhost:8080")}
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.RateLimitDisabled = true
+	})
+	defer server.Close()
+
+	var created, conflicts atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+				"long_url":    "https://example.com",
+				"custom_code": "contested",
+			}, nil)
+			resp.Body.Close()
+			switch resp.StatusCode {
+			case http.StatusOK, http.StatusCreated:
+				created.Add(1)
+			case http.StatusConflict:
+				conflicts.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created.Load() != 1 || conflicts.Load() != 9 {
+		t.Errorf("Expected 1 create and 9 conflicts, got %d and %d", created.Load(), conflicts.Load())
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"tiny-url-service/storage"
+)
+
+func TestCreateRefusedAtCapacity(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithMaxURLs(2))
+	server := setupTestServerWithStore(store, nil)
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+	}
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/full"}, nil)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInsufficientStorage {
+		t.Errorf("Expected status %d once full, got %d", http.StatusInsufficientStorage, resp.StatusCode)
+	}
+}
+
+func TestCreateRefusedPastMaxGeneratedCodeLength(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithMaxGeneratedCodeLength(1))
+	server := setupTestServerWithStore(store, nil)
+	defer server.Close()
+
+	// "Z" (61) is the last one-character code
+	if err := store.SetCounterFloor(60); err != nil {
+		t.Fatalf("SetCounterFloor() failed: %v", err)
+	}
+	if code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"}); code != "Z" {
+		t.Errorf("Expected code Z, got %q", code)
+	}
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/wider"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInsufficientStorage {
+		t.Errorf("Expected status %d once codes would grow, got %d", http.StatusInsufficientStorage, resp.StatusCode)
+	}
+}
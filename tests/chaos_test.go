@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/middleware"
+)
+
+func TestChaosModeRequiresExplicitOptIn(t *testing.T) {
+	for _, mode := range []string{"", "true", "1", "on"} {
+		server := setupTestServerWithConfig(func(cfg *config.Config) {
+			cfg.ChaosMode = mode
+			cfg.ChaosErrorRate = 1
+		})
+		resp := doJSON(t, "GET", server.URL+"/api/expand?short_url=nope", nil, nil)
+		resp.Body.Close()
+		server.Close()
+		if resp.Header.Get(middleware.ChaosHeader) != "" {
+			t.Errorf("CHAOS_MODE=%q should not inject faults", mode)
+		}
+	}
+}
+
+func TestChaosModeSparesHealth(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.ChaosMode = config.ChaosModeOptIn
+		cfg.ChaosErrorRate = 1
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "GET", server.URL+"/urls/abc/stats", nil, nil)
+	resp.Body.Close()
+	if resp.Header.Get(middleware.ChaosHeader) != "true" || resp.StatusCode < http.StatusInternalServerError {
+		t.Errorf("Expected an injected failure on API routes, got %d", resp.StatusCode)
+	}
+
+	for i := 0; i < 5; i++ {
+		resp = doJSON(t, "GET", server.URL+"/health", nil, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected /health to be exempt from chaos, got %d", resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/storage"
+	"tiny-url-service/utils"
+)
+
+func TestChecksumCodes(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithChecksumCodes(true))
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.ChecksumCodes = true
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+	if _, ok := utils.DecodeBase62WithChecksum(code); !ok {
+		t.Fatalf("Expected a checksummed code, got %q", code)
+	}
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/custom", "custom_code": "my-link"})
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	get := func(path string) *http.Response {
+		resp, err := client.Get(server.URL + "/" + path)
+		if err != nil {
+			t.Fatalf("GET /%s failed: %v", path, err)
+		}
+		return resp
+	}
+
+	for _, path := range []string{code, "my-link"} {
+		resp := get(path)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusFound {
+			t.Errorf("Expected /%s to redirect, got %d", path, resp.StatusCode)
+		}
+	}
+
+	// Drop the check character: a likely typo that gets a suggestion
+	resp := get(code[:len(code)-1] + "x")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status %d for a mistyped code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	var body struct {
+		DidYouMean string `json:"did_you_mean"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.DidYouMean != server.URL+"/"+code {
+		t.Errorf("Expected did_you_mean to point at %s, got %q", code, body.DidYouMean)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+)
+
+// clickWithReferrer follows the redirect of code once with a Referer header
+func clickWithReferrer(t *testing.T, serverURL, code, referrer string) {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", serverURL+"/"+code, nil)
+	req.Header.Set("Referer", referrer)
+	req.Header.Set("User-Agent", "sampling-test")
+	resp, err := noRedirectClient.Do(req)
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// statsWithEvents returns the access count and recent events of code
+func statsWithEvents(t *testing.T, serverURL, code string) (int64, []models.AccessEvent) {
+	t.Helper()
+
+	resp := doJSON(t, "GET", serverURL+"/urls/"+code+"/stats?events=1", nil, nil)
+	defer resp.Body.Close()
+	var stats struct {
+		AccessCount  int64                `json:"access_count"`
+		RecentEvents []models.AccessEvent `json:"recent_events"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	return stats.AccessCount, stats.RecentEvents
+}
+
+func TestClickSampleRate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		rate           float64
+		expectedEvents int
+	}{
+		{"none sampled", 0.0, 0},
+		{"all sampled", 1.0, 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := setupTestServerWithConfig(func(cfg *config.Config) {
+				cfg.ClickSampleRate = tc.rate
+			})
+			defer server.Close()
+
+			code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+			for i := 0; i < 5; i++ {
+				clickWithReferrer(t, server.URL, code, "https://news.example.com/")
+			}
+
+			count, events := statsWithEvents(t, server.URL, code)
+			if count != 5 {
+				t.Errorf("Expected every click counted, got access_count %d", count)
+			}
+			if len(events) != tc.expectedEvents {
+				t.Fatalf("Expected %d recorded events, got %d", tc.expectedEvents, len(events))
+			}
+			for _, event := range events {
+				if event.Referrer != "https://news.example.com/" || event.UserAgent != "sampling-test" {
+					t.Errorf("Unexpected event detail: %+v", event)
+				}
+			}
+		})
+	}
+}
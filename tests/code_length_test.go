@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+// countingGetStore counts storage lookups so tests can assert a request never reached storage
+type countingGetStore struct {
+	*storage.MemoryStorage
+	gets *atomic.Int64
+}
+
+func (s countingGetStore) Get(shortCode string) (*models.URLMapping, error) {
+	s.gets.Add(1)
+	return s.MemoryStorage.Get(shortCode)
+}
+
+func TestRedirectRejectsOversizedCodes(t *testing.T) {
+	store := countingGetStore{MemoryStorage: storage.NewMemoryStorage("http://localhost:8080"), gets: &atomic.Int64{}}
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.MaxCodeLength = 11
+	})
+	defer server.Close()
+
+	// A code at the limit is looked up and redirects
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":    "https://example.com/valid",
+		"custom_code": "abcdefghijk",
+	}, nil)
+	resp.Body.Close()
+	resp = doJSON(t, "GET", server.URL+"/abcdefghijk", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d for a valid-length code, got %d", http.StatusFound, resp.StatusCode)
+	}
+	lookups := store.gets.Load()
+
+	// Oversized paths are rejected before touching storage
+	resp = doJSON(t, "GET", server.URL+"/"+strings.Repeat("x", 12), nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for an oversized code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	resp = doJSON(t, "GET", server.URL+"/wp-login.php", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for a bot probe, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	if got := store.gets.Load(); got != lookups {
+		t.Errorf("Expected no storage lookups for rejected codes, got %d", got-lookups)
+	}
+}
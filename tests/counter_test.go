@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/storage"
+)
+
+func TestAdminCounterNeverLowers(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	setCounter := func(value uint64) (int, uint64) {
+		resp := doJSON(t, "POST", server.URL+"/admin/counter", map[string]uint64{"value": value}, adminHeaders())
+		defer resp.Body.Close()
+		var body struct {
+			Counter uint64 `json:"counter"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.Counter
+	}
+
+	if status, counter := setCounter(500); status != http.StatusOK || counter != 500 {
+		t.Fatalf("Expected 200 with counter 500, got %d %d", status, counter)
+	}
+	if status, counter := setCounter(20); status != http.StatusOK || counter != 500 {
+		t.Errorf("Expected a lower value to leave the counter at 500, got %d %d", status, counter)
+	}
+
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+	if counter := store.GetStats()["current_counter"]; counter != uint64(501) {
+		t.Errorf("Expected the next create to use ID 501, counter is %v", counter)
+	}
+
+	// Admin only
+	resp := doJSON(t, "POST", server.URL+"/admin/counter", map[string]uint64{"value": 1}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+)
+
+func TestCreateStatus201(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.CreateStatus201 = true
+		cfg.DedupWindow = time.Minute
+	})
+	defer server.Close()
+
+	body := map[string]interface{}{"long_url": "https://example.com/rest"}
+	resp := doJSON(t, "POST", server.URL+"/urls", body, nil)
+	var created CreateURLResponse
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	if location == "" || location != created.ShortURL {
+		t.Errorf("Expected Location %q matching short_url, got %q", created.ShortURL, location)
+	}
+
+	// Tooling following Location lands on the redirect
+	resp, err := noRedirectClient.Get(location)
+	if err != nil {
+		t.Fatalf("Failed to follow Location: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d from Location, got %d", http.StatusFound, resp.StatusCode)
+	}
+
+	// A deduplicated resubmission returns the existing link, not a new one
+	resp = doJSON(t, "POST", server.URL+"/urls", body, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Location") != "" {
+		t.Errorf("Expected 200 without Location for an existing link, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+func TestCreateStatusDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/plain"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Location") != "" {
+		t.Errorf("Expected 200 without Location by default, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
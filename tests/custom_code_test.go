@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/handlers"
+	"tiny-url-service/storage"
+)
+
+func TestCustomCodeCollisionSuggestions(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/vanity",
+		"custom_code": "mylink",
+	})
+	if code != "mylink" {
+		t.Fatalf("Expected custom code mylink, got %s", code)
+	}
+	createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/vanity-2",
+		"custom_code": "mylink-2",
+	})
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":    "https://example.com/other",
+		"custom_code": "mylink",
+	}, nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	var conflict struct {
+		Error       string   `json:"error"`
+		Suggestions []string `json:"suggestions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&conflict); err != nil {
+		t.Fatalf("Failed to decode conflict response: %v", err)
+	}
+
+	if len(conflict.Suggestions) == 0 {
+		t.Fatal("Expected suggestions in the 409 response")
+	}
+	for _, suggestion := range conflict.Suggestions {
+		if suggestion == "mylink" || suggestion == "mylink-2" {
+			t.Errorf("Suggested a taken code: %s", suggestion)
+		}
+		if !strings.HasPrefix(suggestion, "mylink-") {
+			t.Errorf("Suggestion %q should be a variant of mylink", suggestion)
+		}
+	}
+
+	// A suggestion can be used as-is
+	createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/other",
+		"custom_code": conflict.Suggestions[0],
+	})
+}
+
+func TestCustomCodeValidation(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	for _, code := range []string{"has space", "slash/code", strings.Repeat("x", 33)} {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+			"long_url":    "https://example.com",
+			"custom_code": code,
+		}, nil)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("custom_code %q: expected status %d, got %d", code, http.StatusBadRequest, resp.StatusCode)
+		}
+	}
+}
+
+func TestCustomCodeRejectsHomoglyphs(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	if code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/real",
+		"custom_code": "paypal",
+	}); code != "paypal" {
+		t.Fatalf("Expected custom code paypal, got %s", code)
+	}
+
+	// Cyrillic 'а' (U+0430) renders like the Latin 'a' in the existing code
+	for _, code := range []string{"p\u0430ypal", "paypa\u0301l"} {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+			"long_url":    "https://example.com/phish",
+			"custom_code": code,
+		}, nil)
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(body.Error, "ASCII") {
+			t.Errorf("custom_code %q: expected 400 naming ASCII, got %d %q", code, resp.StatusCode, body.Error)
+		}
+	}
+}
+
+func TestCustomCodeConcurrentCreates(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	// Stay under the per-IP rate limit of 20 requests
+	const contenders = 10
+	statuses := make(chan int, contenders)
+	var wg sync.WaitGroup
+	for i := 0; i < contenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+				"long_url":    "https://example.com/contender",
+				"custom_code": "race",
+			}, nil)
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	created, conflicts := 0, 0
+	for status := range statuses {
+		switch status {
+		case http.StatusOK:
+			created++
+		case http.StatusConflict:
+			conflicts++
+		default:
+			t.Errorf("Unexpected status %d", status)
+		}
+	}
+	if created != 1 || conflicts != contenders-1 {
+		t.Errorf("Expected 1 create and %d conflicts, got %d and %d", contenders-1, created, conflicts)
+	}
+}
+
+func TestCustomCodeIfNoneMatch(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	body := map[string]interface{}{"long_url": "https://example.com", "custom_code": "conditional"}
+	headers := map[string]string{"If-None-Match": "*"}
+
+	resp := doJSON(t, "POST", server.URL+"/urls", body, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d for a free code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "POST", server.URL+"/urls", body, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d for a taken code, got %d", http.StatusPreconditionFailed, resp.StatusCode)
+	}
+}
+
+func TestCustomCodeReservedWords(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.ReservedWords = []string{"login"}
+	})
+	defer server.Close()
+
+	// Configured words and route prefixes are refused, ignoring case
+	for _, code := range []string{"login", "LOGIN", "health", "urls", "admin"} {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+			"long_url":    "https://example.com/reserved",
+			"custom_code": code,
+		}, nil)
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusConflict || body.Error != "Short code is reserved" {
+			t.Errorf("custom_code %q: expected 409 reserved, got %d %q", code, resp.StatusCode, body.Error)
+		}
+	}
+
+	// Ordinary codes, including ones merely starting with a reserved word, still work
+	if code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/normal",
+		"custom_code": "login-page",
+	}); code != "login-page" {
+		t.Errorf("Expected custom code login-page, got %s", code)
+	}
+}
+
+func TestReservedWordsCoverRoutes(t *testing.T) {
+	router := handlers.SetupRouter(storage.NewMemoryStorage("http://localhost:8080"), &config.Config{})
+
+	reserved := make(map[string]bool)
+	for _, word := range handlers.ReservedWords(&config.Config{}) {
+		reserved[word] = true
+	}
+	for _, route := range router.Routes() {
+		prefix, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+		if prefix == "" || strings.HasPrefix(prefix, ":") {
+			continue
+		}
+		if !reserved[prefix] {
+			t.Errorf("Route %s %s is not covered by a reserved word", route.Method, route.Path)
+		}
+	}
+}
+
+func TestCustomCodeCaseInsensitive(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithCaseInsensitiveCodes(true))
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.CaseInsensitiveCodes = true
+	})
+	defer server.Close()
+
+	if code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/vanity",
+		"custom_code": "MyLink",
+	}); code != "MyLink" {
+		t.Fatalf("Expected the typed casing MyLink, got %s", code)
+	}
+
+	// A code differing only in case collides
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":    "https://example.com/other",
+		"custom_code": "mylink",
+	}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status %d for a case-only collision, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	// Any casing redirects
+	resp, err := noRedirectClient.Get(server.URL + "/mylink")
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+
+	// Stats report the casing the link was created with
+	resp = doJSON(t, "GET", server.URL+"/urls/MYLINK/stats", nil, nil)
+	defer resp.Body.Close()
+	var stats struct {
+		ShortCode   string `json:"short_code"`
+		AccessCount int64  `json:"access_count"`
+	}
+	json.NewDecoder(resp.Body).Decode(&stats)
+	if stats.ShortCode != "MyLink" || stats.AccessCount != 1 {
+		t.Errorf("Expected MyLink with 1 click, got %+v", stats)
+	}
+}
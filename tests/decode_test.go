@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+type decodeResponse struct {
+	DecodedID   *uint64 `json:"decoded_id"`
+	DecodeError string  `json:"decode_error"`
+	Mapping     string  `json:"mapping"`
+	MappingID   *uint64 `json:"mapping_id"`
+	IDMatches   bool    `json:"id_matches"`
+	LongURL     string  `json:"long_url"`
+}
+
+// decodeCode calls GET /debug/decode/{code} with the admin token
+func decodeCode(t *testing.T, baseURL, code string) decodeResponse {
+	t.Helper()
+	resp := doJSON(t, "GET", baseURL+"/debug/decode/"+code, nil, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d for %s, got %d", http.StatusOK, code, resp.StatusCode)
+	}
+	var body decodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return body
+}
+
+func TestDebugDecode(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/secret"})
+
+	resp := doJSON(t, "GET", server.URL+"/debug/decode/"+code, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without the admin token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	t.Run("valid code", func(t *testing.T) {
+		body := decodeCode(t, server.URL, code)
+		if body.DecodedID == nil || body.MappingID == nil || *body.DecodedID != *body.MappingID {
+			t.Fatalf("Expected the decoded ID to equal the mapping ID, got %+v", body)
+		}
+		if body.Mapping != "found" || !body.IDMatches || body.DecodeError != "" {
+			t.Errorf("Expected a matching mapping, got %+v", body)
+		}
+		if body.LongURL != "" {
+			t.Errorf("The destination should not be exposed, got %q", body.LongURL)
+		}
+	})
+
+	t.Run("valid code without mapping", func(t *testing.T) {
+		body := decodeCode(t, server.URL, "4c92")
+		if body.DecodedID == nil || *body.DecodedID != 1000000 {
+			t.Errorf("Expected 4c92 to decode to 1000000, got %+v", body)
+		}
+		if body.Mapping != "no mapping" || body.MappingID != nil || body.IDMatches {
+			t.Errorf("Expected no mapping, got %+v", body)
+		}
+	})
+
+	t.Run("invalid character", func(t *testing.T) {
+		body := decodeCode(t, server.URL, "ab-c")
+		if body.DecodedID != nil || !strings.Contains(body.DecodeError, "invalid base62") {
+			t.Errorf("Expected an invalid base62 error, got %+v", body)
+		}
+		if body.Mapping != "no mapping" {
+			t.Errorf("Expected no mapping, got %+v", body)
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		body := decodeCode(t, server.URL, "zzzzzzzzzzzz")
+		if body.DecodedID != nil || !strings.Contains(body.DecodeError, "overflows") {
+			t.Errorf("Expected an overflow error, got %+v", body)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+)
+
+// createShortURL posts body to /urls and returns the short URL from the response
+func createShortURL(t *testing.T, serverURL string, body interface{}) string {
+	t.Helper()
+
+	resp := doJSON(t, "POST", serverURL+"/urls", body, nil)
+	defer resp.Body.Close()
+	var createResp CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	return createResp.ShortURL
+}
+
+func TestDuplicateSubmissionWithinWindow(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.DedupWindow = time.Minute
+	})
+	defer server.Close()
+
+	first := createShortURL(t, server.URL, map[string]interface{}{"long_url": "https://example.com/double-click"})
+	second := createShortURL(t, server.URL, map[string]interface{}{"long_url": "HTTPS://Example.com:443/double-click"})
+	if first == "" || first != second {
+		t.Errorf("Expected identical short URLs within the window, got %q and %q", first, second)
+	}
+
+	// Different settings create a distinct link
+	limited := createShortURL(t, server.URL, map[string]interface{}{"long_url": "https://example.com/double-click", "max_uses": 1})
+	if limited == first {
+		t.Errorf("Expected a new short URL when settings differ, got %q again", limited)
+	}
+
+	// Password-protected links are never deduplicated
+	body := map[string]interface{}{"long_url": "https://example.com/secret", "password": "hunter2"}
+	if a, b := createShortURL(t, server.URL, body), createShortURL(t, server.URL, body); a == b {
+		t.Errorf("Expected distinct short URLs for password-protected links, got %q twice", a)
+	}
+}
+
+func TestDuplicateSubmissionPerOwner(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.DedupWindow = time.Minute
+		cfg.APIKeys = map[string]string{"key-acme": "acme", "key-beta": "beta"}
+	})
+	defer server.Close()
+
+	// Both owners call from the same IP; neither may receive the other's link
+	create := func(apiKey string) CreateURLResponse {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/shared"}, map[string]string{"X-API-Key": apiKey})
+		defer resp.Body.Close()
+		var createResp CreateURLResponse
+		if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
+			t.Fatalf("Failed to decode create response: %v", err)
+		}
+		return createResp
+	}
+	acme, beta := create("key-acme"), create("key-beta")
+	if acme.ShortURL == "" || acme.ShortURL == beta.ShortURL {
+		t.Errorf("Expected distinct short URLs per owner, got %q and %q", acme.ShortURL, beta.ShortURL)
+	}
+	if again := create("key-acme"); again.ShortURL != acme.ShortURL {
+		t.Errorf("Expected the same owner's resubmission to be deduplicated, got %q and %q", acme.ShortURL, again.ShortURL)
+	}
+}
+
+func TestDuplicateSubmissionDisabledByDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	body := map[string]interface{}{"long_url": "https://example.com/double-click"}
+	if a, b := createShortURL(t, server.URL, body), createShortURL(t, server.URL, body); a == b {
+		t.Errorf("Expected distinct short URLs without DEDUP_WINDOW, got %q twice", a)
+	}
+}
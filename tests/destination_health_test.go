@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+)
+
+func TestDestinationHealthWarning(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Servers that don't support HEAD still count as up
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer up.Close()
+
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.CheckDestinationHealth = true
+		cfg.DestinationHealthInterval = 20 * time.Millisecond
+		cfg.RateLimitDisabled = true
+	})
+	defer server.Close()
+
+	downCode := createShortCode(t, server.URL, map[string]interface{}{"long_url": down.URL + "/page"})
+	upCode := createShortCode(t, server.URL, map[string]interface{}{"long_url": up.URL + "/page"})
+
+	// Checks run in the background; wait for the failures to be cached
+	var resp *http.Response
+	var body []byte
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp = doJSON(t, "GET", server.URL+"/"+downCode, nil, nil)
+		body, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the warning page (status %d), got %d", http.StatusOK, resp.StatusCode)
+	}
+	if status := resp.Header.Get("X-Destination-Status"); status != "down" {
+		t.Errorf("Expected X-Destination-Status: down, got %q", status)
+	}
+	if !strings.Contains(string(body), `<a href="`+down.URL+`/page">Continue anyway</a>`) {
+		t.Errorf("Expected a link to continue to the destination, got:\n%s", body)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/"+upCode, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected a healthy destination to redirect, got %d", resp.StatusCode)
+	}
+}
+
+func TestDestinationHealthDisabledByDefault(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": down.URL})
+	resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d without CHECK_DESTINATION_HEALTH, got %d", http.StatusFound, resp.StatusCode)
+	}
+}
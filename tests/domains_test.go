@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestAdminDomainCounts(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	for _, longURL := range []string{
+		"https://spam.example/1",
+		"https://spam.example/2",
+		"https://Spam.Example/3",
+		"https://example.com/a",
+		"https://other.org/b",
+	} {
+		createShortCode(t, server.URL, map[string]interface{}{"long_url": longURL})
+	}
+
+	resp := doJSON(t, "GET", server.URL+"/admin/domains", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without the admin token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/admin/domains?limit=2", nil, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var body struct {
+		Total   int `json:"total"`
+		Domains []struct {
+			Domain string `json:"domain"`
+			Count  int    `json:"count"`
+		} `json:"domains"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Total != 5 {
+		t.Errorf("Expected total 5, got %d", body.Total)
+	}
+	if len(body.Domains) != 2 {
+		t.Fatalf("Expected the top 2 domains, got %+v", body.Domains)
+	}
+	if body.Domains[0].Domain != "spam.example" || body.Domains[0].Count != 3 {
+		t.Errorf("Expected spam.example with 3 links first, got %+v", body.Domains[0])
+	}
+	if body.Domains[1].Domain != "example.com" || body.Domains[1].Count != 1 {
+		t.Errorf("Expected example.com second (ties ordered by name), got %+v", body.Domains[1])
+	}
+}
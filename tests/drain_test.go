@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+	"tiny-url-service/handlers"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+func TestDrainMode(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	shortCode := createShortCode(t, server.URL, CreateURLRequest{LongURL: "https://example.com/drain"})
+
+	// Enable drain mode
+	resp := doJSON(t, "POST", server.URL+"/admin/drain", map[string]bool{"draining": true}, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d enabling drain, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	// Creates are refused
+	resp = doJSON(t, "POST", server.URL+"/urls", CreateURLRequest{LongURL: "https://example.com/new"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected create status %d while draining, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	// Redirects keep working
+	resp = doJSON(t, "GET", server.URL+"/"+shortCode, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected redirect status %d while draining, got %d", http.StatusFound, resp.StatusCode)
+	}
+
+	// Readiness reports not-ready
+	resp = doJSON(t, "GET", server.URL+"/ready", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected /ready status %d while draining, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	// Disable drain mode and creates work again
+	resp = doJSON(t, "POST", server.URL+"/admin/drain", map[string]bool{"draining": false}, adminHeaders())
+	resp.Body.Close()
+
+	resp = doJSON(t, "POST", server.URL+"/urls", CreateURLRequest{LongURL: "https://example.com/new"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected create status %d after draining, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/admin/drain", map[string]bool{"draining": true}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	disabled := setupTestServer()
+	defer disabled.Close()
+
+	resp = doJSON(t, "POST", disabled.URL+"/admin/drain", map[string]bool{"draining": true}, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d when admin API is disabled, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func TestAdminGetURLMappingIncludesExpired(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	pastTime := time.Now().Add(-time.Hour)
+	shortCode, err := store.Store(&models.URLMapping{
+		LongURL:        "https://example.com/expired",
+		ExpirationDate: &pastTime,
+	})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	// The public redirect still treats the link as gone
+	resp, err := noRedirectClient.Get(server.URL + "/" + shortCode)
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected redirect status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/admin/urls/"+shortCode, nil, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected admin status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var body struct {
+		Mapping            models.URLMapping `json:"mapping"`
+		Expired            bool              `json:"expired"`
+		SecondsUntilExpiry *int64            `json:"seconds_until_expiry"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !body.Expired || body.Mapping.LongURL != "https://example.com/expired" {
+		t.Errorf("Expected expired mapping for https://example.com/expired, got %+v", body)
+	}
+	if body.SecondsUntilExpiry == nil || *body.SecondsUntilExpiry > -3500 {
+		t.Errorf("Expected seconds_until_expiry around -3600, got %v", body.SecondsUntilExpiry)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/admin/urls/missing", nil, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestServerStateTracksConnections(t *testing.T) {
+	state := handlers.NewServerState()
+	for _, cs := range []http.ConnState{http.StateNew, http.StateNew, http.StateActive, http.StateIdle, http.StateClosed} {
+		state.TrackConn(nil, cs)
+	}
+	if open := state.OpenConns(); open != 1 {
+		t.Errorf("Expected 1 open connection, got %d", open)
+	}
+	state.TrackConn(nil, http.StateHijacked)
+	if open := state.OpenConns(); open != 0 {
+		t.Errorf("Expected hijacked connections to stop counting, got %d", open)
+	}
+	if inFlight, routes := state.InFlight(); inFlight != 0 || routes != "" {
+		t.Errorf("Expected nothing in flight without a router, got %d (%s)", inFlight, routes)
+	}
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+// getWithAccept fetches url with the given Accept header and returns the response and body
+func getWithAccept(t *testing.T, url, accept string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := noRedirectClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestErrorPageForBrowsers(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	browserAccept := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	resp, body := getWithAccept(t, server.URL+"/missing", browserAccept)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Expected an HTML page for a browser, got %s", ct)
+	}
+	if !strings.Contains(body, "<html>") || !strings.Contains(body, "Short URL not found") {
+		t.Errorf("Unexpected error page: %s", body)
+	}
+
+	// API clients keep getting JSON
+	resp, body = getWithAccept(t, server.URL+"/missing", "application/json")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected JSON for an API client, got %s", ct)
+	}
+	if !strings.Contains(body, `"error":"Short URL not found"`) {
+		t.Errorf("Unexpected JSON error: %s", body)
+	}
+}
+
+func TestErrorPageDir(t *testing.T) {
+	dir := t.TempDir()
+	page := `<html><body>Acme: {{.Title}} ({{.ShortCode}})</body></html>`
+	if err := os.WriteFile(filepath.Join(dir, "410.html"), []byte(page), 0o644); err != nil {
+		t.Fatalf("Failed to write page: %v", err)
+	}
+
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.ErrorPageDir = dir
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/once", "max_uses": 1})
+	resp, _ := getWithAccept(t, server.URL+"/"+code, "text/html")
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("First use should redirect, got %d", resp.StatusCode)
+	}
+
+	resp, body := getWithAccept(t, server.URL+"/"+code, "text/html")
+	if resp.StatusCode != http.StatusGone || body != "<html><body>Acme: Gone ("+code+")</body></html>" {
+		t.Errorf("Expected the custom 410 page, got %d %s", resp.StatusCode, body)
+	}
+
+	// Statuses without a custom file keep the built-in page
+	_, body = getWithAccept(t, server.URL+"/missing", "text/html")
+	if !strings.Contains(body, "Not Found") || strings.Contains(body, "Acme") {
+		t.Errorf("Expected the built-in 404 page, got %s", body)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestExpandShortURL(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/expanded"})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{"full short URL", "url=" + url.QueryEscape(server.URL+"/"+code), http.StatusOK},
+		{"bare code", "code=" + code, http.StatusOK},
+		{"foreign host", "url=" + url.QueryEscape("https://other.example.com/"+code), http.StatusBadRequest},
+		{"unknown code", "code=doesnotexist", http.StatusNotFound},
+		{"nothing supplied", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doJSON(t, "GET", server.URL+"/api/expand?"+tt.query, nil, nil)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var expanded struct {
+				ShortCode string `json:"short_code"`
+				LongURL   string `json:"long_url"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&expanded); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if expanded.ShortCode != code || expanded.LongURL != "https://example.com/expanded" {
+				t.Errorf("Unexpected expansion: %+v", expanded)
+			}
+		})
+	}
+}
+
+func TestExpandProtectedShortURL(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url": "https://example.com/secret",
+		"password": "hunter2",
+	})
+
+	resp := doJSON(t, "GET", server.URL+"/api/expand?code="+code, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without password, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/api/expand?code="+code+"&pw=hunter2", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d with password, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+)
+
+func TestExpiresAtHeader(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.ExpiresAtHeader = true
+	})
+	defer server.Close()
+
+	expiration := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	want := expiration.Format(time.RFC3339)
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":        "https://example.com/expiring",
+		"expiration_date": want,
+	}, nil)
+	var created CreateURLResponse
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Expires-At"); got != want {
+		t.Errorf("Create response X-Expires-At = %q, want %q", got, want)
+	}
+
+	resp, err := noRedirectClient.Get(created.ShortURL)
+	if err != nil {
+		t.Fatalf("Redirect failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("X-Expires-At") != want {
+		t.Errorf("Redirect X-Expires-At = %q (status %d), want %q", resp.Header.Get("X-Expires-At"), resp.StatusCode, want)
+	}
+
+	// Links that never expire get no header
+	resp = doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/forever"}, nil)
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Expires-At"); got != "" {
+		t.Errorf("Non-expiring link should have no X-Expires-At, got %q", got)
+	}
+	resp, err = noRedirectClient.Get(created.ShortURL)
+	if err != nil {
+		t.Fatalf("Redirect failed: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Expires-At"); got != "" {
+		t.Errorf("Non-expiring redirect should have no X-Expires-At, got %q", got)
+	}
+}
+
+func TestExpiresAtHeaderDisabled(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":        "https://example.com/expiring",
+		"expiration_date": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	}, nil)
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Expires-At"); got != "" {
+		t.Errorf("X-Expires-At should be off by default, got %q", got)
+	}
+}
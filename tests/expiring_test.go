@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+func TestExpiringURLs(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	now := time.Now()
+	for code, offset := range map[string]time.Duration{"soon": time.Hour, "later": 30 * time.Hour} {
+		exp := now.Add(offset)
+		if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/" + code, ExpirationDate: &exp}, code); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	expiring := func(query string) (int, []string) {
+		resp := doJSON(t, "GET", server.URL+"/admin/expiring"+query, nil, adminHeaders())
+		defer resp.Body.Close()
+		var body struct {
+			Results []struct {
+				ShortCode          string `json:"short_code"`
+				SecondsUntilExpiry int64  `json:"seconds_until_expiry"`
+			} `json:"results"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		var codes []string
+		for _, result := range body.Results {
+			codes = append(codes, result.ShortCode)
+		}
+		return resp.StatusCode, codes
+	}
+
+	if status, codes := expiring(""); status != http.StatusOK || len(codes) != 1 || codes[0] != "soon" {
+		t.Errorf("Expected only soon within the default 24h, got %d %v", status, codes)
+	}
+	if _, codes := expiring("?within=48h"); len(codes) != 2 || codes[0] != "soon" || codes[1] != "later" {
+		t.Errorf("Expected [soon later] within 48h, got %v", codes)
+	}
+	if status, _ := expiring("?within=-1h"); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a negative window, got %d", http.StatusBadRequest, status)
+	}
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+// setupExportServer starts an admin-enabled server over a store holding count
+// mappings, one of them expired
+func setupExportServer(t *testing.T, count int, configure func(cfg *config.Config)) string {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	pastTime := time.Now().Add(-time.Hour)
+	for i := 0; i < count; i++ {
+		mapping := &models.URLMapping{LongURL: "https://example.com/" + strconv.Itoa(i)}
+		if i == 0 {
+			mapping.ExpirationDate = &pastTime
+		}
+		if err := store.StoreWithCode(mapping, "code"+strconv.Itoa(i)); err != nil {
+			t.Fatalf("StoreWithCode() failed: %v", err)
+		}
+	}
+
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+		if configure != nil {
+			configure(cfg)
+		}
+	})
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestExportNDJSON(t *testing.T) {
+	// More mappings than one flush interval
+	const count = 250
+	serverURL := setupExportServer(t, count, nil)
+
+	headers := adminHeaders()
+	headers["Accept"] = "application/x-ndjson"
+	resp := doJSON(t, "GET", serverURL+"/admin/export", nil, headers)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected NDJSON content type, got %q", ct)
+	}
+
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var mapping models.URLMapping
+		if err := json.Unmarshal(scanner.Bytes(), &mapping); err != nil {
+			t.Fatalf("Line is not a JSON mapping: %q (%v)", scanner.Text(), err)
+		}
+		if mapping.ShortCode == "" || mapping.LongURL == "" {
+			t.Errorf("Incomplete mapping line: %q", scanner.Text())
+		}
+		seen[mapping.ShortCode] = true
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read NDJSON body: %v", err)
+	}
+	if len(seen) != count || !seen["code0"] {
+		t.Errorf("Expected %d mappings including the expired code0, got %d", count, len(seen))
+	}
+}
+
+func TestExportJSONDefault(t *testing.T) {
+	serverURL := setupExportServer(t, 3, nil)
+
+	resp := doJSON(t, "GET", serverURL+"/admin/export", nil, adminHeaders())
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var body struct {
+		Mappings []models.URLMapping `json:"mappings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Mappings) != 3 || body.Mappings[0].ShortCode != "code0" || body.Mappings[2].ShortCode != "code2" {
+		t.Errorf("Expected mappings code0..code2 in order, got %+v", body.Mappings)
+	}
+
+	// Admin only
+	resp = doJSON(t, "GET", serverURL+"/admin/export", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func TestExportNDJSONCamelCase(t *testing.T) {
+	serverURL := setupExportServer(t, 1, func(cfg *config.Config) {
+		cfg.JSONCase = "camel"
+	})
+
+	headers := adminHeaders()
+	headers["Accept"] = "application/x-ndjson"
+	resp := doJSON(t, "GET", serverURL+"/admin/export", nil, headers)
+	defer resp.Body.Close()
+
+	var line map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&line); err != nil {
+		t.Fatalf("Failed to decode line: %v", err)
+	}
+	if line["shortCode"] != "code0" || line["longUrl"] == nil {
+		t.Errorf("Expected camelCase keys in NDJSON lines, got %v", line)
+	}
+}
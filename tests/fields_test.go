@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+// decodeBody decodes a JSON response body into a generic map
+func decodeBody(t *testing.T, resp *http.Response) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return body
+}
+
+// keysOf returns the sorted keys of m
+func keysOf(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestFieldsProjection(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/projected", "tags": []string{"a"}})
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats?fields=short_code,long_url,access_count", nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	stats := decodeBody(t, resp)
+	if keys := keysOf(stats); len(keys) != 3 || keys[0] != "access_count" || keys[1] != "long_url" || keys[2] != "short_code" {
+		t.Errorf("Expected only the requested fields, got %v", keys)
+	}
+	if stats["long_url"] != "https://example.com/projected" {
+		t.Errorf("Unexpected long_url: %v", stats["long_url"])
+	}
+
+	search := doJSON(t, "GET", server.URL+"/urls/search?q=projected&fields=short_url", nil, adminHeaders())
+	defer search.Body.Close()
+	body := decodeBody(t, search)
+	results, _ := body["results"].([]interface{})
+	if len(results) != 1 {
+		t.Fatalf("Expected one search result, got %v", body)
+	}
+	if keys := keysOf(results[0].(map[string]interface{})); len(keys) != 1 || keys[0] != "short_url" {
+		t.Errorf("Expected only short_url in search results, got %v", keys)
+	}
+	if _, ok := body["next_cursor"]; !ok {
+		t.Error("Projection should only apply to the listed items")
+	}
+}
+
+func TestFieldsProjectionUnknownField(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com", "tags": []string{"a"}})
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats?fields=short_code,password_hash", nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	body := decodeBody(t, resp)
+	if body["error"] != "Unknown fields: password_hash" || body["field"] != "fields" {
+		t.Errorf("Unexpected error body: %v", body)
+	}
+
+	// Every field a full stats response can carry must be projectable
+	valid := make(map[string]bool)
+	for _, field := range body["valid_fields"].([]interface{}) {
+		valid[field.(string)] = true
+	}
+	full := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats?series=hourly", nil, nil)
+	defer full.Body.Close()
+	for _, key := range keysOf(decodeBody(t, full)) {
+		if !valid[key] {
+			t.Errorf("Stats field %q is missing from the projectable fields", key)
+		}
+	}
+}
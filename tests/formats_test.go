@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// createWithFormats creates a link with ?formats= and returns the status and formats
+func createWithFormats(t *testing.T, serverURL, formats string, body map[string]interface{}) (int, CreateURLResponse, map[string]string) {
+	t.Helper()
+
+	resp := doJSON(t, "POST", serverURL+"/urls?formats="+formats, body, nil)
+	defer resp.Body.Close()
+	var created struct {
+		CreateURLResponse
+		Formats map[string]string `json:"formats"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return resp.StatusCode, created.CreateURLResponse, created.Formats
+}
+
+func TestCreateFormats(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	status, created, formats := createWithFormats(t, server.URL, "markdown,html,code,plain", map[string]interface{}{
+		"long_url": "https://example.com/docs",
+		"title":    "Docs",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+	expected := map[string]string{
+		"plain":    created.ShortURL,
+		"code":     created.ShortCode,
+		"markdown": "[Docs](" + created.ShortURL + ")",
+		"html":     `<a href="` + created.ShortURL + `">Docs</a>`,
+	}
+	for name, value := range expected {
+		if formats[name] != value {
+			t.Errorf("formats[%s] = %q, expected %q", name, formats[name], value)
+		}
+	}
+
+	// Without a title the long URL is the link text; unrequested formats are omitted
+	_, created, formats = createWithFormats(t, server.URL, "markdown", map[string]interface{}{"long_url": "https://example.com/untitled"})
+	if len(formats) != 1 || formats["markdown"] != "[https://example.com/untitled]("+created.ShortURL+")" {
+		t.Errorf("Expected only a markdown variant labeled with the long URL, got %v", formats)
+	}
+
+	// No formats requested, no formats object
+	if _, _, formats = createWithFormats(t, server.URL, "", map[string]interface{}{"long_url": "https://example.com/none"}); formats != nil {
+		t.Errorf("Expected no formats without ?formats=, got %v", formats)
+	}
+
+	if status, _, _ = createWithFormats(t, server.URL, "markdown,qr", map[string]interface{}{"long_url": "https://example.com/bad"}); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown format, got %d", http.StatusBadRequest, status)
+	}
+}
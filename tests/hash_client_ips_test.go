@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tiny-url-service/config"
+	"tiny-url-service/handlers"
+	"tiny-url-service/middleware"
+	"tiny-url-service/storage"
+)
+
+func TestHashClientIPs(t *testing.T) {
+	const clientIP = "203.0.113.9"
+	const salt = "pepper"
+	hashed := middleware.HashIP(clientIP, salt)
+
+	// The request logger writes to gin.DefaultWriter, captured here
+	var logs bytes.Buffer
+	defer func(w io.Writer) { gin.DefaultWriter = w }(gin.DefaultWriter)
+	gin.DefaultWriter = &logs
+
+	auditLogger, err := storage.NewFileAuditLogger(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to create audit logger: %v", err)
+	}
+	defer auditLogger.Close()
+
+	server := setupTestServerWithStore(nil, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+		cfg.TrustedPlatform = "cloudflare"
+		cfg.HashClientIPs = true
+		cfg.ClientIPSalt = salt
+		cfg.ClickSampleRate = 1
+		cfg.TrackUniqueVisitors = true
+	}, handlers.WithAuditLogger(auditLogger))
+	defer server.Close()
+
+	client := map[string]string{"CF-Connecting-IP": clientIP}
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/private"}, client)
+	var created CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+
+	resp = doJSON(t, "GET", server.URL+"/"+created.ShortCode, nil, client)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/urls/"+created.ShortCode+"/stats?events=1", nil, nil)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read stats: %v", err)
+	}
+	var stats struct {
+		UniqueVisitors int `json:"unique_visitors"`
+		RecentEvents   []struct {
+			Visitor string `json:"visitor"`
+		} `json:"recent_events"`
+	}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if len(stats.RecentEvents) != 1 || stats.RecentEvents[0].Visitor != hashed {
+		t.Errorf("Expected one event from visitor %s, got %+v", hashed, stats.RecentEvents)
+	}
+	if stats.UniqueVisitors != 1 {
+		t.Errorf("Expected 1 unique visitor, got %d", stats.UniqueVisitors)
+	}
+	if strings.Contains(string(body), clientIP) {
+		t.Errorf("Recorded analytics contain the raw client IP:\n%s", body)
+	}
+
+	entries, err := auditLogger.Since(time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "ip:"+hashed {
+		t.Errorf("Expected the create audited as ip:%s, got %+v", hashed, entries)
+	}
+
+	if !strings.Contains(logs.String(), hashed) {
+		t.Errorf("Expected the request log to show the hashed IP, got:\n%s", logs.String())
+	}
+	if strings.Contains(logs.String(), clientIP) {
+		t.Errorf("Request log contains the raw client IP:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+// failingGetStore stores normally but fails every read, simulating a
+// misconfigured backend
+type failingGetStore struct {
+	*storage.MemoryStorage
+}
+
+func (s failingGetStore) Get(shortCode string) (*models.URLMapping, error) {
+	return nil, errors.New("read replica unavailable")
+}
+
+type healthResponse struct {
+	Status  string            `json:"status"`
+	Checks  map[string]string `json:"checks"`
+	Runtime *struct {
+		Goroutines    int    `json:"goroutines"`
+		AllocBytes    uint64 `json:"alloc_bytes"`
+		StartedAt     string `json:"started_at"`
+		UptimeSeconds int64  `json:"uptime_seconds"`
+	} `json:"runtime"`
+	Stats struct {
+		TotalURLs int `json:"total_urls"`
+	} `json:"stats"`
+}
+
+func getHealth(t *testing.T, url string) (int, healthResponse) {
+	t.Helper()
+
+	resp := doJSON(t, "GET", url, nil, nil)
+	defer resp.Body.Close()
+
+	var health healthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatalf("Failed to decode health response: %v", err)
+	}
+	return resp.StatusCode, health
+}
+
+func TestDeepHealthCheck(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	status, health := getHealth(t, server.URL+"/health")
+	if status != http.StatusOK || health.Checks != nil {
+		t.Errorf("Plain health check should skip deep checks, got %d %+v", status, health)
+	}
+
+	status, health = getHealth(t, server.URL+"/health?deep=1")
+	if status != http.StatusOK || health.Status != "healthy" {
+		t.Fatalf("Expected healthy deep check, got %d %+v", status, health)
+	}
+	for name, result := range health.Checks {
+		if result != "ok" {
+			t.Errorf("Check %s = %q, expected ok", name, result)
+		}
+	}
+
+	// The throwaway mapping must not linger
+	_, health = getHealth(t, server.URL+"/health")
+	if health.Stats.TotalURLs != 0 {
+		t.Errorf("Expected no stored URLs after the deep check, got %d", health.Stats.TotalURLs)
+	}
+}
+
+func TestDeepHealthCheckFailureCleansUp(t *testing.T) {
+	store := failingGetStore{storage.NewMemoryStorage("http://localhost:8080")}
+	server := setupTestServerWithStore(store, nil)
+	defer server.Close()
+
+	status, health := getHealth(t, server.URL+"/health?deep=1")
+	if status != http.StatusServiceUnavailable || health.Status != "unhealthy" {
+		t.Fatalf("Expected unhealthy deep check, got %d %+v", status, health)
+	}
+	if health.Checks["storage_round_trip"] == "ok" {
+		t.Error("Expected the storage round trip check to fail")
+	}
+
+	if total := store.GetStats()["total_urls"]; total != 0 {
+		t.Errorf("Throwaway mapping should be cleaned up after a failed check, total_urls = %v", total)
+	}
+}
+
+func TestDeepHealthCheckFullStore(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithMaxURLs(1))
+	if _, err := store.Store(&models.URLMapping{LongURL: "https://www.example.com"}); err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+	server := setupTestServerWithStore(store, nil)
+	defer server.Close()
+
+	// Reaching MAX_URLS is a capacity limit, not an outage
+	status, health := getHealth(t, server.URL+"/health?deep=1")
+	if status != http.StatusOK || health.Checks["storage_round_trip"] != "ok" {
+		t.Fatalf("Expected a full store to pass the deep check, got %d %+v", status, health)
+	}
+	if total := store.GetStats()["total_urls"]; total != 1 {
+		t.Errorf("Expected total_urls 1 after the deep check, got %v", total)
+	}
+}
+
+func TestHealthRuntimeStats(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	// Off by default to keep liveness probes cheap
+	if _, health := getHealth(t, server.URL+"/health"); health.Runtime != nil {
+		t.Errorf("Plain health check should not include runtime stats, got %+v", health.Runtime)
+	}
+
+	status, health := getHealth(t, server.URL+"/health?runtime=1")
+	if status != http.StatusOK || health.Runtime == nil {
+		t.Fatalf("Expected runtime stats, got %d %+v", status, health)
+	}
+	if health.Runtime.Goroutines <= 0 || health.Runtime.AllocBytes == 0 {
+		t.Errorf("Expected non-zero goroutine and allocation figures, got %+v", health.Runtime)
+	}
+	startedAt, err := time.Parse(time.RFC3339, health.Runtime.StartedAt)
+	if err != nil || time.Since(startedAt) > time.Minute || health.Runtime.UptimeSeconds < 0 {
+		t.Errorf("Expected a recent start time, got %q (uptime %ds)", health.Runtime.StartedAt, health.Runtime.UptimeSeconds)
+	}
+}
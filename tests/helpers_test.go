@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const testAdminToken = "test-admin-token"
+
+// noRedirectClient returns redirects to the caller instead of following them
+var noRedirectClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// doJSON sends body (JSON-encoded when not nil) with the given method and headers
+func doJSON(t *testing.T, method, url string, body interface{}, headers map[string]string) *http.Response {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := noRedirectClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	return resp
+}
+
+// adminHeaders returns the Authorization header for admin endpoints
+func adminHeaders() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + testAdminToken}
+}
+
+// createShortCode creates a short URL from body and returns its code
+func createShortCode(t *testing.T, serverURL string, body interface{}) string {
+	t.Helper()
+
+	resp := doJSON(t, "POST", serverURL+"/urls", body, nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d creating short URL, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var createResp CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	return createResp.ShortCode
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+// redirectLocation creates a link from body on serverURL and returns where it redirects
+func redirectLocation(t *testing.T, serverURL string, body map[string]interface{}) string {
+	t.Helper()
+
+	code := createShortCode(t, serverURL, body)
+	resp, err := noRedirectClient.Get(serverURL + "/" + code)
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+	return resp.Header.Get("Location")
+}
+
+func TestUpgradeHTTPRedirects(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.UpgradeHTTPRedirects = true
+	})
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		body     map[string]interface{}
+		expected string
+	}{
+		{"http upgraded", map[string]interface{}{"long_url": "http://example.com/page?q=1"}, "https://example.com/page?q=1"},
+		{"default port dropped", map[string]interface{}{"long_url": "http://example.com:80/page"}, "https://example.com/page"},
+		{"https unchanged", map[string]interface{}{"long_url": "https://example.com/secure"}, "https://example.com/secure"},
+		{"other port unchanged", map[string]interface{}{"long_url": "http://example.com:8080/app"}, "http://example.com:8080/app"},
+		{"link opted out", map[string]interface{}{"long_url": "http://legacy.example.com/", "no_https_upgrade": true}, "http://legacy.example.com/"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if location := redirectLocation(t, server.URL, tc.body); location != tc.expected {
+				t.Errorf("Expected Location %q, got %q", tc.expected, location)
+			}
+		})
+	}
+}
+
+func TestUpgradeHTTPRedirectsDisabledByDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	if location := redirectLocation(t, server.URL, map[string]interface{}{"long_url": "http://example.com/page"}); location != "http://example.com/page" {
+		t.Errorf("Expected the http destination unchanged, got %q", location)
+	}
+}
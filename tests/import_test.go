@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+type importResponse struct {
+	Imported int `json:"imported"`
+	Failed   int `json:"failed"`
+	Errors   []struct {
+		Line  int    `json:"line"`
+		Error string `json:"error"`
+	} `json:"errors"`
+}
+
+func TestImportCSVWithColumnMapping(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/existing",
+		"custom_code": "taken",
+	})
+
+	// Legacy layout: extra columns, URL before the code, different names
+	csv := "owner,target,notes,slug,expires\n" +
+		"alice,https://example.com/docs,\"docs, v2\",docs,2099-12-31\n" +
+		"bob,https://example.com/blog,,blog,\n" +
+		"carol,not a url,,bad-url,\n" +
+		"dave,https://example.com/short\n" +
+		"erin,https://example.com/taken,,taken,\n" +
+		"frank,https://example.com/date,,dated,someday\n"
+
+	resp := doJSON(t, "POST", server.URL+"/admin/import", map[string]interface{}{
+		"csv": csv,
+		"mapping": map[string]string{
+			"code_col":       "slug",
+			"url_col":        "target",
+			"expiration_col": "expires",
+		},
+	}, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var result importResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Imported != 2 || result.Failed != 4 {
+		t.Fatalf("Expected 2 imported and 4 failed, got %+v", result)
+	}
+
+	expectedLines := []int{4, 5, 6, 7}
+	for i, rowErr := range result.Errors {
+		if rowErr.Line != expectedLines[i] {
+			t.Errorf("Error %d: expected line %d, got %d (%s)", i, expectedLines[i], rowErr.Line, rowErr.Error)
+		}
+	}
+	if result.Errors[1].Error != "missing slug" {
+		t.Errorf("Expected the short row to be missing slug, got %q", result.Errors[1].Error)
+	}
+
+	redirect := doJSON(t, "GET", server.URL+"/docs", nil, nil)
+	redirect.Body.Close()
+	if location := redirect.Header.Get("Location"); location != "https://example.com/docs" {
+		t.Errorf("Expected the imported code to redirect, got Location %q", location)
+	}
+
+	stats := doJSON(t, "GET", server.URL+"/urls/docs/stats", nil, nil)
+	defer stats.Body.Close()
+	var body map[string]interface{}
+	if err := json.NewDecoder(stats.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if expiration, _ := body["expiration_date"].(string); len(expiration) < 10 || expiration[:10] != "2099-12-31" {
+		t.Errorf("Expected the imported expiration, got %v", body["expiration_date"])
+	}
+}
+
+func TestImportCSVMissingHeaderColumn(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	// The default columns are short_code and long_url
+	resp := doJSON(t, "POST", server.URL+"/admin/import", map[string]interface{}{
+		"csv": "slug,target\ndocs,https://example.com/docs\n",
+	}, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["field"] != "mapping" {
+		t.Errorf("Expected field mapping, got %v", body["field"])
+	}
+}
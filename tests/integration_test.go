@@ -23,7 +23,9 @@ type CreateURLRequest struct {
 }
 
 type CreateURLResponse struct {
-	ShortURL string `json:"short_url"`
+	ShortURL  string     `json:"short_url"`
+	ShortCode string     `json:"short_code"`
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 type URLStats struct {
@@ -34,6 +36,18 @@ type URLStats struct {
 }
 
 func setupTestServer() *httptest.Server {
+	return setupTestServerWithConfig(nil)
+}
+
+// setupTestServerWithConfig starts a test server backed by memory storage,
+// letting the caller adjust the configuration before the router is built
+func setupTestServerWithConfig(configure func(cfg *config.Config)) *httptest.Server {
+	return setupTestServerWithStore(nil, configure)
+}
+
+// setupTestServerWithStore starts a test server backed by the given storage
+// (memory storage when nil) so tests can seed or inspect it directly
+func setupTestServerWithStore(store storage.Storage, configure func(cfg *config.Config), opts ...handlers.RouterOption) *httptest.Server {
 	server := httptest.NewServer(nil)
 	
 	cfg := &config.Config{
@@ -41,9 +55,14 @@ func setupTestServer() *httptest.Server {
 		BaseURL: server.URL,
 		GinMode: "test",
 	}
+	if configure != nil {
+		configure(cfg)
+	}
 	
-	store := storage.NewMemoryStorage(cfg.BaseURL)
-	router := handlers.SetupRouter(store, cfg)
+	if store == nil {
+		store = storage.NewMemoryStorage(cfg.BaseURL)
+	}
+	router := handlers.SetupRouter(store, cfg, opts...)
 	server.Config.Handler = router
 	
 	return server
@@ -127,6 +146,10 @@ func TestCreateShortURL(t *testing.T) {
 				if !strings.HasPrefix(response.ShortURL, server.URL) {
 					t.Errorf("Short URL should start with %s, got %s", server.URL, response.ShortURL)
 				}
+
+				if response.ShortCode == "" || response.ShortURL != server.URL+"/"+response.ShortCode {
+					t.Errorf("Expected short_code to match short_url %s, got %q", response.ShortURL, response.ShortCode)
+				}
 			}
 		})
 	}
@@ -259,6 +282,7 @@ func TestErrorCases(t *testing.T) {
 		contentType    string
 		body           string
 		expectedStatus int
+		expectedCode   string
 	}{
 		{
 			name:           "Missing Content-Type",
@@ -283,6 +307,7 @@ func TestErrorCases(t *testing.T) {
 			contentType:    "application/json",
 			body:           `{"invalid": json}`,
 			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "MALFORMED_JSON",
 		},
 		{
 			name:           "Non-existent short code",
@@ -325,10 +350,42 @@ func TestErrorCases(t *testing.T) {
 			if resp.StatusCode != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
+			if tt.expectedCode != "" {
+				var errResp struct {
+					Code string `json:"code"`
+				}
+				json.NewDecoder(resp.Body).Decode(&errResp)
+				if errResp.Code != tt.expectedCode {
+					t.Errorf("Expected code %s, got %q", tt.expectedCode, errResp.Code)
+				}
+			}
 		})
 	}
 }
 
+func TestUnmatchedRoutesReturnJSON404(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	for _, path := range []string{"/", "/no/such/path", "/urls/abc/unknown"} {
+		resp := doJSON(t, "GET", server.URL+path, nil, nil)
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("%s: expected a JSON response, got Content-Type %q", path, ct)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Errorf("%s: failed to decode error response: %v", path, err)
+		} else if body["error"] != "Not found" {
+			t.Errorf("%s: expected error \"Not found\", got %v", path, body["error"])
+		}
+		resp.Body.Close()
+	}
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	server := setupTestServer()
 	defer server.Close()
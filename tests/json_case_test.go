@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestCamelCaseResponses(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.JSONCase = "camel"
+	})
+	defer server.Close()
+
+	jsonData, _ := json.Marshal(CreateURLRequest{LongURL: "https://example.com/camel"})
+	resp, err := http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		t.Fatalf("Failed to create short URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var created map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	shortURL, ok := created["shortUrl"].(string)
+	if !ok {
+		t.Fatalf("Expected shortUrl key in response, got %v", created)
+	}
+	shortCode := strings.TrimPrefix(shortURL, server.URL+"/")
+
+	resp, err = http.Get(server.URL + "/urls/" + shortCode + "/stats")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var stats map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+	for _, key := range []string{"shortCode", "longUrl", "createdAt"} {
+		if _, ok := stats[key]; !ok {
+			t.Errorf("Expected %s key in stats response, got %v", key, stats)
+		}
+	}
+	if _, ok := stats["short_code"]; ok {
+		t.Error("snake_case keys should not appear in camelCase mode")
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+type jsonAPIResource struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Meta       map[string]interface{} `json:"meta"`
+}
+
+// decodeJSONAPI checks the JSON:API media type and decodes the document into v
+func decodeJSONAPI(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/vnd.api+json") {
+		t.Errorf("Expected application/vnd.api+json, got %s", ct)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+}
+
+func TestJSONAPIResponses(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.APIFormat = "jsonapi"
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/jsonapi"}, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var created struct {
+		Data jsonAPIResource `json:"data"`
+	}
+	decodeJSONAPI(t, resp, &created)
+	if created.Data.Type != "urls" || created.Data.ID == "" {
+		t.Fatalf("Expected a urls resource identified by its code, got %+v", created.Data)
+	}
+	if created.Data.Attributes["short_url"] != server.URL+"/"+created.Data.ID {
+		t.Errorf("Unexpected attributes: %v", created.Data.Attributes)
+	}
+	if _, ok := created.Data.Attributes["short_code"]; ok {
+		t.Error("The short code should only be the resource id")
+	}
+
+	stats := doJSON(t, "GET", server.URL+"/urls/"+created.Data.ID+"/stats", nil, nil)
+	defer stats.Body.Close()
+	var statsDoc struct {
+		Data jsonAPIResource `json:"data"`
+	}
+	decodeJSONAPI(t, stats, &statsDoc)
+	if statsDoc.Data.ID != created.Data.ID || statsDoc.Data.Attributes["access_count"] != float64(0) {
+		t.Errorf("Unexpected stats resource: %+v", statsDoc.Data)
+	}
+	// "id" is reserved by JSON:API, so the storage ID moves to meta
+	if _, ok := statsDoc.Data.Attributes["id"]; ok || statsDoc.Data.Meta["id"] == nil {
+		t.Errorf("Expected the storage ID in the resource meta, got %+v", statsDoc.Data)
+	}
+
+	search := doJSON(t, "GET", server.URL+"/urls/search?q=jsonapi", nil, adminHeaders())
+	defer search.Body.Close()
+	var list struct {
+		Data []jsonAPIResource      `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	decodeJSONAPI(t, search, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != created.Data.ID || list.Data[0].Type != "urls" {
+		t.Errorf("Expected the link as the only list resource, got %+v", list.Data)
+	}
+	if _, ok := list.Meta["next_cursor"]; !ok {
+		t.Errorf("Expected the cursor in meta, got %v", list.Meta)
+	}
+}
+
+func TestJSONAPIErrors(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.APIFormat = "jsonapi"
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "GET", server.URL+"/urls/missing/stats", nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+	var doc struct {
+		Errors []map[string]interface{} `json:"errors"`
+	}
+	decodeJSONAPI(t, resp, &doc)
+	if len(doc.Errors) != 1 {
+		t.Fatalf("Expected one error object, got %v", doc.Errors)
+	}
+	if e := doc.Errors[0]; e["status"] != "404" || e["title"] != "Not Found" || e["detail"] != "Short URL not found" {
+		t.Errorf("Unexpected error object: %v", e)
+	}
+
+	// Field errors point at the offending member
+	bad := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com", "expiration_date": "soon"}, nil)
+	defer bad.Body.Close()
+	decodeJSONAPI(t, bad, &doc)
+	source, _ := doc.Errors[0]["source"].(map[string]interface{})
+	if bad.StatusCode != http.StatusBadRequest || source["pointer"] != "/expiration_date" {
+		t.Errorf("Expected a 400 pointing at /expiration_date, got %d %v", bad.StatusCode, doc.Errors)
+	}
+}
+
+func TestPlainFormatByDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com"}, nil)
+	defer resp.Body.Close()
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if _, ok := body["data"]; ok || body["short_code"] == nil {
+		t.Errorf("Expected the plain response, got %v", body)
+	}
+}
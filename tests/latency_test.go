@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"tiny-url-service/config"
+)
+
+func TestDebugLatencyEndpoint(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+	for i := 0; i < 3; i++ {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+		resp.Body.Close()
+	}
+
+	resp := doJSON(t, "GET", server.URL+"/debug/latency", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without the admin token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/debug/latency", nil, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var body struct {
+		WindowSeconds float64 `json:"window_seconds"`
+		Routes        map[string]struct {
+			Count int64   `json:"count"`
+			P50Ms float64 `json:"p50_ms"`
+			P99Ms float64 `json:"p99_ms"`
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode latency response: %v", err)
+	}
+
+	redirects, ok := body.Routes["GET /:shortCode"]
+	if !ok || redirects.Count != 3 {
+		t.Fatalf("Expected 3 redirects recorded, got %+v", body.Routes)
+	}
+	if redirects.P50Ms <= 0 || redirects.P99Ms < redirects.P50Ms {
+		t.Errorf("Unexpected percentiles: %+v", redirects)
+	}
+	if _, ok := body.Routes["POST /urls"]; !ok {
+		t.Error("Expected POST /urls to be tracked")
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaxUsesLink(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"long_url": "https://example.com/one-time",
+		"max_uses": 1,
+	})
+	resp, err := http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to create short URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var createResp CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	shortCode := strings.TrimPrefix(createResp.ShortURL, server.URL+"/")
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	for i, expected := range []int{http.StatusFound, http.StatusGone, http.StatusGone} {
+		resp, err := client.Get(server.URL + "/" + shortCode)
+		if err != nil {
+			t.Fatalf("Failed to make redirect request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Errorf("Redirect %d: expected status %d, got %d", i+1, expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestMaxUsesNegativeRejected(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"long_url": "https://example.com",
+		"max_uses": -1,
+	})
+	resp, err := http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestUsesRemainingHeader(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/three", "max_uses": 3})
+
+	for _, expected := range []string{"2", "1", "0"} {
+		resp, err := noRedirectClient.Get(server.URL + "/" + code)
+		if err != nil {
+			t.Fatalf("Failed to make redirect request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+		}
+		if remaining := resp.Header.Get("X-Uses-Remaining"); remaining != expected {
+			t.Errorf("Expected X-Uses-Remaining %q, got %q", expected, remaining)
+		}
+	}
+
+	resp, err := noRedirectClient.Get(server.URL + "/" + code)
+	if err != nil {
+		t.Fatalf("Failed to make redirect request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGone {
+		t.Errorf("Expected status %d once used up, got %d", http.StatusGone, resp.StatusCode)
+	}
+	if remaining := resp.Header.Get("X-Uses-Remaining"); remaining != "" {
+		t.Errorf("Expected no X-Uses-Remaining on 410, got %q", remaining)
+	}
+}
+
+func TestUsesRemainingHeaderOmittedForUnlimitedLinks(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/unlimited"})
+	resp, err := noRedirectClient.Get(server.URL + "/" + code)
+	if err != nil {
+		t.Fatalf("Failed to make redirect request: %v", err)
+	}
+	resp.Body.Close()
+	if _, ok := resp.Header["X-Uses-Remaining"]; ok {
+		t.Errorf("Expected no X-Uses-Remaining for an unlimited link, got %q", resp.Header.Get("X-Uses-Remaining"))
+	}
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func setupNamespaceServer() *httptest.Server {
+	return setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.Namespaces = []string{"acme", "globex"}
+		cfg.APIKeys = map[string]string{"key-acme": "acme-corp"}
+		cfg.OwnerNamespaces = map[string]string{"acme-corp": "acme"}
+		cfg.RateLimitDisabled = true
+	})
+}
+
+func TestNamespacedCodes(t *testing.T) {
+	server := setupNamespaceServer()
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":    "https://acme.example.com/launch",
+		"custom_code": "launch",
+		"namespace":   "acme",
+	}, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var created CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	if created.ShortCode != "acme/launch" {
+		t.Errorf("Expected short code acme/launch, got %q", created.ShortCode)
+	}
+	if !strings.HasSuffix(created.ShortURL, "/acme/launch") {
+		t.Errorf("Expected short URL ending in /acme/launch, got %q", created.ShortURL)
+	}
+
+	// The same code in another namespace and in the default one don't collide
+	globex := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://globex.example.com/launch",
+		"custom_code": "launch",
+		"namespace":   "globex",
+	})
+	plain := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":    "https://example.com/launch",
+		"custom_code": "launch",
+	})
+
+	expected := map[string]string{
+		"acme/launch": "https://acme.example.com/launch",
+		globex:        "https://globex.example.com/launch",
+		plain:         "https://example.com/launch",
+	}
+	for path, target := range expected {
+		redirect, err := noRedirectClient.Get(server.URL + "/" + path)
+		if err != nil {
+			t.Fatalf("Redirect request failed: %v", err)
+		}
+		redirect.Body.Close()
+		if redirect.StatusCode != http.StatusFound {
+			t.Errorf("/%s: expected status %d, got %d", path, http.StatusFound, redirect.StatusCode)
+			continue
+		}
+		if location := redirect.Header.Get("Location"); location != target {
+			t.Errorf("/%s: expected Location %q, got %q", path, target, location)
+		}
+	}
+}
+
+func TestNamespacedGeneratedCode(t *testing.T) {
+	server := setupNamespaceServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":  "https://acme.example.com/generated",
+		"namespace": "acme",
+	})
+	if !strings.HasPrefix(code, "acme/") {
+		t.Fatalf("Expected a code under acme/, got %q", code)
+	}
+
+	resp, err := noRedirectClient.Get(server.URL + "/" + code)
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+
+	// The bare code isn't reachable outside its namespace
+	resp, err = noRedirectClient.Get(server.URL + "/" + strings.TrimPrefix(code, "acme/"))
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for the bare code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestUnknownNamespace(t *testing.T) {
+	server := setupNamespaceServer()
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":  "https://example.com",
+		"namespace": "initech",
+	}, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["field"] != "namespace" {
+		t.Errorf("Expected field namespace, got %v", body["field"])
+	}
+
+	redirect, err := noRedirectClient.Get(server.URL + "/initech/launch")
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	redirect.Body.Close()
+	if redirect.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, redirect.StatusCode)
+	}
+}
+
+func TestOwnerBoundNamespace(t *testing.T) {
+	server := setupNamespaceServer()
+	defer server.Close()
+
+	key := map[string]string{"X-API-Key": "key-acme"}
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":    "https://acme.example.com/owned",
+		"custom_code": "owned",
+	}, key)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var created CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	if created.ShortCode != "acme/owned" {
+		t.Errorf("Expected the key's namespace to apply, got %q", created.ShortCode)
+	}
+
+	other := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":  "https://acme.example.com/elsewhere",
+		"namespace": "globex",
+	}, key)
+	other.Body.Close()
+	if other.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d for another namespace, got %d", http.StatusForbidden, other.StatusCode)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestOwnerRateLimitsAreIndependent(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.APIKeys = map[string]string{"key-acme": "acme", "key-beta": "beta"}
+		cfg.OwnerRateLimit = 4
+		cfg.OwnerRateLimits = map[string]int{"beta": 6}
+	})
+	defer server.Close()
+
+	// Both owners call from the same IP (the test client)
+	exhaust := func(apiKey string) int {
+		allowed := 0
+		for i := 0; i < 8; i++ {
+			resp := doJSON(t, "GET", server.URL+"/health", nil, map[string]string{"X-API-Key": apiKey})
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				allowed++
+			} else if resp.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("Unexpected status %d", resp.StatusCode)
+			}
+		}
+		return allowed
+	}
+
+	if allowed := exhaust("key-acme"); allowed != 4 {
+		t.Errorf("Expected acme to get the default 4 requests, got %d", allowed)
+	}
+	if allowed := exhaust("key-beta"); allowed != 6 {
+		t.Errorf("Expected beta to get its own 6 requests, got %d", allowed)
+	}
+
+	// Anonymous traffic from that IP still has its own allowance
+	resp := doJSON(t, "GET", server.URL+"/health", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected anonymous request to pass, got %d", resp.StatusCode)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/health", nil, map[string]string{"X-API-Key": "bogus"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for an unknown API key, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.RateLimitDisabled = true
+	})
+	defer server.Close()
+
+	// Far beyond the default per-IP limit, all from the test client's IP
+	for i := 0; i < 1000; i++ {
+		resp := doJSON(t, "GET", server.URL+"/health", nil, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, http.StatusOK, resp.StatusCode)
+		}
+		for header := range resp.Header {
+			if strings.HasPrefix(header, "X-Ratelimit-") {
+				t.Fatalf("Request %d: unexpected rate limit header %s", i+1, header)
+			}
+		}
+	}
+}
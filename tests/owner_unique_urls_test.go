@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/storage"
+)
+
+func TestUniqueURLsPerOwner(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithReverseIndex(storage.SHA256Truncated(16)))
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.APIKeys = map[string]string{"key-acme": "acme", "key-beta": "beta"}
+		cfg.UniqueURLsPerOwner = true
+	})
+	defer server.Close()
+
+	create := func(apiKey string) string {
+		t.Helper()
+		var headers map[string]string
+		if apiKey != "" {
+			headers = map[string]string{"X-API-Key": apiKey}
+		}
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com/pricing"}, headers)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+		var created CreateURLResponse
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("Failed to decode create response: %v", err)
+		}
+		return created.ShortCode
+	}
+
+	acme := create("key-acme")
+	if again := create("key-acme"); again != acme {
+		t.Errorf("Expected acme's second create to return %s, got %s", acme, again)
+	}
+
+	beta := create("key-beta")
+	if beta == acme {
+		t.Errorf("Expected beta to get its own code, got acme's %s", acme)
+	}
+	if again := create("key-beta"); again != beta {
+		t.Errorf("Expected beta's second create to return %s, got %s", beta, again)
+	}
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestPasswordProtectedLink(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]string{
+		"long_url": "https://example.com/secret",
+		"password": "hunter2",
+	})
+	resp, err := http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to create short URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var createResp CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	shortCode := strings.TrimPrefix(createResp.ShortURL, server.URL+"/")
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		header         string
+		accept         string
+		expectedStatus int
+	}{
+		{"No password", "/" + shortCode, "", "", http.StatusUnauthorized},
+		{"Wrong password", "/" + shortCode + "?pw=wrong", "", "", http.StatusUnauthorized},
+		{"Correct query password", "/" + shortCode + "?pw=hunter2", "", "", http.StatusFound},
+		{"Correct header password", "/" + shortCode, "hunter2", "", http.StatusFound},
+		{"Browser gets form", "/" + shortCode, "", "text/html", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", server.URL+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Link-Password", tt.header)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.accept == "text/html" {
+				page, _ := io.ReadAll(resp.Body)
+				if !strings.Contains(string(page), "<form") {
+					t.Error("Expected an HTML password form")
+				}
+			}
+		})
+	}
+
+	// Stats report protection without leaking the hash
+	resp, err = http.Get(server.URL + "/urls/" + shortCode + "/stats")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	var stats map[string]interface{}
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+	if stats["password_protected"] != true {
+		t.Errorf("Expected password_protected true, got %v", stats["password_protected"])
+	}
+	if strings.Contains(string(raw), "$2a$") || strings.Contains(string(raw), "password_hash") {
+		t.Error("Stats response must not expose the password hash")
+	}
+}
+
+func TestPasswordFormKeepsPublicURLAndQuery(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.BaseURL += "/go"
+		cfg.Namespaces = []string{"acme"}
+	})
+	defer server.Close()
+
+	createShortCode(t, server.URL, map[string]string{
+		"long_url":    "https://example.com/vault",
+		"custom_code": "vault",
+		"namespace":   "acme",
+		"password":    "hunter2",
+	})
+
+	req, _ := http.NewRequest("GET", server.URL+"/acme/vault?exp=1700000000&sig=abc123&pw=", nil)
+	req.Header.Set("Accept", "text/html")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+	page, _ := io.ReadAll(resp.Body)
+
+	// The form resubmits to the public short URL under the base path, with
+	// the signed link's parameters intact
+	for _, want := range []string{
+		`action="/go/acme/vault"`,
+		`<input type="hidden" name="exp" value="1700000000">`,
+		`<input type="hidden" name="sig" value="abc123">`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("Expected the password form to contain %s, got:\n%s", want, page)
+		}
+	}
+	if strings.Contains(string(page), `name="pw" value`) {
+		t.Error("The password form should not carry an old pw parameter")
+	}
+}
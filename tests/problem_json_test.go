@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestProblemJSONErrors(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.ProblemJSON = true
+		cfg.JSONCase = "camel"
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "not-a-url"}, nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Errorf("Expected application/problem+json, got %s", ct)
+	}
+
+	var problem map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if problem["type"] != "about:blank" || problem["title"] != "Bad Request" || problem["status"] != float64(400) || problem["instance"] != "/urls" {
+		t.Errorf("Unexpected problem members: %v", problem)
+	}
+	if detail, _ := problem["detail"].(string); detail == "" {
+		t.Errorf("Expected the error message as detail, got %v", problem)
+	}
+
+	// Unmatched routes and admin auth failures use the same format
+	for _, path := range []string{"/urls/1/unknown", "/admin/export"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+			t.Errorf("Expected application/problem+json for %s, got %s", path, ct)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// statsBody fetches path and returns its raw body, failing on a non-200
+func statsBody(t *testing.T, method, url string, payload interface{}, headers map[string]string) string {
+	t.Helper()
+	resp := doJSON(t, method, url, payload, headers)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d from %s, got %d: %s", http.StatusOK, url, resp.StatusCode, body)
+	}
+	return string(body)
+}
+
+func TestProtectedStatsHideDestination(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	const secret = "https://example.com/secret-destination"
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":     secret,
+		"password":     "hunter2",
+		"destinations": []map[string]interface{}{{"url": secret + "/b", "weight": 1}},
+	})
+
+	for name, url := range map[string]string{
+		"no password":    server.URL + "/urls/" + code + "/stats",
+		"wrong password": server.URL + "/urls/" + code + "/stats?pw=wrong",
+	} {
+		body := statsBody(t, "GET", url, nil, nil)
+		if strings.Contains(body, secret) {
+			t.Errorf("%s: stats revealed the protected destination: %s", name, body)
+		}
+		if !strings.Contains(body, `"password_protected":true`) || !strings.Contains(body, `"destination_hidden":true`) {
+			t.Errorf("%s: expected the link flagged as protected, got %s", name, body)
+		}
+	}
+
+	batch := statsBody(t, "POST", server.URL+"/urls/stats/batch", map[string]interface{}{"short_codes": []string{code}}, nil)
+	if strings.Contains(batch, secret) {
+		t.Errorf("Batch stats revealed the protected destination: %s", batch)
+	}
+
+	// The link's password unlocks the destination, as it does for a redirect
+	unlocked := statsBody(t, "GET", server.URL+"/urls/"+code+"/stats", nil, map[string]string{"X-Link-Password": "hunter2"})
+	if !strings.Contains(unlocked, `"long_url":"`+secret+`"`) || !strings.Contains(unlocked, secret+"/b") {
+		t.Errorf("Expected the destinations with the correct password, got %s", unlocked)
+	}
+}
+
+func TestSignatureOnlyStatsHideDestination(t *testing.T) {
+	server := setupSigningServer(nil)
+	defer server.Close()
+
+	const secret = "https://example.com/signed-destination"
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": secret, "require_signature": true})
+
+	body := statsBody(t, "GET", server.URL+"/urls/"+code+"/stats", nil, nil)
+	if strings.Contains(body, secret) || !strings.Contains(body, `"destination_hidden":true`) {
+		t.Errorf("Stats revealed a signature-only destination: %s", body)
+	}
+	batch := statsBody(t, "POST", server.URL+"/urls/stats/batch", map[string]interface{}{"short_codes": []string{code}}, nil)
+	if strings.Contains(batch, secret) {
+		t.Errorf("Batch stats revealed a signature-only destination: %s", batch)
+	}
+
+	// A valid signature unlocks it
+	signed := signShortURL(t, server.URL, code, "1h")
+	query := signed[strings.Index(signed, "?"):]
+	unlocked := statsBody(t, "GET", server.URL+"/urls/"+code+"/stats"+query, nil, nil)
+	if !strings.Contains(unlocked, secret) {
+		t.Errorf("Expected the destination with a valid signature, got %s", unlocked)
+	}
+}
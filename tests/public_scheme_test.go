@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func createWithHeaders(t *testing.T, serverURL string, headers map[string]string) CreateURLResponse {
+	t.Helper()
+
+	jsonData, _ := json.Marshal(CreateURLRequest{LongURL: "https://example.com/scheme"})
+	req, _ := http.NewRequest("POST", serverURL+"/urls", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to create short URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	return response
+}
+
+func TestShortURLHonorsForwardedProto(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	response := createWithHeaders(t, server.URL, map[string]string{"X-Forwarded-Proto": "https"})
+	expectedPrefix := "https://" + strings.TrimPrefix(server.URL, "http://") + "/"
+	if !strings.HasPrefix(response.ShortURL, expectedPrefix) {
+		t.Errorf("Expected short URL with prefix %s, got %s", expectedPrefix, response.ShortURL)
+	}
+
+	// Without the header the configured base URL is used as-is
+	response = createWithHeaders(t, server.URL, nil)
+	if !strings.HasPrefix(response.ShortURL, server.URL+"/") {
+		t.Errorf("Expected short URL with prefix %s/, got %s", server.URL, response.ShortURL)
+	}
+}
+
+func TestShortURLPublicSchemeOverride(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.PublicScheme = "https"
+	})
+	defer server.Close()
+
+	// PUBLIC_SCHEME wins over the forwarded header
+	response := createWithHeaders(t, server.URL, map[string]string{"X-Forwarded-Proto": "http"})
+	if !strings.HasPrefix(response.ShortURL, "https://") {
+		t.Errorf("Expected https short URL, got %s", response.ShortURL)
+	}
+}
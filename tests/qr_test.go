@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// createWithQR creates a link with the given query string and returns the
+// status and the qr_data_uri field
+func createWithQR(t *testing.T, serverURL, query string) (int, string) {
+	t.Helper()
+
+	resp := doJSON(t, "POST", serverURL+"/urls?"+query, map[string]interface{}{"long_url": "https://example.com/qr"}, nil)
+	defer resp.Body.Close()
+	var created struct {
+		QRDataURI string `json:"qr_data_uri"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return resp.StatusCode, created.QRDataURI
+}
+
+func TestCreateQRDataURI(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	// qr_size is clamped to 64-1024; the default is 256
+	for query, width := range map[string]int{
+		"qr=true":              256,
+		"qr=true&qr_size=100":  100,
+		"qr=true&qr_size=1":    64,
+		"qr=true&qr_size=5000": 1024,
+	} {
+		status, uri := createWithQR(t, server.URL, query)
+		if status != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", query, http.StatusOK, status)
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,"))
+		if !strings.HasPrefix(uri, "data:image/png;base64,") || err != nil {
+			t.Fatalf("%s: expected a base64 PNG data URI, got %.40q", query, uri)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: qr_data_uri is not a PNG: %v", query, err)
+		}
+		if w := img.Bounds().Dx(); w != width {
+			t.Errorf("%s: expected a width of %d, got %d", query, width, w)
+		}
+	}
+
+	// Not requested, not rendered; qr_size alone is ignored
+	for _, query := range []string{"", "qr=false", "qr_size=abc"} {
+		if status, uri := createWithQR(t, server.URL, query); status != http.StatusOK || uri != "" {
+			t.Errorf("%q: expected 200 without qr_data_uri, got %d and %.40q", query, status, uri)
+		}
+	}
+
+	for _, query := range []string{"qr=true&qr_size=abc", "qr=true&qr_size=0", "qr=true&qr_size=-5"} {
+		if status, _ := createWithQR(t, server.URL, query); status != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, status)
+		}
+	}
+}
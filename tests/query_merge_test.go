@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"tiny-url-service/config"
+)
+
+func TestRedirectMergesQueryParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		precedence string
+		expected   string
+	}{
+		{"incoming wins", "incoming", "https://example.com/page?ref=1&utm_source=x#top"},
+		{"stored wins", "stored", "https://example.com/page?utm_source=stored&ref=1#top"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setupTestServerWithConfig(func(cfg *config.Config) {
+				cfg.MergeQueryParams = true
+				cfg.QueryPrecedence = tt.precedence
+			})
+			defer server.Close()
+
+			code := createShortCode(t, server.URL, map[string]interface{}{
+				"long_url": "https://example.com/page?utm_source=stored&ref=1#top",
+			})
+
+			resp := doJSON(t, "GET", server.URL+"/"+code+"?utm_source=x", nil, nil)
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusFound {
+				t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+			}
+			if location := resp.Header.Get("Location"); location != tt.expected {
+				t.Errorf("Expected Location %q, got %q", tt.expected, location)
+			}
+		})
+	}
+}
+
+func TestRedirectIgnoresQueryParamsByDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/page"})
+
+	resp := doJSON(t, "GET", server.URL+"/"+code+"?utm_source=x", nil, nil)
+	resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); location != "https://example.com/page" {
+		t.Errorf("Expected exact stored URL, got %q", location)
+	}
+}
+
+func TestRedirectMergeDoesNotForwardPassword(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.MergeQueryParams = true
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url": "https://example.com/secret",
+		"password": "hunter2",
+	})
+
+	resp := doJSON(t, "GET", server.URL+"/"+code+"?pw=hunter2&utm_source=x", nil, nil)
+	resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); location != "https://example.com/secret?utm_source=x" {
+		t.Errorf("Expected password to be stripped, got %q", location)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedirectDelayCountdownPage(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	delayed := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":               "https://example.com/sponsored?a=1&b=2",
+		"redirect_delay_seconds": 5,
+	})
+
+	resp, err := noRedirectClient.Get(server.URL + "/" + delayed)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	page := string(body)
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected an HTML countdown page, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(page, `content="5;url=https://example.com/sponsored?a=1&amp;b=2"`) {
+		t.Errorf("Expected a 5 second meta refresh to the destination, got:\n%s", page)
+	}
+	if !strings.Contains(page, `href="https://example.com/sponsored?a=1&amp;b=2"`) {
+		t.Errorf("Expected a link to the destination, got:\n%s", page)
+	}
+
+	// Links without a delay still redirect instantly
+	instant := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/plain"})
+	resp, err = noRedirectClient.Get(server.URL + "/" + instant)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+}
+
+func TestRedirectDelayValidation(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	for _, delay := range []int{-1, 31} {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+			"long_url":               "https://example.com",
+			"redirect_delay_seconds": delay,
+		}, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("redirect_delay_seconds %d: expected status %d, got %d", delay, http.StatusBadRequest, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+// redirectResponse creates a link on serverURL and returns the unfollowed redirect
+func redirectResponse(t *testing.T, serverURL string) *http.Response {
+	t.Helper()
+
+	code := createShortCode(t, serverURL, map[string]interface{}{"long_url": "https://example.com/private"})
+	resp, err := noRedirectClient.Get(serverURL + "/" + code)
+	if err != nil {
+		t.Fatalf("Redirect request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+	return resp
+}
+
+func TestRedirectHeadersDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	if policy := redirectResponse(t, server.URL).Header.Get("Referrer-Policy"); policy != "no-referrer" {
+		t.Errorf("Expected Referrer-Policy no-referrer on redirects, got %q", policy)
+	}
+
+	// API responses are shaped by the security headers only
+	resp := doJSON(t, "GET", server.URL+"/health", nil, nil)
+	resp.Body.Close()
+	if policy := resp.Header.Get("Referrer-Policy"); policy != "" {
+		t.Errorf("Expected no Referrer-Policy outside redirects, got %q", policy)
+	}
+}
+
+func TestRedirectHeadersConfigured(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.RedirectHeaders = map[string]string{
+			"Referrer-Policy": "origin",
+			"Cache-Control":   "private, max-age=90",
+		}
+	})
+	defer server.Close()
+
+	resp := redirectResponse(t, server.URL)
+	if policy := resp.Header.Get("Referrer-Policy"); policy != "origin" {
+		t.Errorf("Expected Referrer-Policy origin, got %q", policy)
+	}
+	if cache := resp.Header.Get("Cache-Control"); cache != "private, max-age=90" {
+		t.Errorf("Expected configured Cache-Control, got %q", cache)
+	}
+
+	// An empty set (REDIRECT_HEADERS=none) adds nothing
+	none := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.RedirectHeaders = map[string]string{}
+	})
+	defer none.Close()
+	if policy := redirectResponse(t, none.URL).Header.Get("Referrer-Policy"); policy != "" {
+		t.Errorf("Expected no Referrer-Policy with REDIRECT_HEADERS=none, got %q", policy)
+	}
+}
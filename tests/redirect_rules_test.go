@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+)
+
+func TestDeviceRedirectRules(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url": "https://example.com/web",
+		"redirect_rules": []map[string]string{
+			{"device": "Mobile", "url": "https://apps.example.com/store"},
+		},
+	})
+
+	tests := []struct {
+		userAgent string
+		expected  string
+	}{
+		{iPhoneUA, "https://apps.example.com/store"},
+		{desktopUA, "https://example.com/web"},
+		{iPhoneUA, "https://apps.example.com/store"},
+	}
+	for _, tt := range tests {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, map[string]string{"User-Agent": tt.userAgent})
+		resp.Body.Close()
+
+		if location := resp.Header.Get("Location"); location != tt.expected {
+			t.Errorf("User-Agent %q: expected Location %q, got %q", tt.userAgent, tt.expected, location)
+		}
+	}
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats", nil, nil)
+	defer resp.Body.Close()
+
+	var stats struct {
+		RedirectRules []struct {
+			Device string `json:"device"`
+			Clicks int    `json:"clicks"`
+		} `json:"redirect_rules"`
+		DefaultRuleClicks int `json:"default_rule_clicks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+
+	if len(stats.RedirectRules) != 1 || stats.RedirectRules[0].Device != "mobile" || stats.RedirectRules[0].Clicks != 2 {
+		t.Errorf("Expected 2 clicks on the mobile rule, got %+v", stats.RedirectRules)
+	}
+	if stats.DefaultRuleClicks != 1 {
+		t.Errorf("Expected 1 default click, got %d", stats.DefaultRuleClicks)
+	}
+}
+
+func TestDeviceRedirectRulesValidation(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		rules []map[string]string
+	}{
+		{"unknown device", []map[string]string{{"device": "watch", "url": "https://example.com/a"}}},
+		{"invalid url", []map[string]string{{"device": "mobile", "url": "javascript:alert(1)"}}},
+		{"duplicate device", []map[string]string{
+			{"device": "mobile", "url": "https://example.com/a"},
+			{"device": "mobile", "url": "https://example.com/b"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+				"long_url":       "https://example.com",
+				"redirect_rules": tt.rules,
+			}, nil)
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+func TestRedirectRejectsHeaderInjection(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	server := setupTestServerWithStore(store, nil)
+	defer server.Close()
+
+	// Seed a malformed mapping directly, bypassing create-time validation
+	shortCode, err := store.Store(&models.URLMapping{
+		LongURL: "https://example.com/\r\nSet-Cookie: injected=1",
+	})
+	if err != nil {
+		t.Fatalf("Store() failed: %v", err)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(server.URL + "/" + shortCode)
+	if err != nil {
+		t.Fatalf("Failed to make redirect request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+	if resp.Header.Get("Set-Cookie") != "" || resp.Header.Get("Location") != "" {
+		t.Error("Malformed stored URL must not reach the response headers")
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/models"
+)
+
+func TestReserveAndClaimShortCode(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	// Reserve a code
+	resp, err := http.Post(server.URL+"/urls/reserve", "application/json", bytes.NewBufferString("{}"))
+	if err != nil {
+		t.Fatalf("Failed to reserve short code: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var reserved models.ReserveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reserved); err != nil {
+		t.Fatalf("Failed to decode reserve response: %v", err)
+	}
+	if reserved.ShortCode == "" || reserved.ReservationToken == "" {
+		t.Fatalf("Expected short_code and reservation_token, got %+v", reserved)
+	}
+
+	// The reserved code should not resolve before it is claimed
+	resp, err = http.Get(server.URL + "/urls/" + reserved.ShortCode + "/stats")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Unclaimed code should return %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	// Claim it
+	body, _ := json.Marshal(map[string]string{
+		"long_url":          "https://example.com/reserved",
+		"reservation_token": reserved.ReservationToken,
+	})
+	resp, err = http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to claim reservation: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var created CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	if created.ShortURL != reserved.ShortURL {
+		t.Errorf("Expected claimed short URL %s, got %s", reserved.ShortURL, created.ShortURL)
+	}
+
+	// The token is single-use
+	resp, err = http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Reusing a token should return %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
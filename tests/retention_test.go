@@ -0,0 +1,240 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+func retentionTestServer() func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		cfg.RetentionTiers = map[string]time.Duration{
+			"short": 24 * time.Hour,
+			"long":  365 * 24 * time.Hour,
+		}
+	}
+}
+
+func TestRetentionTierSetsExpiration(t *testing.T) {
+	server := setupTestServerWithConfig(retentionTestServer())
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]string{
+		"long_url":  "https://example.com/retention",
+		"retention": "short",
+	})
+	resp, err := http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to create short URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var createResp CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	if createResp.ExpiresAt == nil {
+		t.Fatal("Expected expires_at in the create response")
+	}
+	shortCode := createResp.ShortCode
+
+	resp, err = http.Get(server.URL + "/urls/" + shortCode + "/stats")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var stats struct {
+		ExpirationDate *time.Time `json:"expiration_date"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+	if stats.ExpirationDate == nil {
+		t.Fatal("Expected expiration_date to be set by the retention tier")
+	}
+	remaining := time.Until(*stats.ExpirationDate)
+	if remaining < 23*time.Hour || remaining > 25*time.Hour {
+		t.Errorf("Expected expiration ~24h from now, got %v", remaining)
+	}
+}
+
+func TestUnknownRetentionTier(t *testing.T) {
+	server := setupTestServerWithConfig(retentionTestServer())
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]string{
+		"long_url":  "https://example.com/retention",
+		"retention": "forever",
+	})
+	resp, err := http.Post(server.URL+"/urls", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	var errResp struct {
+		ValidTiers []string `json:"valid_tiers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if strings.Join(errResp.ValidTiers, ",") != "long,short" {
+		t.Errorf("Expected valid tiers [long short], got %v", errResp.ValidTiers)
+	}
+}
+
+// createAndFetchExpiration creates a short URL and returns its stored expiration date
+func createAndFetchExpiration(t *testing.T, serverURL string, body map[string]string) time.Time {
+	t.Helper()
+
+	resp := doJSON(t, "POST", serverURL+"/urls", body, nil)
+	defer resp.Body.Close()
+	var createResp CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	shortCode := strings.TrimPrefix(createResp.ShortURL, serverURL+"/")
+
+	statsResp := doJSON(t, "GET", serverURL+"/urls/"+shortCode+"/stats", nil, nil)
+	defer statsResp.Body.Close()
+	var stats struct {
+		ExpirationDate *time.Time `json:"expiration_date"`
+	}
+	if err := json.NewDecoder(statsResp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+	if stats.ExpirationDate == nil {
+		t.Fatal("Expected expiration_date to be set")
+	}
+	return *stats.ExpirationDate
+}
+
+func TestRetentionExpirationJitter(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		retentionTestServer()(cfg)
+		cfg.ExpirationJitter = time.Hour
+	})
+	defer server.Close()
+
+	// Two requests per link: stay under the per-IP rate limit of 20
+	const links = 8
+	start := time.Now()
+	distinct := make(map[time.Time]bool)
+	for i := 0; i < links; i++ {
+		expires := createAndFetchExpiration(t, server.URL, map[string]string{
+			"long_url":  "https://example.com/batch",
+			"retention": "short",
+		})
+		offset := expires.Sub(start) - 24*time.Hour
+		if offset < -time.Hour || offset > time.Hour+time.Minute {
+			t.Errorf("Expected expiration within ±1h of 24h, got offset %v", offset)
+		}
+		distinct[expires.Truncate(time.Second)] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("Expected jittered expirations to differ, got %d distinct values", len(distinct))
+	}
+}
+
+func TestExplicitExpirationNotJittered(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		retentionTestServer()(cfg)
+		cfg.ExpirationJitter = time.Hour
+	})
+	defer server.Close()
+
+	want := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	got := createAndFetchExpiration(t, server.URL, map[string]string{
+		"long_url":        "https://example.com/exact",
+		"expiration_date": want.Format(time.RFC3339),
+	})
+	if !got.Equal(want) {
+		t.Errorf("Expected explicit expiration %v, got %v", want, got)
+	}
+}
+
+func TestStatsReportExpiry(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	server := setupTestServerWithStore(store, retentionTestServer())
+	defer server.Close()
+
+	type expiryStats struct {
+		IsExpired          bool   `json:"is_expired"`
+		SecondsUntilExpiry *int64 `json:"seconds_until_expiry"`
+	}
+	fetch := func(shortCode string) expiryStats {
+		resp := doJSON(t, "GET", server.URL+"/urls/"+shortCode+"/stats", nil, nil)
+		defer resp.Body.Close()
+		var stats expiryStats
+		if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+			t.Fatalf("Failed to decode stats response: %v", err)
+		}
+		return stats
+	}
+
+	expiring := fetch(createShortCode(t, server.URL, map[string]string{"long_url": "https://example.com/a", "retention": "short"}))
+	if expiring.IsExpired {
+		t.Error("Expected is_expired false for a live link")
+	}
+	if s := expiring.SecondsUntilExpiry; s == nil || *s <= 23*3600 || *s > 24*3600 {
+		t.Errorf("Expected seconds_until_expiry ~86400, got %v", s)
+	}
+
+	permanent := fetch(createShortCode(t, server.URL, CreateURLRequest{LongURL: "https://example.com/b"}))
+	if permanent.IsExpired || permanent.SecondsUntilExpiry != nil {
+		t.Errorf("Expected a never-expiring link to report false/null, got %v/%v", permanent.IsExpired, permanent.SecondsUntilExpiry)
+	}
+
+	// Expired but not yet purged: stats still answer instead of 404
+	past := time.Now().Add(-time.Hour)
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/c", ExpirationDate: &past}, "expired"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	expired := fetch("expired")
+	if !expired.IsExpired {
+		t.Error("Expected is_expired true for an expired link")
+	}
+	if s := expired.SecondsUntilExpiry; s == nil || *s > -3500 {
+		t.Errorf("Expected seconds_until_expiry ~-3600, got %v", s)
+	}
+}
+
+func TestStatsReportExpiryWithClockSkew(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithClockSkewTolerance(time.Minute))
+	server := setupTestServerWithStore(store, nil)
+	defer server.Close()
+
+	// Past its expiration but within the tolerance: still live, with the
+	// remaining tolerance as its countdown
+	past := time.Now().Add(-30 * time.Second)
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/skew", ExpirationDate: &past}, "skewed"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	resp := doJSON(t, "GET", server.URL+"/urls/skewed/stats", nil, nil)
+	defer resp.Body.Close()
+	var stats struct {
+		IsExpired          bool   `json:"is_expired"`
+		SecondsUntilExpiry *int64 `json:"seconds_until_expiry"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats response: %v", err)
+	}
+	if stats.IsExpired {
+		t.Error("Expected is_expired false within the clock skew tolerance")
+	}
+	if s := stats.SecondsUntilExpiry; s == nil || *s <= 0 || *s > 30 {
+		t.Errorf("Expected seconds_until_expiry ~30, got %v", s)
+	}
+}
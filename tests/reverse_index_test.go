@@ -0,0 +1,25 @@
+package tests
+
+import (
+	"testing"
+
+	"tiny-url-service/storage"
+)
+
+func TestReverseIndexReusesPlainLinks(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080", storage.WithReverseIndex(storage.SHA256Truncated(16)))
+	server := setupTestServerWithStore(store, nil)
+	defer server.Close()
+
+	first := createShortCode(t, server.URL, CreateURLRequest{LongURL: "https://example.com/shared"})
+	second := createShortCode(t, server.URL, CreateURLRequest{LongURL: "https://EXAMPLE.com/shared"})
+	if first != second {
+		t.Errorf("Expected the existing code %s to be reused, got %s", first, second)
+	}
+
+	// Links with conditions always get their own code
+	limited := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/shared", "max_uses": 3})
+	if limited == first {
+		t.Errorf("Expected a new code for a use-limited link, got %s", limited)
+	}
+}
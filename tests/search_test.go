@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+type searchResponse struct {
+	Results []struct {
+		ShortCode string `json:"short_code"`
+		LongURL   string `json:"long_url"`
+		Expired   bool   `json:"expired"`
+	} `json:"results"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func TestSearchURLs(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	for _, longURL := range []string{
+		"https://example.com/1",
+		"https://other.org/2",
+		"https://example.com/3",
+		"https://EXAMPLE.com/4",
+	} {
+		createShortCode(t, server.URL, CreateURLRequest{LongURL: longURL})
+	}
+
+	// Requires the admin token
+	resp := doJSON(t, "GET", server.URL+"/urls/search?q=example.com", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	var found []string
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		resp = doJSON(t, "GET", server.URL+"/urls/search?q=example.com&limit=2&cursor="+cursor, nil, adminHeaders())
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+		var body searchResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode search response: %v", err)
+		}
+		resp.Body.Close()
+		for _, result := range body.Results {
+			found = append(found, result.LongURL)
+		}
+		if body.NextCursor == "" {
+			break
+		}
+		cursor = body.NextCursor
+	}
+
+	if len(found) != 3 {
+		t.Errorf("Expected 3 matches across pages, got %v", found)
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/urls/search", nil, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d without q, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"tiny-url-service/config"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+
+	for _, path := range []string{"/health", "/urls/" + code + "/stats", "/" + code, "/robots.txt"} {
+		resp := doJSON(t, "GET", server.URL+path, nil, nil)
+		resp.Body.Close()
+
+		if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("%s: expected X-Content-Type-Options nosniff, got %q", path, got)
+		}
+		if got := resp.Header.Get("X-Frame-Options"); got != "DENY" {
+			t.Errorf("%s: expected X-Frame-Options DENY, got %q", path, got)
+		}
+		if got := resp.Header.Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("%s: HSTS should be off by default, got %q", path, got)
+		}
+	}
+}
+
+func TestSecurityHeadersHSTS(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.HSTSMaxAge = 365 * 24 * time.Hour
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "GET", server.URL+"/health", nil, nil)
+	resp.Body.Close()
+
+	expected := "max-age=31536000; includeSubDomains"
+	if got := resp.Header.Get("Strict-Transport-Security"); got != expected {
+		t.Errorf("Expected Strict-Transport-Security %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestSelfLinkRejected(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	shortCode := createShortCode(t, server.URL, CreateURLRequest{LongURL: "https://example.com/target"})
+
+	resp := doJSON(t, "POST", server.URL+"/urls", CreateURLRequest{LongURL: server.URL + "/" + shortCode}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a self link, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	// External links are unaffected
+	resp = doJSON(t, "POST", server.URL+"/urls", CreateURLRequest{LongURL: "https://example.com/" + shortCode}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d for an external link, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestSelfLinkResolved(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.ResolveSelfLinks = true
+	})
+	defer server.Close()
+
+	first := createShortCode(t, server.URL, CreateURLRequest{LongURL: "https://example.com/final"})
+	second := createShortCode(t, server.URL, CreateURLRequest{LongURL: server.URL + "/" + first})
+	third := createShortCode(t, server.URL, CreateURLRequest{LongURL: server.URL + "/" + second})
+
+	// The chain collapses so redirects go straight to the external target
+	resp := doJSON(t, "GET", server.URL+"/"+third, nil, nil)
+	resp.Body.Close()
+	if location := resp.Header.Get("Location"); location != "https://example.com/final" {
+		t.Errorf("Expected redirect to https://example.com/final, got %q", location)
+	}
+
+	// Unknown codes on our host can't be resolved
+	resp = doJSON(t, "POST", server.URL+"/urls", CreateURLRequest{LongURL: server.URL + "/missing"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown self link, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	// Protected links are never resolved, which would leak their target
+	protected := createShortCode(t, server.URL, map[string]string{"long_url": "https://example.com/secret", "password": "hunter2"})
+	resp = doJSON(t, "POST", server.URL+"/urls", CreateURLRequest{LongURL: server.URL + "/" + protected}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a protected self link, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
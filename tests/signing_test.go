@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+	"tiny-url-service/utils"
+)
+
+const testSigningSecret = "test-signing-secret"
+
+func setupSigningServer(configure func(*config.Config)) *httptest.Server {
+	return setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+		cfg.SigningSecret = testSigningSecret
+		if configure != nil {
+			configure(cfg)
+		}
+	})
+}
+
+// signShortURL asks the server to sign code and returns the signed URL
+func signShortURL(t *testing.T, serverURL, code, expiresIn string) string {
+	t.Helper()
+	resp := doJSON(t, "POST", serverURL+"/urls/"+code+"/sign", map[string]string{"expires_in": expiresIn}, adminHeaders())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d signing %s, got %d", http.StatusOK, code, resp.StatusCode)
+	}
+	var result struct {
+		SignedURL string    `json:"signed_url"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(result.SignedURL, serverURL+"/"+code+"?") {
+		t.Fatalf("Unexpected signed URL %q", result.SignedURL)
+	}
+	return result.SignedURL
+}
+
+func TestSignedURLRedirect(t *testing.T) {
+	server := setupSigningServer(nil)
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/private"})
+	signed := signShortURL(t, server.URL, code, "1h")
+
+	resp := doJSON(t, "GET", signed, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d for a valid signature, got %d", http.StatusFound, resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != "https://example.com/private" {
+		t.Errorf("Unexpected Location %q", location)
+	}
+
+	// The unsigned link keeps working unless it requires a signature
+	resp = doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d for the unsigned link, got %d", http.StatusFound, resp.StatusCode)
+	}
+}
+
+func TestSignedURLTampering(t *testing.T) {
+	server := setupSigningServer(nil)
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/a"})
+	other := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/b"})
+	signed, err := url.Parse(signShortURL(t, server.URL, code, "1h"))
+	if err != nil {
+		t.Fatalf("Failed to parse signed URL: %v", err)
+	}
+	params := signed.Query()
+
+	extended := url.Values{"exp": {"4102444800"}, "sig": {params.Get("sig")}}
+	forged := url.Values{"exp": {params.Get("exp")}, "sig": {strings.Repeat("A", len(params.Get("sig")))}}
+	testCases := []struct {
+		name string
+		url  string
+	}{
+		{"extended expiry", server.URL + "/" + code + "?" + extended.Encode()},
+		{"forged signature", server.URL + "/" + code + "?" + forged.Encode()},
+		{"other code", server.URL + "/" + other + "?" + params.Encode()},
+		{"missing signature", server.URL + "/" + code + "?exp=" + params.Get("exp")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := doJSON(t, "GET", tc.url, nil, nil)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusForbidden {
+				t.Errorf("Expected status %d, got %d", http.StatusForbidden, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestSignedURLExpiry(t *testing.T) {
+	server := setupSigningServer(nil)
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/expired"})
+
+	// Signed with the server's secret, but an hour ago
+	signed := utils.NewURLSigner(testSigningSecret).SignShortURL(code, time.Now().Add(-time.Hour))
+	resp := doJSON(t, "GET", server.URL+"/"+signed, nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status %d for an expired signature, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["error"] != "Signed URL has expired" {
+		t.Errorf("Unexpected error %v", body["error"])
+	}
+
+	expand := doJSON(t, "GET", server.URL+"/api/expand?url="+url.QueryEscape(server.URL+"/"+signed), nil, nil)
+	expand.Body.Close()
+	if expand.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d expanding an expired signed URL, got %d", http.StatusForbidden, expand.StatusCode)
+	}
+}
+
+func TestRequireSignature(t *testing.T) {
+	server := setupSigningServer(func(cfg *config.Config) {
+		cfg.MergeQueryParams = true
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":          "https://example.com/members",
+		"require_signature": true,
+	})
+
+	resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status %d without a signature, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+	expand := doJSON(t, "GET", server.URL+"/api/expand?code="+code, nil, nil)
+	expand.Body.Close()
+	if expand.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d expanding without a signature, got %d", http.StatusForbidden, expand.StatusCode)
+	}
+
+	// The signature params are not forwarded to the destination
+	resp = doJSON(t, "GET", signShortURL(t, server.URL, code, "10m")+"&utm_source=mail", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d with a signature, got %d", http.StatusFound, resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != "https://example.com/members?utm_source=mail" {
+		t.Errorf("Unexpected Location %q", location)
+	}
+}
+
+func TestSigningDisabled(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+	resp := doJSON(t, "POST", server.URL+"/urls/"+code+"/sign", map[string]string{"expires_in": "1h"}, adminHeaders())
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Errorf("Expected status %d without SIGNING_SECRET, got %d", http.StatusNotImplemented, resp.StatusCode)
+	}
+
+	resp = doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":          "https://example.com",
+		"require_signature": true,
+	}, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d for require_signature, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["field"] != "require_signature" {
+		t.Errorf("Expected field require_signature, got %v", body["field"])
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+)
+
+func TestSlidingExpirationKeepsUsedLinkAlive(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.RetentionTiers = map[string]time.Duration{"brief": time.Second}
+	})
+	defer server.Close()
+
+	sliding := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":           "https://example.com/sliding",
+		"retention":          "brief",
+		"sliding_expiration": true,
+	})
+	fixed := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url":  "https://example.com/fixed",
+		"retention": "brief",
+	})
+
+	// Each visit moves the expiration a second out, so the link outlives
+	// its original expiry as long as visits keep coming
+	deadline := time.Now().Add(1800 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		resp := doJSON(t, "GET", server.URL+"/"+sliding, nil, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("Expected the sliding link to stay alive, got status %d", resp.StatusCode)
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	resp := doJSON(t, "GET", server.URL+"/"+fixed, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the fixed link to have expired, got status %d", resp.StatusCode)
+	}
+}
+
+func TestSlidingExpirationNeedsExpiration(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url":           "https://example.com",
+		"sliding_expiration": true,
+	}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestFaviconRoute(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/favicon.ico")
+	if err != nil {
+		t.Fatalf("Failed to get favicon: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	// Bot endpoints must not consume rate-limit tokens
+	if resp.Header.Get("X-RateLimit-Limit") != "" {
+		t.Error("favicon.ico should bypass the rate limiter")
+	}
+}
+
+func TestRobotsRoute(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.RobotsDisallow = "/, /urls"
+	})
+	defer server.Close()
+
+	for i := 0; i < 25; i++ {
+		resp, err := http.Get(server.URL + "/robots.txt")
+		if err != nil {
+			t.Fatalf("Failed to get robots.txt: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, http.StatusOK, resp.StatusCode)
+		}
+		if !strings.Contains(string(body), "Disallow: /\n") || !strings.Contains(string(body), "Disallow: /urls\n") {
+			t.Errorf("Unexpected robots.txt body: %q", body)
+		}
+	}
+}
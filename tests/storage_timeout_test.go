@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+// slowStore delays Get and Store to simulate a backend that stopped responding
+type slowStore struct {
+	*storage.MemoryStorage
+	delay time.Duration
+}
+
+func (s slowStore) Get(shortCode string) (*models.URLMapping, error) {
+	time.Sleep(s.delay)
+	return s.MemoryStorage.Get(shortCode)
+}
+
+func (s slowStore) Store(mapping *models.URLMapping) (string, error) {
+	time.Sleep(s.delay)
+	return s.MemoryStorage.Store(mapping)
+}
+
+func TestStorageOpTimeout(t *testing.T) {
+	memory := storage.NewMemoryStorage("http://localhost:8080")
+	if err := memory.StoreWithCode(&models.URLMapping{LongURL: "https://example.com/slow"}, "slow"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	server := setupTestServerWithStore(slowStore{MemoryStorage: memory, delay: time.Second}, func(cfg *config.Config) {
+		cfg.StorageOpTimeout = 50 * time.Millisecond
+	})
+	defer server.Close()
+
+	for _, req := range []struct {
+		method, path string
+		body         interface{}
+	}{
+		{"GET", "/slow", nil},
+		{"POST", "/urls", CreateURLRequest{LongURL: "https://example.com/new"}},
+	} {
+		start := time.Now()
+		resp := doJSON(t, req.method, server.URL+req.path, req.body, nil)
+		resp.Body.Close()
+		elapsed := time.Since(start)
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected status %d, got %d", req.method, req.path, http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") == "" {
+			t.Errorf("%s %s: expected a Retry-After header", req.method, req.path)
+		}
+		if elapsed >= 500*time.Millisecond {
+			t.Errorf("%s %s: handler waited %v for the slow storage", req.method, req.path, elapsed)
+		}
+	}
+}
+
+func TestStorageOpTimeoutNotHitByFastStorage(t *testing.T) {
+	memory := storage.NewMemoryStorage("http://localhost:8080")
+	server := setupTestServerWithStore(slowStore{MemoryStorage: memory, delay: time.Millisecond}, func(cfg *config.Config) {
+		cfg.StorageOpTimeout = time.Second
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, CreateURLRequest{LongURL: "https://example.com/fast"})
+	resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+}
+
+// slowLabelStore delays only labeled click recording
+type slowLabelStore struct {
+	*storage.MemoryStorage
+	delay time.Duration
+}
+
+func (s slowLabelStore) RecordLabeledClick(shortCode, label string) error {
+	time.Sleep(s.delay)
+	return s.MemoryStorage.RecordLabeledClick(shortCode, label)
+}
+
+func TestStorageOpTimeoutLabeledClicks(t *testing.T) {
+	memory := storage.NewMemoryStorage("http://localhost:8080")
+	mapping := &models.URLMapping{
+		LongURL:      "https://example.com/ab",
+		Destinations: []models.WeightedURL{{URL: "https://example.com/a", Weight: 1}},
+	}
+	if err := memory.StoreWithCode(mapping, "ab"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	server := setupTestServerWithStore(slowLabelStore{MemoryStorage: memory, delay: time.Second}, func(cfg *config.Config) {
+		cfg.StorageOpTimeout = 50 * time.Millisecond
+	})
+	defer server.Close()
+
+	// Click stats are best effort, so the redirect goes ahead without them
+	start := time.Now()
+	resp, err := noRedirectClient.Get(server.URL + "/ab")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "https://example.com/a" {
+		t.Errorf("Expected a redirect to the destination, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("Redirect waited %v for the slow click recording", elapsed)
+	}
+}
+
+// slowGetStore delays Get once slow is set
+type slowGetStore struct {
+	*storage.MemoryStorage
+	slow *atomic.Bool
+}
+
+func (s slowGetStore) Get(shortCode string) (*models.URLMapping, error) {
+	if s.slow.Load() {
+		time.Sleep(time.Second)
+	}
+	return s.MemoryStorage.Get(shortCode)
+}
+
+func TestStorageOpTimeoutDuplicateSubmission(t *testing.T) {
+	store := slowGetStore{MemoryStorage: storage.NewMemoryStorage("http://localhost:8080"), slow: &atomic.Bool{}}
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.StorageOpTimeout = 50 * time.Millisecond
+		cfg.DedupWindow = time.Minute
+	})
+	defer server.Close()
+
+	body := CreateURLRequest{LongURL: "https://example.com/double-click"}
+	createShortCode(t, server.URL, body)
+
+	// Loading the remembered link times out like any other storage call
+	store.slow.Store(true)
+	start := time.Now()
+	resp := doJSON(t, "POST", server.URL+"/urls", body, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("Handler waited %v for the slow storage", elapsed)
+	}
+}
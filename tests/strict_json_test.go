@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateRejectsInvalidFields(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		body          string
+		expectedField string
+		expectedCode  string
+	}{
+		{"unknown field", `{"long_ur": "https://example.com"}`, "long_ur", "UNKNOWN_FIELD"},
+		{"unknown field alongside valid ones", `{"long_url": "https://example.com", "max_use": 3}`, "max_use", "UNKNOWN_FIELD"},
+		{"expiration_date as number", `{"long_url": "https://example.com", "expiration_date": 1735689600}`, "expiration_date", "INVALID_FIELD"},
+		{"expiration_date not a timestamp", `{"long_url": "https://example.com", "expiration_date": "tomorrow"}`, "expiration_date", "INVALID_FIELD"},
+		{"max_uses as string", `{"long_url": "https://example.com", "max_uses": "five"}`, "max_uses", "INVALID_FIELD"},
+		{"missing long_url", `{"max_uses": 1}`, "long_url", "MISSING_FIELD"},
+		{"empty long_url", `{"long_url": ""}`, "long_url", "MISSING_FIELD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/urls", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+
+			var errResp struct {
+				Error string `json:"error"`
+				Field string `json:"field"`
+				Code  string `json:"code"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if errResp.Code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s (error: %s)", tt.expectedCode, errResp.Code, errResp.Error)
+			}
+			if errResp.Field != tt.expectedField {
+				t.Errorf("Expected field %q, got %q (error: %s)", tt.expectedField, errResp.Field, errResp.Error)
+			}
+			if !strings.Contains(errResp.Error, tt.expectedField) {
+				t.Errorf("Expected error message to name %q, got %q", tt.expectedField, errResp.Error)
+			}
+		})
+	}
+}
+
+func TestCreateRejectsMalformedJSON(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"syntax error", `{"long_url": https://example.com}`},
+		{"truncated", `{"long_url": "https://example.com"`},
+		{"empty body", ``},
+		{"not an object", `["https://example.com"]`},
+		{"two objects", `{"long_url": "https://example.com/a"} {"long_url": "https://example.com/b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/urls", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+			var errResp struct {
+				Error string `json:"error"`
+				Field string `json:"field"`
+				Code  string `json:"code"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if errResp.Code != "MALFORMED_JSON" || errResp.Field != "" {
+				t.Errorf("Expected MALFORMED_JSON without a field, got %+v", errResp)
+			}
+		})
+	}
+}
+
+func TestCreateExpirationDateFormats(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		expirationDate string
+		want           time.Time
+	}{
+		{"RFC3339", "2099-06-15T12:30:00Z", time.Date(2099, 6, 15, 12, 30, 0, 0, time.UTC)},
+		{"RFC3339 with offset", "2099-06-15T12:30:00+02:00", time.Date(2099, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{"plain date is end of day UTC", "2099-06-15", time.Date(2099, 6, 15, 23, 59, 59, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"long_url": "https://example.com/` + strings.ReplaceAll(tt.name, " ", "-") + `", "expiration_date": "` + tt.expirationDate + `"}`
+			resp, err := http.Post(server.URL+"/urls", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+			}
+			var result struct {
+				ExpiresAt *time.Time `json:"expires_at"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.ExpiresAt == nil || !result.ExpiresAt.Equal(tt.want) {
+				t.Errorf("Expected expires_at %v, got %v", tt.want, result.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestCreateRejectsUnparseableExpirationDate(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	body := `{"long_url": "https://example.com", "expiration_date": "15/06/2099"}`
+	resp, err := http.Post(server.URL+"/urls", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	var errResp struct {
+		Error   string `json:"error"`
+		Field   string `json:"field"`
+		Example string `json:"example"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if errResp.Error != "expiration_date must be RFC3339" || errResp.Field != "expiration_date" {
+		t.Errorf("Unexpected error response: %+v", errResp)
+	}
+	if _, err := time.Parse(time.RFC3339, errResp.Example); err != nil {
+		t.Errorf("Expected an RFC3339 example, got %q", errResp.Example)
+	}
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+// redirectTarget creates a link for longURL and returns where it redirects
+func redirectTarget(t *testing.T, baseURL, longURL string) string {
+	t.Helper()
+	code := createShortCode(t, baseURL, map[string]interface{}{"long_url": longURL})
+	resp := doJSON(t, "GET", baseURL+"/"+code, nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+	}
+	return resp.Header.Get("Location")
+}
+
+func TestURLStripFragmentAndParams(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.URLStripFragment = true
+		cfg.URLStripParams = []string{"utm_*", "fbclid"}
+	})
+	defer server.Close()
+
+	if got := redirectTarget(t, server.URL, "https://example.com/docs#section"); got != "https://example.com/docs" {
+		t.Errorf("Expected the fragment stripped, got %s", got)
+	}
+
+	got := redirectTarget(t, server.URL, "https://example.com/p?utm_source=news&id=42&utm_campaign=spring&fbclid=abc&ref=home")
+	if want := "https://example.com/p?id=42&ref=home"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestURLStripPreservesByDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	longURL := "https://example.com/p?utm_source=news&id=42#section"
+	if got := redirectTarget(t, server.URL, longURL); got != longURL {
+		t.Errorf("Expected the URL unchanged, got %s", got)
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+type tagErrorResponse struct {
+	Error       string   `json:"error"`
+	InvalidTags []string `json:"invalid_tags"`
+}
+
+func TestTagsNormalizedBeforeStorage(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	// Duplicates collapse once trimmed and lowercased
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url": "https://example.com/campaign",
+		"tags":     []string{"Marketing ", "marketing", "MARKETING", " News"},
+	})
+
+	resp := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats", nil, nil)
+	defer resp.Body.Close()
+	var stats struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if !reflect.DeepEqual(stats.Tags, []string{"marketing", "news"}) {
+		t.Errorf("Expected tags [marketing news], got %v", stats.Tags)
+	}
+}
+
+func TestTagsRejected(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.MaxTags = 2
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		tags     []string
+		offender []string
+	}{
+		{"invalid tag", []string{"ok", "two words", "-dash"}, []string{"two words", "-dash"}},
+		// Counted after duplicates collapse, so only the third distinct tag is over
+		{"over limit", []string{"a", "A", "b", "c"}, []string{"c"}},
+	}
+	for _, tt := range tests {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+			"long_url": "https://example.com/tagged",
+			"tags":     tt.tags,
+		}, nil)
+		var body tagErrorResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", tt.name, http.StatusBadRequest, resp.StatusCode)
+		}
+		if !reflect.DeepEqual(body.InvalidTags, tt.offender) {
+			t.Errorf("%s: expected invalid_tags %v, got %v (%s)", tt.name, tt.offender, body.InvalidTags, body.Error)
+		}
+	}
+}
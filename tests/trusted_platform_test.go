@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestTrustedPlatformCloudflare(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.TrustedPlatform = "cloudflare"
+	})
+	defer server.Close()
+
+	create := func(clientIP string) int {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com"}, map[string]string{
+			"CF-Connecting-IP": clientIP,
+			"X-Forwarded-For":  "198.51.100.7",
+		})
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Every request arrives from the same peer, but the limiter charges the visitor Cloudflare reports
+	for i := 0; i < 20; i++ {
+		if status := create("203.0.113.1"); status != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, http.StatusOK, status)
+		}
+	}
+	if status := create("203.0.113.1"); status != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d once 203.0.113.1 used its allowance, got %d", http.StatusTooManyRequests, status)
+	}
+	if status := create("203.0.113.2"); status != http.StatusOK {
+		t.Errorf("Expected status %d for another Cloudflare client, got %d", http.StatusOK, status)
+	}
+}
+
+func TestTrustedPlatformCustomHeader(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.TrustedPlatform = "X-Appengine-User-IP"
+	})
+	defer server.Close()
+
+	for i := 0; i < 20; i++ {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com"}, map[string]string{
+			"X-Appengine-User-IP": "203.0.113.9",
+		})
+		resp.Body.Close()
+	}
+
+	// The peer itself still has its whole allowance
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d for a request without the platform header, got %d", http.StatusOK, resp.StatusCode)
+	}
+	resp = doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": "https://example.com"}, map[string]string{
+		"X-Appengine-User-IP": "203.0.113.9",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d for the limited platform client, got %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+const slackbotUA = "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"
+
+func TestUnfurlPreview(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.UnfurlPreview = true
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{
+		"long_url": "https://example.com/launch?ref=a&b=1",
+		"title":    "Launch <announcement>",
+	})
+
+	resp := doJSON(t, "GET", server.URL+"/"+code, nil, map[string]string{"User-Agent": slackbotUA})
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d for Slackbot, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Expected an HTML preview, got Content-Type %q", contentType)
+	}
+	for _, tag := range []string{
+		`<meta property="og:title" content="Launch &lt;announcement&gt;">`,
+		`<meta property="og:url" content="https://example.com/launch?ref=a&amp;b=1">`,
+		`<meta property="og:description" content="Redirects to https://example.com/launch?ref=a&amp;b=1">`,
+	} {
+		if !strings.Contains(string(body), tag) {
+			t.Errorf("Expected the preview to contain %s, got:\n%s", tag, body)
+		}
+	}
+
+	resp = doJSON(t, "GET", server.URL+"/"+code, nil, map[string]string{"User-Agent": desktopUA})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected status %d for a browser, got %d", http.StatusFound, resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != "https://example.com/launch?ref=a&b=1" {
+		t.Errorf("Unexpected Location %q", location)
+	}
+
+	// Only the browser's visit counts as a click
+	stats := doJSON(t, "GET", server.URL+"/urls/"+code+"/stats", nil, nil)
+	defer stats.Body.Close()
+	var result map[string]interface{}
+	if err := json.NewDecoder(stats.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if result["access_count"] != float64(1) {
+		t.Errorf("Expected access_count 1, got %v", result["access_count"])
+	}
+}
+
+func TestUnfurlPreviewCustomCrawlers(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.UnfurlPreview = true
+		cfg.UnfurlCrawlers = []string{"InternalUnfurler"}
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/docs"})
+
+	testCases := []struct {
+		userAgent string
+		expected  int
+	}{
+		{"internalunfurler/2.0", http.StatusOK},
+		{slackbotUA, http.StatusFound},
+	}
+	for _, tc := range testCases {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, map[string]string{"User-Agent": tc.userAgent})
+		resp.Body.Close()
+		if resp.StatusCode != tc.expected {
+			t.Errorf("User-Agent %q: expected status %d, got %d", tc.userAgent, tc.expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestUnfurlPreviewDisabledByDefault(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+
+	resp := doJSON(t, "GET", server.URL+"/"+code, nil, map[string]string{"User-Agent": slackbotUA})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status %d without UNFURL_PREVIEW, got %d", http.StatusFound, resp.StatusCode)
+	}
+}
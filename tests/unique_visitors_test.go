@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+// linkStats fetches the stats of code as a generic map
+func linkStats(t *testing.T, serverURL, code string) map[string]interface{} {
+	t.Helper()
+	resp := doJSON(t, "GET", serverURL+"/urls/"+code+"/stats", nil, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d for stats, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var stats map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	return stats
+}
+
+func TestUniqueVisitors(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.TrustedPlatform = "cloudflare"
+		cfg.TrackUniqueVisitors = true
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+	visit := func(clientIP string) {
+		resp := doJSON(t, "GET", server.URL+"/"+code, nil, map[string]string{"CF-Connecting-IP": clientIP})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusFound, resp.StatusCode)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		visit("203.0.113.1")
+	}
+	stats := linkStats(t, server.URL, code)
+	if stats["access_count"] != float64(3) {
+		t.Errorf("Expected access_count 3, got %v", stats["access_count"])
+	}
+	if stats["unique_visitors"] != float64(1) {
+		t.Errorf("Expected 1 unique visitor for repeat visits from one IP, got %v", stats["unique_visitors"])
+	}
+
+	visit("203.0.113.2")
+	if stats := linkStats(t, server.URL, code); stats["unique_visitors"] != float64(2) {
+		t.Errorf("Expected 2 unique visitors, got %v", stats["unique_visitors"])
+	}
+}
+
+func TestUniqueVisitorsDisabled(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+	resp := doJSON(t, "GET", server.URL+"/"+code, nil, nil)
+	resp.Body.Close()
+
+	if _, ok := linkStats(t, server.URL, code)["unique_visitors"]; ok {
+		t.Error("Expected no unique_visitors without TRACK_UNIQUE_VISITORS")
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"tiny-url-service/config"
+)
+
+func TestConfiguredURLValidators(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.URLValidators = []string{"control_chars", "scheme", "host", "max_length", "blocklist", "private_hosts"}
+		cfg.MaxURLLength = 64
+		cfg.BlockedHosts = []string{"evil.example"}
+		cfg.RateLimitDisabled = true
+	})
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		url     string
+		message string
+	}{
+		{"wrong scheme", "ftp://example.com/file", "http:// or https://"},
+		{"too long", "https://example.com/" + strings.Repeat("a", 64), "longer than 64"},
+		{"blocked host", "https://www.evil.example/", "blocked"},
+		{"private address", "http://169.254.169.254/latest/meta-data", "private address"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": tc.url}, nil)
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if msg, _ := body["error"].(string); !strings.Contains(msg, tc.message) {
+				t.Errorf("Expected error mentioning %q, got %q", tc.message, msg)
+			}
+		})
+	}
+
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/fine"})
+}
+
+func TestDefaultURLValidatorsAllowPrivateHosts(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	// Only the configured chain adds the SSRF guard; the default matches IsValidURL
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "http://192.168.1.1/router"})
+}
+
+func TestURLValidatorsCheckDestinations(t *testing.T) {
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.URLValidators = []string{"scheme", "host", "blocklist"}
+		cfg.BlockedHosts = []string{"evil.example"}
+	})
+	defer server.Close()
+
+	resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{
+		"long_url": "https://example.com",
+		"destinations": []map[string]interface{}{
+			{"url": "https://example.com/a", "weight": 1},
+			{"url": "https://evil.example/b", "weight": 1},
+		},
+	}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a blocked destination, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
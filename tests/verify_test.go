@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"tiny-url-service/config"
+	"tiny-url-service/models"
+	"tiny-url-service/storage"
+)
+
+func TestAdminVerify(t *testing.T) {
+	store := storage.NewMemoryStorage("http://localhost:8080")
+	server := setupTestServerWithStore(store, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	defer server.Close()
+
+	createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com"})
+
+	verify := func() (int, bool, []string) {
+		resp := doJSON(t, "POST", server.URL+"/admin/verify", struct{}{}, adminHeaders())
+		defer resp.Body.Close()
+		var body struct {
+			Consistent bool     `json:"consistent"`
+			Issues     []string `json:"issues"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.Consistent, body.Issues
+	}
+
+	if status, consistent, issues := verify(); status != http.StatusOK || !consistent || len(issues) != 0 {
+		t.Fatalf("Expected a consistent store, got %d %v %v", status, consistent, issues)
+	}
+
+	// A custom code is exempt from ID checks but still needs a valid URL
+	if err := store.StoreWithCode(&models.URLMapping{LongURL: "not-a-url"}, "imported"); err != nil {
+		t.Fatalf("StoreWithCode() failed: %v", err)
+	}
+	if _, consistent, issues := verify(); consistent || len(issues) != 1 {
+		t.Errorf("Expected one issue for the invalid URL, got %v", issues)
+	}
+
+	// Admin only
+	resp := doJSON(t, "POST", server.URL+"/admin/verify", struct{}{}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tiny-url-service/config"
+)
+
+func TestWebhookDeliversCreates(t *testing.T) {
+	events := make(chan map[string]interface{}, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer receiver.Close()
+
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.WebhookURL = receiver.URL
+	})
+	defer server.Close()
+
+	code := createShortCode(t, server.URL, map[string]interface{}{"long_url": "https://example.com/hooked"})
+
+	select {
+	case event := <-events:
+		if event["event"] != "url.created" || event["short_code"] != code || event["long_url"] != "https://example.com/hooked" {
+			t.Errorf("Unexpected webhook event: %v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Webhook was never delivered")
+	}
+}
+
+func TestWebhookBurstStaysBounded(t *testing.T) {
+	// Quiet the expected "queue full" lines
+	previous := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(previous)
+
+	// The receiver holds every delivery until the burst is over
+	const concurrency, queueSize = 4, 16
+	release := make(chan struct{})
+	var inFlight, maxInFlight, received atomic.Int64
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		<-release
+		inFlight.Add(-1)
+		received.Add(1)
+	}))
+	defer receiver.Close()
+
+	server := setupTestServerWithConfig(func(cfg *config.Config) {
+		cfg.RateLimitDisabled = true
+		cfg.WebhookURL = receiver.URL
+		cfg.WebhookConcurrency = concurrency
+		cfg.WebhookQueueSize = queueSize
+	})
+	defer server.Close()
+
+	baseline := runtime.NumGoroutine()
+	for i := 0; i < 1000; i++ {
+		resp := doJSON(t, "POST", server.URL+"/urls", map[string]interface{}{"long_url": fmt.Sprintf("https://example.com/burst/%d", i)}, nil)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Create %d failed with status %d", i, resp.StatusCode)
+		}
+	}
+
+	// Workers and their receiver connections are the only growth allowed
+	if grown := runtime.NumGoroutine() - baseline; grown > 4*concurrency+10 {
+		t.Errorf("Goroutines grew by %d during a 1000-create burst", grown)
+	}
+	if max := maxInFlight.Load(); max > concurrency {
+		t.Errorf("Receiver saw %d concurrent deliveries, limit is %d", max, concurrency)
+	}
+
+	close(release)
+	deadline := time.Now().Add(2 * time.Second)
+	for received.Load() < concurrency+queueSize && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := received.Load(); got < queueSize || got > concurrency+queueSize {
+		t.Errorf("Expected at most %d deliveries with the rest dropped, got %d", concurrency+queueSize, got)
+	}
+}
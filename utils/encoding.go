@@ -1,8 +1,26 @@
 package utils
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrInvalidBase62 is returned by DecodeBase62Strict for an empty string
+	// or one with a character outside 0-9, a-z and A-Z
+	ErrInvalidBase62 = errors.New("invalid base62")
+	// ErrBase62Overflow is returned by DecodeBase62Strict for a value above math.MaxUint64
+	ErrBase62Overflow = errors.New("base62 value overflows uint64")
+)
+
 // Base62 characters: 0-9, a-z, A-Z (62 characters total)
 const base62Chars = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
+// maxBase62Len is the length of the largest uint64 (2^64-1) in base62
+const maxBase62Len = 11
+
 // EncodeBase62 converts a numeric ID to a base62 string
 // Example: 1 -> "1", 62 -> "10", 63 -> "11"
 func EncodeBase62(id uint64) string {
@@ -10,12 +28,15 @@ func EncodeBase62(id uint64) string {
 		return "0"
 	}
 
-	result := ""
+	// Fill a fixed buffer from the end to avoid per-digit allocations
+	var buf [maxBase62Len]byte
+	i := len(buf)
 	for id > 0 {
-		result = string(base62Chars[id%62]) + result
+		i--
+		buf[i] = base62Chars[id%62]
 		id /= 62
 	}
-	return result
+	return string(buf[i:])
 }
 
 // DecodeBase62 converts a base62 string back to a numeric ID
@@ -44,4 +65,99 @@ func DecodeBase62(encoded string) uint64 {
 	}
 
 	return result
-} 
\ No newline at end of file
+}
+
+// DecodeBase62Strict converts a base62 string to a numeric ID like
+// DecodeBase62, but reports what is wrong with the input instead of
+// returning 0 or a wrapped-around value: ErrInvalidBase62 for an empty
+// string or a bad character (with its position), ErrBase62Overflow when the
+// value doesn't fit in a uint64.
+func DecodeBase62Strict(encoded string) (uint64, error) {
+	if encoded == "" {
+		return 0, fmt.Errorf("%w: empty string", ErrInvalidBase62)
+	}
+	
+	result := uint64(0)
+	for i := 0; i < len(encoded); i++ {
+		value := strings.IndexByte(base62Chars, encoded[i])
+		if value < 0 {
+			return 0, fmt.Errorf("%w: character %q at position %d", ErrInvalidBase62, encoded[i], i)
+		}
+		if result > (math.MaxUint64-uint64(value))/62 {
+			return 0, fmt.Errorf("%w: %s", ErrBase62Overflow, encoded)
+		}
+		result = result*62 + uint64(value)
+	}
+	return result, nil
+}
+
+// base62Checksum returns the check character for a base62 string: a sum of
+// its digits weighted 1, 3, 5, ... from the right, mod 62. The weights are
+// odd and below 31, so each is coprime to 62 and any single mistyped
+// character changes the sum; most swaps of neighbouring characters do too.
+func base62Checksum(encoded string) byte {
+	sum := 0
+	for i := 0; i < len(encoded); i++ {
+		weight := 2*(len(encoded)-1-i) + 1
+		sum += weight * strings.IndexByte(base62Chars, encoded[i])
+	}
+	return base62Chars[sum%62]
+}
+
+// EncodeBase62WithChecksum encodes id like EncodeBase62 and appends a check
+// character, so mistyped codes can be told apart from other valid codes
+// Example: 1 -> "11", 62 -> "103"
+func EncodeBase62WithChecksum(id uint64) string {
+	encoded := EncodeBase62(id)
+	return encoded + string(base62Checksum(encoded))
+}
+
+// DecodeBase62WithChecksum decodes a string made by EncodeBase62WithChecksum.
+// ok is false when s is not exactly such a string: too short, containing
+// non-base62 characters, non-canonical (leading zeros, overflow) or with a
+// check character that doesn't match.
+func DecodeBase62WithChecksum(s string) (id uint64, ok bool) {
+	if len(s) < 2 || len(s) > maxBase62Len+1 {
+		return 0, false
+	}
+	encoded, check := s[:len(s)-1], s[len(s)-1]
+	id = DecodeBase62(encoded)
+	if EncodeBase62(id) != encoded || base62Checksum(encoded) != check {
+		return 0, false
+	}
+	return id, true
+}
+
+// SuggestBase62Checksum returns the checksummed codes one typo away from s,
+// most likely first: s with its check character fixed, then s with one
+// neighbouring pair swapped, then s with one other character replaced. It
+// returns nothing for a code that already passes the check.
+func SuggestBase62Checksum(s string) []string {
+	if _, ok := DecodeBase62WithChecksum(s); ok || len(s) < 2 {
+		return nil
+	}
+	var suggestions []string
+	seen := make(map[string]bool)
+	add := func(candidate string) {
+		if _, ok := DecodeBase62WithChecksum(candidate); ok && !seen[candidate] {
+			seen[candidate] = true
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	
+	encoded := s[:len(s)-1]
+	add(encoded + string(base62Checksum(encoded)))
+	for i := 0; i+1 < len(s); i++ {
+		swapped := []byte(s)
+		swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+		add(string(swapped))
+	}
+	for i := 0; i < len(encoded); i++ {
+		for j := 0; j < len(base62Chars); j++ {
+			replaced := []byte(s)
+			replaced[i] = base62Chars[j]
+			add(string(replaced))
+		}
+	}
+	return suggestions
+}
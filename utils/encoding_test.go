@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	"math"
 	"testing"
 )
 
@@ -23,6 +25,7 @@ func TestEncodeBase62(t *testing.T) {
 		{3844, "100"},   // 62^2
 		{238328, "1000"}, // 62^3
 		{1000000000, "15FTGg"}, // 1 billion
+		{18446744073709551615, "lYGhA16ahyf"}, // max uint64
 	}
 
 	for _, tc := range testCases {
@@ -112,4 +115,132 @@ func BenchmarkDecodeBase62(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		DecodeBase62(encoded)
 	}
-} 
\ No newline at end of file
+}
+
+// encodeBase62Concat is the original string-concatenation encoder, kept as a
+// reference for output parity and benchmarking
+func encodeBase62Concat(id uint64) string {
+	if id == 0 {
+		return "0"
+	}
+
+	result := ""
+	for id > 0 {
+		result = string(base62Chars[id%62]) + result
+		id /= 62
+	}
+	return result
+}
+
+func TestEncodeBase62MatchesConcat(t *testing.T) {
+	for id := uint64(1); id <= 1000000000000000000; id = id*7 + 3 {
+		if got, want := EncodeBase62(id), encodeBase62Concat(id); got != want {
+			t.Errorf("EncodeBase62(%d) = %s; reference encoder gives %s", id, got, want)
+		}
+	}
+}
+
+var benchmarkIDs = []uint64{1, 1000, 1000000, 1000000000, 1000000000000, 1000000000000000, 1000000000000000000}
+
+func BenchmarkEncodeBase62Buffer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		EncodeBase62(benchmarkIDs[i%len(benchmarkIDs)])
+	}
+}
+
+func BenchmarkEncodeBase62Concat(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encodeBase62Concat(benchmarkIDs[i%len(benchmarkIDs)])
+	}
+}
+
+func TestBase62WithChecksumRoundTrip(t *testing.T) {
+	testValues := []uint64{
+		0, 1, 61, 62, 1000, 1000000000, 18446744073709551615,
+	}
+
+	for _, val := range testValues {
+		encoded := EncodeBase62WithChecksum(val)
+		if len(encoded) != len(EncodeBase62(val))+1 {
+			t.Errorf("EncodeBase62WithChecksum(%d) = %s; expected one check character appended", val, encoded)
+		}
+		decoded, ok := DecodeBase62WithChecksum(encoded)
+		if !ok || decoded != val {
+			t.Errorf("Round trip failed for %d: encoded to %s, decoded to %d (ok=%v)", val, encoded, decoded, ok)
+		}
+	}
+}
+
+func TestDecodeBase62WithChecksumCorrupted(t *testing.T) {
+	encoded := EncodeBase62WithChecksum(1000000000)
+
+	// Every single-character substitution is caught, check character included
+	for i := 0; i < len(encoded); i++ {
+		for j := 0; j < len(base62Chars); j++ {
+			corrupted := []byte(encoded)
+			if corrupted[i] == base62Chars[j] {
+				continue
+			}
+			corrupted[i] = base62Chars[j]
+			if id, ok := DecodeBase62WithChecksum(string(corrupted)); ok {
+				t.Errorf("DecodeBase62WithChecksum(%s) accepted a corrupted code as %d", corrupted, id)
+			}
+		}
+	}
+
+	for _, input := range []string{"", "1", "0" + encoded, encoded + "0", "a@b"} {
+		if _, ok := DecodeBase62WithChecksum(input); ok {
+			t.Errorf("DecodeBase62WithChecksum(%q) should fail", input)
+		}
+	}
+}
+
+func TestSuggestBase62Checksum(t *testing.T) {
+	encoded := EncodeBase62WithChecksum(1000000000)
+
+	typo := []byte(encoded)
+	typo[2] = 'x'
+	if suggestions := SuggestBase62Checksum(string(typo)); !containsString(suggestions, encoded) {
+		t.Errorf("SuggestBase62Checksum(%s) = %v; expected it to include %s", typo, suggestions, encoded)
+	}
+
+	if suggestions := SuggestBase62Checksum(encoded); len(suggestions) != 0 {
+		t.Errorf("SuggestBase62Checksum(%s) = %v; expected none for a valid code", encoded, suggestions)
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDecodeBase62Strict(t *testing.T) {
+	for _, id := range []uint64{0, 1, 61, 62, 1000000, math.MaxUint64} {
+		decoded, err := DecodeBase62Strict(EncodeBase62(id))
+		if err != nil || decoded != id {
+			t.Errorf("DecodeBase62Strict(EncodeBase62(%d)) = %d, %v", id, decoded, err)
+		}
+	}
+
+	testCases := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrInvalidBase62},
+		{"ab-c", ErrInvalidBase62},
+		{"abc!", ErrInvalidBase62},
+		{"lYGhA16ahyg", ErrBase62Overflow}, // math.MaxUint64 + 1
+		{"zzzzzzzzzzzz", ErrBase62Overflow},
+	}
+	for _, tc := range testCases {
+		if id, err := DecodeBase62Strict(tc.input); !errors.Is(err, tc.want) {
+			t.Errorf("DecodeBase62Strict(%q) = %d, %v; expected %v", tc.input, id, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "time"
+
+// DateOnlyLayout is the plain-date form accepted for expiration dates
+const DateOnlyLayout = "2006-01-02"
+
+// ParseExpirationDate parses an RFC3339 timestamp or a plain date. A plain
+// date means the end of that day in UTC, so "2025-12-31" stays valid for
+// all of December 31st.
+func ParseExpirationDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(DateOnlyLayout, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.Add(24*time.Hour - time.Second), nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExpirationDate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2025-12-31T10:30:00Z", time.Date(2025, 12, 31, 10, 30, 0, 0, time.UTC)},
+		{"2025-12-31T10:30:00+02:00", time.Date(2025, 12, 31, 8, 30, 0, 0, time.UTC)},
+		{"2025-12-31", time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseExpirationDate(tt.input)
+		if err != nil {
+			t.Errorf("ParseExpirationDate(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseExpirationDate(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "tomorrow", "31/12/2025", "2025-13-01", "2025-12-31 10:30:00"} {
+		if _, err := ParseExpirationDate(input); err == nil {
+			t.Errorf("ParseExpirationDate(%q) should fail", input)
+		}
+	}
+}
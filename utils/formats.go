@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"html"
+	"strings"
+)
+
+// ShortURLFormats lists the format names FormatShortURL understands
+var ShortURLFormats = []string{"plain", "markdown", "html", "code"}
+
+// markdownEscaper escapes the characters that would end a markdown link label
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+
+// FormatShortURL renders a short URL in the named format: the plain URL, a
+// markdown link, an HTML anchor or the bare code. label is the link text for
+// markdown and HTML. It reports false for an unknown format.
+func FormatShortURL(format, shortURL, code, label string) (string, bool) {
+	switch format {
+	case "plain":
+		return shortURL, true
+	case "markdown":
+		return "[" + markdownEscaper.Replace(label) + "](" + shortURL + ")", true
+	case "html":
+		return `<a href="` + html.EscapeString(shortURL) + `">` + html.EscapeString(label) + "</a>", true
+	case "code":
+		return code, true
+	}
+	return "", false
+}
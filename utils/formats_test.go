@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestFormatShortURL(t *testing.T) {
+	tests := []struct {
+		format   string
+		label    string
+		expected string
+	}{
+		{"plain", "Docs", "https://sho.rt/abc"},
+		{"code", "Docs", "abc"},
+		{"markdown", "Docs [v2]", `[Docs \[v2\]](https://sho.rt/abc)`},
+		{"html", `Q&A <"beta">`, `<a href="https://sho.rt/abc">Q&amp;A &lt;&#34;beta&#34;&gt;</a>`},
+	}
+
+	for _, tt := range tests {
+		got, ok := FormatShortURL(tt.format, "https://sho.rt/abc", "abc", tt.label)
+		if !ok || got != tt.expected {
+			t.Errorf("FormatShortURL(%q) = %q, %v; expected %q", tt.format, got, ok, tt.expected)
+		}
+	}
+
+	if _, ok := FormatShortURL("qr", "https://sho.rt/abc", "abc", ""); ok {
+		t.Error("FormatShortURL() should reject unknown formats")
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// SnakeToCamel converts a snake_case identifier to camelCase
+// Example: "short_code" -> "shortCode", "id" -> "id"
+func SnakeToCamel(s string) string {
+	parts := strings.Split(s, "_")
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		if i == 0 || sb.Len() == 0 {
+			sb.WriteString(part)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// CamelCaseKeys re-encodes v with every JSON object key converted from
+// snake_case to camelCase. Numbers are preserved exactly via json.Number.
+func CamelCaseKeys(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return camelizeValue(generic), nil
+}
+
+// camelizeValue walks a decoded JSON value renaming object keys
+func camelizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			out[SnakeToCamel(k)] = camelizeValue(child)
+		}
+		return out
+	case []interface{}:
+		for i, child := range val {
+			val[i] = camelizeValue(child)
+		}
+		return val
+	default:
+		return val
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSnakeToCamel(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"id", "id"},
+		{"short_code", "shortCode"},
+		{"expiration_date", "expirationDate"},
+		{"password_protected", "passwordProtected"},
+		{"already", "already"},
+		{"_leading", "leading"},
+	}
+
+	for _, tc := range testCases {
+		if result := SnakeToCamel(tc.input); result != tc.expected {
+			t.Errorf("SnakeToCamel(%s) = %s; expected %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestCamelCaseKeys(t *testing.T) {
+	input := map[string]interface{}{
+		"short_url": "http://localhost:8080/1",
+		"id":        uint64(18446744073709551615),
+		"nested":    []interface{}{map[string]interface{}{"long_url": "https://example.com"}},
+	}
+
+	shaped, err := CamelCaseKeys(input)
+	if err != nil {
+		t.Fatalf("CamelCaseKeys() failed: %v", err)
+	}
+
+	data, _ := json.Marshal(shaped)
+	expected := `{"id":18446744073709551615,"nested":[{"longUrl":"https://example.com"}],"shortUrl":"http://localhost:8080/1"}`
+	if string(data) != expected {
+		t.Errorf("CamelCaseKeys() = %s; expected %s", data, expected)
+	}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeURL returns a canonical form of rawURL for equality checks:
+// scheme and host are lowercased, default ports dropped and an empty path
+// becomes "/". Path, query and fragment are kept as-is since servers may
+// treat them case-sensitively. Unparseable input is returned unchanged.
+func NormalizeURL(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+	
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	if port := parsed.Port(); port != "" && !isDefaultPort(parsed.Scheme, port) {
+		host += ":" + port
+	}
+	parsed.Host = host
+	if parsed.Path == "" && parsed.Opaque == "" {
+		parsed.Path = "/"
+	}
+	
+	return parsed.String()
+}
+
+// StripURL removes the parts of rawURL an operator chose not to store: the
+// fragment when stripFragment is set, and every query parameter named in
+// params. A name ending in "*" matches by prefix, so "utm_*" removes all UTM
+// parameters. The remaining parameters keep their order and encoding.
+// Unparseable input is returned unchanged.
+func StripURL(rawURL string, stripFragment bool, params []string) string {
+	if !stripFragment && len(params) == 0 {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	
+	if stripFragment {
+		parsed.Fragment = ""
+		parsed.RawFragment = ""
+	}
+	if len(params) > 0 && parsed.RawQuery != "" {
+		var kept []string
+		for _, part := range strings.Split(parsed.RawQuery, "&") {
+			rawKey, _, _ := strings.Cut(part, "=")
+			key, err := url.QueryUnescape(rawKey)
+			if err != nil {
+				key = rawKey
+			}
+			if !matchesParam(key, params) {
+				kept = append(kept, part)
+			}
+		}
+		parsed.RawQuery = strings.Join(kept, "&")
+		parsed.ForceQuery = false
+	}
+	return parsed.String()
+}
+
+// matchesParam reports whether key is one of names, where a name ending in
+// "*" matches any key with that prefix
+func matchesParam(key string, names []string) bool {
+	for _, name := range names {
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isDefaultPort reports whether port is the default for scheme
+func isDefaultPort(scheme, port string) bool {
+	return scheme == "http" && port == "80" || scheme == "https" && port == "443"
+}
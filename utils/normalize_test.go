@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://example.com", "https://example.com/"},
+		{"HTTPS://Example.COM/Path", "https://example.com/Path"},
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"https://example.com:443/a", "https://example.com/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"  https://example.com/a?b=C#Frag  ", "https://example.com/a?b=C#Frag"},
+		{"http://[::1]:80/", "http://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeURL(tt.in); got != tt.want {
+			t.Errorf("NormalizeURL(%q) = %q; expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripURL(t *testing.T) {
+	tests := []struct {
+		in       string
+		fragment bool
+		params   []string
+		want     string
+	}{
+		{"https://example.com/a?b=1#top", false, nil, "https://example.com/a?b=1#top"},
+		{"https://example.com/a?b=1#top", true, nil, "https://example.com/a?b=1"},
+		{"https://example.com/a?utm_source=x&id=7&utm_medium=y&fbclid=z#top", false, []string{"utm_*", "fbclid"}, "https://example.com/a?id=7#top"},
+		{"https://example.com/a?utm_source=x", true, []string{"utm_*"}, "https://example.com/a"},
+		{"https://example.com/a?b=%2F&utm=1&c", false, []string{"utm"}, "https://example.com/a?b=%2F&c"},
+		{"https://example.com/a?utmost=1", false, []string{"utm_*"}, "https://example.com/a?utmost=1"},
+	}
+
+	for _, tt := range tests {
+		if got := StripURL(tt.in, tt.fragment, tt.params); got != tt.want {
+			t.Errorf("StripURL(%q, %v, %v) = %q; expected %q", tt.in, tt.fragment, tt.params, got, tt.want)
+		}
+	}
+}
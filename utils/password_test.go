@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestHashAndCheckPassword(t *testing.T) {
+	hash, err := HashPassword("s3cret")
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+
+	if hash == "s3cret" {
+		t.Error("HashPassword() should not return the plaintext password")
+	}
+
+	if !CheckPassword(hash, "s3cret") {
+		t.Error("CheckPassword() should accept the correct password")
+	}
+
+	if CheckPassword(hash, "wrong") {
+		t.Error("CheckPassword() should reject an incorrect password")
+	}
+}
@@ -0,0 +1,506 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QR code defaults for rendered PNGs. Sizes are the image width in pixels;
+// requests outside [QRMinSize, QRMaxSize] are clamped.
+const (
+	QRDefaultSize = 256
+	QRMinSize     = 64
+	QRMaxSize     = 1024
+)
+
+// qrQuietZone is the light border, in modules, the spec requires around a symbol
+const qrQuietZone = 4
+
+// ErrQRTooLong is returned when text doesn't fit the largest supported version
+var ErrQRTooLong = errors.New("text too long for a QR code")
+
+// qrVersion describes the error correction layout of one version at level M
+type qrVersion struct {
+	ecPerBlock int
+	blocks     []int // Data codewords in each block
+	alignment  []int // Alignment pattern centre coordinates
+}
+
+// qrVersions holds versions 1-10 at error correction level M, enough for
+// 213 bytes, which is far more than any short URL needs
+var qrVersions = []qrVersion{
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// QRCode encodes text in byte mode at error correction level M using the
+// smallest version it fits, and returns the modules as rows of dark (true)
+// and light cells without the quiet zone.
+func QRCode(text string) ([][]bool, error) {
+	data := []byte(text)
+	for i, v := range qrVersions {
+		version := i + 1
+		capacity := 0
+		for _, n := range v.blocks {
+			capacity += n
+		}
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*capacity {
+			continue
+		}
+		codewords := qrCodewords(v, qrDataCodewords(data, countBits, capacity))
+		return newQRMatrix(version, v.alignment).render(codewords), nil
+	}
+	return nil, ErrQRTooLong
+}
+
+// QRPNG renders text as a square black-on-white PNG size pixels wide,
+// clamping size to [QRMinSize, QRMaxSize]. Modules are a whole number of
+// pixels, so any remainder widens the quiet zone around the centred symbol.
+func QRPNG(text string, size int) ([]byte, error) {
+	modules, err := QRCode(text)
+	if err != nil {
+		return nil, err
+	}
+	size = ClampQRSize(size)
+
+	span := len(modules) + 2*qrQuietZone
+	scale := size / span
+	if scale < 1 {
+		scale = 1
+	}
+	width := max(size, span)
+	offset := (width - len(modules)*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			px, py := offset+x*scale, offset+y*scale
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(px+dx, py+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// QRDataURI renders text with QRPNG as a data:image/png;base64 URI
+func QRDataURI(text string, size int) (string, error) {
+	img, err := QRPNG(text, size)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(img), nil
+}
+
+// ClampQRSize limits a requested image size to [QRMinSize, QRMaxSize]
+func ClampQRSize(size int) int {
+	if size < QRMinSize {
+		return QRMinSize
+	}
+	if size > QRMaxSize {
+		return QRMaxSize
+	}
+	return size
+}
+
+// qrDataCodewords builds the byte mode segment, terminator and padding
+func qrDataCodewords(data []byte, countBits, capacity int) []byte {
+	var bits []bool
+	put := func(value, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	put(0x4, 4) // Byte mode
+	put(len(data), countBits)
+	for _, b := range data {
+		put(int(b), 8)
+	}
+	for i := 0; i < 4 && len(bits) < 8*capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	out := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for _, bit := range bits[i : i+8] {
+			b <<= 1
+			if bit {
+				b |= 1
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// qrCodewords splits data into blocks, appends each block's error correction
+// and interleaves the result in symbol order
+func qrCodewords(v qrVersion, data []byte) []byte {
+	divisor := rsDivisor(v.ecPerBlock)
+	blocks := make([][]byte, len(v.blocks))
+	ecc := make([][]byte, len(v.blocks))
+	longest := 0
+	for i, n := range v.blocks {
+		blocks[i], data = data[:n], data[n:]
+		ecc[i] = rsRemainder(blocks[i], divisor)
+		if n > longest {
+			longest = n
+		}
+	}
+
+	var out []byte
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, block := range ecc {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the given
+// degree, highest coefficient first with the leading 1 omitted
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder computes the error correction codewords of data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// qrFormatBits returns the 15-bit format information for level M and mask
+func qrFormatBits(mask int) int {
+	data := mask // Level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// qrVersionBits returns the 18-bit version information used from version 7
+func qrVersionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+// qrMatrix is a symbol under construction. function marks the modules of
+// finder, timing, alignment and format patterns, which data and masks skip.
+type qrMatrix struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newQRMatrix(version int, alignment []int) *qrMatrix {
+	size := 17 + 4*version
+	m := &qrMatrix{version: version, size: size}
+	m.modules = make([][]bool, size)
+	m.function = make([][]bool, size)
+	for i := range m.modules {
+		m.modules[i] = make([]bool, size)
+		m.function[i] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+	m.finder(3, 3)
+	m.finder(size-4, 3)
+	m.finder(3, size-4)
+
+	last := len(alignment) - 1
+	for i, cx := range alignment {
+		for j, cy := range alignment {
+			// Skip the three corners occupied by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas; render fills them in once the mask is chosen
+	m.format(0)
+	if version >= 7 {
+		bits := qrVersionBits(version)
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			m.set(a, b, dark)
+			m.set(b, a, dark)
+		}
+	}
+	return m
+}
+
+// set places a function module at column x, row y
+func (m *qrMatrix) set(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.function[y][x] = true
+}
+
+// finder draws a finder pattern and its separator centred on (x, y)
+func (m *qrMatrix) finder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= m.size || yy < 0 || yy >= m.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			m.set(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// format writes both copies of the format information for mask
+func (m *qrMatrix) format(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true) // Always dark
+}
+
+// render places codewords in the zigzag data area, applies the mask with the
+// lowest penalty and returns the finished modules
+func (m *qrMatrix) render(codewords []byte) [][]bool {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				m.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.format(mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		m.applyMask(mask) // XOR again to undo
+	}
+	m.applyMask(best)
+	m.format(best)
+	return m.modules
+}
+
+// applyMask flips the data modules selected by mask
+func (m *qrMatrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !m.function[y][x] {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the current modules with the four rules of the spec's mask
+// evaluation; lower is better
+func (m *qrMatrix) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return m.modules[x][y]
+		}
+		return m.modules[y][x]
+	}
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+
+	penalty := 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < m.size; y++ {
+			// Rule 1: runs of five or more modules of one colour
+			run := 1
+			for x := 1; x <= m.size; x++ {
+				if x < m.size && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Rule 3: 1:1:3:1:1 finder-like patterns next to four light modules
+			for x := 0; x+11 <= m.size; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, dark := range pattern {
+						if at(x+k, y, transpose) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+			// Rule 2: 2x2 blocks of one colour
+			if x+1 < m.size && y+1 < m.size {
+				c := m.modules[y][x]
+				if c == m.modules[y][x+1] && c == m.modules[y+1][x] && c == m.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	// Rule 4: 10 points per 5% the dark proportion strays from half
+	total := m.size * m.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	penalty += k * 10
+	return penalty
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,353 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" at 1-M, the worked example from the QR specification
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("rsRemainder() = %v, expected %v", got, expected)
+	}
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	formats := map[int]int{
+		0: 0b101010000010010,
+		1: 0b101000100100101,
+		4: 0b100010111111001,
+		7: 0b100101010100000,
+	}
+	for mask, expected := range formats {
+		if got := qrFormatBits(mask); got != expected {
+			t.Errorf("qrFormatBits(%d) = %015b, expected %015b", mask, got, expected)
+		}
+	}
+	if got := qrVersionBits(7); got != 0b000111110010010100 {
+		t.Errorf("qrVersionBits(7) = %018b, expected 000111110010010100", got)
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	tests := []struct {
+		text string
+		size int
+	}{
+		{"https://x.io", 21},
+		{"https://sho.rt/abc123", 25},
+		{"https://sho.rt/" + strings.Repeat("a", 150), 53},
+	}
+	for _, tt := range tests {
+		modules, err := QRCode(tt.text)
+		if err != nil {
+			t.Fatalf("QRCode(%q) error: %v", tt.text, err)
+		}
+		if len(modules) != tt.size || len(modules[0]) != tt.size {
+			t.Errorf("QRCode(%q) is %dx%d, expected %dx%d", tt.text, len(modules), len(modules[0]), tt.size, tt.size)
+			continue
+		}
+
+		// Finder patterns in three corners: dark ring, light ring, dark core
+		for _, corner := range [][2]int{{0, 0}, {tt.size - 7, 0}, {0, tt.size - 7}} {
+			for dy := 0; dy < 7; dy++ {
+				for dx := 0; dx < 7; dx++ {
+					ring := max(abs(dx-3), abs(dy-3))
+					if modules[corner[1]+dy][corner[0]+dx] != (ring != 2) {
+						t.Fatalf("QRCode(%q) has a broken finder pattern at %v", tt.text, corner)
+					}
+				}
+			}
+		}
+
+		// Both copies of the format information name the same valid mask
+		first, second := 0, 0
+		for i := 0; i < 15; i++ {
+			var a, b bool
+			switch {
+			case i <= 5:
+				a = modules[i][8]
+			case i <= 7:
+				a = modules[i+1][8]
+			case i == 8:
+				a = modules[8][7]
+			default:
+				a = modules[8][14-i]
+			}
+			if i < 8 {
+				b = modules[8][tt.size-1-i]
+			} else {
+				b = modules[tt.size-15+i][8]
+			}
+			if a {
+				first |= 1 << i
+			}
+			if b {
+				second |= 1 << i
+			}
+		}
+		valid := false
+		for mask := 0; mask < 8; mask++ {
+			valid = valid || first == qrFormatBits(mask)
+		}
+		if !valid || first != second {
+			t.Errorf("QRCode(%q) format information %015b / %015b is not a level M mask", tt.text, first, second)
+		}
+	}
+
+	if _, err := QRCode(strings.Repeat("a", 214)); !errors.Is(err, ErrQRTooLong) {
+		t.Errorf("Expected ErrQRTooLong for 214 bytes, got %v", err)
+	}
+}
+
+func TestQRPNG(t *testing.T) {
+	tests := []struct {
+		size     int
+		expected int
+	}{
+		{256, 256},
+		{1, QRMinSize},
+		{100000, QRMaxSize},
+	}
+	for _, tt := range tests {
+		data, err := QRPNG("https://x.io", tt.size)
+		if err != nil {
+			t.Fatalf("QRPNG(size %d) error: %v", tt.size, err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("QRPNG(size %d) is not a PNG: %v", tt.size, err)
+		}
+		if w, h := img.Bounds().Dx(), img.Bounds().Dy(); w != tt.expected || h != tt.expected {
+			t.Errorf("QRPNG(size %d) is %dx%d, expected %dx%d", tt.size, w, h, tt.expected, tt.expected)
+		}
+		// The top-left finder pattern starts at least four light modules in
+		if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xffff {
+			t.Errorf("QRPNG(size %d) is missing its quiet zone", tt.size)
+		}
+	}
+
+	uri, err := QRDataURI("https://x.io", QRDefaultSize)
+	if err != nil || !strings.HasPrefix(uri, "data:image/png;base64,") {
+		t.Fatalf("QRDataURI() = %q, %v", uri, err)
+	}
+	if _, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,")); err != nil {
+		t.Errorf("QRDataURI() payload is not base64: %v", err)
+	}
+}
+
+// qrSpecLayout is the level M layout per version as printed in the QR code
+// specification, kept apart from qrVersions so decodeQR checks the encoder
+// against the spec rather than against itself
+var qrSpecLayout = map[int]struct {
+	ecPerBlock int
+	blocks     []int
+	alignment  []int
+}{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// decodeQR reads a symbol back the way a scanner does: format information,
+// unmasking, the zigzag walk, de-interleaving, a zero Reed-Solomon syndrome
+// check for every block and the byte mode payload
+func decodeQR(t *testing.T, modules [][]bool) string {
+	t.Helper()
+	n := len(modules)
+	version := (n - 17) / 4
+	layout, ok := qrSpecLayout[version]
+	if !ok {
+		t.Fatalf("decodeQR: no spec layout for version %d", version)
+	}
+
+	format := 0
+	for i := 0; i < 15; i++ {
+		var dark bool
+		switch {
+		case i <= 5:
+			dark = modules[i][8]
+		case i <= 7:
+			dark = modules[i+1][8]
+		case i == 8:
+			dark = modules[8][7]
+		default:
+			dark = modules[8][14-i]
+		}
+		if dark {
+			format |= 1 << i
+		}
+	}
+	format ^= 0x5412
+	if level := format >> 13; level != 0 {
+		t.Fatalf("decodeQR: error correction level %d, expected M", level)
+	}
+	mask := format >> 10 & 7
+
+	reserved := make([][]bool, n)
+	for i := range reserved {
+		reserved[i] = make([]bool, n)
+	}
+	reserve := func(row, col, height, width int) {
+		for r := row; r < row+height; r++ {
+			for c := col; c < col+width; c++ {
+				if r >= 0 && r < n && c >= 0 && c < n {
+					reserved[r][c] = true
+				}
+			}
+		}
+	}
+	reserve(0, 0, 9, 9)
+	reserve(0, n-8, 9, 8)
+	reserve(n-8, 0, 8, 9)
+	reserve(6, 0, 1, n)
+	reserve(0, 6, n, 1)
+	for _, r := range layout.alignment {
+		for _, c := range layout.alignment {
+			if (r < 9 && c < 9) || (r < 9 && c > n-10) || (r > n-10 && c < 9) {
+				continue
+			}
+			reserve(r-2, c-2, 5, 5)
+		}
+	}
+	if version >= 7 {
+		reserve(0, n-11, 6, 3)
+		reserve(n-11, 0, 3, 6)
+	}
+
+	masks := []func(r, c int) bool{
+		func(r, c int) bool { return (r+c)%2 == 0 },
+		func(r, c int) bool { return r%2 == 0 },
+		func(r, c int) bool { return c%3 == 0 },
+		func(r, c int) bool { return (r+c)%3 == 0 },
+		func(r, c int) bool { return (r/2+c/3)%2 == 0 },
+		func(r, c int) bool { return r*c%2+r*c%3 == 0 },
+		func(r, c int) bool { return (r*c%2+r*c%3)%2 == 0 },
+		func(r, c int) bool { return ((r+c)%2+r*c%3)%2 == 0 },
+	}
+	var bits []bool
+	upward := true
+	for col := n - 1; col > 0; col -= 2 {
+		if col == 6 {
+			col--
+		}
+		for i := 0; i < n; i++ {
+			row := i
+			if upward {
+				row = n - 1 - i
+			}
+			for _, c := range []int{col, col - 1} {
+				if !reserved[row][c] {
+					bits = append(bits, modules[row][c] != masks[mask](row, c))
+				}
+			}
+		}
+		upward = !upward
+	}
+	var codewords []byte
+	for i := 0; i+8 <= len(bits); i += 8 {
+		var b byte
+		for _, bit := range bits[i : i+8] {
+			b <<= 1
+			if bit {
+				b |= 1
+			}
+		}
+		codewords = append(codewords, b)
+	}
+
+	blocks := make([][]byte, len(layout.blocks))
+	next := 0
+	for i := 0; i < layout.blocks[len(layout.blocks)-1]; i++ {
+		for j, size := range layout.blocks {
+			if i < size {
+				blocks[j] = append(blocks[j], codewords[next])
+				next++
+			}
+		}
+	}
+	for i := 0; i < layout.ecPerBlock; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[next])
+			next++
+		}
+	}
+
+	// Syndromes of a valid block, evaluated at each root of the generator, are zero
+	var exp [255]byte
+	var logs [256]int
+	x := 1
+	for i := range exp {
+		exp[i], logs[x] = byte(x), i
+		if x <<= 1; x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	multiply := func(a, b byte) byte {
+		if a == 0 || b == 0 {
+			return 0
+		}
+		return exp[(logs[a]+logs[b])%255]
+	}
+	var data []byte
+	for j, block := range blocks {
+		for k := 0; k < layout.ecPerBlock; k++ {
+			var syndrome byte
+			for _, b := range block {
+				syndrome = multiply(syndrome, exp[k]) ^ b
+			}
+			if syndrome != 0 {
+				t.Fatalf("decodeQR: block %d syndrome %d is %d", j, k, syndrome)
+			}
+		}
+		data = append(data, block[:layout.blocks[j]]...)
+	}
+
+	read := func(pos, width int) int {
+		value := 0
+		for i := pos; i < pos+width; i++ {
+			value = value<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+		return value
+	}
+	if mode := read(0, 4); mode != 0x4 {
+		t.Fatalf("decodeQR: mode %b, expected byte mode", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	length := read(4, countBits)
+	payload := make([]byte, length)
+	for i := range payload {
+		payload[i] = byte(read(4+countBits+8*i, 8))
+	}
+	return string(payload)
+}
+
+func TestQRCodeDecodes(t *testing.T) {
+	for _, text := range []string{
+		"https://x.io",                               // Version 1
+		"http://127.0.0.1:41234/abc",                 // Version 2
+		"https://sho.rt/" + strings.Repeat("k", 100), // Version 7: version information, four blocks
+		"https://sho.rt/" + strings.Repeat("z", 150), // Version 9: blocks of two lengths
+		"https://sho.rt/" + strings.Repeat("z", 190), // Version 10: 16-bit length
+	} {
+		modules, err := QRCode(text)
+		if err != nil {
+			t.Fatalf("QRCode(%q) error: %v", text, err)
+		}
+		if got := decodeQR(t, modules); got != text {
+			t.Errorf("QRCode(%q) decodes to %q", text, got)
+		}
+	}
+}
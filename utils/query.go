@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MergeQueryParams appends incoming query parameters to target. When a key
+// appears in both, incomingWins selects which side's values are kept. The
+// stored query keeps its original order and encoding, and any fragment on
+// target stays at the end of the URL.
+func MergeQueryParams(target string, incoming url.Values, incomingWins bool) (string, error) {
+	if len(incoming) == 0 {
+		return target, nil
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	stored := make(map[string]bool)
+	if parsed.RawQuery != "" {
+		for _, part := range strings.Split(parsed.RawQuery, "&") {
+			rawKey, _, _ := strings.Cut(part, "=")
+			key, err := url.QueryUnescape(rawKey)
+			if err != nil {
+				key = rawKey
+			}
+			stored[key] = true
+			if _, overridden := incoming[key]; overridden && incomingWins {
+				continue
+			}
+			parts = append(parts, part)
+		}
+	}
+
+	keys := make([]string, 0, len(incoming))
+	for key := range incoming {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if stored[key] && !incomingWins {
+			continue
+		}
+		for _, value := range incoming[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	parsed.RawQuery = strings.Join(parts, "&")
+	parsed.ForceQuery = false
+	return parsed.String(), nil
+}
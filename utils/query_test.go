@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestMergeQueryParams(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		incoming     string
+		incomingWins bool
+		expected     string
+	}{
+		{"no incoming params", "https://example.com/a?b=1", "", true, "https://example.com/a?b=1"},
+		{"no stored query", "https://example.com/a", "utm_source=x", true, "https://example.com/a?utm_source=x"},
+		{"append to stored query", "https://example.com/a?b=1", "utm_source=x", true, "https://example.com/a?b=1&utm_source=x"},
+		{"incoming wins", "https://example.com/a?utm_source=stored&b=1", "utm_source=x", true, "https://example.com/a?b=1&utm_source=x"},
+		{"stored wins", "https://example.com/a?utm_source=stored&b=1", "utm_source=x&c=2", false, "https://example.com/a?utm_source=stored&b=1&c=2"},
+		{"fragment stays last", "https://example.com/a?b=1#section", "utm_source=x", true, "https://example.com/a?b=1&utm_source=x#section"},
+		{"fragment without query", "https://example.com/a#section", "utm_source=x", true, "https://example.com/a?utm_source=x#section"},
+		{"values are escaped", "https://example.com/a", "q=a b&x=%26", true, "https://example.com/a?q=a+b&x=%26"},
+		{"stored encoding preserved", "https://example.com/a?q=a%20b", "z=1", true, "https://example.com/a?q=a%20b&z=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incoming, err := url.ParseQuery(tt.incoming)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) failed: %v", tt.incoming, err)
+			}
+
+			merged, err := MergeQueryParams(tt.target, incoming, tt.incomingWins)
+			if err != nil {
+				t.Fatalf("MergeQueryParams() failed: %v", err)
+			}
+			if merged != tt.expected {
+				t.Errorf("MergeQueryParams() = %q, expected %q", merged, tt.expected)
+			}
+		})
+	}
+}
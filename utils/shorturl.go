@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrInvalidShortURL is returned when a short URL cannot be parsed or has no code
+	ErrInvalidShortURL = errors.New("invalid short URL")
+
+	// ErrForeignShortURL is returned when a short URL does not belong to our base URL
+	ErrForeignShortURL = errors.New("short URL does not belong to this service")
+)
+
+// ExtractShortCode returns the code from a full short URL such as
+// "https://sho.rt/abc". The URL's host must match baseURL's host so the
+// service never acts as a resolver for other domains; any path on baseURL
+// (e.g. "https://example.com/s") must prefix the code.
+func ExtractShortCode(shortURL, baseURL string) (string, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Accept scheme-less input such as "sho.rt/abc"
+	if !strings.Contains(shortURL, "://") {
+		shortURL = base.Scheme + "://" + shortURL
+	}
+	parsed, err := url.Parse(strings.TrimSpace(shortURL))
+	if err != nil || parsed.Host == "" {
+		return "", ErrInvalidShortURL
+	}
+
+	if !strings.EqualFold(parsed.Host, base.Host) {
+		return "", ErrForeignShortURL
+	}
+
+	basePath := strings.TrimSuffix(base.Path, "/")
+	path := strings.TrimSuffix(parsed.Path, "/")
+	if !strings.HasPrefix(path, basePath+"/") {
+		return "", ErrForeignShortURL
+	}
+
+	code := strings.TrimPrefix(path, basePath+"/")
+	if code == "" || strings.Contains(code, "/") {
+		return "", ErrInvalidShortURL
+	}
+	return code, nil
+}
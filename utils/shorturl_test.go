@@ -0,0 +1,39 @@
+package utils
+
+import "testing"
+
+func TestExtractShortCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		shortURL string
+		baseURL  string
+		expected string
+		err      error
+	}{
+		{"matching host", "http://localhost:8080/abc", "http://localhost:8080", "abc", nil},
+		{"host is case-insensitive", "https://SHO.RT/Ab1", "https://sho.rt", "Ab1", nil},
+		{"scheme may differ", "https://sho.rt/abc", "http://sho.rt", "abc", nil},
+		{"trailing slash", "https://sho.rt/abc/", "https://sho.rt", "abc", nil},
+		{"query is ignored", "https://sho.rt/abc?utm_source=x", "https://sho.rt", "abc", nil},
+		{"scheme-less input", "sho.rt/abc", "https://sho.rt", "abc", nil},
+		{"code with base path", "https://example.com/s/abc", "https://example.com/s", "abc", nil},
+		{"base path with trailing slash", "https://example.com/s/abc", "https://example.com/s/", "abc", nil},
+		{"mismatched host", "https://evil.com/abc", "https://sho.rt", "", ErrForeignShortURL},
+		{"mismatched port", "http://localhost:9090/abc", "http://localhost:8080", "", ErrForeignShortURL},
+		{"missing base path", "https://example.com/abc", "https://example.com/s", "", ErrForeignShortURL},
+		{"no code", "https://sho.rt/", "https://sho.rt", "", ErrForeignShortURL},
+		{"nested path", "https://sho.rt/a/b", "https://sho.rt", "", ErrInvalidShortURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := ExtractShortCode(tt.shortURL, tt.baseURL)
+			if err != tt.err {
+				t.Fatalf("ExtractShortCode(%q, %q) error = %v, expected %v", tt.shortURL, tt.baseURL, err, tt.err)
+			}
+			if code != tt.expected {
+				t.Errorf("ExtractShortCode(%q, %q) = %q, expected %q", tt.shortURL, tt.baseURL, code, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseSigned(t *testing.T, signed string) (code, exp, sig string) {
+	t.Helper()
+	code, query, ok := strings.Cut(signed, "?")
+	if !ok {
+		t.Fatalf("Expected query params in %q", signed)
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", query, err)
+	}
+	return code, params.Get("exp"), params.Get("sig")
+}
+
+func TestSignShortURL(t *testing.T) {
+	signer := NewURLSigner("secret")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	exp := now.Add(time.Hour)
+
+	code, expParam, sig := parseSigned(t, signer.SignShortURL("abc", exp))
+	if code != "abc" {
+		t.Errorf("Expected code abc, got %q", code)
+	}
+	if expParam != "1748782800" {
+		t.Errorf("Expected exp 1748782800, got %q", expParam)
+	}
+	if err := signer.Verify("abc", expParam, sig, now); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	signer := NewURLSigner("secret")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, exp, sig := parseSigned(t, signer.SignShortURL("abc", now.Add(time.Hour)))
+
+	testCases := []struct {
+		name   string
+		signer *URLSigner
+		code   string
+		exp    string
+		sig    string
+	}{
+		{"other code", signer, "abd", exp, sig},
+		{"extended expiry", signer, "abc", "1893456000", sig},
+		{"altered signature", signer, "abc", exp, "A" + sig[1:]},
+		{"missing signature", signer, "abc", exp, ""},
+		{"malformed expiry", signer, "abc", "tomorrow", sig},
+		{"other secret", NewURLSigner("other"), "abc", exp, sig},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.signer.Verify(tc.code, tc.exp, tc.sig, now); !errors.Is(err, ErrSignatureInvalid) {
+				t.Errorf("Expected ErrSignatureInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsExpired(t *testing.T) {
+	signer := NewURLSigner("secret")
+	exp := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, expParam, sig := parseSigned(t, signer.SignShortURL("abc", exp))
+
+	if err := signer.Verify("abc", expParam, sig, exp.Add(-time.Second)); err != nil {
+		t.Errorf("Expected the signature to be valid before exp, got %v", err)
+	}
+	if err := signer.Verify("abc", expParam, sig, exp); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("Expected ErrSignatureExpired at exp, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import "strings"
+
+// Device classes returned by ClassifyDevice
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// ClassifyDevice maps a User-Agent header to a coarse device class. It only
+// looks for well-known platform tokens; anything unrecognized, including
+// bots and empty headers, is treated as desktop.
+func ClassifyDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case strings.Contains(ua, "iphone"),
+		strings.Contains(ua, "ipod"),
+		strings.Contains(ua, "windows phone"),
+		strings.Contains(ua, "blackberry"):
+		return DeviceMobile
+	case strings.Contains(ua, "ipad"),
+		strings.Contains(ua, "tablet"),
+		strings.Contains(ua, "kindle"),
+		strings.Contains(ua, "silk/"),
+		strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// IsDeviceClass reports whether s is one of the classes ClassifyDevice returns
+func IsDeviceClass(s string) bool {
+	return s == DeviceMobile || s == DeviceTablet || s == DeviceDesktop
+}
@@ -0,0 +1,26 @@
+package utils
+
+import "testing"
+
+func TestClassifyDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", DeviceMobile},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36", DeviceMobile},
+		{"Mozilla/5.0 (Windows Phone 10.0; Android 6.0.1) Edge/15.15063", DeviceMobile},
+		{"Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", DeviceTablet},
+		{"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", DeviceTablet},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", DeviceDesktop},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15", DeviceDesktop},
+		{"curl/8.4.0", DeviceDesktop},
+		{"", DeviceDesktop},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyDevice(tt.userAgent); got != tt.expected {
+			t.Errorf("ClassifyDevice(%q) = %q, expected %q", tt.userAgent, got, tt.expected)
+		}
+	}
+}
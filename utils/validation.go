@@ -1,33 +1,90 @@
 package utils
 
-import (
-	"net/url"
-	"strings"
-)
+import "strings"
 
-// IsValidURL validates that a string is a proper HTTP or HTTPS URL
+// IsValidURL validates that a string is a proper HTTP or HTTPS URL, using
+// DefaultValidator
 func IsValidURL(urlStr string) bool {
-	// Basic empty check
-	if strings.TrimSpace(urlStr) == "" {
-		return false
+	return DefaultValidator.Validate(urlStr) == nil
+}
+
+// ContainsControlChars reports whether s contains ASCII control characters
+// (including CR and LF) or DEL, which must never reach a response header
+func ContainsControlChars(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return true
+		}
 	}
+	return false
+}
+
+// MaxCustomCodeLength is the longest custom short code accepted
+const MaxCustomCodeLength = 32
+
+// MaxTagLength is the longest tag accepted
+const MaxTagLength = 32
 
-	// Parse the URL
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
+// DefaultMaxTags is how many tags a link may carry when no limit is configured
+const DefaultMaxTags = 10
+
+// IsValidTag reports whether tag is a normalized tag: 1 to MaxTagLength
+// lowercase ASCII letters, digits, '-' or '_', starting with a letter or digit
+func IsValidTag(tag string) bool {
+	if tag == "" || len(tag) > MaxTagLength || tag[0] == '-' || tag[0] == '_' {
 		return false
 	}
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
 
-	// Check scheme is HTTP or HTTPS
-	scheme := strings.ToLower(parsedURL.Scheme)
-	if scheme != "http" && scheme != "https" {
-		return false
+// NormalizeTags trims and lowercases tags and drops duplicates, keeping the
+// first occurrence's position. Tags that are still not valid after
+// normalization are returned as invalid, as the caller sent them.
+func NormalizeTags(tags []string) (normalized, invalid []string) {
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		norm := strings.ToLower(strings.TrimSpace(tag))
+		if !IsValidTag(norm) {
+			invalid = append(invalid, tag)
+			continue
+		}
+		if !seen[norm] {
+			seen[norm] = true
+			normalized = append(normalized, norm)
+		}
 	}
+	return normalized, invalid
+}
 
-	// Check host is present
-	if parsedURL.Host == "" {
-		return false
+// IsASCII reports whether s contains only ASCII bytes. Custom codes must be
+// ASCII so Unicode homoglyphs (e.g. Cyrillic 'а' for Latin 'a') and
+// combining marks can't imitate an existing code.
+func IsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
 	}
+	return true
+}
 
+// IsValidCustomCode reports whether code is usable as a custom short code:
+// 1 to MaxCustomCodeLength ASCII letters, digits, '-' or '_'
+func IsValidCustomCode(code string) bool {
+	if code == "" || len(code) > MaxCustomCodeLength {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
 	return true
-} 
\ No newline at end of file
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -91,4 +93,70 @@ func BenchmarkIsValidURL(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		IsValidURL(url)
 	}
-} 
\ No newline at end of file
+}
+
+func TestIsValidURLControlChars(t *testing.T) {
+	injected := []string{
+		"https://example.com/\r\nSet-Cookie: a=b",
+		"https://example.com/\npath",
+		"https://example.com/\x00",
+		"https://example.com/\x7f",
+	}
+
+	for _, url := range injected {
+		if IsValidURL(url) {
+			t.Errorf("IsValidURL(%q) = true; expected false for control characters", url)
+		}
+		if !ContainsControlChars(url) {
+			t.Errorf("ContainsControlChars(%q) = false; expected true", url)
+		}
+	}
+
+	if ContainsControlChars("https://example.com/path?q=1#frag") {
+		t.Error("ContainsControlChars() should be false for a clean URL")
+	}
+}
+
+func TestIsValidCustomCode(t *testing.T) {
+	valid := []string{"a", "mylink", "my-link_2", "ABC123", strings.Repeat("x", MaxCustomCodeLength)}
+	for _, code := range valid {
+		if !IsValidCustomCode(code) {
+			t.Errorf("IsValidCustomCode(%q) = false, expected true", code)
+		}
+	}
+
+	invalid := []string{"", "my link", "my/link", "a.b", "a?b", strings.Repeat("x", MaxCustomCodeLength+1),
+		"p\u0430ypal", // Cyrillic 'а' posing as Latin 'a'
+		"cafe\u0301",  // Combining acute accent
+		"\uff41bc",    // Fullwidth 'ａ'
+	}
+	for _, code := range invalid {
+		if IsValidCustomCode(code) {
+			t.Errorf("IsValidCustomCode(%q) = true, expected false", code)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	normalized, invalid := NormalizeTags([]string{"Marketing ", "marketing", " Q3-launch", "MARKETING", "news_2025"})
+	if !reflect.DeepEqual(normalized, []string{"marketing", "q3-launch", "news_2025"}) || invalid != nil {
+		t.Errorf("NormalizeTags() = %v, %v; expected duplicates collapsed in order", normalized, invalid)
+	}
+
+	bad := []string{"", "  ", "two words", "-leading", "emoji🙂", "a.b", strings.Repeat("x", MaxTagLength+1)}
+	normalized, invalid = NormalizeTags(append([]string{"ok"}, bad...))
+	if !reflect.DeepEqual(normalized, []string{"ok"}) || !reflect.DeepEqual(invalid, bad) {
+		t.Errorf("NormalizeTags() = %v, %v; expected %v rejected as sent", normalized, invalid, bad)
+	}
+}
+
+func TestIsASCII(t *testing.T) {
+	if !IsASCII("paypal-2_X") {
+		t.Error("IsASCII() should accept plain ASCII")
+	}
+	for _, s := range []string{"p\u0430ypal", "cafe\u0301", "\u00e9"} {
+		if IsASCII(s) {
+			t.Errorf("IsASCII(%q) = true, expected false", s)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// DefaultMaxURLLength is the longest URL MaxLength accepts when no limit is configured
+const DefaultMaxURLLength = 2048
+
+// ValidatorFunc checks one aspect of a URL and returns the reason it fails,
+// or nil. The message is shown to clients as-is.
+type ValidatorFunc func(rawURL string) error
+
+// Validator runs an ordered chain of ValidatorFuncs and stops at the first failure
+type Validator struct {
+	funcs []ValidatorFunc
+}
+
+// NewValidator creates a Validator running funcs in order. Empty URLs are
+// always rejected before the chain runs.
+func NewValidator(funcs ...ValidatorFunc) *Validator {
+	return &Validator{funcs: funcs}
+}
+
+// DefaultValidator is the chain IsValidURL runs: no control characters, an
+// http or https scheme and a host
+var DefaultValidator = NewValidator(NoControlChars, HTTPScheme, HasHost)
+
+// Validate returns the first failure's reason, or nil if rawURL passes every check
+func (v *Validator) Validate(rawURL string) error {
+	if strings.TrimSpace(rawURL) == "" {
+		return errors.New("URL is required")
+	}
+	for _, fn := range v.funcs {
+		if err := fn(rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NoControlChars rejects control characters (CR/LF etc.) that could enable
+// header injection
+func NoControlChars(rawURL string) error {
+	if ContainsControlChars(rawURL) {
+		return errors.New("URL contains control characters")
+	}
+	return nil
+}
+
+// HTTPScheme requires a parseable http:// or https:// URL
+func HTTPScheme(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("URL could not be parsed")
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return errors.New("URL must be http:// or https://")
+	}
+	return nil
+}
+
+// HasHost requires a parseable URL with a host
+func HasHost(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("URL could not be parsed")
+	}
+	if u.Host == "" {
+		return errors.New("URL must include a host")
+	}
+	return nil
+}
+
+// MaxLength returns a check rejecting URLs longer than n bytes
+// (n <= 0 means DefaultMaxURLLength)
+func MaxLength(n int) ValidatorFunc {
+	if n <= 0 {
+		n = DefaultMaxURLLength
+	}
+	return func(rawURL string) error {
+		if len(rawURL) > n {
+			return fmt.Errorf("URL is longer than %d characters", n)
+		}
+		return nil
+	}
+}
+
+// BlockedHosts returns a check rejecting URLs whose host is one of hosts or
+// a subdomain of one, ignoring case
+func BlockedHosts(hosts []string) ValidatorFunc {
+	blocked := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.Trim(strings.TrimSpace(host), ".")); host != "" {
+			blocked = append(blocked, host)
+		}
+	}
+	return func(rawURL string) error {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil // Not ours to report; the scheme and host checks do
+		}
+		host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+		for _, b := range blocked {
+			if host == b || strings.HasSuffix(host, "."+b) {
+				return fmt.Errorf("URL host %s is blocked", host)
+			}
+		}
+		return nil
+	}
+}
+
+// NoPrivateHosts rejects localhost and IP literals in loopback, private,
+// link-local or unspecified ranges, so short links can't point clients or
+// preview fetchers at internal services. Hostnames are not resolved.
+func NoPrivateHosts(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil // Not ours to report; the scheme and host checks do
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("URL must not point to a private address")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			return errors.New("URL must not point to a private address")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatorStopsAtFirstFailure(t *testing.T) {
+	calls := 0
+	counting := func(string) error {
+		calls++
+		return nil
+	}
+	v := NewValidator(HTTPScheme, counting)
+
+	err := v.Validate("ftp://example.com")
+	if err == nil || !strings.Contains(err.Error(), "http") {
+		t.Fatalf("Expected the scheme failure, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected the chain to stop at the first failure, later check ran %d times", calls)
+	}
+
+	if err := v.Validate("https://example.com"); err != nil {
+		t.Errorf("Expected a valid URL to pass, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the later check to run once, got %d", calls)
+	}
+}
+
+func TestValidatorRejectsEmpty(t *testing.T) {
+	if err := NewValidator().Validate("   "); err == nil {
+		t.Error("Expected an empty chain to still reject a blank URL")
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	check := MaxLength(30)
+	if err := check("https://example.com/short"); err != nil {
+		t.Errorf("Expected a short URL to pass, got %v", err)
+	}
+	if err := check("https://example.com/" + strings.Repeat("a", 20)); err == nil {
+		t.Error("Expected a URL over the limit to fail")
+	}
+	if err := MaxLength(0)("https://example.com/" + strings.Repeat("a", DefaultMaxURLLength)); err == nil {
+		t.Error("Expected the default limit to apply when n is 0")
+	}
+}
+
+func TestBlockedHosts(t *testing.T) {
+	check := BlockedHosts([]string{"evil.example", " Spam.Test "})
+
+	testCases := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://evil.example/phish", true},
+		{"https://login.evil.example/", true},
+		{"https://SPAM.test:8443/", true},
+		{"https://notevil.example/", false},
+		{"https://example.com/evil.example", false},
+	}
+	for _, tc := range testCases {
+		if err := check(tc.url); (err != nil) != tc.blocked {
+			t.Errorf("BlockedHosts(%q) = %v; expected blocked=%v", tc.url, err, tc.blocked)
+		}
+	}
+}
+
+func TestNoPrivateHosts(t *testing.T) {
+	testCases := []struct {
+		url     string
+		private bool
+	}{
+		{"http://localhost:8080/", true},
+		{"http://api.localhost/", true},
+		{"http://127.0.0.1/", true},
+		{"http://10.1.2.3/admin", true},
+		{"http://192.168.1.1/", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[::1]/", true},
+		{"http://0.0.0.0/", true},
+		{"https://example.com/", false},
+		{"https://8.8.8.8/", false},
+	}
+	for _, tc := range testCases {
+		if err := NoPrivateHosts(tc.url); (err != nil) != tc.private {
+			t.Errorf("NoPrivateHosts(%q) = %v; expected private=%v", tc.url, err, tc.private)
+		}
+	}
+}